@@ -3,12 +3,26 @@ package main
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"log"
+	"log/slog"
 	"os"
+	"os/signal"
+	"strconv"
+	"sync"
+	"syscall"
+	"time"
 
+	"github.com/j-ordep/gateway/go-gateway/internal/domain"
+	"github.com/j-ordep/gateway/go-gateway/internal/logging"
+	"github.com/j-ordep/gateway/go-gateway/internal/metrics"
 	"github.com/j-ordep/gateway/go-gateway/internal/repository"
+	"github.com/j-ordep/gateway/go-gateway/internal/rpc"
 	"github.com/j-ordep/gateway/go-gateway/internal/service"
+	"github.com/j-ordep/gateway/go-gateway/internal/tracing"
+	"github.com/j-ordep/gateway/go-gateway/internal/web/middleware"
+	"github.com/j-ordep/gateway/go-gateway/internal/web/request"
 	"github.com/j-ordep/gateway/go-gateway/internal/web/server"
 	"github.com/joho/godotenv"
 
@@ -22,11 +36,42 @@ func getEnv(key, defaultValue string) string {
 	return defaultValue
 }
 
+// getEnvDuration lê uma duração (ex: 30s, 1m) e encerra a aplicação se o valor for inválido
+func getEnvDuration(key, defaultValue string) time.Duration {
+	value, err := time.ParseDuration(getEnv(key, defaultValue))
+	if err != nil {
+		log.Fatalf("Invalid %s: %v", key, err)
+	}
+	return value
+}
+
+// waitWithTimeout executa uma etapa do shutdown e desiste de esperar após o timeout
+func waitWithTimeout(phase string, timeout time.Duration, fn func() error) {
+	done := make(chan error, 1)
+	go func() {
+		done <- fn()
+	}()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			slog.Error("erro no shutdown", "phase", phase, "error", err)
+			return
+		}
+		slog.Info("shutdown concluído", "phase", phase)
+	case <-time.After(timeout):
+		slog.Error("timeout no shutdown", "phase", phase, "timeout", timeout.String())
+	}
+}
+
 func main() {
 	if err := godotenv.Load(); err != nil {
 		log.Fatal("Error loading .env file")
 	}
 
+	// logs estruturados (JSON por padrão) com request_id/account_id vindos do contexto
+	slog.SetDefault(logging.NewLogger(os.Stdout, getEnv("LOG_FORMAT", "json"), getEnv("LOG_LEVEL", "info")))
+
 	connStr := fmt.Sprintf(
 		"host=%s port=%s user=%s password=%s dbname=%s sslmode=%s",
 		getEnv("DB_HOST", "db"),
@@ -37,11 +82,39 @@ func main() {
 		getEnv("DB_SSL_MODE", "disable"),
 	)
 
+	// SIGTERM (deploy) ou SIGINT (Ctrl+C) iniciam o shutdown; tempo máximo de cada etapa configurável
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	readinessShutdownDelay := getEnvDuration("SHUTDOWN_READINESS_DELAY", "0s")
+	httpShutdownTimeout := getEnvDuration("SHUTDOWN_HTTP_TIMEOUT", "30s")
+	grpcShutdownTimeout := getEnvDuration("SHUTDOWN_GRPC_TIMEOUT", "30s")
+	consumerShutdownTimeout := getEnvDuration("SHUTDOWN_KAFKA_CONSUMER_TIMEOUT", "15s")
+	webhookShutdownTimeout := getEnvDuration("SHUTDOWN_WEBHOOK_TIMEOUT", "15s")
+	exportShutdownTimeout := getEnvDuration("SHUTDOWN_INVOICE_EXPORT_TIMEOUT", "30s")
+	producerShutdownTimeout := getEnvDuration("SHUTDOWN_KAFKA_PRODUCER_TIMEOUT", "10s")
+	tracingShutdownTimeout := getEnvDuration("SHUTDOWN_TRACING_TIMEOUT", "5s")
+	dbShutdownTimeout := getEnvDuration("SHUTDOWN_DB_TIMEOUT", "5s")
+
+	// OTEL_TRACES_EXPORTER: otlp (OTEL_EXPORTER_OTLP_ENDPOINT), stdout, file (TRACING_FILE) ou none
+	sampleRatio, err := strconv.ParseFloat(getEnv("OTEL_TRACES_SAMPLER_ARG", "1"), 64)
+	if err != nil {
+		log.Fatal("Invalid OTEL_TRACES_SAMPLER_ARG: ", err)
+	}
+	shutdownTracing, err := tracing.Setup(ctx, tracing.Config{
+		ServiceName: getEnv("OTEL_SERVICE_NAME", "gateway-api"),
+		Exporter:    getEnv("OTEL_TRACES_EXPORTER", "none"),
+		File:        getEnv("TRACING_FILE", "traces.json"),
+		SampleRatio: sampleRatio,
+	})
+	if err != nil {
+		log.Fatal("Error configuring tracing: ", err)
+	}
+
 	db, err := sql.Open("postgres", connStr)
 	if err != nil {
 		log.Fatal("Error connecting to db: ", err)
 	}
-	defer db.Close()
 
 	// Configura e inicializa o Kafka
 	baseKafkaConfig := service.NewKafkaConfig()
@@ -51,13 +124,83 @@ func main() {
 	producerConfig := baseKafkaConfig.WithTopic(producerTopic)
 
 	kafkaProducer := service.NewKafkaProducer(producerConfig)
-	defer kafkaProducer.Close()
 
 	accountRepository := repository.NewAccountRepository(db)
-	accountService := service.NewAccountService(accountRepository)
-
 	invoiceRepository := repository.NewInvoiceRepository(db)
-	invoiceService := service.NewInvoiceService(invoiceRepository, *accountService, kafkaProducer)
+
+	// cache das apiKeys resolvidas; API_KEY_CACHE_SIZE=0 desliga
+	apiKeyCacheSize, err := strconv.Atoi(getEnv("API_KEY_CACHE_SIZE", "10000"))
+	if err != nil {
+		log.Fatalf("Invalid API_KEY_CACHE_SIZE: %v", err)
+	}
+	apiKeyCache := service.NewAPIKeyCache(apiKeyCacheSize, getEnvDuration("API_KEY_CACHE_TTL", "30s"))
+	accountService := service.NewAccountService(accountRepository, apiKeyCache)
+
+	// VELOCITY_STORE=postgres compartilha os contadores entre instâncias, memory serve para rodar local
+	var velocityCounter domain.VelocityCounter
+	if getEnv("VELOCITY_STORE", "postgres") == "memory" {
+		velocityCounter = repository.NewMemoryVelocityRepository()
+	} else {
+		velocityCounter = repository.NewVelocityRepository(db)
+	}
+	velocityService := service.NewVelocityService(velocityCounter, service.NewVelocityConfig())
+
+	customerRepository := repository.NewCustomerRepository(db)
+	paymentMethodRepository := repository.NewPaymentMethodRepository(db)
+
+	threeDSChallengeRepository := repository.NewThreeDSChallengeRepository(db)
+	threeDSService := service.NewThreeDSService(threeDSChallengeRepository, service.NewThreeDSConfig())
+
+	webhookService := service.NewWebhookService(repository.NewWebhookEndpointRepository(db), repository.NewWebhookDeliveryRepository(db), service.NewWebhookConfig())
+
+	// stream SSE: cada mudança de status é gravada em invoice_events e distribuída às instâncias pelo LISTEN/NOTIFY
+	invoiceEventRepository := repository.NewInvoiceEventRepository(db)
+	invoiceStreamConfig := service.NewInvoiceStreamConfig()
+	invoiceEventHub := service.NewInvoiceEventHub(invoiceStreamConfig.BufferSize)
+	invoiceStreamService := service.NewInvoiceStreamService(invoiceRepository, invoiceEventRepository, invoiceEventHub, invoiceStreamConfig)
+
+	invoiceEventListener := repository.NewInvoiceEventListener(connStr)
+	listenerCtx, cancelListener := context.WithCancel(context.Background())
+	go func() {
+		if err := invoiceEventListener.Run(listenerCtx, invoiceEventHub.Publish); err != nil {
+			log.Printf("Error listening to invoice events: %v", err)
+		}
+	}()
+
+	invoiceService := service.NewInvoiceService(invoiceRepository, *accountService, kafkaProducer, velocityService, customerRepository, paymentMethodRepository, threeDSService, webhookService)
+	customerService := service.NewCustomerService(customerRepository, paymentMethodRepository, invoiceRepository)
+
+	// arquivos das exportações assíncronas; com várias instâncias INVOICE_EXPORT_DIR precisa ser um volume compartilhado
+	exportStorage, err := repository.NewFileExportStorage(getEnv("INVOICE_EXPORT_DIR", "exports"))
+	if err != nil {
+		log.Fatal("Error creating INVOICE_EXPORT_DIR: ", err)
+	}
+	invoiceExportService := service.NewInvoiceExportService(invoiceRepository, repository.NewInvoiceExportRepository(db), exportStorage, service.NewInvoiceExportConfig())
+
+	// relatórios da conta: INVOICE_REPORT_SOURCE=rollup lê os totais diários, atualizados a cada INVOICE_REPORT_ROLLUP_INTERVAL
+	// o refresh roda numa transação: cancelado no shutdown, é refeito por inteiro na próxima execução
+	invoiceReportConfig := service.NewInvoiceReportConfig()
+	invoiceReportService := service.NewInvoiceReportService(repository.NewInvoiceReportRepository(db), invoiceReportConfig)
+	if invoiceReportConfig.UseRollup {
+		rollupInterval := getEnvDuration("INVOICE_REPORT_ROLLUP_INTERVAL", "5m")
+		go func() {
+			ticker := time.NewTicker(rollupInterval)
+			defer ticker.Stop()
+			for {
+				if days, err := invoiceReportService.RefreshRollup(ctx); err != nil {
+					slog.Error("erro ao atualizar o rollup dos relatórios", "error", err)
+				} else if days > 0 {
+					slog.Info("rollup dos relatórios atualizado", "days", days)
+				}
+
+				select {
+				case <-ctx.Done():
+					return
+				case <-ticker.C:
+				}
+			}
+		}()
+	}
 
 	// docker-compose cria o tópico 'transactions_result'
 	// README/.env usam KAFKA_TRANSACTIONS_RESULT_TOPIC
@@ -66,21 +209,265 @@ func main() {
 	groupID := getEnv("KAFKA_CONSUMER_GROUP_ID", "gateway-group")
 
 	kafkaConsumer := service.NewKafkaConsumer(consumerConfig, groupID, invoiceService)
-	defer kafkaConsumer.Close()
 
+	// /metrics: pool do banco e lag do consumer são lidos no momento do scrape
+	metrics.RegisterDB(db, getEnv("DB_NAME", "gateway"))
+	metrics.RegisterKafkaReader(groupID, kafkaConsumer.Stats)
+
+	// contexto próprio: o consumer só é cancelado depois que o HTTP terminou de drenar
+	consumerCtx, cancelConsumer := context.WithCancel(context.Background())
+	consumerDone := make(chan struct{})
 	go func() {
-		if err := kafkaConsumer.Consume(context.Background()); err != nil {
+		defer close(consumerDone)
+		if err := kafkaConsumer.Consume(consumerCtx); err != nil {
 			log.Printf("Error consuming kafka messages: %v", err)
 		}
 	}()
 
+	// dispatcher dos webhooks: envia as entregas pendentes a cada WEBHOOK_DISPATCH_INTERVAL, emendando lotes enquanto houver entregas vencidas
+	// a tentativa em andamento não é cancelada no shutdown, stopDispatcher só impede o próximo lote
+	webhookDispatchInterval := getEnvDuration("WEBHOOK_DISPATCH_INTERVAL", "2s")
+	stopDispatcher := make(chan struct{})
+	dispatcherDone := make(chan struct{})
+	go func() {
+		defer close(dispatcherDone)
+		ticker := time.NewTicker(webhookDispatchInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stopDispatcher:
+				return
+			case <-ticker.C:
+			}
+
+			for {
+				dispatched, err := webhookService.DispatchDue(context.Background())
+				if err != nil {
+					slog.Error("erro ao enviar webhooks", "error", err)
+				}
+				if err != nil || dispatched == 0 {
+					break
+				}
+				select {
+				case <-stopDispatcher:
+					return
+				default:
+				}
+			}
+		}
+	}()
+
+	// worker das exportações assíncronas: gera uma por vez, emendando enquanto houver pendentes
+	// stopExportWorker só impede a próxima; a que está em andamento termina (ou é retomada por outra instância quando a reserva vencer)
+	exportWorkerInterval := getEnvDuration("INVOICE_EXPORT_INTERVAL", "5s")
+	stopExportWorker := make(chan struct{})
+	exportWorkerDone := make(chan struct{})
+	go func() {
+		defer close(exportWorkerDone)
+		ticker := time.NewTicker(exportWorkerInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stopExportWorker:
+				return
+			case <-ticker.C:
+			}
+
+			for {
+				processed, err := invoiceExportService.ProcessPending(context.Background())
+				if err != nil {
+					slog.Error("erro ao processar exportações de faturas", "error", err)
+				}
+				if err != nil || processed == 0 {
+					break
+				}
+				select {
+				case <-stopExportWorker:
+					return
+				default:
+				}
+			}
+		}
+	}()
+
+	// respostas guardadas por Idempotency-Key expiram após IDEMPOTENCY_KEY_TTL
+	idempotencyTTL := getEnvDuration("IDEMPOTENCY_KEY_TTL", "24h")
+	// uma requisição em andamento segura a chave por IDEMPOTENCY_LOCK_TIMEOUT; se cair antes de concluir, a retentativa assume
+	idempotencyLockTimeout := getEnvDuration("IDEMPOTENCY_LOCK_TIMEOUT", "1m")
+	idempotencyService := service.NewIdempotencyService(repository.NewIdempotencyRepository(db), idempotencyTTL, idempotencyLockTimeout)
+
+	// POST /invoices/batch: até INVOICE_BATCH_MAX_ITEMS faturas, INVOICE_BATCH_CONCURRENCY processadas ao mesmo tempo
+	invoiceBatchService := service.NewInvoiceBatchService(invoiceService, idempotencyService, service.NewInvoiceBatchConfig())
+
+	go func() {
+		ticker := time.NewTicker(time.Hour)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				idempotencyService.PurgeExpired()
+				invoiceStreamService.PurgeExpired(ctx)
+				invoiceExportService.PurgeExpired(ctx)
+			}
+		}
+	}()
+
+	// RATE_LIMIT_STORE=postgres compartilha os limites entre instâncias, memory (padrão) vale por instância
+	var rateLimitStore domain.RateLimitStore
+	if getEnv("RATE_LIMIT_STORE", "memory") == "postgres" {
+		rateLimitRepository := repository.NewRateLimitRepository(db)
+		rateLimitStore = rateLimitRepository
+
+		go func() {
+			ticker := time.NewTicker(time.Hour)
+			defer ticker.Stop()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case <-ticker.C:
+					if _, err := rateLimitRepository.DeleteIdle(time.Now().Add(-time.Hour)); err != nil {
+						log.Printf("Error deleting idle rate limit buckets: %v", err)
+					}
+				}
+			}
+		}()
+	} else {
+		rateLimitStore = repository.NewMemoryRateLimitRepository()
+	}
+	rateLimitService := service.NewRateLimitService(rateLimitStore, service.NewRateLimitConfig())
+
 	port := getEnv("HTTP_PORT", "8081")
 
-	srv := server.NewServer(accountService, invoiceService, port)
+	// tokens dos operadores das rotas /admin (operador:sha256 do token), vazio desabilita o acesso administrativo
+	operatorTokens, err := middleware.ParseOperatorTokens(getEnv("ADMIN_OPERATOR_TOKENS", ""))
+	if err != nil {
+		log.Fatal("Invalid ADMIN_OPERATOR_TOKENS: ", err)
+	}
+	if os.Getenv("ADMIN_API_KEY") != "" {
+		slog.Warn("ADMIN_API_KEY não é mais usada, configure os operadores em ADMIN_OPERATOR_TOKENS")
+	}
+	adminService := service.NewAdminService(accountService, invoiceService, repository.NewAuditLogRepository(db))
+
+	// rotas sem /v1 são aliases depreciados, removidos na data de LEGACY_ROUTES_SUNSET (YYYY-MM-DD)
+	legacySunset, err := time.Parse(time.DateOnly, getEnv("LEGACY_ROUTES_SUNSET", "2027-04-30"))
+	if err != nil {
+		log.Fatal("Invalid LEGACY_ROUTES_SUNSET: ", err)
+	}
+
+	// readiness: banco, brokers/tópicos do kafka e loop do consumer
+	healthService := service.NewHealthService(getEnvDuration("HEALTH_CHECK_TIMEOUT", "2s"))
+	healthService.AddCheck("postgres", db.PingContext)
+	healthService.AddCheck("kafka", func(ctx context.Context) error {
+		return baseKafkaConfig.CheckTopics(ctx, producerTopic, consumerTopic)
+	})
+	healthService.AddCheck("kafka_consumer", func(ctx context.Context) error {
+		if !kafkaConsumer.Running() {
+			return errors.New("kafka consumer is not running")
+		}
+		return nil
+	})
+
+	// IPs/CIDRs dos proxies à frente do gateway; o X-Forwarded-For só é aceito quando a conexão vem de um deles
+	trustedProxies, err := request.ParseTrustedProxies(getEnv("TRUSTED_PROXIES", ""))
+	if err != nil {
+		log.Fatal("Invalid TRUSTED_PROXIES: ", err)
+	}
+
+	srv := server.NewServer(accountService, invoiceService, invoiceBatchService, customerService, threeDSService, idempotencyService, rateLimitService, healthService, adminService, webhookService, invoiceStreamService, invoiceExportService, invoiceReportService, port, operatorTokens, legacySunset, trustedProxies)
 	srv.ConfigureRoutes() // handler esta aqui encapsulado
 
-	if err := srv.Start(); err != nil {
-		log.Fatal("Error starting server: ", err)
+	// API gRPC para os serviços internos, mesma autenticação e mapeamento de erros da REST em outra porta
+	grpcSrv := rpc.NewServer(accountService, invoiceService, rateLimitService, getEnv("GRPC_PORT", "9091"), getEnvDuration("GRPC_WATCH_INTERVAL", "1s"), trustedProxies)
+
+	serverErr := make(chan error, 2)
+	go func() {
+		serverErr <- srv.Start()
+	}()
+	go func() {
+		serverErr <- grpcSrv.Start()
+	}()
+
+	exitCode := 0
+	select {
+	case <-ctx.Done():
+		slog.Info("sinal recebido, iniciando shutdown")
+	case err := <-serverErr:
+		if err != nil {
+			slog.Error("erro ao iniciar o servidor", "error", err)
+			exitCode = 1
+		}
+	}
+	// um segundo sinal durante o shutdown encerra o processo imediatamente
+	stop()
+
+	// 0. /readyz passa a responder 503; o atraso dá tempo do balanceador tirar a instância antes de fechar as conexões
+	healthService.SetShuttingDown()
+	time.Sleep(readinessShutdownDelay)
+
+	// 1. encerra os streams SSE (os clientes reconectam em outra instância com o Last-Event-ID), que nunca terminariam sozinhos,
+	// depois para de aceitar conexões e drena as requisições em andamento, HTTP e gRPC em paralelo
+	invoiceEventHub.Close()
+	cancelListener()
+	if err := invoiceEventListener.Close(); err != nil {
+		slog.Error("erro ao fechar o LISTEN dos eventos de fatura", "error", err)
 	}
 
+	var drain sync.WaitGroup
+	drain.Add(2)
+	go func() {
+		defer drain.Done()
+		httpCtx, cancelHTTP := context.WithTimeout(context.Background(), httpShutdownTimeout)
+		defer cancelHTTP()
+		waitWithTimeout("http", httpShutdownTimeout, func() error {
+			return srv.Shutdown(httpCtx)
+		})
+	}()
+	go func() {
+		defer drain.Done()
+		grpcCtx, cancelGRPC := context.WithTimeout(context.Background(), grpcShutdownTimeout)
+		defer cancelGRPC()
+		waitWithTimeout("grpc", grpcShutdownTimeout, func() error {
+			return grpcSrv.Shutdown(grpcCtx)
+		})
+	}()
+	drain.Wait()
+
+	// 2. o consumer termina a mensagem atual (e commita o offset) antes de parar
+	cancelConsumer()
+	waitWithTimeout("kafka_consumer", consumerShutdownTimeout, func() error {
+		<-consumerDone
+		return kafkaConsumer.Close()
+	})
+
+	// 3. o dispatcher termina o lote atual; o que ficar pendente é enviado por outra instância ou no próximo start
+	close(stopDispatcher)
+	waitWithTimeout("webhook_dispatcher", webhookShutdownTimeout, func() error {
+		<-dispatcherDone
+		return nil
+	})
+
+	// 4. a exportação em andamento termina de gerar o arquivo; se estourar o prazo, outra instância a refaz quando a reserva vencer
+	close(stopExportWorker)
+	waitWithTimeout("invoice_export_worker", exportShutdownTimeout, func() error {
+		<-exportWorkerDone
+		return nil
+	})
+
+	// 5. envia as mensagens pendentes do producer
+	waitWithTimeout("kafka_producer", producerShutdownTimeout, kafkaProducer.Close)
+
+	// 6. exporta os spans que ainda estão no buffer
+	tracingCtx, cancelTracing := context.WithTimeout(context.Background(), tracingShutdownTimeout)
+	waitWithTimeout("tracing", tracingShutdownTimeout, func() error {
+		return shutdownTracing(tracingCtx)
+	})
+	cancelTracing()
+
+	// 7. por último o banco, usado por todas as etapas anteriores
+	waitWithTimeout("db", dbShutdownTimeout, db.Close)
+
+	os.Exit(exitCode)
 }
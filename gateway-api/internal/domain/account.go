@@ -9,15 +9,28 @@ import (
 	"github.com/google/uuid"
 )
 
+// AccountStatus segue a mesma ideia do Status da Invoice (type safety + valores centralizados)
+type AccountStatus string
+
+const (
+	AccountStatusActive    AccountStatus = "active"
+	AccountStatusSuspended AccountStatus = "suspended"
+	AccountStatusClosed    AccountStatus = "closed"
+)
+
 type Account struct {
-	ID        string
-	Name      string
-	Email     string
-	APIKey    string
-	Balance   float64
-	mu        sync.RWMutex // race conditions 
-	CreatedAt time.Time
-	UpdatedAt time.Time
+	ID           string
+	Name         string
+	Email        string
+	APIKey       string
+	Balance      float64
+	Status       AccountStatus
+	StatusReason string // motivo da ultima mudança de status (suspensão, encerramento...)
+	ThreeDSRule  ThreeDSRule // quando exigir autenticação 3DS nas cobranças
+	Tier         AccountTier // plano da conta, define o limite de requisições
+	mu           sync.RWMutex // race conditions 
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
 }
 
 func generateAPIKey() string {
@@ -33,6 +46,9 @@ func NewAccount(name, email string) *Account {
 		Email: email,
 		Balance: 0,
 		APIKey: generateAPIKey(),
+		Status: AccountStatusActive,
+		ThreeDSRule: ThreeDSRule{Mode: ThreeDSModeOff},
+		Tier: AccountTierStandard,
 		CreatedAt: time.Now(),
 		UpdatedAt: time.Now(),
 	}
@@ -44,4 +60,99 @@ func (a *Account) AddBalance(amount float64) {
 	defer a.mu.Unlock()
 	a.Balance += amount
 	a.UpdatedAt = time.Now()
-}
\ No newline at end of file
+}
+
+// Update altera os dados cadastrais da conta, campos vazios são ignorados
+// só contas ativas alteram o cadastro; suspensas e encerradas recebem o erro do status
+func (a *Account) Update(name, email string) error {
+	if err := a.CanTransact(); err != nil {
+		return err
+	}
+
+	if name != "" {
+		a.Name = name
+	}
+	if email != "" {
+		a.Email = email
+	}
+	a.UpdatedAt = time.Now()
+	return nil
+}
+
+// SetThreeDSRule troca a regra de 3DS da conta
+func (a *Account) SetThreeDSRule(rule ThreeDSRule) {
+	a.ThreeDSRule = rule
+	a.UpdatedAt = time.Now()
+}
+
+// SetTier troca o plano da conta (ação administrativa)
+func (a *Account) SetTier(tier AccountTier) {
+	a.Tier = tier
+	a.UpdatedAt = time.Now()
+}
+
+// CanTransact informa se a conta pode autenticar e gerar cobranças
+func (a *Account) CanTransact() error {
+	switch a.Status {
+	case AccountStatusSuspended:
+		return ErrAccountSuspended
+	case AccountStatusClosed:
+		return ErrAccountClosed
+	}
+	return nil
+}
+
+// Suspend bloqueia a conta (ex: lojista comprometido), pode ser revertido com Reactivate
+func (a *Account) Suspend(reason string) error {
+	if a.Status != AccountStatusActive {
+		return ErrInvalidAccountStatus
+	}
+
+	a.Status = AccountStatusSuspended
+	a.StatusReason = reason
+	a.UpdatedAt = time.Now()
+	return nil
+}
+
+func (a *Account) Reactivate() error {
+	if a.Status != AccountStatusSuspended {
+		return ErrInvalidAccountStatus
+	}
+
+	a.Status = AccountStatusActive
+	a.StatusReason = ""
+	a.UpdatedAt = time.Now()
+	return nil
+}
+
+// Close encerra a conta definitivamente, só é permitido com saldo zerado
+// a verificação de faturas pendentes depende do repositório e fica no service
+func (a *Account) Close(reason string) error {
+	if a.Status == AccountStatusClosed {
+		return ErrInvalidAccountStatus
+	}
+
+	a.mu.RLock()
+	balance := a.Balance
+	a.mu.RUnlock()
+
+	if balance != 0 {
+		return ErrAccountHasBalance
+	}
+
+	a.Status = AccountStatusClosed
+	a.StatusReason = reason
+	a.UpdatedAt = time.Now()
+	return nil
+}
+
+// RotateAPIKey gera uma nova apiKey, a anterior deixa de autenticar assim que a troca é persistida
+func (a *Account) RotateAPIKey() error {
+	if a.Status == AccountStatusClosed {
+		return ErrAccountClosed
+	}
+
+	a.APIKey = generateAPIKey()
+	a.UpdatedAt = time.Now()
+	return nil
+}
@@ -0,0 +1,64 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Operator é a pessoa de suporte/operação autenticada nas rotas /admin, separada das contas de lojista
+type Operator struct {
+	ID string
+}
+
+type AuditAction string
+
+const (
+	AuditActionAccountViewed          AuditAction = "account.viewed"
+	AuditActionAccountInvoicesListed  AuditAction = "account.invoices_listed"
+	AuditActionAccountSuspended       AuditAction = "account.suspended"
+	AuditActionAccountReactivated     AuditAction = "account.reactivated"
+	AuditActionAccountTierUpdated     AuditAction = "account.tier_updated"
+	AuditActionAccountBalanceAdjusted AuditAction = "account.balance_adjusted"
+	AuditActionInvoiceStatusForced    AuditAction = "invoice.status_forced"
+)
+
+const (
+	AuditTargetAccount = "account"
+	AuditTargetInvoice = "invoice"
+)
+
+// AuditEntry registra uma ação administrativa: quem (operador), o quê (ação + alvo) e por quê (motivo)
+// Details guarda os parâmetros da ação (ex: novo status, valor do ajuste)
+type AuditEntry struct {
+	ID         string
+	OperatorID string
+	Action     AuditAction
+	TargetType string
+	TargetID   string
+	Reason     string
+	Details    map[string]string
+	RequestID  string
+	CreatedAt  time.Time
+}
+
+func NewAuditEntry(operator Operator, action AuditAction, targetType, targetID, reason string, details map[string]string) *AuditEntry {
+	return &AuditEntry{
+		ID:         uuid.New().String(),
+		OperatorID: operator.ID,
+		Action:     action,
+		TargetType: targetType,
+		TargetID:   targetID,
+		Reason:     reason,
+		Details:    details,
+		CreatedAt:  time.Now(),
+	}
+}
+
+// AuditLogFilter filtra a consulta da trilha de auditoria, campos vazios não filtram
+type AuditLogFilter struct {
+	OperatorID string
+	TargetType string
+	TargetID   string
+	Limit      int
+}
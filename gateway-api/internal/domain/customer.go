@@ -0,0 +1,53 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Customer é o comprador (pagador) de uma Account, permite agrupar as faturas de um mesmo cliente
+type Customer struct {
+	ID        string
+	AccountID string
+	Name      string
+	Email     string
+	Document  string // CPF/CNPJ
+	Phone     string
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+func NewCustomer(accountID, name, email, document, phone string) (*Customer, error) {
+	if name == "" {
+		return nil, ErrInvalidCustomer
+	}
+
+	return &Customer{
+		ID:        uuid.New().String(),
+		AccountID: accountID,
+		Name:      name,
+		Email:     email,
+		Document:  document,
+		Phone:     phone,
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}, nil
+}
+
+// Update altera os dados do cliente, campos vazios são ignorados
+func (c *Customer) Update(name, email, document, phone string) {
+	if name != "" {
+		c.Name = name
+	}
+	if email != "" {
+		c.Email = email
+	}
+	if document != "" {
+		c.Document = document
+	}
+	if phone != "" {
+		c.Phone = phone
+	}
+	c.UpdatedAt = time.Now()
+}
@@ -9,13 +9,127 @@ var (
 	// ErrDuplicatedAPIKey é retornado quando há tentativa de criar conta com API key duplciada
 	ErrDuplicatedAPIKey = errors.New("api key already exists")
 
+	// ErrInvalidAPIKey é retornado quando a apiKey informada não pertence a nenhuma conta
+	ErrInvalidAPIKey = errors.New("invalid api key")
+
+	// ErrDuplicatedEmail é retornado quando já existe uma conta com o email informado
+	ErrDuplicatedEmail = errors.New("email already exists")
+
 	// ErrInvoiceNotFound é retornado quando uma fatura não é encontrada
 	ErrInvoiceNotFound = errors.New("invoice not found")
 
+	// ErrCustomerNotFound é retornado quando o cliente (pagador) não é encontrado
+	ErrCustomerNotFound = errors.New("customer not found")
+
+	// ErrInvalidCustomer é retornado quando os dados obrigatórios do cliente não são informados
+	ErrInvalidCustomer = errors.New("invalid customer")
+
+	// ErrPaymentMethodNotFound é retornado quando o cartão salvo não é encontrado
+	ErrPaymentMethodNotFound = errors.New("payment method not found")
+
+	// ErrInvalidCard é retornado quando os dados do cartão são inválidos
+	ErrInvalidCard = errors.New("invalid card")
+
+	// ErrInvalidThreeDSRule é retornado quando a regra de 3DS da conta é inválida
+	ErrInvalidThreeDSRule = errors.New("invalid 3ds rule")
+
+	// ErrThreeDSChallengeNotFound é retornado quando o desafio 3DS não é encontrado
+	ErrThreeDSChallengeNotFound = errors.New("3ds challenge not found")
+
+	// ErrThreeDSChallengeCompleted é retornado ao responder um desafio 3DS que já foi respondido
+	ErrThreeDSChallengeCompleted = errors.New("3ds challenge already completed")
+
+	// ErrThreeDSChallengePending é retornado quando o callback chega antes do comprador responder o desafio
+	ErrThreeDSChallengePending = errors.New("3ds challenge not completed yet")
+
+	// ErrIdempotencyKeyNotFound é retornado quando não existe registro para a Idempotency-Key
+	ErrIdempotencyKeyNotFound = errors.New("idempotency key not found")
+
+	// ErrIdempotencyKeyReused é retornado quando a mesma Idempotency-Key é usada com outra requisição
+	ErrIdempotencyKeyReused = errors.New("idempotency key already used with a different request")
+
+	// ErrIdempotencyRequestInProgress é retornado quando a requisição original da chave ainda está em andamento
+	ErrIdempotencyRequestInProgress = errors.New("a request with this idempotency key is already in progress")
+
+	// ErrIdempotencyLockLost é retornado ao concluir ou liberar uma chave cujo prazo venceu e outra tentativa assumiu
+	ErrIdempotencyLockLost = errors.New("idempotency key lock expired and was taken over")
+
 	// ErrUnauthorizedAccess é retornado quando há tentativa de acesso não autorizado a um recurso
 	ErrUnauthorizedAccess = errors.New("unauthorized access")
 
 	ErrInvalidAmount = errors.New("invalid amount")
 
 	ErrInvalidStatus = errors.New("invalid status")
+
+	// ErrInvalidCursor é retornado quando o cursor de paginação não pode ser decodificado
+	ErrInvalidCursor = errors.New("invalid cursor")
+
+	// ErrInvalidMetadata é retornado quando o metadata ou a external_reference excedem os limites
+	ErrInvalidMetadata = errors.New("invalid metadata")
+
+	// ErrDuplicatedExternalReference é retornado quando a conta já possui uma fatura com a mesma external_reference
+	ErrDuplicatedExternalReference = errors.New("external reference already exists")
+
+	// ErrAccountSuspended é retornado quando uma conta suspensa tenta autenticar ou transacionar
+	ErrAccountSuspended = errors.New("account suspended")
+
+	// ErrAccountClosed é retornado quando uma conta encerrada tenta autenticar ou transacionar
+	ErrAccountClosed = errors.New("account closed")
+
+	// ErrInvalidAccountStatus é retornado quando a transição de status da conta não é permitida
+	ErrInvalidAccountStatus = errors.New("invalid account status transition")
+
+	// ErrAccountHasBalance é retornado ao tentar encerrar uma conta com saldo
+	ErrAccountHasBalance = errors.New("account still has balance")
+
+	// ErrVelocityLimitExceeded é retornado quando o limite de tentativas (conta, cartão ou IP) é excedido
+	ErrVelocityLimitExceeded = errors.New("velocity limit exceeded")
+
+	// ErrAccountHasPendingInvoices é retornado ao tentar encerrar uma conta com faturas pendentes ou aguardando o 3DS
+	ErrAccountHasPendingInvoices = errors.New("account still has pending invoices")
+
+	// ErrRateLimitExceeded é retornado quando a conta (ou IP) excede o limite de requisições
+	ErrRateLimitExceeded = errors.New("rate limit exceeded")
+
+	// ErrInvalidAccountTier é retornado quando o plano informado não existe
+	ErrInvalidAccountTier = errors.New("invalid account tier")
+
+	// ErrInsufficientScope é retornado quando a credencial não tem o escopo exigido pela rota
+	ErrInsufficientScope = errors.New("insufficient scope")
+
+	// ErrWebhookEndpointNotFound é retornado quando o endpoint de webhook não é encontrado
+	ErrWebhookEndpointNotFound = errors.New("webhook endpoint not found")
+
+	// ErrWebhookDeliveryNotFound é retornado quando a entrega de webhook não é encontrada no endpoint
+	ErrWebhookDeliveryNotFound = errors.New("webhook delivery not found")
+
+	// ErrInvalidWebhookURL é retornado quando a URL do endpoint não é uma URL http(s) absoluta
+	ErrInvalidWebhookURL = errors.New("invalid webhook url")
+
+	// ErrInvalidWebhookEventType é retornado quando a lista de eventos está vazia ou contém um evento desconhecido
+	ErrInvalidWebhookEventType = errors.New("invalid webhook event type")
+
+	// ErrInvalidExportFormat é retornado quando o formato da exportação não é csv nem ndjson
+	ErrInvalidExportFormat = errors.New("invalid export format")
+
+	// ErrInvalidTimezone é retornado quando o fuso informado não existe na base IANA
+	ErrInvalidTimezone = errors.New("invalid timezone")
+
+	// ErrInvalidExportPeriod é retornado quando created_from/created_to não são datas válidas ou o período está invertido
+	ErrInvalidExportPeriod = errors.New("invalid export period")
+
+	// ErrInvoiceExportNotFound é retornado quando a exportação não é encontrada
+	ErrInvoiceExportNotFound = errors.New("invoice export not found")
+
+	// ErrInvoiceExportNotReady é retornado ao baixar uma exportação que não foi concluída (ou já expirou)
+	ErrInvoiceExportNotReady = errors.New("invoice export not ready")
+
+	// ErrInvalidReportGranularity é retornado quando a granularidade do relatório não é day, week nem month
+	ErrInvalidReportGranularity = errors.New("invalid report granularity")
+
+	// ErrInvalidReportPeriod é retornado quando from/to não são datas válidas, o período está invertido ou tem períodos demais
+	ErrInvalidReportPeriod = errors.New("invalid report period")
+
+	// ErrInvalidBatchSize é retornado quando o lote de faturas está vazio ou excede o limite de itens
+	ErrInvalidBatchSize = errors.New("invalid batch size")
 )
@@ -0,0 +1,62 @@
+package domain
+
+import "time"
+
+type IdempotencyStatus string
+
+const (
+	IdempotencyStatusProcessing IdempotencyStatus = "processing" // requisição original ainda em andamento
+	IdempotencyStatusCompleted  IdempotencyStatus = "completed"  // resposta gravada, pronta para replay
+)
+
+// IdempotencyRecord guarda a resposta de uma requisição identificada pelo header Idempotency-Key
+// a chave é escopada por conta: duas contas podem usar a mesma chave sem conflito
+type IdempotencyRecord struct {
+	AccountID    string
+	Key          string
+	RequestHash  string // hash de método + rota + body, detecta reuso da chave com outra requisição
+	Status       IdempotencyStatus
+	ResponseCode int
+	ResponseBody []byte
+	CreatedAt    time.Time
+	ExpiresAt    time.Time
+	// LockedUntil é o prazo da requisição em processing; vencido, uma nova tentativa assume a chave
+	// também identifica o dono atual: concluir ou liberar só vale com o mesmo LockedUntil
+	LockedUntil time.Time
+}
+
+func NewIdempotencyRecord(accountID, key, requestHash string, ttl, lockTimeout time.Duration) *IdempotencyRecord {
+	now := time.Now()
+	record := &IdempotencyRecord{
+		AccountID:   accountID,
+		Key:         key,
+		RequestHash: requestHash,
+		Status:      IdempotencyStatusProcessing,
+		CreatedAt:   now,
+		ExpiresAt:   now.Add(ttl),
+	}
+	record.Lock(lockTimeout)
+	return record
+}
+
+func (r *IdempotencyRecord) IsExpired() bool {
+	return time.Now().After(r.ExpiresAt)
+}
+
+// IsLocked indica que a requisição dona da chave ainda está dentro do prazo
+func (r *IdempotencyRecord) IsLocked() bool {
+	return time.Now().Before(r.LockedUntil)
+}
+
+// Lock renova o prazo de processamento
+// truncado em microssegundos, a precisão do TIMESTAMP do Postgres, para comparar com o valor gravado
+func (r *IdempotencyRecord) Lock(lockTimeout time.Duration) {
+	r.LockedUntil = time.Now().Add(lockTimeout).Truncate(time.Microsecond)
+}
+
+// Complete grava a resposta que será devolvida nas próximas tentativas com a mesma chave
+func (r *IdempotencyRecord) Complete(responseCode int, responseBody []byte) {
+	r.Status = IdempotencyStatusCompleted
+	r.ResponseCode = responseCode
+	r.ResponseBody = responseBody
+}
@@ -1,6 +1,9 @@
 package domain
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
 	"math/rand"
 	"time"
 
@@ -17,18 +20,69 @@ const (
 	StatusPending  Status = "pending"
 	StatusApproved Status = "approved"
 	StatusRejected Status = "rejected"
+
+	// aguardando o comprador concluir o desafio 3DS, depois volta para o fluxo normal
+	StatusRequiresAction Status = "requires_action"
+)
+
+// UnresolvedStatuses são os status de faturas ainda sem decisão (antifraude ou 3DS); impedem o encerramento da conta
+var UnresolvedStatuses = []Status{StatusPending, StatusRequiresAction}
+
+// IsFinal indica que a fatura já foi decidida (approved/rejected) e não muda mais de status
+func (s Status) IsFinal() bool {
+	return s == StatusApproved || s == StatusRejected
+}
+
+// limites do metadata, evitam que o lojista use a fatura como armazenamento genérico
+const (
+	MaxMetadataKeys            = 50
+	MaxMetadataKeyLength       = 40
+	MaxMetadataValueLength     = 500
+	MaxExternalReferenceLength = 255
 )
 
 type Invoice struct {
-	ID             string
-	AccountID      string
-	Amount         float64
-	Status         Status
-	Description    string
-	PaymentType    string
-	CardLastDigits string
-	CreatedAt      time.Time
-	UpdatedAt      time.Time
+	ID                string
+	AccountID         string
+	Amount            float64
+	Status            Status
+	Description       string
+	PaymentType       string
+	CardLastDigits    string
+	CardBrand         string            // bandeira do cartão (visa, mastercard...), usada nos relatórios
+	ExternalReference string            // referência do pedido no sistema do lojista, única por conta
+	Metadata          map[string]string // chave/valor livre do lojista
+	CustomerID        string            // opcional, comprador da fatura
+	PaymentMethodID   string            // opcional, cartão salvo usado na cobrança
+	ThreeDSResult     ThreeDSResult     // resultado do 3DS, vazio quando não foi exigido
+	ThreeDSECI        string
+	CreatedAt         time.Time
+	UpdatedAt         time.Time
+}
+
+// InvoiceFilter agrupa os filtros da listagem de faturas de uma conta
+// campos com valor zero são ignorados
+type InvoiceFilter struct {
+	ExternalReference string
+	Metadata          map[string]string // todas as chaves/valores precisam existir na fatura
+	CustomerID        string
+	Status            Status
+	PaymentType       string
+	MinAmount         float64
+	MaxAmount         float64
+	CreatedFrom       time.Time // inclusivo
+	CreatedTo         time.Time // exclusivo
+	CardLastDigits    string
+
+	// paginação por keyset: ordenado por (created_at, id) decrescente
+	Limit  int            // 0 = sem limite
+	Cursor *InvoiceCursor // retorna apenas faturas depois deste ponto
+}
+
+// InvoiceCursor é a posição da última fatura retornada na página anterior
+type InvoiceCursor struct {
+	CreatedAt time.Time
+	ID        string
 }
 
 type CreditCard struct {
@@ -39,13 +93,37 @@ type CreditCard struct {
 	CardholderName string
 }
 
+// Fingerprint identifica o cartão sem expor o número completo (BIN + últimos 4 + validade)
+// o mesmo cartão gera sempre o mesmo fingerprint, o que permite contar tentativas por cartão
+func (c CreditCard) Fingerprint() string {
+	bin := c.Number
+	if len(bin) > 6 {
+		bin = bin[:6]
+	}
+
+	lastDigits := c.Number
+	if len(lastDigits) > 4 {
+		lastDigits = lastDigits[len(lastDigits)-4:]
+	}
+
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%s|%s|%02d/%d", bin, lastDigits, c.ExpiryMonth, c.ExpiryYear)))
+	return hex.EncodeToString(sum[:])
+}
+
 func NewInvoice(accountId string, amount float64, description string, paymentType string, card CreditCard) (*Invoice, error) {
 	if amount <= 0 {
 		return nil, ErrInvalidAmount
 	}
 
 	// len(card.Number) = 16
-	lastDigits := card.Number[len(card.Number)-4:] // 16 - 4 = [12:] (basicamente ele pega do 12º numero para frente, ou seja ultimos 4 numeros)
+	// sem número (ex: cobrança com cartão salvo) os últimos dígitos vêm do PaymentMethod
+	var lastDigits, brand string
+	if card.Number != "" {
+		brand = CardBrand(card.Number)
+	}
+	if len(card.Number) >= 4 {
+		lastDigits = card.Number[len(card.Number)-4:] // 16 - 4 = [12:] (basicamente ele pega do 12º numero para frente, ou seja ultimos 4 numeros)
+	}
 
 	return &Invoice{
 		ID:             uuid.New().String(),
@@ -55,11 +133,108 @@ func NewInvoice(accountId string, amount float64, description string, paymentTyp
 		Description:    description,
 		PaymentType:    paymentType,
 		CardLastDigits: lastDigits,
+		CardBrand:      brand,
 		CreatedAt:      time.Now(),
 		UpdatedAt:      time.Now(),
 	}, nil
 }
 
+// SetReference associa a fatura ao pedido do lojista, validando os limites do metadata
+func (i *Invoice) SetReference(externalReference string, metadata map[string]string) error {
+	if len(externalReference) > MaxExternalReferenceLength {
+		return ErrInvalidMetadata
+	}
+
+	if len(metadata) > MaxMetadataKeys {
+		return ErrInvalidMetadata
+	}
+
+	for key, value := range metadata {
+		if key == "" || len(key) > MaxMetadataKeyLength || len(value) > MaxMetadataValueLength {
+			return ErrInvalidMetadata
+		}
+	}
+
+	i.ExternalReference = externalReference
+	i.Metadata = metadata
+	return nil
+}
+
+// AttachCustomer vincula a fatura a um cliente da mesma conta
+func (i *Invoice) AttachCustomer(customer *Customer) error {
+	if customer.AccountID != i.AccountID {
+		return ErrUnauthorizedAccess
+	}
+
+	i.CustomerID = customer.ID
+	return nil
+}
+
+// UsePaymentMethod cobra a fatura em um cartão salvo, o cliente da fatura passa a ser o dono do cartão
+func (i *Invoice) UsePaymentMethod(paymentMethod *PaymentMethod) error {
+	if paymentMethod.AccountID != i.AccountID {
+		return ErrUnauthorizedAccess
+	}
+
+	if i.CustomerID != "" && i.CustomerID != paymentMethod.CustomerID {
+		return ErrPaymentMethodNotFound
+	}
+
+	i.CustomerID = paymentMethod.CustomerID
+	i.PaymentMethodID = paymentMethod.ID
+	i.CardLastDigits = paymentMethod.LastDigits
+	i.CardBrand = paymentMethod.Brand
+	return nil
+}
+
+// ApplyFrictionless registra o 3DS autenticado sem desafio, o processamento segue normalmente
+func (i *Invoice) ApplyFrictionless() {
+	i.ThreeDSResult = ThreeDSFrictionless
+	i.ThreeDSECI = ECIAuthenticated
+}
+
+// RequireAction coloca a fatura aguardando o desafio 3DS
+func (i *Invoice) RequireAction() error {
+	if i.Status != StatusPending {
+		return ErrInvalidStatus
+	}
+
+	i.Status = StatusRequiresAction
+	i.UpdatedAt = time.Now()
+	return nil
+}
+
+// CompleteThreeDS aplica o resultado do desafio
+// falha rejeita a fatura, sucesso devolve para pending para seguir o fluxo normal de aprovação (Process)
+func (i *Invoice) CompleteThreeDS(challenge *ThreeDSChallenge) error {
+	if i.Status != StatusRequiresAction {
+		return ErrInvalidStatus
+	}
+
+	i.ThreeDSResult = challenge.Result()
+	i.ThreeDSECI = challenge.ECI
+
+	if i.ThreeDSResult == ThreeDSFailed {
+		i.Status = StatusRejected
+	} else {
+		i.Status = StatusPending
+	}
+	i.UpdatedAt = time.Now()
+	return nil
+}
+
+// ReopenThreeDS devolve para requires_action a fatura que não conseguiu seguir após o desafio (falha ao publicar no kafka)
+// o callback pode ser chamado de novo
+func (i *Invoice) ReopenThreeDS() error {
+	if i.Status != StatusPending {
+		return ErrInvalidStatus
+	}
+
+	i.Status = StatusRequiresAction
+	i.UpdatedAt = time.Now()
+	return nil
+}
+
 func (i *Invoice) Process() error {
 	if i.Amount > 10000 {
 		return nil // mantem o status como pendente (StatusPending), com isso enviamos o invoice para apache kafka
@@ -93,3 +268,11 @@ func (i *Invoice) UpdateStatus(newStatus Status) error {
 	i.UpdatedAt = time.Now()
 	return nil
 }
+
+// InvoiceOutbox é o que é gravado na mesma transação da fatura: só existe se a fatura for gravada, e vice-versa
+type InvoiceOutbox struct {
+	Challenge  *ThreeDSChallenge  // desafio 3DS da fatura criada em requires_action
+	Audit      *AuditEntry        // decisão forçada pelo operador (rotas /admin)
+	Deliveries []*WebhookDelivery // webhooks do novo status; endpoints removidos nesse meio tempo são ignorados
+	Event      *InvoiceEvent      // evento do stream SSE, avisado às instâncias (NOTIFY) só no commit
+}
@@ -0,0 +1,24 @@
+package domain
+
+import "time"
+
+// InvoiceEvent é uma mudança de status de fatura, entregue aos clientes do stream SSE
+// o ID é sequencial (BIGSERIAL) e vira o id do evento SSE, usado no Last-Event-ID para retomar o stream
+type InvoiceEvent struct {
+	ID        int64     `json:"id"`
+	AccountID string    `json:"account_id"`
+	InvoiceID string    `json:"invoice_id"`
+	Status    Status    `json:"status"`
+	Amount    float64   `json:"amount"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func NewInvoiceEvent(invoice *Invoice) *InvoiceEvent {
+	return &InvoiceEvent{
+		AccountID: invoice.AccountID,
+		InvoiceID: invoice.ID,
+		Status:    invoice.Status,
+		Amount:    invoice.Amount,
+		CreatedAt: time.Now(),
+	}
+}
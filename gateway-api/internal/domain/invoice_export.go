@@ -0,0 +1,110 @@
+package domain
+
+import (
+	"io"
+	"time"
+	// base IANA embutida: o fuso da exportação funciona mesmo em imagens sem /usr/share/zoneinfo
+	_ "time/tzdata"
+
+	"github.com/google/uuid"
+)
+
+// ExportFormat é o formato do arquivo de exportação de faturas
+type ExportFormat string
+
+const (
+	ExportCSV    ExportFormat = "csv"
+	ExportNDJSON ExportFormat = "ndjson" // um objeto JSON por linha
+)
+
+func ParseExportFormat(format string) (ExportFormat, error) {
+	switch ExportFormat(format) {
+	case "", ExportCSV:
+		return ExportCSV, nil
+	case ExportNDJSON:
+		return ExportNDJSON, nil
+	}
+	return "", ErrInvalidExportFormat
+}
+
+// ExportStatus é a situação de uma exportação assíncrona
+type ExportStatus string
+
+const (
+	ExportPending    ExportStatus = "pending"    // aguardando o worker
+	ExportProcessing ExportStatus = "processing" // reservada por uma instância até LockedUntil
+	ExportCompleted  ExportStatus = "completed"  // arquivo disponível para download até ExpiresAt
+	ExportFailed     ExportStatus = "failed"
+)
+
+// InvoiceExport é uma exportação assíncrona: o filtro e o fuso ficam gravados para o worker gerar o arquivo depois
+type InvoiceExport struct {
+	ID          string
+	AccountID   string
+	Format      ExportFormat
+	Filter      InvoiceFilter // apenas Status, PaymentType, CreatedFrom e CreatedTo são gravados
+	Timezone    string
+	Status      ExportStatus
+	RowCount    int
+	Error       string
+	LockedUntil *time.Time
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	CompletedAt *time.Time
+	ExpiresAt   *time.Time
+}
+
+func NewInvoiceExport(accountID string, format ExportFormat, filter InvoiceFilter, timezone string) *InvoiceExport {
+	now := time.Now()
+	return &InvoiceExport{
+		ID:        uuid.New().String(),
+		AccountID: accountID,
+		Format:    format,
+		Filter:    filter,
+		Timezone:  timezone,
+		Status:    ExportPending,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+}
+
+// Location é o fuso usado nas datas do arquivo, validado na criação
+func (e *InvoiceExport) Location() (*time.Location, error) {
+	location, err := time.LoadLocation(e.Timezone)
+	if err != nil {
+		return nil, ErrInvalidTimezone
+	}
+	return location, nil
+}
+
+// Complete disponibiliza o arquivo para download até now + retention
+func (e *InvoiceExport) Complete(rowCount int, retention time.Duration) {
+	now := time.Now()
+	expiresAt := now.Add(retention)
+	e.Status = ExportCompleted
+	e.RowCount = rowCount
+	e.Error = ""
+	e.LockedUntil = nil
+	e.CompletedAt = &now
+	e.ExpiresAt = &expiresAt
+	e.UpdatedAt = now
+}
+
+// Fail encerra a exportação; o motivo fica visível para o lojista
+func (e *InvoiceExport) Fail(cause string, retention time.Duration) {
+	now := time.Now()
+	expiresAt := now.Add(retention)
+	e.Status = ExportFailed
+	e.Error = cause
+	e.LockedUntil = nil
+	e.CompletedAt = &now
+	e.ExpiresAt = &expiresAt
+	e.UpdatedAt = now
+}
+
+// ExportStorage guarda os arquivos gerados pelas exportações assíncronas, identificados pelo ID da exportação
+type ExportStorage interface {
+	Create(id string) (io.WriteCloser, error)
+	Open(id string) (io.ReadCloser, error)
+	Delete(id string) error
+}
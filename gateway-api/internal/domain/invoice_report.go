@@ -0,0 +1,197 @@
+package domain
+
+import "time"
+
+// ReportGranularity é o tamanho de cada período do relatório da conta
+type ReportGranularity string
+
+const (
+	ReportDay   ReportGranularity = "day"
+	ReportWeek  ReportGranularity = "week" // começa na segunda-feira, como o date_trunc('week') do Postgres
+	ReportMonth ReportGranularity = "month"
+)
+
+// MaxReportPeriods limita o tamanho da resposta (pouco mais de um ano por dia)
+const MaxReportPeriods = 400
+
+func ParseReportGranularity(granularity string) (ReportGranularity, error) {
+	switch ReportGranularity(granularity) {
+	case "", ReportDay:
+		return ReportDay, nil
+	case ReportWeek, ReportMonth:
+		return ReportGranularity(granularity), nil
+	}
+	return "", ErrInvalidReportGranularity
+}
+
+// Truncate retorna o início (UTC) do período que contém t
+func (g ReportGranularity) Truncate(t time.Time) time.Time {
+	year, month, day := t.UTC().Date()
+	start := time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+
+	switch g {
+	case ReportWeek:
+		// Weekday: domingo = 0, a semana começa na segunda
+		return start.AddDate(0, 0, -((int(start.Weekday()) + 6) % 7))
+	case ReportMonth:
+		return time.Date(year, month, 1, 0, 0, 0, 0, time.UTC)
+	}
+	return start
+}
+
+// Next retorna o início do período seguinte a start
+func (g ReportGranularity) Next(start time.Time) time.Time {
+	switch g {
+	case ReportWeek:
+		return start.AddDate(0, 0, 7)
+	case ReportMonth:
+		return start.AddDate(0, 1, 0)
+	}
+	return start.AddDate(0, 0, 1)
+}
+
+// DefaultFrom é o início do relatório quando from não é informado: 30 dias, 12 semanas ou 12 meses antes de to
+func (g ReportGranularity) DefaultFrom(to time.Time) time.Time {
+	switch g {
+	case ReportWeek:
+		return to.AddDate(0, 0, -7*12)
+	case ReportMonth:
+		return to.AddDate(0, -12, 0)
+	}
+	return to.AddDate(0, 0, -30)
+}
+
+// ReportSource indica de onde os totais do relatório foram lidos
+type ReportSource string
+
+const (
+	ReportFromInvoices ReportSource = "invoices" // agregação direta, sempre atualizada
+	ReportFromRollup   ReportSource = "rollup"   // totais diários pré-calculados, atualizados até RefreshedUntil
+)
+
+// InvoiceAggregate é a contagem e a soma das faturas de um período com o mesmo status, tipo de pagamento e bandeira
+type InvoiceAggregate struct {
+	PeriodStart time.Time
+	Status      Status
+	PaymentType string
+	CardBrand   string
+	Count       int
+	Amount      float64
+}
+
+// ReportTotals é a quantidade e o valor de um grupo de faturas
+type ReportTotals struct {
+	Count  int
+	Amount float64
+}
+
+func (t *ReportTotals) add(count int, amount float64) {
+	t.Count += count
+	t.Amount += amount
+}
+
+// ReportPeriod são os totais das faturas criadas em [Start, End)
+type ReportPeriod struct {
+	Start         time.Time
+	End           time.Time
+	Total         ReportTotals
+	ByStatus      map[Status]ReportTotals
+	ByPaymentType map[string]ReportTotals
+	ByCardBrand   map[string]ReportTotals
+}
+
+// ApprovalRate é approved / (approved + rejected); false quando nenhuma fatura do período foi decidida
+func (p *ReportPeriod) ApprovalRate() (float64, bool) {
+	approved := p.ByStatus[StatusApproved].Count
+	decided := approved + p.ByStatus[StatusRejected].Count
+	if decided == 0 {
+		return 0, false
+	}
+	return float64(approved) / float64(decided), true
+}
+
+// AverageTicket é o valor médio das faturas aprovadas; false quando não há aprovadas no período
+func (p *ReportPeriod) AverageTicket() (float64, bool) {
+	approved := p.ByStatus[StatusApproved]
+	if approved.Count == 0 {
+		return 0, false
+	}
+	return approved.Amount / float64(approved.Count), true
+}
+
+// InvoiceReport é o relatório de uma conta: um ReportPeriod por período entre From e To, inclusive os sem faturas
+type InvoiceReport struct {
+	AccountID      string
+	Granularity    ReportGranularity
+	From           time.Time // início do primeiro período
+	To             time.Time // fim do último período (exclusivo)
+	Source         ReportSource
+	RefreshedUntil time.Time // apenas no rollup: faturas alteradas depois disso ainda não entraram
+	Periods        []*ReportPeriod
+
+	periodByStart map[int64]*ReportPeriod // Start em Unix -> período, para o Add
+}
+
+// NewInvoiceReport alinha from/to ao início dos períodos (os períodos são sempre completos) e cria os períodos vazios
+func NewInvoiceReport(accountID string, granularity ReportGranularity, from, to time.Time) (*InvoiceReport, error) {
+	from = granularity.Truncate(from)
+	if truncated := granularity.Truncate(to); truncated.Before(to) {
+		to = granularity.Next(truncated)
+	} else {
+		to = truncated
+	}
+	if !from.Before(to) {
+		return nil, ErrInvalidReportPeriod
+	}
+
+	report := &InvoiceReport{
+		AccountID:   accountID,
+		Granularity: granularity,
+		From:        from,
+		To:          to,
+		Source:      ReportFromInvoices,
+
+		periodByStart: make(map[int64]*ReportPeriod),
+	}
+	for start := from; start.Before(to); start = granularity.Next(start) {
+		if len(report.Periods) == MaxReportPeriods {
+			return nil, ErrInvalidReportPeriod
+		}
+		period := &ReportPeriod{
+			Start:         start,
+			End:           granularity.Next(start),
+			ByStatus:      make(map[Status]ReportTotals),
+			ByPaymentType: make(map[string]ReportTotals),
+			ByCardBrand:   make(map[string]ReportTotals),
+		}
+		report.Periods = append(report.Periods, period)
+		report.periodByStart[start.Unix()] = period
+	}
+
+	return report, nil
+}
+
+// Add soma um agregado ao período correspondente; agregados fora do relatório são ignorados
+func (r *InvoiceReport) Add(aggregate InvoiceAggregate) {
+	period, ok := r.periodByStart[r.Granularity.Truncate(aggregate.PeriodStart).Unix()]
+	if !ok {
+		return
+	}
+
+	// faturas cobradas sem cartão, ou criadas antes de a bandeira ser gravada
+	brand := aggregate.CardBrand
+	if brand == "" {
+		brand = "unknown"
+	}
+
+	period.Total.add(aggregate.Count, aggregate.Amount)
+	addTotals(period.ByStatus, aggregate.Status, aggregate.Count, aggregate.Amount)
+	addTotals(period.ByPaymentType, aggregate.PaymentType, aggregate.Count, aggregate.Amount)
+	addTotals(period.ByCardBrand, brand, aggregate.Count, aggregate.Amount)
+}
+
+func addTotals[K comparable](totals map[K]ReportTotals, key K, count int, amount float64) {
+	current := totals[key]
+	current.add(count, amount)
+	totals[key] = current
+}
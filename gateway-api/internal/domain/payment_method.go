@@ -0,0 +1,74 @@
+package domain
+
+import (
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// PaymentMethod é um cartão tokenizado de um Customer
+// o número completo e o CVV nunca são guardados, apenas os dados necessários para exibição e antifraude
+type PaymentMethod struct {
+	ID             string // funciona como token do cartão nas novas cobranças
+	AccountID      string
+	CustomerID     string
+	Brand          string
+	LastDigits     string
+	ExpiryMonth    int
+	ExpiryYear     int
+	CardholderName string
+	Fingerprint    string // mesmo valor de CreditCard.Fingerprint, usado no controle de velocidade
+	CreatedAt      time.Time
+}
+
+func NewPaymentMethod(accountID, customerID string, card CreditCard) (*PaymentMethod, error) {
+	if len(card.Number) < 12 || card.ExpiryMonth < 1 || card.ExpiryMonth > 12 || card.ExpiryYear <= 0 {
+		return nil, ErrInvalidCard
+	}
+
+	return &PaymentMethod{
+		ID:             uuid.New().String(),
+		AccountID:      accountID,
+		CustomerID:     customerID,
+		Brand:          CardBrand(card.Number),
+		LastDigits:     card.Number[len(card.Number)-4:],
+		ExpiryMonth:    card.ExpiryMonth,
+		ExpiryYear:     card.ExpiryYear,
+		CardholderName: card.CardholderName,
+		Fingerprint:    card.Fingerprint(),
+		CreatedAt:      time.Now(),
+	}, nil
+}
+
+// CardBrand identifica a bandeira pelo prefixo (BIN) do cartão
+func CardBrand(number string) string {
+	switch {
+	case strings.HasPrefix(number, "4"):
+		return "visa"
+	case hasPrefixInRange(number, 51, 55, 2), hasPrefixInRange(number, 2221, 2720, 4):
+		return "mastercard"
+	case strings.HasPrefix(number, "34"), strings.HasPrefix(number, "37"):
+		return "amex"
+	case strings.HasPrefix(number, "6011"), strings.HasPrefix(number, "65"):
+		return "discover"
+	default:
+		return "unknown"
+	}
+}
+
+func hasPrefixInRange(number string, min, max, size int) bool {
+	if len(number) < size {
+		return false
+	}
+
+	prefix := 0
+	for _, digit := range number[:size] {
+		if digit < '0' || digit > '9' {
+			return false
+		}
+		prefix = prefix*10 + int(digit-'0')
+	}
+
+	return prefix >= min && prefix <= max
+}
@@ -0,0 +1,70 @@
+package domain
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"slices"
+)
+
+// Scope é uma permissão concedida à credencial, verificada por rota
+type Scope string
+
+const (
+	ScopeAccountRead    Scope = "account:read"
+	ScopeAccountWrite   Scope = "account:write"
+	ScopeInvoicesRead   Scope = "invoices:read"
+	ScopeInvoicesWrite  Scope = "invoices:write"
+	ScopeCustomersRead  Scope = "customers:read"
+	ScopeCustomersWrite Scope = "customers:write"
+	ScopeWebhooksRead   Scope = "webhooks:read"
+	ScopeWebhooksWrite  Scope = "webhooks:write"
+)
+
+// MerchantScopes são os escopos da apiKey do lojista: acesso completo aos recursos da própria conta
+var MerchantScopes = []Scope{
+	ScopeAccountRead, ScopeAccountWrite,
+	ScopeInvoicesRead, ScopeInvoicesWrite,
+	ScopeCustomersRead, ScopeCustomersWrite,
+	ScopeWebhooksRead, ScopeWebhooksWrite,
+}
+
+// Principal é quem fez a requisição, resolvido a partir da apiKey pelo middleware de autenticação
+// Status e Tier acompanham o principal para que autenticação e rate limit não precisem buscar a conta de novo
+type Principal struct {
+	AccountID string
+	KeyID     string // identifica a apiKey sem expô-la (pode ir para logs)
+	Scopes    []Scope
+	Status    AccountStatus
+	Tier      AccountTier
+}
+
+func NewPrincipal(account *Account) Principal {
+	return Principal{
+		AccountID: account.ID,
+		KeyID:     APIKeyID(account.APIKey),
+		Scopes:    MerchantScopes,
+		Status:    account.Status,
+		Tier:      account.Tier,
+	}
+}
+
+func (p Principal) HasScope(scope Scope) bool {
+	return slices.Contains(p.Scopes, scope)
+}
+
+// CanTransact segue a mesma regra de Account.CanTransact
+func (p Principal) CanTransact() error {
+	switch p.Status {
+	case AccountStatusSuspended:
+		return ErrAccountSuspended
+	case AccountStatusClosed:
+		return ErrAccountClosed
+	}
+	return nil
+}
+
+// APIKeyID deriva um identificador estável e não secreto da apiKey (prefixo do sha256)
+func APIKeyID(apiKey string) string {
+	sum := sha256.Sum256([]byte(apiKey))
+	return "key_" + hex.EncodeToString(sum[:6])
+}
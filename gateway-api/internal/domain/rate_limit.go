@@ -0,0 +1,86 @@
+package domain
+
+import (
+	"math"
+	"time"
+)
+
+// AccountTier define o plano da conta, usado para escolher o limite de requisições
+type AccountTier string
+
+const (
+	AccountTierStandard   AccountTier = "standard"
+	AccountTierPremium    AccountTier = "premium"
+	AccountTierEnterprise AccountTier = "enterprise"
+)
+
+func ParseAccountTier(tier string) (AccountTier, error) {
+	switch AccountTier(tier) {
+	case AccountTierStandard, AccountTierPremium, AccountTierEnterprise:
+		return AccountTier(tier), nil
+	}
+	return "", ErrInvalidAccountTier
+}
+
+// RateLimit é a configuração de um token bucket
+// Rate: tokens repostos por segundo, Burst: capacidade do bucket (rajada máxima)
+type RateLimit struct {
+	Rate  float64
+	Burst int
+}
+
+// Disabled indica limite desligado (rate ou burst zerados)
+func (l RateLimit) Disabled() bool {
+	return l.Rate <= 0 || l.Burst <= 0
+}
+
+// RateLimitResult é o que o middleware precisa para responder os headers RateLimit-*
+type RateLimitResult struct {
+	Allowed    bool
+	Limit      int
+	Remaining  int
+	ResetAfter time.Duration // tempo até o bucket encher de novo
+	RetryAfter time.Duration // tempo até o próximo token, apenas quando Allowed = false
+}
+
+// TokenBucket é o estado persistido por chave (conta ou IP)
+// a reposição é calculada sob demanda a partir do UpdatedAt, sem timers
+type TokenBucket struct {
+	Tokens    float64
+	UpdatedAt time.Time
+}
+
+// NewTokenBucket começa cheio, o primeiro acesso de uma chave nunca é bloqueado
+func NewTokenBucket(limit RateLimit, now time.Time) *TokenBucket {
+	return &TokenBucket{Tokens: float64(limit.Burst), UpdatedAt: now}
+}
+
+// Take repõe os tokens do período e consome um, se houver
+func (b *TokenBucket) Take(limit RateLimit, now time.Time) RateLimitResult {
+	elapsed := now.Sub(b.UpdatedAt).Seconds()
+	if elapsed > 0 {
+		b.Tokens = math.Min(float64(limit.Burst), b.Tokens+elapsed*limit.Rate)
+		b.UpdatedAt = now
+	}
+
+	result := RateLimitResult{Limit: limit.Burst}
+	if b.Tokens >= 1 {
+		b.Tokens--
+		result.Allowed = true
+	} else {
+		result.RetryAfter = secondsToDuration((1 - b.Tokens) / limit.Rate)
+	}
+
+	result.Remaining = int(math.Floor(b.Tokens))
+	result.ResetAfter = secondsToDuration((float64(limit.Burst) - b.Tokens) / limit.Rate)
+	return result
+}
+
+// Idle informa se o bucket já teria enchido novamente, podendo ser descartado
+func (b *TokenBucket) Idle(limit RateLimit, now time.Time) bool {
+	return b.Tokens+now.Sub(b.UpdatedAt).Seconds()*limit.Rate >= float64(limit.Burst)
+}
+
+func secondsToDuration(seconds float64) time.Duration {
+	return time.Duration(seconds * float64(time.Second))
+}
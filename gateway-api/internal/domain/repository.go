@@ -1,15 +1,150 @@
 package domain
 
+import (
+	"context"
+	"time"
+)
+
 type AccountRepository interface {
 	Save(account *Account) error
 	FindByAPIKey(apiKey string) (*Account, error)
 	FindByID(id string) (*Account, error)
-	UpdateBalance(account *Account) error
+	// UpdateBalance soma amount ao saldo gravado e atualiza account.Balance com o resultado
+	UpdateBalance(account *Account, amount float64) error
+	// UpdateProfile grava nome, email e regra de 3DS; só contas ativas, senão retorna o erro do status atual
+	UpdateProfile(account *Account) error
+	// UpdateTier e UpdateStatus são ações do operador, gravadas junto com a entrada de auditoria
+	UpdateTier(ctx context.Context, account *Account, entry *AuditEntry) error
+	// UpdateStatus grava o novo status só se o gravado ainda for from, senão ErrInvalidAccountStatus
+	UpdateStatus(ctx context.Context, account *Account, from AccountStatus, entry *AuditEntry) error
+	UpdateAPIKey(account *Account) error
+	// AdjustBalance é o ajuste manual do operador: soma amount ao saldo e grava entry na mesma transação
+	// (com o saldo resultante em entry.Details["balance"])
+	AdjustBalance(ctx context.Context, account *Account, amount float64, entry *AuditEntry) error
+	// Close encerra a conta de forma atômica: recusa com ErrAccountHasPendingInvoices se houver faturas não resolvidas
+	Close(account *Account, reason string) error
 }
 
+// InvoiceRepository recebe o ctx para que as consultas apareçam no trace da cobrança
+// Save e UpdateStatus creditam o saldo da conta na mesma transação quando a fatura fica approved
 type InvoiceRepository interface {
-	Save(invoice *Invoice) error
-	FindByID(id string) (*Invoice, error)
-	FindByAccountID(accountID string) ([]*Invoice, error)
-	UpdateStatus(invoice *Invoice) error
-}
\ No newline at end of file
+	// Save grava a fatura e o outbox (opcional) na mesma transação
+	Save(ctx context.Context, invoice *Invoice, outbox *InvoiceOutbox) error
+	FindByID(ctx context.Context, id string) (*Invoice, error)
+	FindByAccountID(ctx context.Context, accountID string, filter InvoiceFilter) ([]*Invoice, error)
+	FindByExternalReference(ctx context.Context, accountID, externalReference string) (*Invoice, error)
+	// UpdateStatus grava a transição (e o outbox, opcional) só se a fatura ainda estiver em from;
+	// ErrInvalidStatus se outra chamada já a decidiu
+	UpdateStatus(ctx context.Context, invoice *Invoice, from Status, outbox *InvoiceOutbox) error
+	// Each percorre as faturas do filtro direto do cursor do banco, sem carregá-las em memória
+	// (mesma ordem do FindByAccountID); um erro de fn interrompe a leitura
+	Each(ctx context.Context, accountID string, filter InvoiceFilter, fn func(*Invoice) error) error
+}
+
+type CustomerRepository interface {
+	Save(customer *Customer) error
+	FindByID(id string) (*Customer, error)
+	FindByAccountID(accountID string) ([]*Customer, error)
+	Update(customer *Customer) error
+	// Delete é um soft delete do cliente e dos cartões: as faturas continuam vinculadas
+	Delete(id string) error
+}
+
+type PaymentMethodRepository interface {
+	Save(paymentMethod *PaymentMethod) error
+	FindByID(id string) (*PaymentMethod, error)
+	FindByCustomerID(customerID string) ([]*PaymentMethod, error)
+	Delete(id string) error
+}
+
+// ThreeDSChallengeRepository lê e responde os desafios; a criação acontece junto com a fatura (InvoiceOutbox)
+type ThreeDSChallengeRepository interface {
+	FindByID(id string) (*ThreeDSChallenge, error)
+	// Update grava a resposta só se o desafio ainda estiver pendente; ErrThreeDSChallengeCompleted se já foi respondido
+	Update(challenge *ThreeDSChallenge) error
+}
+
+type IdempotencyRepository interface {
+	// Create reserva a chave, retorna false se ela já existir
+	Create(record *IdempotencyRecord) (bool, error)
+	Find(accountID, key string) (*IdempotencyRecord, error)
+	// TakeOver assume uma chave em processing com o prazo vencido; previous é o LockedUntil lido
+	// retorna false se outra tentativa assumiu antes
+	TakeOver(record *IdempotencyRecord, previous time.Time) (bool, error)
+	// Complete e Delete só valem para o dono atual da chave (mesmo LockedUntil), senão ErrIdempotencyLockLost
+	Complete(record *IdempotencyRecord) error
+	Delete(record *IdempotencyRecord) error
+	DeleteExpired() (int64, error)
+}
+
+// AuditLogRepository guarda a trilha das ações administrativas (somente inserção)
+type AuditLogRepository interface {
+	Save(ctx context.Context, entry *AuditEntry) error
+	// List retorna as entradas mais recentes primeiro
+	List(ctx context.Context, filter AuditLogFilter) ([]*AuditEntry, error)
+}
+
+type WebhookEndpointRepository interface {
+	Save(ctx context.Context, endpoint *WebhookEndpoint) error
+	FindByID(ctx context.Context, id string) (*WebhookEndpoint, error)
+	FindByAccountID(ctx context.Context, accountID string) ([]*WebhookEndpoint, error)
+	Delete(ctx context.Context, id string) error
+}
+
+// WebhookDeliveryRepository controla as entregas; a criação acontece junto com a fatura (InvoiceOutbox)
+type WebhookDeliveryRepository interface {
+	FindByID(ctx context.Context, id string) (*WebhookDelivery, error)
+	// FindByEndpointID retorna o log de entregas do endpoint, mais recentes primeiro
+	FindByEndpointID(ctx context.Context, endpointID string, filter WebhookDeliveryFilter) ([]*WebhookDelivery, error)
+	// ClaimDue reserva até limit entregas pendentes vencidas, adiando next_attempt_at por lease
+	// para que outra instância não envie a mesma entrega ao mesmo tempo
+	ClaimDue(ctx context.Context, lease time.Duration, limit int) ([]*WebhookDelivery, error)
+	// RecordAttempt grava o resultado de uma tentativa (status, tentativas, próximo retry)
+	RecordAttempt(ctx context.Context, delivery *WebhookDelivery) error
+}
+
+// InvoiceEventRepository lê as mudanças de status das faturas para o stream SSE
+// os eventos são gravados junto com a fatura (InvoiceOutbox)
+type InvoiceEventRepository interface {
+	// FindAfter retorna os eventos da conta com ID maior que afterID, em ordem crescente
+	FindAfter(ctx context.Context, accountID string, afterID int64, limit int) ([]*InvoiceEvent, error)
+	// DeleteBefore remove os eventos antigos, que não podem mais ser retomados
+	DeleteBefore(ctx context.Context, before time.Time) (int64, error)
+}
+
+// InvoiceExportRepository guarda as exportações assíncronas de faturas
+type InvoiceExportRepository interface {
+	Save(ctx context.Context, export *InvoiceExport) error
+	FindByID(ctx context.Context, id string) (*InvoiceExport, error)
+	// ClaimPending reserva até limit exportações pendentes (ou com a reserva vencida) por lease,
+	// para que outra instância não gere o mesmo arquivo ao mesmo tempo
+	ClaimPending(ctx context.Context, lease time.Duration, limit int) ([]*InvoiceExport, error)
+	// Update grava o resultado (status, linhas, erro, expiração)
+	Update(ctx context.Context, export *InvoiceExport) error
+	// DeleteExpired remove as exportações expiradas e retorna os IDs, para os arquivos serem apagados
+	DeleteExpired(ctx context.Context, now time.Time) ([]string, error)
+}
+
+// InvoiceReportRepository agrega as faturas para os relatórios da conta
+type InvoiceReportRepository interface {
+	// Aggregate agrupa as faturas criadas em [from, to) por período, status, tipo de pagamento e bandeira
+	Aggregate(ctx context.Context, accountID string, granularity ReportGranularity, from, to time.Time) ([]InvoiceAggregate, error)
+	// AggregateRollup faz o mesmo a partir dos totais diários, sem ler as faturas
+	AggregateRollup(ctx context.Context, accountID string, granularity ReportGranularity, from, to time.Time) ([]InvoiceAggregate, error)
+	// RollupRefreshedUntil retorna até onde o rollup está atualizado; zero se ele nunca foi calculado
+	RollupRefreshedUntil(ctx context.Context) (time.Time, error)
+	// RefreshRollup recalcula os dias das faturas alteradas desde o último refresh e retorna quantos dias foram recalculados
+	RefreshRollup(ctx context.Context, until time.Time) (int, error)
+}
+
+// VelocityCounter conta tentativas por chave (conta, cartão, IP) em uma janela deslizante
+type VelocityCounter interface {
+	// Increment registra uma tentativa agora e retorna o total dentro da janela (incluindo esta)
+	Increment(key string, window time.Duration) (int, error)
+}
+
+// RateLimitStore guarda os token buckets do rate limiter por chave (conta ou IP)
+type RateLimitStore interface {
+	// Take consome um token do bucket da chave, criando-o cheio se ainda não existir
+	Take(key string, limit RateLimit) (RateLimitResult, error)
+}
@@ -0,0 +1,119 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ThreeDSMode define quando a conta exige autenticação 3-D Secure nas cobranças
+type ThreeDSMode string
+
+const (
+	ThreeDSModeOff         ThreeDSMode = "off"          // nunca exige 3DS
+	ThreeDSModeAlways      ThreeDSMode = "always"       // exige em todas as cobranças
+	ThreeDSModeAboveAmount ThreeDSMode = "above_amount" // exige a partir de MinAmount
+)
+
+// ThreeDSRule é a regra de 3DS configurada por conta
+type ThreeDSRule struct {
+	Mode      ThreeDSMode
+	MinAmount float64
+}
+
+func NewThreeDSRule(mode string, minAmount float64) (ThreeDSRule, error) {
+	rule := ThreeDSRule{Mode: ThreeDSMode(mode), MinAmount: minAmount}
+
+	switch rule.Mode {
+	case ThreeDSModeOff, ThreeDSModeAlways:
+		return rule, nil
+	case ThreeDSModeAboveAmount:
+		if minAmount <= 0 {
+			return ThreeDSRule{}, ErrInvalidThreeDSRule
+		}
+		return rule, nil
+	default:
+		return ThreeDSRule{}, ErrInvalidThreeDSRule
+	}
+}
+
+// Requires informa se uma cobrança desse valor precisa passar pelo 3DS
+func (r ThreeDSRule) Requires(amount float64) bool {
+	switch r.Mode {
+	case ThreeDSModeAlways:
+		return true
+	case ThreeDSModeAboveAmount:
+		return amount >= r.MinAmount
+	default:
+		return false
+	}
+}
+
+// ThreeDSResult é o resultado da autenticação guardado na Invoice
+type ThreeDSResult string
+
+const (
+	ThreeDSFrictionless ThreeDSResult = "frictionless" // emissor autenticou sem interação do comprador
+	ThreeDSChallenged   ThreeDSResult = "challenged"   // comprador passou pelo desafio com sucesso
+	ThreeDSFailed       ThreeDSResult = "failed"       // autenticação recusada
+)
+
+// valores de ECI (Electronic Commerce Indicator) no padrão Visa
+const (
+	ECIAuthenticated    = "05"
+	ECINotAuthenticated = "07"
+)
+
+type ThreeDSChallengeStatus string
+
+const (
+	ChallengeStatusPending       ThreeDSChallengeStatus = "pending"
+	ChallengeStatusAuthenticated ThreeDSChallengeStatus = "authenticated"
+	ChallengeStatusFailed        ThreeDSChallengeStatus = "failed"
+)
+
+// ThreeDSChallenge é o desafio aberto no ACS simulado para uma Invoice em requires_action
+type ThreeDSChallenge struct {
+	ID          string
+	InvoiceID   string
+	Status      ThreeDSChallengeStatus
+	ECI         string
+	CreatedAt   time.Time
+	CompletedAt *time.Time
+}
+
+func NewThreeDSChallenge(invoiceID string) *ThreeDSChallenge {
+	return &ThreeDSChallenge{
+		ID:        uuid.New().String(),
+		InvoiceID: invoiceID,
+		Status:    ChallengeStatusPending,
+		CreatedAt: time.Now(),
+	}
+}
+
+// Complete registra a resposta do comprador no ACS, um desafio só pode ser respondido uma vez
+func (c *ThreeDSChallenge) Complete(authenticated bool) error {
+	if c.Status != ChallengeStatusPending {
+		return ErrThreeDSChallengeCompleted
+	}
+
+	if authenticated {
+		c.Status = ChallengeStatusAuthenticated
+		c.ECI = ECIAuthenticated
+	} else {
+		c.Status = ChallengeStatusFailed
+		c.ECI = ECINotAuthenticated
+	}
+
+	now := time.Now()
+	c.CompletedAt = &now
+	return nil
+}
+
+// Result converte o desafio respondido no resultado que fica na Invoice
+func (c *ThreeDSChallenge) Result() ThreeDSResult {
+	if c.Status == ChallengeStatusAuthenticated {
+		return ThreeDSChallenged
+	}
+	return ThreeDSFailed
+}
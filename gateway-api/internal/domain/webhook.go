@@ -0,0 +1,213 @@
+package domain
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/url"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// WebhookEventType é o evento enviado para os endpoints do lojista
+type WebhookEventType string
+
+const (
+	WebhookInvoiceCreated  WebhookEventType = "invoice.created"
+	WebhookInvoiceApproved WebhookEventType = "invoice.approved"
+	WebhookInvoiceRejected WebhookEventType = "invoice.rejected"
+)
+
+// WebhookEventTypes são os eventos que podem ser assinados
+// refund.created fica de fora até o gateway ter estornos
+var WebhookEventTypes = []WebhookEventType{WebhookInvoiceCreated, WebhookInvoiceApproved, WebhookInvoiceRejected}
+
+// InvoiceWebhookEvent devolve o evento correspondente ao status final da fatura
+func InvoiceWebhookEvent(status Status) (WebhookEventType, bool) {
+	switch status {
+	case StatusApproved:
+		return WebhookInvoiceApproved, true
+	case StatusRejected:
+		return WebhookInvoiceRejected, true
+	}
+	return "", false
+}
+
+// WebhookEndpoint é uma URL do lojista que recebe os eventos assinados, assinados com o Secret
+type WebhookEndpoint struct {
+	ID         string
+	AccountID  string
+	URL        string
+	EventTypes []WebhookEventType
+	Secret     string // chave do HMAC-SHA256, devolvida apenas na criação
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+}
+
+func NewWebhookEndpoint(accountID, endpointURL string, eventTypes []string) (*WebhookEndpoint, error) {
+	if err := validateWebhookURL(endpointURL); err != nil {
+		return nil, err
+	}
+
+	types, err := parseWebhookEventTypes(eventTypes)
+	if err != nil {
+		return nil, err
+	}
+
+	secret, err := newWebhookSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	return &WebhookEndpoint{
+		ID:         uuid.New().String(),
+		AccountID:  accountID,
+		URL:        endpointURL,
+		EventTypes: types,
+		Secret:     secret,
+		CreatedAt:  time.Now(),
+		UpdatedAt:  time.Now(),
+	}, nil
+}
+
+// Subscribes indica se o endpoint assinou o evento
+func (e *WebhookEndpoint) Subscribes(eventType WebhookEventType) bool {
+	return slices.Contains(e.EventTypes, eventType)
+}
+
+// Sign gera o header X-Gateway-Signature: t=<unix>,v1=<hex HMAC-SHA256(secret, "<t>.<corpo>")>
+// o timestamp entra na assinatura para o lojista poder recusar reenvios antigos (replay)
+func (e *WebhookEndpoint) Sign(timestamp time.Time, payload []byte) string {
+	t := strconv.FormatInt(timestamp.Unix(), 10)
+
+	mac := hmac.New(sha256.New, []byte(e.Secret))
+	mac.Write([]byte(t + "."))
+	mac.Write(payload)
+
+	return "t=" + t + ",v1=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// validateWebhookURL aceita apenas URLs absolutas http(s), sem credenciais embutidas
+func validateWebhookURL(endpointURL string) error {
+	parsed, err := url.Parse(endpointURL)
+	if err != nil || parsed.Host == "" || parsed.User != nil {
+		return ErrInvalidWebhookURL
+	}
+	if parsed.Scheme != "https" && parsed.Scheme != "http" {
+		return ErrInvalidWebhookURL
+	}
+	return nil
+}
+
+// parseWebhookEventTypes recusa eventos desconhecidos e remove os repetidos
+func parseWebhookEventTypes(eventTypes []string) ([]WebhookEventType, error) {
+	if len(eventTypes) == 0 {
+		return nil, ErrInvalidWebhookEventType
+	}
+
+	var types []WebhookEventType
+	for _, eventType := range eventTypes {
+		parsed := WebhookEventType(eventType)
+		if !slices.Contains(WebhookEventTypes, parsed) {
+			return nil, ErrInvalidWebhookEventType
+		}
+		if !slices.Contains(types, parsed) {
+			types = append(types, parsed)
+		}
+	}
+	return types, nil
+}
+
+func newWebhookSecret() (string, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", err
+	}
+	return "whsec_" + hex.EncodeToString(secret), nil
+}
+
+// DeliveryStatus é a situação de uma entrega de webhook
+type DeliveryStatus string
+
+const (
+	DeliveryPending   DeliveryStatus = "pending"   // aguardando a primeira tentativa ou um retry
+	DeliverySucceeded DeliveryStatus = "succeeded" // o endpoint respondeu 2xx
+	DeliveryFailed    DeliveryStatus = "failed"    // tentativas esgotadas, só volta com o reenvio manual
+)
+
+// WebhookDelivery é o envio de um evento para um endpoint, com o histórico da última tentativa
+// o Payload é guardado já serializado para que os retries (e o reenvio manual) mandem exatamente o mesmo corpo
+type WebhookDelivery struct {
+	ID                 string
+	EndpointID         string
+	AccountID          string
+	EventID            string
+	EventType          WebhookEventType
+	Payload            []byte
+	Status             DeliveryStatus
+	Attempts           int
+	NextAttemptAt      *time.Time
+	LastResponseStatus int
+	LastError          string
+	CreatedAt          time.Time
+	UpdatedAt          time.Time
+	DeliveredAt        *time.Time
+}
+
+func NewWebhookDelivery(endpoint *WebhookEndpoint, eventID string, eventType WebhookEventType, payload []byte) *WebhookDelivery {
+	now := time.Now()
+	return &WebhookDelivery{
+		ID:            uuid.New().String(),
+		EndpointID:    endpoint.ID,
+		AccountID:     endpoint.AccountID,
+		EventID:       eventID,
+		EventType:     eventType,
+		Payload:       payload,
+		Status:        DeliveryPending,
+		NextAttemptAt: &now,
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	}
+}
+
+// RecordSuccess encerra a entrega após uma resposta 2xx
+func (d *WebhookDelivery) RecordSuccess(responseStatus int) {
+	now := time.Now()
+	d.Attempts++
+	d.Status = DeliverySucceeded
+	d.LastResponseStatus = responseStatus
+	d.LastError = ""
+	d.NextAttemptAt = nil
+	d.DeliveredAt = &now
+	d.UpdatedAt = now
+}
+
+// RecordFailure agenda o próximo retry com backoff exponencial (baseDelay, 2x, 4x...)
+// ao atingir maxAttempts a entrega fica failed; responseStatus é 0 quando o endpoint não respondeu
+func (d *WebhookDelivery) RecordFailure(responseStatus int, cause string, baseDelay time.Duration, maxAttempts int) {
+	now := time.Now()
+	d.Attempts++
+	d.LastResponseStatus = responseStatus
+	d.LastError = cause
+	d.UpdatedAt = now
+
+	if d.Attempts >= maxAttempts {
+		d.Status = DeliveryFailed
+		d.NextAttemptAt = nil
+		return
+	}
+
+	next := now.Add(baseDelay << (d.Attempts - 1))
+	d.Status = DeliveryPending
+	d.NextAttemptAt = &next
+}
+
+// WebhookDeliveryFilter são os filtros do log de entregas de um endpoint
+type WebhookDeliveryFilter struct {
+	Status DeliveryStatus
+	Limit  int
+}
@@ -7,18 +7,48 @@ import (
 )
 
 type CreateAccountInput struct {
-	Name  string `json:"name"`
-	Email string `jason:"email"`
+	Name  string `json:"name" validate:"required,max=255"`
+	Email string `json:"email" validate:"required,email,max=255"`
+}
+
+// UpdateAccountInput é usado no PATCH /accounts, campos omitidos não são alterados
+type UpdateAccountInput struct {
+	Name  string `json:"name" validate:"max=255"`
+	Email string `json:"email" validate:"email,max=255"`
+
+	// regra de 3DS: off, always ou above_amount (usa three_ds_min_amount)
+	ThreeDSMode      string   `json:"three_ds_mode" validate:"oneof=off always above_amount"`
+	ThreeDSMinAmount *float64 `json:"three_ds_min_amount" validate:"min=0"`
+}
+
+// AccountStatusInput carrega o motivo opcional das mudanças de status (reativar, encerrar)
+type AccountStatusInput struct {
+	Reason string `json:"reason" validate:"max=500"`
+}
+
+// AccountTierInput troca o plano da conta (rota administrativa)
+type AccountTierInput struct {
+	Tier string `json:"tier" validate:"required,oneof=standard premium enterprise"`
+}
+
+// SuspendAccountInput exige o motivo, a suspensão é uma ação de suporte que precisa ser justificada
+type SuspendAccountInput struct {
+	Reason string `json:"reason" validate:"required,max=500"`
 }
 
 type AccountOutput struct {
-	ID        string    `json:"id"`
-	Name      string    `json:"name"`
-	Email     string    `json:"email"`
-	APIKey    string    `json:"api_key,omitempty"`
-	Balance   float64   `json:"balance"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	ID           string    `json:"id"`
+	Name         string    `json:"name"`
+	Email        string    `json:"email"`
+	APIKey       string    `json:"api_key,omitempty"`
+	Balance      float64   `json:"balance"`
+	Status       string    `json:"status"`
+	StatusReason string    `json:"status_reason,omitempty"`
+	ThreeDSMode      string  `json:"three_ds_mode"`
+	ThreeDSMinAmount float64 `json:"three_ds_min_amount"`
+	Tier             string  `json:"tier"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
 }
 
 func ToAccount(input CreateAccountInput) *domain.Account {
@@ -32,6 +62,11 @@ func FromAccount(account *domain.Account) AccountOutput {
 		Email: account.Email,
 		Balance: account.Balance,
 		APIKey: account.APIKey,
+		Status: string(account.Status),
+		StatusReason: account.StatusReason,
+		ThreeDSMode: string(account.ThreeDSRule.Mode),
+		ThreeDSMinAmount: account.ThreeDSRule.MinAmount,
+		Tier: string(account.Tier),
 		CreatedAt: account.CreatedAt ,
 		UpdatedAt: account.UpdatedAt,
 	}
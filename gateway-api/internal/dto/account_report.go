@@ -0,0 +1,121 @@
+package dto
+
+import (
+	"math"
+	"time"
+
+	"github.com/j-ordep/gateway/go-gateway/internal/domain"
+)
+
+// AccountReportInput representa os filtros de GET /accounts/reports (query string)
+type AccountReportInput struct {
+	Granularity string // ?granularity=day (padrão), week ou month
+	From        string // ?from=2025-01-01 (UTC); padrão: 30 dias, 12 semanas ou 12 meses antes de to
+	To          string // ?to=2025-01-31 (UTC, inclusivo); padrão: hoje
+}
+
+// ToInvoiceReport valida a granularidade e o período; as datas são dias UTC, os mesmos do rollup
+func ToInvoiceReport(input AccountReportInput, accountID string, now time.Time) (*domain.InvoiceReport, error) {
+	granularity, err := domain.ParseReportGranularity(input.Granularity)
+	if err != nil {
+		return nil, err
+	}
+
+	to := domain.ReportDay.Truncate(now)
+	if input.To != "" {
+		if to, err = time.Parse(time.DateOnly, input.To); err != nil {
+			return nil, domain.ErrInvalidReportPeriod
+		}
+	}
+	// o último dia entra inteiro
+	to = to.AddDate(0, 0, 1)
+
+	from := granularity.DefaultFrom(to)
+	if input.From != "" {
+		if from, err = time.Parse(time.DateOnly, input.From); err != nil {
+			return nil, domain.ErrInvalidReportPeriod
+		}
+	}
+
+	return domain.NewInvoiceReport(accountID, granularity, from, to)
+}
+
+type AccountReportTotalsOutput struct {
+	Count  int     `json:"count"`
+	Amount float64 `json:"amount"`
+}
+
+// reembolsos ficam de fora: o gateway ainda não tem estornos, o campo entra junto com eles
+type AccountReportPeriodOutput struct {
+	PeriodStart   string                               `json:"period_start"` // YYYY-MM-DD
+	PeriodEnd     string                               `json:"period_end"`   // YYYY-MM-DD, último dia do período
+	Count         int                                  `json:"count"`
+	Amount        float64                              `json:"amount"`
+	ApprovalRate  *float64                             `json:"approval_rate"`  // null quando nenhuma fatura foi decidida
+	AverageTicket *float64                             `json:"average_ticket"` // null quando não há aprovadas
+	ByStatus      map[string]AccountReportTotalsOutput `json:"by_status"`
+	ByPaymentType map[string]AccountReportTotalsOutput `json:"by_payment_type"`
+	ByCardBrand   map[string]AccountReportTotalsOutput `json:"by_card_brand"`
+}
+
+type AccountReportOutput struct {
+	Granularity    string                      `json:"granularity"`
+	From           string                      `json:"from"` // início do primeiro período
+	To             string                      `json:"to"`   // último dia do último período
+	Source         string                      `json:"source"`
+	RefreshedUntil *time.Time                  `json:"refreshed_until,omitempty"` // apenas no rollup
+	Periods        []AccountReportPeriodOutput `json:"periods"`
+}
+
+func FromInvoiceReport(report *domain.InvoiceReport) *AccountReportOutput {
+	output := &AccountReportOutput{
+		Granularity: string(report.Granularity),
+		From:        report.From.Format(time.DateOnly),
+		To:          report.To.AddDate(0, 0, -1).Format(time.DateOnly),
+		Source:      string(report.Source),
+		Periods:     make([]AccountReportPeriodOutput, 0, len(report.Periods)),
+	}
+	if report.Source == domain.ReportFromRollup {
+		refreshedUntil := report.RefreshedUntil
+		output.RefreshedUntil = &refreshedUntil
+	}
+
+	for _, period := range report.Periods {
+		periodOutput := AccountReportPeriodOutput{
+			PeriodStart:   period.Start.Format(time.DateOnly),
+			PeriodEnd:     period.End.AddDate(0, 0, -1).Format(time.DateOnly),
+			Count:         period.Total.Count,
+			Amount:        roundAmount(period.Total.Amount),
+			ByStatus:      make(map[string]AccountReportTotalsOutput, len(period.ByStatus)),
+			ByPaymentType: fromReportTotals(period.ByPaymentType),
+			ByCardBrand:   fromReportTotals(period.ByCardBrand),
+		}
+		for status, totals := range period.ByStatus {
+			periodOutput.ByStatus[string(status)] = AccountReportTotalsOutput{Count: totals.Count, Amount: roundAmount(totals.Amount)}
+		}
+		if rate, ok := period.ApprovalRate(); ok {
+			rate = math.Round(rate*10000) / 10000
+			periodOutput.ApprovalRate = &rate
+		}
+		if ticket, ok := period.AverageTicket(); ok {
+			ticket = roundAmount(ticket)
+			periodOutput.AverageTicket = &ticket
+		}
+		output.Periods = append(output.Periods, periodOutput)
+	}
+
+	return output
+}
+
+func fromReportTotals(totals map[string]domain.ReportTotals) map[string]AccountReportTotalsOutput {
+	output := make(map[string]AccountReportTotalsOutput, len(totals))
+	for key, value := range totals {
+		output[key] = AccountReportTotalsOutput{Count: value.Count, Amount: roundAmount(value.Amount)}
+	}
+	return output
+}
+
+// roundAmount arredonda para centavos o que foi somado em float64
+func roundAmount(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
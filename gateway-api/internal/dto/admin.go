@@ -0,0 +1,80 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/j-ordep/gateway/go-gateway/internal/domain"
+)
+
+// ForceInvoiceStatusInput decide manualmente uma fatura pendente (antifraude fora do ar)
+type ForceInvoiceStatusInput struct {
+	Status string `json:"status" validate:"required,oneof=approved rejected"`
+	Reason string `json:"reason" validate:"required,max=500"`
+}
+
+// BalanceAdjustmentInput credita (valor positivo) ou debita (negativo) o saldo da conta
+type BalanceAdjustmentInput struct {
+	Amount float64 `json:"amount" validate:"required"`
+	Reason string  `json:"reason" validate:"required,max=500"`
+}
+
+// AuditLogFilterInput são os filtros de GET /admin/audit-log
+type AuditLogFilterInput struct {
+	OperatorID string // ?operator_id=...
+	TargetType string // ?target_type=account
+	TargetID   string // ?target_id=...
+	Limit      int    // ?limit=50
+}
+
+// limites da listagem de GET /admin/audit-log
+const (
+	DefaultAuditLogLimit = 50
+	MaxAuditLogLimit     = 200
+)
+
+func ToAuditLogFilter(input AuditLogFilterInput) domain.AuditLogFilter {
+	limit := input.Limit
+	if limit <= 0 {
+		limit = DefaultAuditLogLimit
+	}
+	if limit > MaxAuditLogLimit {
+		limit = MaxAuditLogLimit
+	}
+
+	return domain.AuditLogFilter{
+		OperatorID: input.OperatorID,
+		TargetType: input.TargetType,
+		TargetID:   input.TargetID,
+		Limit:      limit,
+	}
+}
+
+type AuditEntryOutput struct {
+	ID         string            `json:"id"`
+	OperatorID string            `json:"operator_id"`
+	Action     string            `json:"action"`
+	TargetType string            `json:"target_type"`
+	TargetID   string            `json:"target_id"`
+	Reason     string            `json:"reason"`
+	Details    map[string]string `json:"details,omitempty"`
+	RequestID  string            `json:"request_id,omitempty"`
+	CreatedAt  time.Time         `json:"created_at"`
+}
+
+type AuditLogOutput struct {
+	Data []*AuditEntryOutput `json:"data"`
+}
+
+func FromAuditEntry(entry *domain.AuditEntry) *AuditEntryOutput {
+	return &AuditEntryOutput{
+		ID:         entry.ID,
+		OperatorID: entry.OperatorID,
+		Action:     string(entry.Action),
+		TargetType: entry.TargetType,
+		TargetID:   entry.TargetID,
+		Reason:     entry.Reason,
+		Details:    entry.Details,
+		RequestID:  entry.RequestID,
+		CreatedAt:  entry.CreatedAt,
+	}
+}
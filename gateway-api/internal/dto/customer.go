@@ -0,0 +1,95 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/j-ordep/gateway/go-gateway/internal/domain"
+)
+
+type CreateCustomerInput struct {
+	Name     string `json:"name" validate:"required,max=255"`
+	Email    string `json:"email" validate:"email,max=255"`
+	Document string `json:"document" validate:"max=50"`
+	Phone    string `json:"phone" validate:"max=50"`
+}
+
+// UpdateCustomerInput é usado no PATCH /customers/{id}, campos omitidos não são alterados
+type UpdateCustomerInput struct {
+	Name     string `json:"name" validate:"max=255"`
+	Email    string `json:"email" validate:"email,max=255"`
+	Document string `json:"document" validate:"max=50"`
+	Phone    string `json:"phone" validate:"max=50"`
+}
+
+type CustomerOutput struct {
+	ID        string    `json:"id"`
+	AccountID string    `json:"account_id"`
+	Name      string    `json:"name"`
+	Email     string    `json:"email"`
+	Document  string    `json:"document"`
+	Phone     string    `json:"phone"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// CreatePaymentMethodInput recebe o cartão completo uma única vez, para tokenizar
+type CreatePaymentMethodInput struct {
+	CardNumber     string `json:"card_number" validate:"required,numeric,min=13,max=19"`
+	CVV            string `json:"cvv" validate:"required,numeric,min=3,max=4"`
+	ExpiryMonth    int    `json:"expiry_month" validate:"required,min=1,max=12"`
+	ExpiryYear     int    `json:"expiry_year" validate:"required,min=2000,max=9999"`
+	CardholderName string `json:"cardholder_name" validate:"required,max=255"`
+}
+
+type PaymentMethodOutput struct {
+	ID             string    `json:"id"`
+	CustomerID     string    `json:"customer_id"`
+	Brand          string    `json:"brand"`
+	CardLastDigits string    `json:"card_last_digits"`
+	ExpiryMonth    int       `json:"expiry_month"`
+	ExpiryYear     int       `json:"expiry_year"`
+	CardholderName string    `json:"cardholder_name"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+func ToCustomer(input CreateCustomerInput, accountID string) (*domain.Customer, error) {
+	return domain.NewCustomer(accountID, input.Name, input.Email, input.Document, input.Phone)
+}
+
+func FromCustomer(customer *domain.Customer) *CustomerOutput {
+	return &CustomerOutput{
+		ID:        customer.ID,
+		AccountID: customer.AccountID,
+		Name:      customer.Name,
+		Email:     customer.Email,
+		Document:  customer.Document,
+		Phone:     customer.Phone,
+		CreatedAt: customer.CreatedAt,
+		UpdatedAt: customer.UpdatedAt,
+	}
+}
+
+func ToPaymentMethod(input CreatePaymentMethodInput, accountID, customerID string) (*domain.PaymentMethod, error) {
+	card := domain.CreditCard{
+		Number:         input.CardNumber,
+		CVV:            input.CVV,
+		ExpiryMonth:    input.ExpiryMonth,
+		ExpiryYear:     input.ExpiryYear,
+		CardholderName: input.CardholderName,
+	}
+
+	return domain.NewPaymentMethod(accountID, customerID, card)
+}
+
+func FromPaymentMethod(paymentMethod *domain.PaymentMethod) *PaymentMethodOutput {
+	return &PaymentMethodOutput{
+		ID:             paymentMethod.ID,
+		CustomerID:     paymentMethod.CustomerID,
+		Brand:          paymentMethod.Brand,
+		CardLastDigits: paymentMethod.LastDigits,
+		ExpiryMonth:    paymentMethod.ExpiryMonth,
+		ExpiryYear:     paymentMethod.ExpiryYear,
+		CardholderName: paymentMethod.CardholderName,
+		CreatedAt:      paymentMethod.CreatedAt,
+	}
+}
@@ -0,0 +1,15 @@
+package dto
+
+// HealthOutput é a resposta do /healthz e do /readyz
+// status: ok, unavailable (alguma dependência fora) ou shutting_down (graceful shutdown em andamento)
+type HealthOutput struct {
+	Status string                       `json:"status"`
+	Checks map[string]HealthCheckOutput `json:"checks,omitempty"`
+}
+
+// HealthCheckOutput é o resultado de uma dependência verificada pelo /readyz
+type HealthCheckOutput struct {
+	Status    string `json:"status"` // up ou down
+	Error     string `json:"error,omitempty"`
+	LatencyMs int64  `json:"latency_ms"`
+}
@@ -1,6 +1,8 @@
 package dto
 
 import (
+	"encoding/base64"
+	"strings"
 	"time"
 
 	"github.com/j-ordep/gateway/go-gateway/internal/domain"
@@ -14,20 +16,57 @@ const (
 	StatusPending  = string(domain.StatusPending)
 	StatusApproved = string(domain.StatusApproved)
 	StatusRejected = string(domain.StatusRejected)
+	StatusRequiresAction = string(domain.StatusRequiresAction)
 )
 
 type CreateInvoiceInput struct {
-	APIKey         string // receber apiKey para buscar a account
-	Amount         float64 `json:"amount"`
-	Description    string  `json:"description"`
-	PaymentType    string  `json:"payment_type"`
-
-	// card
-	CardNumber     string  `json:"card_number"`
-	CVV            string  `json:"cvv"`
-	ExpiryMonth    int     `json:"expiry_mounth"`
-	ExpiryYear     int     `json:"expiry_year"`
-	CardholderName string  `json:"cardholder_name"`
+	ClientIP       string  `json:"-"` // preenchido pelo handler, usado no controle de velocidade
+	Amount         float64 `json:"amount" validate:"required,gt=0"`
+	Description    string  `json:"description" validate:"max=255"`
+	PaymentType    string  `json:"payment_type" validate:"required,oneof=credit_card"`
+
+	// card, obrigatório quando não é usado um cartão salvo
+	CardNumber     string  `json:"card_number" validate:"required_without=payment_method_id,numeric,min=13,max=19"`
+	CVV            string  `json:"cvv" validate:"required_without=payment_method_id,numeric,min=3,max=4"`
+	ExpiryMonth    int     `json:"expiry_month" validate:"required_without=payment_method_id,min=1,max=12"`
+	ExpiryYear     int     `json:"expiry_year" validate:"required_without=payment_method_id,min=2000,max=9999"`
+	CardholderName string  `json:"cardholder_name" validate:"required_without=payment_method_id,max=255"`
+
+	// vínculo com o pedido do lojista
+	ExternalReference string            `json:"external_reference" validate:"max=255"`
+	Metadata          map[string]string `json:"metadata" validate:"max=50"`
+
+	// comprador e cartão salvo (opcionais), com payment_method_id os dados do cartão podem ser omitidos
+	CustomerID      string `json:"customer_id" validate:"uuid"`
+	PaymentMethodID string `json:"payment_method_id" validate:"uuid"`
+}
+
+// InvoiceFilterInput representa os filtros de GET /invoices (query string)
+type InvoiceFilterInput struct {
+	ExternalReference string            // ?external_reference=...
+	Metadata          map[string]string // ?metadata[chave]=valor
+	CustomerID        string            // ?customer_id=...
+	Status            string            // ?status=approved
+	PaymentType       string            // ?payment_type=credit_card
+	MinAmount         float64           // ?min_amount=100
+	MaxAmount         float64           // ?max_amount=500
+	CreatedFrom       time.Time         // ?created_from=2025-01-01T00:00:00Z
+	CreatedTo         time.Time         // ?created_to=2025-02-01T00:00:00Z
+	CardLastDigits    string            // ?card_last_digits=1111
+	Limit             int               // ?limit=20
+	Cursor            string            // ?cursor=<next_cursor da página anterior>
+}
+
+// limites da paginação de GET /invoices
+const (
+	DefaultInvoiceListLimit = 20
+	MaxInvoiceListLimit     = 100
+)
+
+// InvoiceListOutput é uma página da listagem, next_cursor é null na última página
+type InvoiceListOutput struct {
+	Data       []*InvoiceOutput `json:"data"`
+	NextCursor *string          `json:"next_cursor"`
 }
 
 type InvoiceOutput struct {
@@ -38,26 +77,105 @@ type InvoiceOutput struct {
 	Description    string    `json:"description"`
 	PaymentType    string    `json:"payment_type"`
 	CardLastDigits string    `json:"card_last_digits"`
+	ExternalReference string            `json:"external_reference,omitempty"`
+	Metadata          map[string]string `json:"metadata"`
+	CustomerID        string            `json:"customer_id,omitempty"`
+	PaymentMethodID   string            `json:"payment_method_id,omitempty"`
+	ThreeDSResult     string            `json:"three_ds_result,omitempty"`
+	ThreeDSECI        string            `json:"three_ds_eci,omitempty"`
+	ChallengeURL      string            `json:"challenge_url,omitempty"` // preenchido quando status = requires_action
 	CreatedAt      time.Time `json:"created_at"`
 	UpdatedAt      time.Time `json:"updated_at"`
 }
 
-func ToInvoice(input CreateInvoiceInput, accountId string) (*domain.Invoice, error) {
-	card := domain.CreditCard{
+func ToCreditCard(input CreateInvoiceInput) domain.CreditCard {
+	return domain.CreditCard{
 		Number:         input.CardNumber,
 		CVV:            input.CVV,
 		ExpiryMonth:    input.ExpiryMonth,
 		ExpiryYear:     input.ExpiryYear,
 		CardholderName: input.CardholderName,
 	}
+}
+
+func ToInvoice(input CreateInvoiceInput, accountId string) (*domain.Invoice, error) {
+	card := ToCreditCard(input)
 
-	return domain.NewInvoice(
+	invoice, err := domain.NewInvoice(
 		accountId,
 		input.Amount,
 		input.Description,
 		input.PaymentType,
 		card,
 	)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := invoice.SetReference(input.ExternalReference, input.Metadata); err != nil {
+		return nil, err
+	}
+
+	return invoice, nil
+}
+
+func ToInvoiceFilter(input InvoiceFilterInput) (domain.InvoiceFilter, error) {
+	limit := input.Limit
+	if limit <= 0 {
+		limit = DefaultInvoiceListLimit
+	}
+	if limit > MaxInvoiceListLimit {
+		limit = MaxInvoiceListLimit
+	}
+
+	filter := domain.InvoiceFilter{
+		ExternalReference: input.ExternalReference,
+		Metadata:          input.Metadata,
+		CustomerID:        input.CustomerID,
+		Status:            domain.Status(input.Status),
+		PaymentType:       input.PaymentType,
+		MinAmount:         input.MinAmount,
+		MaxAmount:         input.MaxAmount,
+		CreatedFrom:       input.CreatedFrom,
+		CreatedTo:         input.CreatedTo,
+		CardLastDigits:    input.CardLastDigits,
+		Limit:             limit,
+	}
+
+	if input.Cursor != "" {
+		cursor, err := DecodeInvoiceCursor(input.Cursor)
+		if err != nil {
+			return domain.InvoiceFilter{}, err
+		}
+		filter.Cursor = cursor
+	}
+
+	return filter, nil
+}
+
+// EncodeInvoiceCursor gera um cursor opaco (base64) a partir da última fatura da página
+func EncodeInvoiceCursor(invoice *domain.Invoice) string {
+	raw := invoice.CreatedAt.Format(time.RFC3339Nano) + "|" + invoice.ID
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func DecodeInvoiceCursor(cursor string) (*domain.InvoiceCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, domain.ErrInvalidCursor
+	}
+
+	createdAt, id, found := strings.Cut(string(raw), "|")
+	if !found || id == "" {
+		return nil, domain.ErrInvalidCursor
+	}
+
+	parsed, err := time.Parse(time.RFC3339Nano, createdAt)
+	if err != nil {
+		return nil, domain.ErrInvalidCursor
+	}
+
+	return &domain.InvoiceCursor{CreatedAt: parsed, ID: id}, nil
 }
 
 func FromInvoice(invoice *domain.Invoice) *InvoiceOutput {
@@ -69,7 +187,21 @@ func FromInvoice(invoice *domain.Invoice) *InvoiceOutput {
 		Description:    invoice.Description,
 		PaymentType:    invoice.PaymentType,
 		CardLastDigits: invoice.CardLastDigits,
+		ExternalReference: invoice.ExternalReference,
+		Metadata:       metadataOrEmpty(invoice.Metadata),
+		CustomerID:     invoice.CustomerID,
+		PaymentMethodID: invoice.PaymentMethodID,
+		ThreeDSResult:  string(invoice.ThreeDSResult),
+		ThreeDSECI:     invoice.ThreeDSECI,
 		CreatedAt:      invoice.CreatedAt,
 		UpdatedAt:      invoice.UpdatedAt,
 	}
-}
\ No newline at end of file
+}
+
+// metadataOrEmpty garante que o JSON sempre tenha um objeto ({}), nunca null
+func metadataOrEmpty(metadata map[string]string) map[string]string {
+	if metadata == nil {
+		return map[string]string{}
+	}
+	return metadata
+}
@@ -0,0 +1,30 @@
+package dto
+
+// BatchInvoiceItemInput é um item de POST /invoices/batch
+// idempotency_key é opcional e vale só para o item: a retentativa do lote devolve as faturas já criadas sem cobrar de novo
+type BatchInvoiceItemInput struct {
+	IdempotencyKey string             `json:"idempotency_key" validate:"max=255"`
+	Invoice        CreateInvoiceInput `json:"invoice" validate:"required"`
+}
+
+// BatchInvoiceInput é o corpo de POST /invoices/batch; o limite de itens vem de INVOICE_BATCH_MAX_ITEMS
+type BatchInvoiceInput struct {
+	Items []BatchInvoiceItemInput `json:"items" validate:"required"`
+}
+
+// BatchInvoiceResultOutput é o resultado de um item, na mesma posição (index) do corpo
+// status é o código HTTP que o item teria no POST /invoice: 201 com invoice ou o erro com error
+type BatchInvoiceResultOutput struct {
+	Index          int            `json:"index"`
+	Status         int            `json:"status"`
+	IdempotencyKey string         `json:"idempotency_key,omitempty"`
+	Replayed       bool           `json:"replayed,omitempty"` // fatura criada por uma tentativa anterior com a mesma chave
+	Invoice        *InvoiceOutput `json:"invoice,omitempty"`
+	Error          any            `json:"error,omitempty"` // o mesmo envelope de erro das demais rotas
+}
+
+type BatchInvoiceOutput struct {
+	Created int                        `json:"created"`
+	Failed  int                        `json:"failed"`
+	Results []BatchInvoiceResultOutput `json:"results"`
+}
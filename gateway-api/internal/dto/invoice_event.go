@@ -0,0 +1,32 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/j-ordep/gateway/go-gateway/internal/domain"
+)
+
+// InvoiceStreamInput são os parâmetros de GET /invoices/stream
+type InvoiceStreamInput struct {
+	LastEventID int64  // header Last-Event-ID (ou ?last_event_id=): retoma a partir do evento seguinte
+	InvoiceID   string // ?invoice_id=: apenas os eventos de uma fatura
+}
+
+// InvoiceEventOutput é o data de cada evento invoice.status do stream
+type InvoiceEventOutput struct {
+	ID        int64     `json:"id"`
+	InvoiceID string    `json:"invoice_id"`
+	Status    string    `json:"status"`
+	Amount    float64   `json:"amount"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func FromInvoiceEvent(event *domain.InvoiceEvent) *InvoiceEventOutput {
+	return &InvoiceEventOutput{
+		ID:        event.ID,
+		InvoiceID: event.InvoiceID,
+		Status:    string(event.Status),
+		Amount:    event.Amount,
+		CreatedAt: event.CreatedAt,
+	}
+}
@@ -0,0 +1,195 @@
+package dto
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/j-ordep/gateway/go-gateway/internal/domain"
+)
+
+// InvoiceExportInput são os filtros da exportação: query string do GET /invoices/export ou corpo do POST /invoices/exports
+type InvoiceExportInput struct {
+	Format      string `json:"format"`       // csv (padrão) ou ndjson
+	Status      string `json:"status"`       // ?status=approved
+	PaymentType string `json:"payment_type"` // ?payment_type=credit_card
+	CreatedFrom string `json:"created_from"` // RFC3339, ou YYYY-MM-DD (início do dia no fuso)
+	CreatedTo   string `json:"created_to"`   // RFC3339 (exclusivo), ou YYYY-MM-DD (o dia inteiro entra)
+	Timezone    string `json:"timezone"`     // nome IANA, ex: America/Sao_Paulo; vazio usa INVOICE_EXPORT_TIMEZONE
+}
+
+// ToInvoiceExport valida o formato, o fuso e o período; as datas sem horário são interpretadas no fuso da exportação
+func ToInvoiceExport(input InvoiceExportInput, accountID, defaultTimezone string) (*domain.InvoiceExport, error) {
+	format, err := domain.ParseExportFormat(input.Format)
+	if err != nil {
+		return nil, err
+	}
+
+	timezone := input.Timezone
+	if timezone == "" {
+		timezone = defaultTimezone
+	}
+	location, err := time.LoadLocation(timezone)
+	if err != nil {
+		return nil, domain.ErrInvalidTimezone
+	}
+
+	filter := domain.InvoiceFilter{
+		Status:      domain.Status(input.Status),
+		PaymentType: input.PaymentType,
+	}
+	if filter.CreatedFrom, err = parseExportDate(input.CreatedFrom, location, false); err != nil {
+		return nil, err
+	}
+	if filter.CreatedTo, err = parseExportDate(input.CreatedTo, location, true); err != nil {
+		return nil, err
+	}
+	if !filter.CreatedFrom.IsZero() && !filter.CreatedTo.IsZero() && !filter.CreatedFrom.Before(filter.CreatedTo) {
+		return nil, domain.ErrInvalidExportPeriod
+	}
+
+	return domain.NewInvoiceExport(accountID, format, filter, timezone), nil
+}
+
+// parseExportDate aceita RFC3339 ou YYYY-MM-DD; no fim do período a data sem horário vira o início do dia seguinte
+func parseExportDate(value string, location *time.Location, end bool) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+
+	if parsed, err := time.Parse(time.RFC3339, value); err == nil {
+		return parsed, nil
+	}
+
+	parsed, err := time.ParseInLocation(time.DateOnly, value, location)
+	if err != nil {
+		return time.Time{}, domain.ErrInvalidExportPeriod
+	}
+	if end {
+		parsed = parsed.AddDate(0, 0, 1)
+	}
+	return parsed, nil
+}
+
+// InvoiceExportColumns é a ordem fixa das colunas do CSV e dos campos do NDJSON
+// colunas novas entram sempre no final, para não quebrar as planilhas que leem por posição
+var InvoiceExportColumns = []string{
+	"id",
+	"external_reference",
+	"status",
+	"amount",
+	"payment_type",
+	"card_last_digits",
+	"description",
+	"customer_id",
+	"created_at",
+	"updated_at",
+}
+
+// InvoiceExportRow é uma linha da exportação, com as datas já no fuso escolhido
+// os campos seguem a ordem de InvoiceExportColumns (o encoding/json respeita a ordem da struct)
+type InvoiceExportRow struct {
+	ID                string  `json:"id"`
+	ExternalReference string  `json:"external_reference"`
+	Status            string  `json:"status"`
+	Amount            float64 `json:"amount"`
+	PaymentType       string  `json:"payment_type"`
+	CardLastDigits    string  `json:"card_last_digits"`
+	Description       string  `json:"description"`
+	CustomerID        string  `json:"customer_id"`
+	CreatedAt         string  `json:"created_at"`
+	UpdatedAt         string  `json:"updated_at"`
+}
+
+func ToInvoiceExportRow(invoice *domain.Invoice, location *time.Location) InvoiceExportRow {
+	return InvoiceExportRow{
+		ID:                invoice.ID,
+		ExternalReference: invoice.ExternalReference,
+		Status:            string(invoice.Status),
+		Amount:            invoice.Amount,
+		PaymentType:       invoice.PaymentType,
+		CardLastDigits:    invoice.CardLastDigits,
+		Description:       invoice.Description,
+		CustomerID:        invoice.CustomerID,
+		CreatedAt:         invoice.CreatedAt.In(location).Format(time.RFC3339),
+		UpdatedAt:         invoice.UpdatedAt.In(location).Format(time.RFC3339),
+	}
+}
+
+// Record é a linha do CSV, na ordem de InvoiceExportColumns
+// os textos livres do lojista são neutralizados para a planilha não interpretá-los como fórmula
+func (r InvoiceExportRow) Record() []string {
+	return []string{
+		r.ID,
+		spreadsheetSafe(r.ExternalReference),
+		r.Status,
+		strconv.FormatFloat(r.Amount, 'f', 2, 64),
+		r.PaymentType,
+		r.CardLastDigits,
+		spreadsheetSafe(r.Description),
+		r.CustomerID,
+		r.CreatedAt,
+		r.UpdatedAt,
+	}
+}
+
+// spreadsheetSafe prefixa com ' os valores que o Excel/Sheets executariam como fórmula (CSV injection)
+func spreadsheetSafe(value string) string {
+	if value != "" && strings.ContainsRune("=+-@\t\r", rune(value[0])) {
+		return "'" + value
+	}
+	return value
+}
+
+// InvoiceExportOutput é a exportação assíncrona; download_url aparece quando o arquivo está pronto
+type InvoiceExportOutput struct {
+	ID          string               `json:"id"`
+	Format      string               `json:"format"`
+	Status      string               `json:"status"`
+	Filters     InvoiceExportFilters `json:"filters"`
+	Timezone    string               `json:"timezone"`
+	RowCount    int                  `json:"row_count"`
+	Error       string               `json:"error,omitempty"`
+	DownloadURL string               `json:"download_url,omitempty"`
+	CreatedAt   time.Time            `json:"created_at"`
+	CompletedAt *time.Time           `json:"completed_at"`
+	ExpiresAt   *time.Time           `json:"expires_at"`
+}
+
+// InvoiceExportFilters são os filtros gravados na exportação, com o período já resolvido no fuso
+type InvoiceExportFilters struct {
+	Status      string     `json:"status,omitempty"`
+	PaymentType string     `json:"payment_type,omitempty"`
+	CreatedFrom *time.Time `json:"created_from,omitempty"`
+	CreatedTo   *time.Time `json:"created_to,omitempty"`
+}
+
+func FromInvoiceExport(export *domain.InvoiceExport) *InvoiceExportOutput {
+	output := &InvoiceExportOutput{
+		ID:     export.ID,
+		Format: string(export.Format),
+		Status: string(export.Status),
+		Filters: InvoiceExportFilters{
+			Status:      string(export.Filter.Status),
+			PaymentType: export.Filter.PaymentType,
+		},
+		Timezone:    export.Timezone,
+		RowCount:    export.RowCount,
+		Error:       export.Error,
+		CreatedAt:   export.CreatedAt,
+		CompletedAt: export.CompletedAt,
+		ExpiresAt:   export.ExpiresAt,
+	}
+
+	if !export.Filter.CreatedFrom.IsZero() {
+		output.Filters.CreatedFrom = &export.Filter.CreatedFrom
+	}
+	if !export.Filter.CreatedTo.IsZero() {
+		output.Filters.CreatedTo = &export.Filter.CreatedTo
+	}
+	if export.Status == domain.ExportCompleted {
+		output.DownloadURL = "/v1/invoices/exports/" + export.ID + "/download"
+	}
+
+	return output
+}
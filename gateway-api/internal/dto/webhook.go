@@ -0,0 +1,111 @@
+package dto
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/j-ordep/gateway/go-gateway/internal/domain"
+)
+
+// CreateWebhookEndpointInput cadastra uma URL para receber os eventos listados em event_types
+type CreateWebhookEndpointInput struct {
+	URL        string   `json:"url" validate:"required,max=2048"`
+	EventTypes []string `json:"event_types" validate:"required,min=1"`
+}
+
+// WebhookEndpointOutput só traz o secret na resposta da criação
+type WebhookEndpointOutput struct {
+	ID         string    `json:"id"`
+	AccountID  string    `json:"account_id"`
+	URL        string    `json:"url"`
+	EventTypes []string  `json:"event_types"`
+	Secret     string    `json:"secret,omitempty"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+func FromWebhookEndpoint(endpoint *domain.WebhookEndpoint) *WebhookEndpointOutput {
+	eventTypes := make([]string, len(endpoint.EventTypes))
+	for i, eventType := range endpoint.EventTypes {
+		eventTypes[i] = string(eventType)
+	}
+
+	return &WebhookEndpointOutput{
+		ID:         endpoint.ID,
+		AccountID:  endpoint.AccountID,
+		URL:        endpoint.URL,
+		EventTypes: eventTypes,
+		CreatedAt:  endpoint.CreatedAt,
+		UpdatedAt:  endpoint.UpdatedAt,
+	}
+}
+
+// WebhookEvent é o corpo enviado ao endpoint do lojista
+type WebhookEvent struct {
+	ID        string    `json:"id"`
+	Type      string    `json:"type"`
+	CreatedAt time.Time `json:"created_at"`
+	Data      any       `json:"data"`
+}
+
+// WebhookDeliveryFilterInput são os filtros de GET /webhooks/{id}/deliveries
+type WebhookDeliveryFilterInput struct {
+	Status string // ?status=failed
+	Limit  int    // ?limit=50
+}
+
+// limites do log de entregas
+const (
+	DefaultWebhookDeliveryLimit = 50
+	MaxWebhookDeliveryLimit     = 200
+)
+
+func ToWebhookDeliveryFilter(input WebhookDeliveryFilterInput) domain.WebhookDeliveryFilter {
+	limit := input.Limit
+	if limit <= 0 {
+		limit = DefaultWebhookDeliveryLimit
+	}
+	if limit > MaxWebhookDeliveryLimit {
+		limit = MaxWebhookDeliveryLimit
+	}
+
+	return domain.WebhookDeliveryFilter{Status: domain.DeliveryStatus(input.Status), Limit: limit}
+}
+
+type WebhookDeliveryOutput struct {
+	ID                 string          `json:"id"`
+	EndpointID         string          `json:"endpoint_id"`
+	EventID            string          `json:"event_id"`
+	EventType          string          `json:"event_type"`
+	Payload            json.RawMessage `json:"payload"`
+	Status             string          `json:"status"`
+	Attempts           int             `json:"attempts"`
+	NextAttemptAt      *time.Time      `json:"next_attempt_at"`
+	LastResponseStatus int             `json:"last_response_status,omitempty"`
+	LastError          string          `json:"last_error,omitempty"`
+	CreatedAt          time.Time       `json:"created_at"`
+	UpdatedAt          time.Time       `json:"updated_at"`
+	DeliveredAt        *time.Time      `json:"delivered_at"`
+}
+
+type WebhookDeliveryListOutput struct {
+	Data []*WebhookDeliveryOutput `json:"data"`
+}
+
+func FromWebhookDelivery(delivery *domain.WebhookDelivery) *WebhookDeliveryOutput {
+	return &WebhookDeliveryOutput{
+		ID:                 delivery.ID,
+		EndpointID:         delivery.EndpointID,
+		EventID:            delivery.EventID,
+		EventType:          string(delivery.EventType),
+		Payload:            json.RawMessage(delivery.Payload),
+		Status:             string(delivery.Status),
+		Attempts:           delivery.Attempts,
+		NextAttemptAt:      delivery.NextAttemptAt,
+		LastResponseStatus: delivery.LastResponseStatus,
+		LastError:          delivery.LastError,
+		CreatedAt:          delivery.CreatedAt,
+		UpdatedAt:          delivery.UpdatedAt,
+		DeliveredAt:        delivery.DeliveredAt,
+	}
+}
@@ -0,0 +1,79 @@
+package logging
+
+import (
+	"context"
+	"sync"
+)
+
+type contextKey struct{}
+
+// fields são os dados de correlação de uma requisição (ou mensagem do kafka)
+// o ponteiro fica no contexto para que middlewares internos (ex: autenticação) preencham
+// o account_id e o access log, que roda por fora, enxergue o valor
+type fields struct {
+	mu         sync.RWMutex
+	requestID  string
+	accountID  string
+	operatorID string
+}
+
+// WithRequestID devolve um contexto carregando o request ID, usado por ContextHandler nos logs
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, contextKey{}, &fields{requestID: requestID})
+}
+
+// RequestID devolve o request ID do contexto, vazio quando não houver
+func RequestID(ctx context.Context) string {
+	f, ok := ctx.Value(contextKey{}).(*fields)
+	if !ok {
+		return ""
+	}
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	return f.requestID
+}
+
+// SetAccountID registra a conta autenticada no contexto criado por WithRequestID
+// sem request ID no contexto a chamada é ignorada
+func SetAccountID(ctx context.Context, accountID string) {
+	f, ok := ctx.Value(contextKey{}).(*fields)
+	if !ok {
+		return
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.accountID = accountID
+}
+
+// AccountID devolve a conta registrada por SetAccountID
+func AccountID(ctx context.Context) string {
+	f, ok := ctx.Value(contextKey{}).(*fields)
+	if !ok {
+		return ""
+	}
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	return f.accountID
+}
+
+// SetOperatorID registra o operador autenticado nas rotas /admin, mesma regra de SetAccountID
+func SetOperatorID(ctx context.Context, operatorID string) {
+	f, ok := ctx.Value(contextKey{}).(*fields)
+	if !ok {
+		return
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.operatorID = operatorID
+}
+
+// OperatorID devolve o operador registrado por SetOperatorID
+func OperatorID(ctx context.Context) string {
+	f, ok := ctx.Value(contextKey{}).(*fields)
+	if !ok {
+		return ""
+	}
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	return f.operatorID
+}
@@ -0,0 +1,18 @@
+package metrics
+
+import "github.com/j-ordep/gateway/go-gateway/internal/domain"
+
+// InvoiceCreated registra a fatura recém-salva; se já nasceu aprovada/rejeitada conta também como decisão
+func InvoiceCreated(invoice *domain.Invoice) {
+	InvoicesCreatedTotal.WithLabelValues(string(invoice.Status), invoice.PaymentType).Inc()
+	InvoiceDecided(invoice)
+}
+
+// InvoiceDecided registra faturas em status final (approved/rejected); demais status são ignorados
+func InvoiceDecided(invoice *domain.Invoice) {
+	if invoice.Status != domain.StatusApproved && invoice.Status != domain.StatusRejected {
+		return
+	}
+	InvoiceDecisionsTotal.WithLabelValues(string(invoice.Status), invoice.PaymentType).Inc()
+	InvoiceAmountTotal.WithLabelValues(string(invoice.Status), invoice.PaymentType).Add(invoice.Amount)
+}
@@ -0,0 +1,33 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/segmentio/kafka-go"
+)
+
+var kafkaConsumerLagDesc = prometheus.NewDesc(
+	prometheus.BuildFQName(namespace, "kafka", "consumer_lag"),
+	"Mensagens ainda não lidas pelo consumer (kafka.Reader.Stats().Lag).",
+	[]string{"topic", "group_id"}, nil,
+)
+
+// kafkaReaderCollector lê o lag no momento do scrape
+// Stats() zera os contadores internos do reader a cada chamada, por isso só o lag (gauge) é usado
+type kafkaReaderCollector struct {
+	stats   func() kafka.ReaderStats
+	groupID string
+}
+
+// RegisterKafkaReader expõe o lag do consumer; stats normalmente é o Stats do kafka.Reader
+func RegisterKafkaReader(groupID string, stats func() kafka.ReaderStats) {
+	Registry.MustRegister(&kafkaReaderCollector{stats: stats, groupID: groupID})
+}
+
+func (c *kafkaReaderCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- kafkaConsumerLagDesc
+}
+
+func (c *kafkaReaderCollector) Collect(ch chan<- prometheus.Metric) {
+	stats := c.stats()
+	ch <- prometheus.MustNewConstMetric(kafkaConsumerLagDesc, prometheus.GaugeValue, float64(stats.Lag), stats.Topic, c.groupID)
+}
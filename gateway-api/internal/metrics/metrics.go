@@ -0,0 +1,122 @@
+package metrics
+
+import (
+	"database/sql"
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "gateway"
+
+// Registry próprio em vez do global do client, assim só as métricas do gateway (+ runtime/processo) são expostas
+var Registry = prometheus.NewRegistry()
+
+var (
+	// HTTP, por padrão de rota (ex: /v1/invoice/{id}) para não explodir a cardinalidade com IDs
+	HTTPRequestsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_total",
+		Help:      "Total de requisições HTTP por método, rota e status.",
+	}, []string{"method", "route", "status"})
+
+	HTTPRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "Latência das requisições HTTP por método, rota e status.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+
+	// gRPC, por método completo (ex: /gateway.v1.InvoiceService/GetInvoice) e código de status
+	GRPCRequestsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "grpc_requests_total",
+		Help:      "Total de chamadas gRPC por método e código de status.",
+	}, []string{"method", "code"})
+
+	GRPCRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "grpc_request_duration_seconds",
+		Help:      "Latência das chamadas gRPC por método e código de status (streams contam até o encerramento).",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "code"})
+
+	// negócio
+	InvoicesCreatedTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "invoices_created_total",
+		Help:      "Faturas criadas por status inicial e tipo de pagamento.",
+	}, []string{"status", "payment_type"})
+
+	// taxa de aprovação = rate(approved) / rate(approved + rejected)
+	InvoiceDecisionsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "invoice_decisions_total",
+		Help:      "Faturas que chegaram ao status final (approved ou rejected) por tipo de pagamento.",
+	}, []string{"status", "payment_type"})
+
+	InvoiceAmountTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "invoice_amount_total",
+		Help:      "Soma dos valores das faturas que chegaram ao status final, por status e tipo de pagamento.",
+	}, []string{"status", "payment_type"})
+
+	// result: succeeded, retrying (novo retry agendado) ou failed (tentativas esgotadas)
+	WebhookDeliveryAttemptsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "webhook_delivery_attempts_total",
+		Help:      "Tentativas de entrega de webhooks por tipo de evento e resultado.",
+	}, []string{"event_type", "result"})
+
+	// kafka
+	KafkaProduceDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "kafka_produce_duration_seconds",
+		Help:      "Latência do envio de mensagens ao Kafka por tópico.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"topic"})
+
+	KafkaProduceErrorsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "kafka_produce_errors_total",
+		Help:      "Falhas no envio de mensagens ao Kafka por tópico.",
+	}, []string{"topic"})
+
+	// result: processed, failed (erro transitório, a mensagem é reprocessada), discarded (fatura já decidida ou inexistente)
+	// ou invalid (mensagem que não pôde ser lida)
+	KafkaConsumerMessagesTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "kafka_consumer_messages_total",
+		Help:      "Mensagens consumidas do Kafka por tópico e resultado.",
+	}, []string{"topic", "result"})
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		HTTPRequestsTotal,
+		HTTPRequestDuration,
+		GRPCRequestsTotal,
+		GRPCRequestDuration,
+		InvoicesCreatedTotal,
+		InvoiceDecisionsTotal,
+		InvoiceAmountTotal,
+		WebhookDeliveryAttemptsTotal,
+		KafkaProduceDuration,
+		KafkaProduceErrorsTotal,
+		KafkaConsumerMessagesTotal,
+	)
+}
+
+// RegisterDB expõe as estatísticas do pool de conexões (sql.DB.Stats) nas métricas go_sql_*, com o label db_name
+func RegisterDB(db *sql.DB, name string) {
+	Registry.MustRegister(collectors.NewDBStatsCollector(db, name))
+}
+
+// Handler serve as métricas no formato texto do Prometheus
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{Registry: Registry})
+}
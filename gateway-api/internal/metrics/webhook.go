@@ -0,0 +1,12 @@
+package metrics
+
+import "github.com/j-ordep/gateway/go-gateway/internal/domain"
+
+// WebhookAttempt registra o resultado da última tentativa de entrega
+func WebhookAttempt(delivery *domain.WebhookDelivery) {
+	result := string(delivery.Status)
+	if delivery.Status == domain.DeliveryPending {
+		result = "retrying"
+	}
+	WebhookDeliveryAttemptsTotal.WithLabelValues(string(delivery.EventType), result).Inc()
+}
@@ -1,10 +1,13 @@
 package repository
 
 import (
+	"context"
 	"database/sql"
+	"strconv"
 	"time"
 
 	"github.com/j-ordep/gateway/go-gateway/internal/domain"
+	"github.com/lib/pq"
 )
 
 type AccountRepository struct {
@@ -23,8 +26,8 @@ func (repo *AccountRepository) Save(account *domain.Account) error {
     // - Validação da sintaxe SQL antes da execução
     // Neste caso, usamos Prepare pois não precisamos de transação ou lock de linha
 	stmt, err := repo.db.Prepare(`
-		INSERT INTO accounts (id, name, email, api_key, balance, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7)	
+		INSERT INTO accounts (id, name, email, api_key, balance, status, status_reason, three_ds_mode, three_ds_min_amount, tier, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)	
 	`)
 	if err != nil {
 		return err
@@ -37,11 +40,16 @@ func (repo *AccountRepository) Save(account *domain.Account) error {
 		account.Email,
 		account.APIKey,
 		account.Balance,
+		account.Status,
+		account.StatusReason,
+		account.ThreeDSRule.Mode,
+		account.ThreeDSRule.MinAmount,
+		account.Tier,
 		account.CreatedAt,
 		account.UpdatedAt,
 	)
 	if err != nil {
-		return err
+		return translateAccountError(err)
 	}
 
 	return nil
@@ -49,7 +57,7 @@ func (repo *AccountRepository) Save(account *domain.Account) error {
 
 func (repo *AccountRepository) FindByAPIKey(apiKey string) (*domain.Account, error) {
 	query := `
-		SELECT id, name, email, api_key, balance, created_at, updated_at
+		SELECT id, name, email, api_key, balance, status, status_reason, three_ds_mode, three_ds_min_amount, tier, created_at, updated_at
 		FROM accounts
 		WHERE api_key = $1
 	`
@@ -66,6 +74,11 @@ func (repo *AccountRepository) FindByAPIKey(apiKey string) (*domain.Account, err
 		&account.Email,
 		&account.APIKey,
 		&account.Balance,
+		&account.Status,
+		&account.StatusReason,
+		&account.ThreeDSRule.Mode,
+		&account.ThreeDSRule.MinAmount,
+		&account.Tier,
 		&createdAt,
 		&updatedAt,
 	)
@@ -89,7 +102,7 @@ func (r *AccountRepository) FindByID(id string) (*domain.Account, error) {
 	var createdAt, updatedAt time.Time
 
 	err := r.db.QueryRow(`
-		SELECT id, name, email, api_key, balance, created_at, updated_at
+		SELECT id, name, email, api_key, balance, status, status_reason, three_ds_mode, three_ds_min_amount, tier, created_at, updated_at
 		FROM accounts
 		WHERE id = $1
 	`, id).Scan(
@@ -98,6 +111,11 @@ func (r *AccountRepository) FindByID(id string) (*domain.Account, error) {
 		&account.Email,
 		&account.APIKey,
 		&account.Balance,
+		&account.Status,
+		&account.StatusReason,
+		&account.ThreeDSRule.Mode,
+		&account.ThreeDSRule.MinAmount,
+		&account.Tier,
 		&createdAt,
 		&updatedAt,
 	)
@@ -113,7 +131,7 @@ func (r *AccountRepository) FindByID(id string) (*domain.Account, error) {
 	return &account, nil
 }
 
-func (repo *AccountRepository) UpdateBalance(account *domain.Account) error {
+func (repo *AccountRepository) UpdateBalance(account *domain.Account, amount float64) error {
 	
 	// 1. FOR UPDATE: Permite fazer lock pessimista na linha (impede leituras/escritas concorrentes)
     // 2. Atomicidade: Garante que SELECT + UPDATE aconteçam como uma operação única
@@ -125,9 +143,40 @@ func (repo *AccountRepository) UpdateBalance(account *domain.Account) error {
 	}
 	defer tx.Rollback()
 	
+	if err := addBalance(tx, account, amount); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// AdjustBalance é o ajuste manual do operador: o saldo e a entrada de auditoria são gravados na mesma transação,
+// então não existe ajuste sem trilha nem trilha sem ajuste; o saldo resultante vai em entry.Details["balance"]
+func (repo *AccountRepository) AdjustBalance(ctx context.Context, account *domain.Account, amount float64, entry *domain.AuditEntry) error {
+	tx, err := repo.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := addBalance(tx, account, amount); err != nil {
+		return err
+	}
+
+	if entry.Details == nil {
+		entry.Details = make(map[string]string)
+	}
+	entry.Details["balance"] = strconv.FormatFloat(account.Balance, 'f', -1, 64)
+	if err := insertAuditEntry(ctx, tx, entry); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// addBalance soma amount ao saldo lido com lock (FOR UPDATE) dentro da transação
+func addBalance(tx *sql.Tx, account *domain.Account, amount float64) error {
 	var currentBalance float64
 
-	err = tx.QueryRow(`SELECT balance FROM accounts WHERE id = $1 FOR UPDATE`, account.ID).Scan(&currentBalance)
+	err := tx.QueryRow(`SELECT balance FROM accounts WHERE id = $1 FOR UPDATE`, account.ID).Scan(&currentBalance)
 	if err == sql.ErrNoRows {
 		return domain.ErrAccountNotFound
 	}
@@ -135,13 +184,203 @@ func (repo *AccountRepository) UpdateBalance(account *domain.Account) error {
 		return err
 	}
 
+	// a soma parte do saldo lido com lock, não do account em memória (que outra transação pode ter deixado desatualizado)
+	account.Balance = currentBalance
+	account.AddBalance(amount)
+
 	_, err = tx.Exec(`
 		UPDATE accounts
 		SET balance = $1, updated_at = $2
 		WHERE id = $3
 	`, account.Balance, time.Now(), account.ID)
+	return err
+}
+
+// UpdateProfile persiste os dados cadastrais e a regra de 3DS, só das contas ativas
+// as colunas de status e plano ficam de fora: um PATCH concorrente com uma suspensão ou um encerramento não desfaz a mudança de status
+func (repo *AccountRepository) UpdateProfile(account *domain.Account) error {
+	result, err := repo.db.Exec(`
+		UPDATE accounts
+		SET name = $1, email = $2, three_ds_mode = $3, three_ds_min_amount = $4, updated_at = $5
+		WHERE id = $6 AND status = $7
+	`, account.Name, account.Email, account.ThreeDSRule.Mode, account.ThreeDSRule.MinAmount, account.UpdatedAt, account.ID, domain.AccountStatusActive)
+	if err != nil {
+		return translateAccountError(err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		// a conta não existe ou deixou de estar ativa depois da leitura
+		current, err := repo.FindByID(account.ID)
+		if err != nil {
+			return err
+		}
+		if err := current.CanTransact(); err != nil {
+			return err
+		}
+		return domain.ErrInvalidAccountStatus
+	}
+
+	return nil
+}
+
+// UpdateTier persiste só o plano da conta, com a entrada de auditoria na mesma transação
+func (repo *AccountRepository) UpdateTier(ctx context.Context, account *domain.Account, entry *domain.AuditEntry) error {
+	tx, err := repo.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx, `
+		UPDATE accounts
+		SET tier = $1, updated_at = $2
+		WHERE id = $3
+	`, account.Tier, account.UpdatedAt, account.ID)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
 	if err != nil {
 		return err
 	}
+
+	if rowsAffected == 0 {
+		return domain.ErrAccountNotFound
+	}
+
+	if err := insertAuditEntry(ctx, tx, entry); err != nil {
+		return err
+	}
 	return tx.Commit()
-}
\ No newline at end of file
+}
+
+// UpdateStatus persiste a suspensão/reativação condicionada ao status lido (from), com a entrada de auditoria na mesma transação
+// se outra ação mudou o status no meio do caminho nada é gravado e retorna ErrInvalidAccountStatus
+func (repo *AccountRepository) UpdateStatus(ctx context.Context, account *domain.Account, from domain.AccountStatus, entry *domain.AuditEntry) error {
+	tx, err := repo.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx, `
+		UPDATE accounts
+		SET status = $1, status_reason = $2, updated_at = $3
+		WHERE id = $4 AND status = $5
+	`, account.Status, account.StatusReason, account.UpdatedAt, account.ID, from)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		if _, err := repo.FindByID(account.ID); err != nil {
+			return err
+		}
+		return domain.ErrInvalidAccountStatus
+	}
+
+	if err := insertAuditEntry(ctx, tx, entry); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// Close encerra a conta em uma transação com lock na linha da conta
+// InvoiceRepository.Save trava a mesma linha antes de gravar, então nenhuma fatura nova entra entre a contagem e o encerramento
+func (repo *AccountRepository) Close(account *domain.Account, reason string) error {
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// o status e o saldo validados pelo account.Close são os lidos com lock, não os do account em memória
+	var balance float64
+	var status domain.AccountStatus
+	err = tx.QueryRow(`SELECT balance, status FROM accounts WHERE id = $1 FOR UPDATE`, account.ID).Scan(&balance, &status)
+	if err == sql.ErrNoRows {
+		return domain.ErrAccountNotFound
+	}
+	if err != nil {
+		return err
+	}
+	account.Balance = balance
+	account.Status = status
+
+	unresolved := make([]string, len(domain.UnresolvedStatuses))
+	for i, status := range domain.UnresolvedStatuses {
+		unresolved[i] = string(status)
+	}
+
+	var count int
+	err = tx.QueryRow(`
+		SELECT COUNT(*)
+		FROM invoices
+		WHERE account_id = $1 AND status = ANY($2)
+	`, account.ID, pq.Array(unresolved)).Scan(&count)
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return domain.ErrAccountHasPendingInvoices
+	}
+
+	if err := account.Close(reason); err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`
+		UPDATE accounts
+		SET status = $1, status_reason = $2, updated_at = $3
+		WHERE id = $4
+	`, account.Status, account.StatusReason, account.UpdatedAt, account.ID)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// UpdateAPIKey persiste a rotação da apiKey
+func (repo *AccountRepository) UpdateAPIKey(account *domain.Account) error {
+	result, err := repo.db.Exec(`
+		UPDATE accounts
+		SET api_key = $1, updated_at = $2
+		WHERE id = $3
+	`, account.APIKey, account.UpdatedAt, account.ID)
+	if err != nil {
+		return translateAccountError(err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return domain.ErrAccountNotFound
+	}
+
+	return nil
+}
+
+// translateAccountError converte as violações das constraints UNIQUE de accounts em erros de domínio
+func translateAccountError(err error) error {
+	switch {
+	case isUniqueViolation(err, "accounts_email_key"):
+		return domain.ErrDuplicatedEmail
+	case isUniqueViolation(err, "accounts_api_key_key"):
+		return domain.ErrDuplicatedAPIKey
+	}
+	return err
+}
@@ -0,0 +1,118 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/j-ordep/gateway/go-gateway/internal/domain"
+	"github.com/j-ordep/gateway/go-gateway/internal/tracing"
+)
+
+type AuditLogRepository struct {
+	db *sql.DB
+}
+
+func NewAuditLogRepository(db *sql.DB) *AuditLogRepository {
+	return &AuditLogRepository{db: db}
+}
+
+func (r *AuditLogRepository) Save(ctx context.Context, entry *domain.AuditEntry) (err error) {
+	ctx, span := tracing.StartRepository(ctx, "AuditLogRepository.Save", "INSERT", "admin_audit_log")
+	defer func() { tracing.End(span, err) }()
+
+	return insertAuditEntry(ctx, r.db, entry)
+}
+
+// execer é satisfeito por *sql.DB e *sql.Tx: a auditoria pode entrar na transação da própria ação
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+}
+
+func insertAuditEntry(ctx context.Context, db execer, entry *domain.AuditEntry) (err error) {
+	details := []byte("{}")
+	if len(entry.Details) > 0 {
+		if details, err = json.Marshal(entry.Details); err != nil {
+			return err
+		}
+	}
+
+	_, err = db.ExecContext(ctx, `
+		INSERT INTO admin_audit_log (id, operator_id, action, target_type, target_id, reason, details, request_id, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+	`, entry.ID, entry.OperatorID, entry.Action, entry.TargetType, entry.TargetID, entry.Reason, details, entry.RequestID, entry.CreatedAt)
+	return err
+}
+
+func (r *AuditLogRepository) List(ctx context.Context, filter domain.AuditLogFilter) (entries []*domain.AuditEntry, err error) {
+	ctx, span := tracing.StartRepository(ctx, "AuditLogRepository.List", "SELECT", "admin_audit_log")
+	defer func() { tracing.End(span, err) }()
+
+	var conditions []string
+	var args []any
+	addCondition := func(condition string, value any) {
+		args = append(args, value)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+
+	if filter.OperatorID != "" {
+		addCondition("operator_id = $%d", filter.OperatorID)
+	}
+	if filter.TargetType != "" {
+		addCondition("target_type = $%d", filter.TargetType)
+	}
+	if filter.TargetID != "" {
+		addCondition("target_id = $%d", filter.TargetID)
+	}
+
+	query := `
+		SELECT id, operator_id, action, target_type, target_id, reason, details, request_id, created_at
+		FROM admin_audit_log`
+	if len(conditions) > 0 {
+		query += `
+		WHERE ` + strings.Join(conditions, " AND ")
+	}
+	query += `
+		ORDER BY created_at DESC, id DESC`
+
+	if filter.Limit > 0 {
+		args = append(args, filter.Limit)
+		query += fmt.Sprintf(" LIMIT $%d", len(args))
+	}
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var entry domain.AuditEntry
+		var details []byte
+
+		err := rows.Scan(
+			&entry.ID,
+			&entry.OperatorID,
+			&entry.Action,
+			&entry.TargetType,
+			&entry.TargetID,
+			&entry.Reason,
+			&details,
+			&entry.RequestID,
+			&entry.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := json.Unmarshal(details, &entry.Details); err != nil {
+			return nil, err
+		}
+
+		entries = append(entries, &entry)
+	}
+
+	return entries, rows.Err()
+}
@@ -0,0 +1,150 @@
+package repository
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/j-ordep/gateway/go-gateway/internal/domain"
+)
+
+type CustomerRepository struct {
+	db *sql.DB
+}
+
+func NewCustomerRepository(db *sql.DB) *CustomerRepository {
+	return &CustomerRepository{db: db}
+}
+
+func (r *CustomerRepository) Save(customer *domain.Customer) error {
+	_, err := r.db.Exec(`
+		INSERT INTO customers (id, account_id, name, email, document, phone, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	`,
+		customer.ID,
+		customer.AccountID,
+		customer.Name,
+		customer.Email,
+		customer.Document,
+		customer.Phone,
+		customer.CreatedAt,
+		customer.UpdatedAt,
+	)
+	return err
+}
+
+func (r *CustomerRepository) FindByID(id string) (*domain.Customer, error) {
+	var customer domain.Customer
+
+	err := r.db.QueryRow(`
+		SELECT id, account_id, name, email, document, phone, created_at, updated_at
+		FROM customers
+		WHERE id = $1 AND deleted_at IS NULL
+	`, id).Scan(
+		&customer.ID,
+		&customer.AccountID,
+		&customer.Name,
+		&customer.Email,
+		&customer.Document,
+		&customer.Phone,
+		&customer.CreatedAt,
+		&customer.UpdatedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, domain.ErrCustomerNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &customer, nil
+}
+
+func (r *CustomerRepository) FindByAccountID(accountID string) ([]*domain.Customer, error) {
+	rows, err := r.db.Query(`
+		SELECT id, account_id, name, email, document, phone, created_at, updated_at
+		FROM customers
+		WHERE account_id = $1 AND deleted_at IS NULL
+		ORDER BY created_at DESC
+	`, accountID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var customers []*domain.Customer
+
+	for rows.Next() {
+		var customer domain.Customer
+		err := rows.Scan(
+			&customer.ID,
+			&customer.AccountID,
+			&customer.Name,
+			&customer.Email,
+			&customer.Document,
+			&customer.Phone,
+			&customer.CreatedAt,
+			&customer.UpdatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		customers = append(customers, &customer)
+	}
+
+	return customers, rows.Err()
+}
+
+func (r *CustomerRepository) Update(customer *domain.Customer) error {
+	result, err := r.db.Exec(`
+		UPDATE customers
+		SET name = $1, email = $2, document = $3, phone = $4, updated_at = $5
+		WHERE id = $6 AND deleted_at IS NULL
+	`, customer.Name, customer.Email, customer.Document, customer.Phone, customer.UpdatedAt, customer.ID)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return domain.ErrCustomerNotFound
+	}
+
+	return nil
+}
+
+// Delete marca o cliente e os cartões dele como removidos (soft delete), na mesma transação
+// as faturas mantêm o customer_id e o payment_method_id: o histórico de quem pagou não se perde
+func (r *CustomerRepository) Delete(id string) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	result, err := tx.Exec(`UPDATE customers SET deleted_at = $1 WHERE id = $2 AND deleted_at IS NULL`, now, id)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return domain.ErrCustomerNotFound
+	}
+
+	_, err = tx.Exec(`UPDATE payment_methods SET deleted_at = $1 WHERE customer_id = $2 AND deleted_at IS NULL`, now, id)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,130 @@
+package repository
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/j-ordep/gateway/go-gateway/internal/domain"
+)
+
+type IdempotencyRepository struct {
+	db *sql.DB
+}
+
+func NewIdempotencyRepository(db *sql.DB) *IdempotencyRepository {
+	return &IdempotencyRepository{db: db}
+}
+
+// Create usa ON CONFLICT DO NOTHING: com duas requisições simultâneas só uma consegue reservar a chave
+func (r *IdempotencyRepository) Create(record *domain.IdempotencyRecord) (bool, error) {
+	result, err := r.db.Exec(`
+		INSERT INTO idempotency_keys (account_id, key, request_hash, status, created_at, expires_at, locked_until)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (account_id, key) DO NOTHING
+	`, record.AccountID, record.Key, record.RequestHash, record.Status, record.CreatedAt, record.ExpiresAt, record.LockedUntil)
+	if err != nil {
+		return false, err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return rowsAffected == 1, nil
+}
+
+func (r *IdempotencyRepository) Find(accountID, key string) (*domain.IdempotencyRecord, error) {
+	var record domain.IdempotencyRecord
+	var responseCode sql.NullInt64
+
+	err := r.db.QueryRow(`
+		SELECT account_id, key, request_hash, status, response_code, response_body, created_at, expires_at, locked_until
+		FROM idempotency_keys
+		WHERE account_id = $1 AND key = $2
+	`, accountID, key).Scan(
+		&record.AccountID,
+		&record.Key,
+		&record.RequestHash,
+		&record.Status,
+		&responseCode,
+		&record.ResponseBody,
+		&record.CreatedAt,
+		&record.ExpiresAt,
+		&record.LockedUntil,
+	)
+	if err == sql.ErrNoRows {
+		return nil, domain.ErrIdempotencyKeyNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	record.ResponseCode = int(responseCode.Int64)
+	return &record, nil
+}
+
+// TakeOver troca o prazo vencido pelo novo; a comparação com previous impede duas tentativas de assumirem juntas
+func (r *IdempotencyRepository) TakeOver(record *domain.IdempotencyRecord, previous time.Time) (bool, error) {
+	result, err := r.db.Exec(`
+		UPDATE idempotency_keys
+		SET locked_until = $1
+		WHERE account_id = $2 AND key = $3 AND status = $4 AND locked_until = $5
+	`, record.LockedUntil, record.AccountID, record.Key, domain.IdempotencyStatusProcessing, previous)
+	if err != nil {
+		return false, err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return rowsAffected == 1, nil
+}
+
+func (r *IdempotencyRepository) Complete(record *domain.IdempotencyRecord) error {
+	result, err := r.db.Exec(`
+		UPDATE idempotency_keys
+		SET status = $1, response_code = $2, response_body = $3
+		WHERE account_id = $4 AND key = $5 AND locked_until = $6
+	`, record.Status, record.ResponseCode, record.ResponseBody, record.AccountID, record.Key, record.LockedUntil)
+	if err != nil {
+		return err
+	}
+
+	return lockOwned(result)
+}
+
+func (r *IdempotencyRepository) Delete(record *domain.IdempotencyRecord) error {
+	result, err := r.db.Exec(`
+		DELETE FROM idempotency_keys WHERE account_id = $1 AND key = $2 AND locked_until = $3
+	`, record.AccountID, record.Key, record.LockedUntil)
+	if err != nil {
+		return err
+	}
+
+	return lockOwned(result)
+}
+
+// lockOwned confere se o UPDATE/DELETE condicionado ao locked_until encontrou a chave, senão outra tentativa a assumiu
+func lockOwned(result sql.Result) error {
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return domain.ErrIdempotencyLockLost
+	}
+
+	return nil
+}
+
+func (r *IdempotencyRepository) DeleteExpired() (int64, error) {
+	result, err := r.db.Exec(`DELETE FROM idempotency_keys WHERE expires_at <= $1`, time.Now())
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
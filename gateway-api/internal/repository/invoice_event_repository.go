@@ -0,0 +1,132 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"log/slog"
+	"time"
+
+	"github.com/j-ordep/gateway/go-gateway/internal/domain"
+	"github.com/j-ordep/gateway/go-gateway/internal/tracing"
+	"github.com/lib/pq"
+)
+
+// InvoiceEventsChannel é o canal do LISTEN/NOTIFY que distribui os eventos entre as instâncias
+const InvoiceEventsChannel = "invoice_events"
+
+type InvoiceEventRepository struct {
+	db *sql.DB
+}
+
+func NewInvoiceEventRepository(db *sql.DB) *InvoiceEventRepository {
+	return &InvoiceEventRepository{db: db}
+}
+
+// insertInvoiceEvent grava o evento na transação da fatura e preenche o ID sequencial
+// o NOTIFY também é transacional: o aviso às instâncias (inclusive a própria) só sai quando a fatura é confirmada
+func insertInvoiceEvent(ctx context.Context, tx *sql.Tx, event *domain.InvoiceEvent) error {
+	err := tx.QueryRowContext(ctx, `
+		INSERT INTO invoice_events (account_id, invoice_id, status, amount, created_at)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id
+	`, event.AccountID, event.InvoiceID, event.Status, event.Amount, event.CreatedAt).Scan(&event.ID)
+	if err != nil {
+		return err
+	}
+
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	_, err = tx.ExecContext(ctx, `SELECT pg_notify($1, $2)`, InvoiceEventsChannel, string(payload))
+	return err
+}
+
+func (r *InvoiceEventRepository) FindAfter(ctx context.Context, accountID string, afterID int64, limit int) (events []*domain.InvoiceEvent, err error) {
+	ctx, span := tracing.StartRepository(ctx, "InvoiceEventRepository.FindAfter", "SELECT", "invoice_events")
+	defer func() { tracing.End(span, err) }()
+
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT id, account_id, invoice_id, status, amount, created_at
+		FROM invoice_events
+		WHERE account_id = $1 AND id > $2
+		ORDER BY id
+		LIMIT $3
+	`, accountID, afterID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var event domain.InvoiceEvent
+		if err := rows.Scan(&event.ID, &event.AccountID, &event.InvoiceID, &event.Status, &event.Amount, &event.CreatedAt); err != nil {
+			return nil, err
+		}
+		events = append(events, &event)
+	}
+
+	return events, rows.Err()
+}
+
+func (r *InvoiceEventRepository) DeleteBefore(ctx context.Context, before time.Time) (int64, error) {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM invoice_events WHERE created_at < $1`, before)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// InvoiceEventListener escuta o canal invoice_events (LISTEN) em uma conexão dedicada, fora do pool do sql.DB
+type InvoiceEventListener struct {
+	listener *pq.Listener
+}
+
+func NewInvoiceEventListener(connStr string) *InvoiceEventListener {
+	listener := pq.NewListener(connStr, time.Second, time.Minute, func(event pq.ListenerEventType, err error) {
+		switch event {
+		case pq.ListenerEventDisconnected:
+			slog.Warn("conexão do LISTEN perdida, reconectando", "channel", InvoiceEventsChannel, "error", err)
+		case pq.ListenerEventReconnected:
+			// o que foi notificado durante a queda não é reenviado; os clientes recuperam pelo Last-Event-ID ao reconectar
+			slog.Info("conexão do LISTEN restabelecida", "channel", InvoiceEventsChannel)
+		case pq.ListenerEventConnectionAttemptFailed:
+			slog.Error("erro ao conectar o LISTEN", "channel", InvoiceEventsChannel, "error", err)
+		}
+	})
+	return &InvoiceEventListener{listener: listener}
+}
+
+// Run entrega cada evento notificado para handle até o ctx ser cancelado
+func (l *InvoiceEventListener) Run(ctx context.Context, handle func(*domain.InvoiceEvent)) error {
+	if err := l.listener.Listen(InvoiceEventsChannel); err != nil {
+		return err
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case notification := <-l.listener.Notify:
+			// nil indica que a conexão foi restabelecida
+			if notification == nil {
+				continue
+			}
+
+			var event domain.InvoiceEvent
+			if err := json.Unmarshal([]byte(notification.Extra), &event); err != nil {
+				slog.Error("notificação de evento de fatura inválida", "error", err, "payload", notification.Extra)
+				continue
+			}
+			handle(&event)
+		case <-time.After(90 * time.Second):
+			// sem notificações por muito tempo: confirma que a conexão ainda está viva
+			go l.listener.Ping()
+		}
+	}
+}
+
+func (l *InvoiceEventListener) Close() error {
+	return l.listener.Close()
+}
@@ -0,0 +1,268 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/j-ordep/gateway/go-gateway/internal/domain"
+	"github.com/j-ordep/gateway/go-gateway/internal/tracing"
+)
+
+type InvoiceExportRepository struct {
+	db *sql.DB
+}
+
+func NewInvoiceExportRepository(db *sql.DB) *InvoiceExportRepository {
+	return &InvoiceExportRepository{db: db}
+}
+
+const invoiceExportColumns = `id, account_id, format, filter_status, filter_payment_type, created_from, created_to, timezone,
+		status, row_count, error, locked_until, created_at, updated_at, completed_at, expires_at`
+
+func (r *InvoiceExportRepository) Save(ctx context.Context, export *domain.InvoiceExport) (err error) {
+	ctx, span := tracing.StartRepository(ctx, "InvoiceExportRepository.Save", "INSERT", "invoice_exports")
+	defer func() { tracing.End(span, err) }()
+
+	_, err = r.db.ExecContext(ctx, `
+		INSERT INTO invoice_exports (`+invoiceExportColumns+`)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16)
+	`,
+		export.ID,
+		export.AccountID,
+		export.Format,
+		export.Filter.Status,
+		export.Filter.PaymentType,
+		nullTime(export.Filter.CreatedFrom),
+		nullTime(export.Filter.CreatedTo),
+		export.Timezone,
+		export.Status,
+		export.RowCount,
+		export.Error,
+		export.LockedUntil,
+		export.CreatedAt,
+		export.UpdatedAt,
+		export.CompletedAt,
+		export.ExpiresAt,
+	)
+	return err
+}
+
+func (r *InvoiceExportRepository) FindByID(ctx context.Context, id string) (export *domain.InvoiceExport, err error) {
+	ctx, span := tracing.StartRepository(ctx, "InvoiceExportRepository.FindByID", "SELECT", "invoice_exports")
+	defer func() { tracing.End(span, err) }()
+
+	export, err = scanInvoiceExport(r.db.QueryRowContext(ctx, `
+		SELECT `+invoiceExportColumns+`
+		FROM invoice_exports
+		WHERE id = $1
+	`, id))
+	if err == sql.ErrNoRows {
+		return nil, domain.ErrInvoiceExportNotFound
+	}
+	return export, err
+}
+
+// ClaimPending usa FOR UPDATE SKIP LOCKED: instâncias concorrentes pegam exportações diferentes
+// uma exportação processing com a reserva vencida (instância que caiu no meio) volta a ser reservada
+func (r *InvoiceExportRepository) ClaimPending(ctx context.Context, lease time.Duration, limit int) (exports []*domain.InvoiceExport, err error) {
+	ctx, span := tracing.StartRepository(ctx, "InvoiceExportRepository.ClaimPending", "UPDATE", "invoice_exports")
+	defer func() { tracing.End(span, err) }()
+
+	now := time.Now()
+	rows, err := r.db.QueryContext(ctx, `
+		UPDATE invoice_exports
+		SET status = 'processing', locked_until = $1, updated_at = $2
+		WHERE id IN (
+			SELECT id FROM invoice_exports
+			WHERE status = 'pending' OR (status = 'processing' AND locked_until <= $2)
+			ORDER BY created_at
+			LIMIT $3
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING `+invoiceExportColumns,
+		now.Add(lease), now, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		export, err := scanInvoiceExport(rows)
+		if err != nil {
+			return nil, err
+		}
+		exports = append(exports, export)
+	}
+
+	return exports, rows.Err()
+}
+
+func (r *InvoiceExportRepository) Update(ctx context.Context, export *domain.InvoiceExport) (err error) {
+	ctx, span := tracing.StartRepository(ctx, "InvoiceExportRepository.Update", "UPDATE", "invoice_exports")
+	defer func() { tracing.End(span, err) }()
+
+	result, err := r.db.ExecContext(ctx, `
+		UPDATE invoice_exports
+		SET status = $1, row_count = $2, error = $3, locked_until = $4, updated_at = $5, completed_at = $6, expires_at = $7
+		WHERE id = $8
+	`,
+		export.Status,
+		export.RowCount,
+		export.Error,
+		export.LockedUntil,
+		export.UpdatedAt,
+		export.CompletedAt,
+		export.ExpiresAt,
+		export.ID,
+	)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return domain.ErrInvoiceExportNotFound
+	}
+	return nil
+}
+
+func (r *InvoiceExportRepository) DeleteExpired(ctx context.Context, now time.Time) (ids []string, err error) {
+	ctx, span := tracing.StartRepository(ctx, "InvoiceExportRepository.DeleteExpired", "DELETE", "invoice_exports")
+	defer func() { tracing.End(span, err) }()
+
+	rows, err := r.db.QueryContext(ctx, `DELETE FROM invoice_exports WHERE expires_at <= $1 RETURNING id`, now)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+
+	return ids, rows.Err()
+}
+
+func scanInvoiceExport(row rowScanner) (*domain.InvoiceExport, error) {
+	var export domain.InvoiceExport
+	var createdFrom, createdTo sql.NullTime
+
+	err := row.Scan(
+		&export.ID,
+		&export.AccountID,
+		&export.Format,
+		&export.Filter.Status,
+		&export.Filter.PaymentType,
+		&createdFrom,
+		&createdTo,
+		&export.Timezone,
+		&export.Status,
+		&export.RowCount,
+		&export.Error,
+		&export.LockedUntil,
+		&export.CreatedAt,
+		&export.UpdatedAt,
+		&export.CompletedAt,
+		&export.ExpiresAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	export.Filter.CreatedFrom = createdFrom.Time
+	export.Filter.CreatedTo = createdTo.Time
+	return &export, nil
+}
+
+// nullTime converte a data zero (filtro não informado) em NULL
+func nullTime(value time.Time) sql.NullTime {
+	return sql.NullTime{Time: value, Valid: !value.IsZero()}
+}
+
+// FileExportStorage guarda os arquivos das exportações em um diretório local
+// com mais de uma instância o diretório precisa ser compartilhado (volume), já que o download pode cair em outra instância
+type FileExportStorage struct {
+	dir string
+}
+
+func NewFileExportStorage(dir string) (*FileExportStorage, error) {
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, err
+	}
+	return &FileExportStorage{dir: dir}, nil
+}
+
+// Create escreve em um arquivo temporário que só assume o nome final no Close,
+// assim um download nunca lê um arquivo pela metade
+func (s *FileExportStorage) Create(id string) (io.WriteCloser, error) {
+	path, err := s.path(id)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := os.CreateTemp(s.dir, id+".*.tmp")
+	if err != nil {
+		return nil, err
+	}
+	return &exportFile{File: file, path: path}, nil
+}
+
+func (s *FileExportStorage) Open(id string) (io.ReadCloser, error) {
+	path, err := s.path(id)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, domain.ErrInvoiceExportNotReady
+	}
+	return file, err
+}
+
+func (s *FileExportStorage) Delete(id string) error {
+	path, err := s.path(id)
+	if err != nil {
+		return err
+	}
+
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// path aceita apenas UUIDs, o ID nunca vira um caminho fora do diretório
+func (s *FileExportStorage) path(id string) (string, error) {
+	if _, err := uuid.Parse(id); err != nil {
+		return "", fmt.Errorf("invalid export id %q", id)
+	}
+	return filepath.Join(s.dir, id), nil
+}
+
+type exportFile struct {
+	*os.File
+	path string
+}
+
+func (f *exportFile) Close() error {
+	if err := f.File.Close(); err != nil {
+		os.Remove(f.File.Name())
+		return err
+	}
+	return os.Rename(f.File.Name(), f.path)
+}
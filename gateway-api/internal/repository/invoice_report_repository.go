@@ -0,0 +1,166 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/j-ordep/gateway/go-gateway/internal/domain"
+	"github.com/j-ordep/gateway/go-gateway/internal/tracing"
+)
+
+// rollupOverlap relê as faturas alteradas um pouco antes do último refresh: cobre as gravadas por uma instância
+// com o relógio atrasado e as transações que só commitaram depois do refresh anterior
+const rollupOverlap = 5 * time.Minute
+
+type InvoiceReportRepository struct {
+	db *sql.DB
+}
+
+func NewInvoiceReportRepository(db *sql.DB) *InvoiceReportRepository {
+	return &InvoiceReportRepository{db: db}
+}
+
+// Aggregate usa o índice (account_id, created_at) e agrupa no banco, só os totais trafegam
+func (r *InvoiceReportRepository) Aggregate(ctx context.Context, accountID string, granularity domain.ReportGranularity, from, to time.Time) (aggregates []domain.InvoiceAggregate, err error) {
+	ctx, span := tracing.StartRepository(ctx, "InvoiceReportRepository.Aggregate", "SELECT", "invoices")
+	defer func() { tracing.End(span, err) }()
+
+	return queryAggregates(ctx, r.db, `
+		SELECT date_trunc($2, created_at), status, payment_type, card_brand, COUNT(*), SUM(amount)
+		FROM invoices
+		WHERE account_id = $1 AND created_at >= $3 AND created_at < $4
+		GROUP BY 1, 2, 3, 4
+	`, accountID, string(granularity), from.UTC(), to.UTC())
+}
+
+func (r *InvoiceReportRepository) AggregateRollup(ctx context.Context, accountID string, granularity domain.ReportGranularity, from, to time.Time) (aggregates []domain.InvoiceAggregate, err error) {
+	ctx, span := tracing.StartRepository(ctx, "InvoiceReportRepository.AggregateRollup", "SELECT", "invoice_daily_rollups")
+	defer func() { tracing.End(span, err) }()
+
+	return queryAggregates(ctx, r.db, `
+		SELECT date_trunc($2, day::timestamp), status, payment_type, card_brand, SUM(invoice_count), SUM(amount_sum)
+		FROM invoice_daily_rollups
+		WHERE account_id = $1 AND day >= $3::date AND day < $4::date
+		GROUP BY 1, 2, 3, 4
+	`, accountID, string(granularity), from.UTC().Format(time.DateOnly), to.UTC().Format(time.DateOnly))
+}
+
+func queryAggregates(ctx context.Context, db *sql.DB, query string, args ...any) ([]domain.InvoiceAggregate, error) {
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var aggregates []domain.InvoiceAggregate
+	for rows.Next() {
+		var aggregate domain.InvoiceAggregate
+		err := rows.Scan(
+			&aggregate.PeriodStart,
+			&aggregate.Status,
+			&aggregate.PaymentType,
+			&aggregate.CardBrand,
+			&aggregate.Count,
+			&aggregate.Amount,
+		)
+		if err != nil {
+			return nil, err
+		}
+		aggregates = append(aggregates, aggregate)
+	}
+
+	return aggregates, rows.Err()
+}
+
+func (r *InvoiceReportRepository) RollupRefreshedUntil(ctx context.Context) (refreshedUntil time.Time, err error) {
+	ctx, span := tracing.StartRepository(ctx, "InvoiceReportRepository.RollupRefreshedUntil", "SELECT", "invoice_rollup_state")
+	defer func() { tracing.End(span, err) }()
+
+	err = r.db.QueryRowContext(ctx, `SELECT refreshed_until FROM invoice_rollup_state`).Scan(&refreshedUntil)
+	if err == sql.ErrNoRows {
+		return time.Time{}, nil
+	}
+	return refreshedUntil, err
+}
+
+// RefreshRollup recalcula por inteiro cada dia (da conta) que tem alguma fatura alterada desde o último refresh,
+// então uma fatura que mudou de status sai do total antigo e entra no novo
+// a linha de invoice_rollup_state é reservada com SKIP LOCKED: se outra instância já está atualizando, retorna 0
+func (r *InvoiceReportRepository) RefreshRollup(ctx context.Context, until time.Time) (days int, err error) {
+	ctx, span := tracing.StartRepository(ctx, "InvoiceReportRepository.RefreshRollup", "UPDATE", "invoice_daily_rollups")
+	defer func() { tracing.End(span, err) }()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	// primeira execução: o rollup é calculado desde o início
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO invoice_rollup_state (id, refreshed_until, refreshed_at)
+		VALUES (TRUE, 'epoch', $1)
+		ON CONFLICT (id) DO NOTHING
+	`, until.UTC())
+	if err != nil {
+		return 0, err
+	}
+
+	var refreshedUntil time.Time
+	err = tx.QueryRowContext(ctx, `SELECT refreshed_until FROM invoice_rollup_state FOR UPDATE SKIP LOCKED`).Scan(&refreshedUntil)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	// os dias alterados ficam numa tabela temporária, para o DELETE e o INSERT usarem exatamente o mesmo conjunto
+	result, err := tx.ExecContext(ctx, `
+		CREATE TEMP TABLE invoice_rollup_dirty ON COMMIT DROP AS
+		SELECT DISTINCT account_id, created_at::date AS day
+		FROM invoices
+		WHERE updated_at >= $1 AND updated_at < $2
+	`, refreshedUntil.Add(-rollupOverlap), until.UTC())
+	if err != nil {
+		return 0, err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		DELETE FROM invoice_daily_rollups rollup
+		USING invoice_rollup_dirty dirty
+		WHERE rollup.account_id = dirty.account_id AND rollup.day = dirty.day
+	`)
+	if err != nil {
+		return 0, err
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO invoice_daily_rollups (account_id, day, status, payment_type, card_brand, invoice_count, amount_sum)
+		SELECT invoices.account_id, dirty.day, invoices.status, invoices.payment_type, invoices.card_brand, COUNT(*), SUM(invoices.amount)
+		FROM invoice_rollup_dirty dirty
+		JOIN invoices ON invoices.account_id = dirty.account_id
+			AND invoices.created_at >= dirty.day AND invoices.created_at < dirty.day + 1
+		GROUP BY 1, 2, 3, 4, 5
+	`)
+	if err != nil {
+		return 0, err
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE invoice_rollup_state SET refreshed_until = $1, refreshed_at = $2
+	`, until.UTC(), time.Now().UTC())
+	if err != nil {
+		return 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	return int(affected), nil
+}
@@ -0,0 +1,140 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/j-ordep/gateway/go-gateway/internal/domain"
+	"github.com/j-ordep/gateway/go-gateway/internal/dto"
+)
+
+// insertReportAccount cria uma conta só para o teste e apaga tudo dela no fim
+func insertReportAccount(t *testing.T, db *sql.DB) string {
+	t.Helper()
+	id := uuid.NewString()
+	_, err := db.Exec(`INSERT INTO accounts (id, name, email, api_key) VALUES ($1, 'Relatório', $2, $3)`, id, id+"@example.com", id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		db.Exec(`DELETE FROM invoice_daily_rollups WHERE account_id = $1`, id)
+		db.Exec(`DELETE FROM invoices WHERE account_id = $1`, id)
+		db.Exec(`DELETE FROM accounts WHERE id = $1`, id)
+	})
+	return id
+}
+
+func insertReportInvoice(t *testing.T, db *sql.DB, accountID string, status domain.Status, brand string, amount float64, createdAt, updatedAt time.Time) string {
+	t.Helper()
+	id := uuid.NewString()
+	_, err := db.Exec(`
+		INSERT INTO invoices (id, account_id, amount, status, description, payment_type, card_brand, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, 'relatório', 'credit_card', $5, $6, $7)
+	`, id, accountID, amount, string(status), brand, createdAt, updatedAt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return id
+}
+
+func sortAggregates(aggregates []domain.InvoiceAggregate) []domain.InvoiceAggregate {
+	sort.Slice(aggregates, func(i, j int) bool {
+		if !aggregates[i].PeriodStart.Equal(aggregates[j].PeriodStart) {
+			return aggregates[i].PeriodStart.Before(aggregates[j].PeriodStart)
+		}
+		return aggregates[i].Status < aggregates[j].Status
+	})
+	for i := range aggregates {
+		aggregates[i].PeriodStart = aggregates[i].PeriodStart.UTC()
+	}
+	return aggregates
+}
+
+// semanas começam na segunda-feira, o último dia do período (to) entra inteiro e o dia seguinte fica de fora;
+// o rollup dá os mesmos totais e acompanha a mudança de status depois do refresh
+func TestInvoiceReportAggregate(t *testing.T) {
+	db := testDB(t)
+	ctx := context.Background()
+	repository := NewInvoiceReportRepository(db)
+	accountID := insertReportAccount(t, db)
+
+	day := func(d, hour, minute, second int) time.Time {
+		return time.Date(2025, 3, d, hour, minute, second, 0, time.UTC)
+	}
+	updatedAt := time.Now().UTC().Add(-time.Minute).Truncate(time.Microsecond)
+
+	// domingo 02/03 ainda é a semana de 24/02; segunda 03/03 abre a semana seguinte
+	insertReportInvoice(t, db, accountID, domain.StatusApproved, "visa", 100, day(2, 10, 0, 0), updatedAt)
+	insertReportInvoice(t, db, accountID, domain.StatusApproved, "visa", 50, day(2, 23, 59, 59), updatedAt)
+	monday := insertReportInvoice(t, db, accountID, domain.StatusRejected, "mastercard", 30, day(3, 0, 0, 0), updatedAt)
+	// último instante do to (09/03) entra; 10/03 00:00 e o dia antes do from ficam de fora
+	insertReportInvoice(t, db, accountID, domain.StatusPending, "elo", 20, day(9, 23, 59, 59), updatedAt)
+	insertReportInvoice(t, db, accountID, domain.StatusApproved, "visa", 999, day(10, 0, 0, 0), updatedAt)
+	insertReportInvoice(t, db, accountID, domain.StatusApproved, "visa", 999, time.Date(2025, 2, 23, 23, 59, 59, 0, time.UTC), updatedAt)
+
+	report, err := dto.ToInvoiceReport(dto.AccountReportInput{Granularity: "week", From: "2025-02-24", To: "2025-03-09"}, accountID, day(19, 0, 0, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	week1 := time.Date(2025, 2, 24, 0, 0, 0, 0, time.UTC)
+	week2 := time.Date(2025, 3, 3, 0, 0, 0, 0, time.UTC)
+	expected := []domain.InvoiceAggregate{
+		{PeriodStart: week1, Status: domain.StatusApproved, PaymentType: "credit_card", CardBrand: "visa", Count: 2, Amount: 150},
+		{PeriodStart: week2, Status: domain.StatusPending, PaymentType: "credit_card", CardBrand: "elo", Count: 1, Amount: 20},
+		{PeriodStart: week2, Status: domain.StatusRejected, PaymentType: "credit_card", CardBrand: "mastercard", Count: 1, Amount: 30},
+	}
+
+	aggregates, err := repository.Aggregate(ctx, accountID, report.Granularity, report.From, report.To)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if aggregates = sortAggregates(aggregates); !reflect.DeepEqual(aggregates, expected) {
+		t.Fatalf("Aggregate = %+v, esperado %+v", aggregates, expected)
+	}
+
+	// o refresh relê a partir de refreshed_until; recua o estado para incluir as faturas do teste
+	refresh := func(until time.Time) {
+		t.Helper()
+		if _, err := db.Exec(`UPDATE invoice_rollup_state SET refreshed_until = LEAST(refreshed_until, $1)`, updatedAt.Add(-time.Hour)); err != nil {
+			t.Fatal(err)
+		}
+		days, err := repository.RefreshRollup(ctx, until)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if days == 0 {
+			t.Fatal("RefreshRollup não recalculou nenhum dia")
+		}
+	}
+
+	refresh(time.Now().UTC())
+	rollup, err := repository.AggregateRollup(ctx, accountID, report.Granularity, report.From, report.To)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rollup = sortAggregates(rollup); !reflect.DeepEqual(rollup, expected) {
+		t.Fatalf("AggregateRollup = %+v, esperado %+v", rollup, expected)
+	}
+
+	// a fatura que mudou de status sai do total antigo e entra no novo
+	changedAt := time.Now().UTC().Truncate(time.Microsecond)
+	if _, err := db.Exec(`UPDATE invoices SET status = $1, updated_at = $2 WHERE id = $3`, string(domain.StatusApproved), changedAt, monday); err != nil {
+		t.Fatal(err)
+	}
+	refresh(changedAt.Add(time.Second))
+	rollup, err = repository.AggregateRollup(ctx, accountID, report.Granularity, report.From, report.To)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected[2] = domain.InvoiceAggregate{PeriodStart: week2, Status: domain.StatusApproved, PaymentType: "credit_card", CardBrand: "mastercard", Count: 1, Amount: 30}
+	expected[1], expected[2] = expected[2], expected[1]
+	if rollup = sortAggregates(rollup); !reflect.DeepEqual(rollup, expected) {
+		t.Fatalf("AggregateRollup depois da mudança de status = %+v, esperado %+v", rollup, expected)
+	}
+}
@@ -1,11 +1,23 @@
 package repository
 
 import (
+	"context"
 	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
 
 	"github.com/j-ordep/gateway/go-gateway/internal/domain"
+	"github.com/j-ordep/gateway/go-gateway/internal/tracing"
+	"github.com/lib/pq"
 )
 
+// colunas lidas em todas as consultas de invoice, na mesma ordem do scanInvoice
+const invoiceColumns = `id, account_id, amount, status, description, payment_type, card_last_digits, card_brand,
+		COALESCE(external_reference, ''), metadata, COALESCE(customer_id::text, ''), COALESCE(payment_method_id::text, ''),
+		COALESCE(three_ds_result, ''), COALESCE(three_ds_eci, ''), created_at, updated_at`
+
 type InvoiceRepository struct {
 	db *sql.DB
 }
@@ -14,13 +26,97 @@ func NewInvoiceRepository(db *sql.DB) *InvoiceRepository {
 	return &InvoiceRepository{db:db}
 }
 
-func (r *InvoiceRepository) Save(invoice *domain.Invoice) error {
+// rowScanner é satisfeito tanto por *sql.Row quanto por *sql.Rows
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanInvoice(row rowScanner) (*domain.Invoice, error) {
+	var invoice domain.Invoice
+	var metadata []byte
+
+	err := row.Scan(
+		&invoice.ID,
+		&invoice.AccountID, 
+		&invoice.Amount, 
+		&invoice.Status, 
+		&invoice.Description, 
+		&invoice.PaymentType, 
+		&invoice.CardLastDigits, 
+		&invoice.CardBrand,
+		&invoice.ExternalReference,
+		&metadata,
+		&invoice.CustomerID,
+		&invoice.PaymentMethodID,
+		&invoice.ThreeDSResult,
+		&invoice.ThreeDSECI,
+		&invoice.CreatedAt, 
+		&invoice.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(metadata, &invoice.Metadata); err != nil {
+		return nil, err
+	}
+
+	return &invoice, nil
+}
+
+// Save grava a fatura em uma transação com FOR SHARE na linha da conta:
+// o encerramento (AccountRepository.Close) trava a mesma linha, então não entra fatura em conta encerrada
+// o outbox (desafio 3DS, auditoria, webhooks, evento do stream) entra na mesma transação
+func (r *InvoiceRepository) Save(ctx context.Context, invoice *domain.Invoice, outbox *domain.InvoiceOutbox) error {
+	ctx, span := tracing.StartRepository(ctx, "InvoiceRepository.Save", "INSERT", "invoices")
+	defer span.End()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		tracing.RecordError(span, err)
+		return err
+	}
+	defer tx.Rollback()
+
+	// fatura aprovada credita o saldo na mesma transação, então a conta já é travada para escrita
+	lock := "FOR SHARE"
+	if invoice.Status == domain.StatusApproved {
+		lock = "FOR UPDATE"
+	}
+
+	var accountStatus domain.AccountStatus
+	err = tx.QueryRowContext(ctx, `SELECT status FROM accounts WHERE id = $1 `+lock, invoice.AccountID).Scan(&accountStatus)
+	if err == sql.ErrNoRows {
+		return domain.ErrAccountNotFound
+	}
+	if err != nil {
+		tracing.RecordError(span, err)
+		return err
+	}
+	switch accountStatus {
+	case domain.AccountStatusClosed:
+		return domain.ErrAccountClosed
+	case domain.AccountStatusSuspended:
+		return domain.ErrAccountSuspended
+	}
+
 	query := `
-		INSERT INTO invoices (id, account_id, amount, status, description, payment_type, card_last_digits, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		INSERT INTO invoices (id, account_id, amount, status, description, payment_type, card_last_digits, card_brand, external_reference, metadata, customer_id, payment_method_id, three_ds_result, three_ds_eci, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16)
 	`
 
-	_, err := r.db.Exec(query, 
+	metadata, err := json.Marshal(invoice.Metadata)
+	if err != nil {
+		return err
+	}
+	if invoice.Metadata == nil {
+		metadata = []byte("{}")
+	}
+
+	// external_reference vazio vira NULL, assim o índice único só vale para quem informou
+	externalReference := nullString(invoice.ExternalReference)
+
+	_, err = tx.ExecContext(ctx, query, 
 		invoice.ID, 
 		invoice.AccountID, 
 		invoice.Amount, 
@@ -28,97 +124,261 @@ func (r *InvoiceRepository) Save(invoice *domain.Invoice) error {
 		invoice.Description, 
 		invoice.PaymentType, 
 		invoice.CardLastDigits, 
+		invoice.CardBrand,
+		externalReference,
+		metadata,
+		nullString(invoice.CustomerID),
+		nullString(invoice.PaymentMethodID),
+		nullString(string(invoice.ThreeDSResult)),
+		nullString(invoice.ThreeDSECI),
 		invoice.CreatedAt, 
 		invoice.UpdatedAt)
 
 	if err != nil {
+		if isUniqueViolation(err, "idx_invoices_account_external_reference") {
+			return domain.ErrDuplicatedExternalReference
+		}
+		tracing.RecordError(span, err)
+		return err
+	}
+
+	if err := creditApproved(ctx, tx, invoice); err != nil {
+		tracing.RecordError(span, err)
 		return err
 	}
 
+	if err := writeOutbox(ctx, tx, outbox); err != nil {
+		tracing.RecordError(span, err)
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		tracing.RecordError(span, err)
+		return err
+	}
+	return nil
+}
+
+// writeOutbox grava o que acompanha a fatura na transação dela; outbox nil não grava nada
+func writeOutbox(ctx context.Context, tx *sql.Tx, outbox *domain.InvoiceOutbox) error {
+	if outbox == nil {
+		return nil
+	}
+
+	if outbox.Challenge != nil {
+		if err := insertThreeDSChallenge(ctx, tx, outbox.Challenge); err != nil {
+			return err
+		}
+	}
+	if outbox.Audit != nil {
+		if err := insertAuditEntry(ctx, tx, outbox.Audit); err != nil {
+			return err
+		}
+	}
+	for _, delivery := range outbox.Deliveries {
+		if err := insertWebhookDelivery(ctx, tx, delivery); err != nil {
+			return err
+		}
+	}
+	if outbox.Event != nil {
+		if err := insertInvoiceEvent(ctx, tx, outbox.Event); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
-func (r *InvoiceRepository) FindByID(id string) (*domain.Invoice, error) {
+func (r *InvoiceRepository) FindByID(ctx context.Context, id string) (*domain.Invoice, error) {
+	ctx, span := tracing.StartRepository(ctx, "InvoiceRepository.FindByID", "SELECT", "invoices")
+	defer span.End()
+
 	query := `
-		SELECT id, account_id, amount, status, description, payment_type, card_last_digits, created_at, updated_at
+		SELECT ` + invoiceColumns + `
 		FROM invoices
 		WHERE id = $1
 	`
 
-	var invoice domain.Invoice
-
-	// ...existing code...
-	err := r.db.QueryRow(query, id).Scan(
-		&invoice.ID,
-		&invoice.AccountID, 
-		&invoice.Amount, 
-		&invoice.Status, 
-		&invoice.Description, 
-		&invoice.PaymentType, 
-		&invoice.CardLastDigits, 
-		&invoice.CreatedAt, 
-		&invoice.UpdatedAt,
-	)
+	invoice, err := scanInvoice(r.db.QueryRowContext(ctx, query, id))
 
 	if err == sql.ErrNoRows {
 		return nil, domain.ErrInvoiceNotFound
 	}
 
 	if err != nil {
+		tracing.RecordError(span, err)
 		return nil, err
 	}
 
-	return &invoice ,nil
+	return invoice, nil
 }
 
-// pode retornar varios Invoices, pois varios invoices podem ter o mesmo accountID, (1 account pode ter mais de um invoice)
-func (r *InvoiceRepository) FindByAccountID(accountId string) ([]*domain.Invoice, error) {
+// FindByExternalReference busca a fatura de uma conta pela referência do pedido do lojista
+func (r *InvoiceRepository) FindByExternalReference(ctx context.Context, accountId, externalReference string) (*domain.Invoice, error) {
+	ctx, span := tracing.StartRepository(ctx, "InvoiceRepository.FindByExternalReference", "SELECT", "invoices")
+	defer span.End()
+
 	query := `
-		SELECT id, account_id, amount, status, description, payment_type, card_last_digits, created_at, updated_at
+		SELECT ` + invoiceColumns + `
 		FROM invoices
-		WHERE account_id = $1
+		WHERE account_id = $1 AND external_reference = $2
 	`
-	
-	rows, err := r.db.Query(query, accountId)
+
+	invoice, err := scanInvoice(r.db.QueryRowContext(ctx, query, accountId, externalReference))
+	if err == sql.ErrNoRows {
+		return nil, domain.ErrInvoiceNotFound
+	}
 	if err != nil {
+		tracing.RecordError(span, err)
 		return nil, err
 	}
 
-	var invoices []*domain.Invoice
-	
+	return invoice, nil
+}
+
+// pode retornar varios Invoices, pois varios invoices podem ter o mesmo accountID, (1 account pode ter mais de um invoice)
+// ordenado do mais recente para o mais antigo, (created_at, id) garante ordem estável para o cursor
+func (r *InvoiceRepository) FindByAccountID(ctx context.Context, accountId string, filter domain.InvoiceFilter) (invoices []*domain.Invoice, err error) {
+	ctx, span := tracing.StartRepository(ctx, "InvoiceRepository.FindByAccountID", "SELECT", "invoices")
+	defer func() { tracing.End(span, err) }()
+
+	err = r.each(ctx, accountId, filter, func(invoice *domain.Invoice) error {
+		invoices = append(invoices, invoice)
+		return nil
+	})
+	return invoices, err
+}
+
+// Each é usado na exportação: o lib/pq lê as linhas do socket conforme rows.Next avança,
+// então só a fatura atual fica em memória
+func (r *InvoiceRepository) Each(ctx context.Context, accountId string, filter domain.InvoiceFilter, fn func(*domain.Invoice) error) (err error) {
+	ctx, span := tracing.StartRepository(ctx, "InvoiceRepository.Each", "SELECT", "invoices")
+	defer func() { tracing.End(span, err) }()
+
+	return r.each(ctx, accountId, filter, fn)
+}
+
+func (r *InvoiceRepository) each(ctx context.Context, accountId string, filter domain.InvoiceFilter, fn func(*domain.Invoice) error) error {
+	query, args, err := invoiceFilterQuery(accountId, filter)
+	if err != nil {
+		return err
+	}
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
 	for rows.Next() {
-		var invoice domain.Invoice
-		err := rows.Scan(
-			&invoice.ID,
-			&invoice.AccountID,
-			&invoice.Amount,
-			&invoice.Status,
-			&invoice.Description,
-			&invoice.PaymentType,
-			&invoice.CardLastDigits,
-			&invoice.CreatedAt,
-			&invoice.UpdatedAt,
-		)
+		invoice, err := scanInvoice(rows)
 		if err != nil {
-			return nil, err
+			return err
 		}
 
-		invoices = append(invoices, &invoice)
+		if err := fn(invoice); err != nil {
+			return err
+		}
 	}
-	defer rows.Close()
 
-	return invoices, nil
+	return rows.Err()
+}
+
+// invoiceFilterQuery monta o SELECT das faturas da conta com os filtros e a paginação
+func invoiceFilterQuery(accountId string, filter domain.InvoiceFilter) (string, []any, error) {
+	conditions := []string{"account_id = $1"}
+	args := []any{accountId}
+
+	// addCondition adiciona o argumento e usa o placeholder ($n) correspondente
+	addCondition := func(condition string, value any) {
+		args = append(args, value)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+
+	if filter.ExternalReference != "" {
+		addCondition("external_reference = $%d", filter.ExternalReference)
+	}
+
+	if filter.CustomerID != "" {
+		addCondition("customer_id = $%d", filter.CustomerID)
+	}
+
+	if filter.Status != "" {
+		addCondition("status = $%d", filter.Status)
+	}
+
+	if filter.PaymentType != "" {
+		addCondition("payment_type = $%d", filter.PaymentType)
+	}
+
+	if filter.MinAmount > 0 {
+		addCondition("amount >= $%d", filter.MinAmount)
+	}
+
+	if filter.MaxAmount > 0 {
+		addCondition("amount <= $%d", filter.MaxAmount)
+	}
+
+	if !filter.CreatedFrom.IsZero() {
+		addCondition("created_at >= $%d", filter.CreatedFrom)
+	}
+
+	if !filter.CreatedTo.IsZero() {
+		addCondition("created_at < $%d", filter.CreatedTo)
+	}
+
+	if filter.CardLastDigits != "" {
+		addCondition("card_last_digits = $%d", filter.CardLastDigits)
+	}
+
+	if len(filter.Metadata) > 0 {
+		metadata, err := json.Marshal(filter.Metadata)
+		if err != nil {
+			return "", nil, err
+		}
+		// @> (contém): a fatura precisa ter todas as chaves/valores do filtro
+		addCondition("metadata @> $%d::jsonb", metadata)
+	}
+
+	if filter.Cursor != nil {
+		// comparação de tupla: usa o índice (account_id, created_at DESC, id DESC) sem OFFSET
+		args = append(args, filter.Cursor.CreatedAt, filter.Cursor.ID)
+		conditions = append(conditions, fmt.Sprintf("(created_at, id) < ($%d, $%d)", len(args)-1, len(args)))
+	}
+
+	query := `
+		SELECT ` + invoiceColumns + `
+		FROM invoices
+		WHERE ` + strings.Join(conditions, " AND ") + `
+		ORDER BY created_at DESC, id DESC`
+
+	if filter.Limit > 0 {
+		args = append(args, filter.Limit)
+		query += fmt.Sprintf(" LIMIT $%d", len(args))
+	}
+	
+	return query, args, nil
 }
 
 // unica reponsabilidade do repository é salva no DB, o invoice já vem alterado
-func (r *InvoiceRepository) UpdateStatus(invoice *domain.Invoice) error {
+// o resultado do 3DS acompanha o status, pois é gravado na mesma transição (requires_action -> pending/rejected)
+// a condição no status de origem garante uma única transição quando callback, kafka e admin concorrem
+func (r *InvoiceRepository) UpdateStatus(ctx context.Context, invoice *domain.Invoice, from domain.Status, outbox *domain.InvoiceOutbox) (err error) {
+	ctx, span := tracing.StartRepository(ctx, "InvoiceRepository.UpdateStatus", "UPDATE", "invoices")
+	defer func() { tracing.End(span, err) }()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
 	query := `
 		UPDATE invoices 
-		SET status = $1, updated_at = $2 
-		WHERE id = $3
+		SET status = $1, three_ds_result = $2, three_ds_eci = $3, updated_at = $4 
+		WHERE id = $5 AND status = $6
 	`
 
-	rows, err := r.db.Exec(query, invoice.Status, invoice.UpdatedAt, invoice.ID)
+	rows, err := tx.ExecContext(ctx, query, invoice.Status, nullString(string(invoice.ThreeDSResult)), nullString(invoice.ThreeDSECI), invoice.UpdatedAt, invoice.ID, from)
 	if err != nil {
 		return err
 	}
@@ -129,8 +389,52 @@ func (r *InvoiceRepository) UpdateStatus(invoice *domain.Invoice) error {
 	}
 	
 	if rowsAffedted == 0 {
-		return domain.ErrInvoiceNotFound
+		var exists bool
+		if err := r.db.QueryRowContext(ctx, `SELECT EXISTS(SELECT 1 FROM invoices WHERE id = $1)`, invoice.ID).Scan(&exists); err != nil {
+			return err
+		}
+		if !exists {
+			return domain.ErrInvoiceNotFound
+		}
+		return domain.ErrInvalidStatus
 	}
 
-	return nil
-}
\ No newline at end of file
+	// só quem gravou a transição credita, junto com ela
+	if err := creditApproved(ctx, tx, invoice); err != nil {
+		return err
+	}
+
+	if err := writeOutbox(ctx, tx, outbox); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// creditApproved soma o valor da fatura aprovada ao saldo da conta, na transação que grava a fatura ou o status
+func creditApproved(ctx context.Context, tx *sql.Tx, invoice *domain.Invoice) error {
+	if invoice.Status != domain.StatusApproved {
+		return nil
+	}
+
+	_, err := tx.ExecContext(ctx, `
+		UPDATE accounts
+		SET balance = balance + $1, updated_at = $2
+		WHERE id = $3
+	`, invoice.Amount, time.Now(), invoice.AccountID)
+	return err
+}
+
+// nullString converte string vazia em NULL (colunas opcionais, uuid/índices únicos)
+func nullString(value string) sql.NullString {
+	return sql.NullString{String: value, Valid: value != ""}
+}
+
+// isUniqueViolation identifica o erro 23505 (unique_violation) do Postgres para uma constraint específica
+func isUniqueViolation(err error, constraint string) bool {
+	pqErr, ok := err.(*pq.Error)
+	if !ok {
+		return false
+	}
+	return pqErr.Code == "23505" && pqErr.Constraint == constraint
+}
@@ -0,0 +1,90 @@
+package repository
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/j-ordep/gateway/go-gateway/internal/domain"
+)
+
+// cada filtro vira uma condição com o próprio placeholder, na ordem dos argumentos
+func TestInvoiceFilterQuery(t *testing.T) {
+	from := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC)
+	cursor := &domain.InvoiceCursor{CreatedAt: time.Date(2025, 1, 15, 12, 0, 0, 0, time.UTC), ID: "invoice-9"}
+
+	tests := []struct {
+		name   string
+		filter domain.InvoiceFilter
+		where  string
+		limit  string
+		args   []any
+	}{
+		{
+			name:  "sem filtros",
+			where: "account_id = $1",
+			args:  []any{"account-1"},
+		},
+		{
+			name:   "status e tipo de pagamento",
+			filter: domain.InvoiceFilter{Status: domain.StatusApproved, PaymentType: "credit_card"},
+			where:  "account_id = $1 AND status = $2 AND payment_type = $3",
+			args:   []any{"account-1", domain.StatusApproved, "credit_card"},
+		},
+		{
+			name:   "faixa de valor e período",
+			filter: domain.InvoiceFilter{MinAmount: 10, MaxAmount: 500, CreatedFrom: from, CreatedTo: to},
+			where:  "account_id = $1 AND amount >= $2 AND amount <= $3 AND created_at >= $4 AND created_at < $5",
+			args:   []any{"account-1", 10.0, 500.0, from, to},
+		},
+		{
+			name:   "referência, cliente, cartão e metadata",
+			filter: domain.InvoiceFilter{ExternalReference: "order-1", CustomerID: "customer-1", CardLastDigits: "4242", Metadata: map[string]string{"order": "1"}},
+			where:  "account_id = $1 AND external_reference = $2 AND customer_id = $3 AND card_last_digits = $4 AND metadata @> $5::jsonb",
+			args:   []any{"account-1", "order-1", "customer-1", "4242", []byte(`{"order":"1"}`)},
+		},
+		{
+			name:   "primeira página",
+			filter: domain.InvoiceFilter{Status: domain.StatusPending, Limit: 21},
+			where:  "account_id = $1 AND status = $2",
+			limit:  " LIMIT $3",
+			args:   []any{"account-1", domain.StatusPending, 21},
+		},
+		{
+			name:   "página seguinte",
+			filter: domain.InvoiceFilter{Status: domain.StatusPending, Limit: 21, Cursor: cursor},
+			where:  "account_id = $1 AND status = $2 AND (created_at, id) < ($3, $4)",
+			limit:  " LIMIT $5",
+			args:   []any{"account-1", domain.StatusPending, cursor.CreatedAt, cursor.ID, 21},
+		},
+		{
+			name:   "todos os filtros com cursor",
+			filter: domain.InvoiceFilter{ExternalReference: "order-1", CustomerID: "customer-1", Status: domain.StatusRejected, PaymentType: "pix", MinAmount: 1, MaxAmount: 2, CreatedFrom: from, CreatedTo: to, CardLastDigits: "0000", Metadata: map[string]string{"a": "b"}, Limit: 10, Cursor: cursor},
+			where:  "account_id = $1 AND external_reference = $2 AND customer_id = $3 AND status = $4 AND payment_type = $5 AND amount >= $6 AND amount <= $7 AND created_at >= $8 AND created_at < $9 AND card_last_digits = $10 AND metadata @> $11::jsonb AND (created_at, id) < ($12, $13)",
+			limit:  " LIMIT $14",
+			args:   []any{"account-1", "order-1", "customer-1", domain.StatusRejected, "pix", 1.0, 2.0, from, to, "0000", []byte(`{"a":"b"}`), cursor.CreatedAt, cursor.ID, 10},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query, args, err := invoiceFilterQuery("account-1", tt.filter)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			suffix := "WHERE " + tt.where + "\n\t\tORDER BY created_at DESC, id DESC" + tt.limit
+			if !strings.HasSuffix(query, suffix) {
+				t.Fatalf("query = %q, esperado terminar com %q", query, suffix)
+			}
+			if !strings.Contains(query, "SELECT "+invoiceColumns+"\n\t\tFROM invoices\n") {
+				t.Fatalf("query não seleciona as colunas da fatura: %q", query)
+			}
+			if !reflect.DeepEqual(args, tt.args) {
+				t.Fatalf("args = %#v, esperado %#v", args, tt.args)
+			}
+		})
+	}
+}
@@ -0,0 +1,62 @@
+package repository
+
+import (
+	"sync"
+	"time"
+
+	"github.com/j-ordep/gateway/go-gateway/internal/domain"
+)
+
+// rateLimitSweepInterval define de quanto em quanto tempo os buckets ociosos são descartados
+const rateLimitSweepInterval = time.Minute
+
+// MemoryRateLimitRepository guarda os token buckets em memória
+// cada instância do gateway tem seus próprios limites, use o Postgres quando houver mais de uma
+type MemoryRateLimitRepository struct {
+	mu        sync.Mutex
+	buckets   map[string]*memoryBucket
+	lastSweep time.Time
+}
+
+type memoryBucket struct {
+	bucket *domain.TokenBucket
+	limit  domain.RateLimit
+}
+
+func NewMemoryRateLimitRepository() *MemoryRateLimitRepository {
+	return &MemoryRateLimitRepository{
+		buckets:   make(map[string]*memoryBucket),
+		lastSweep: time.Now(),
+	}
+}
+
+func (r *MemoryRateLimitRepository) Take(key string, limit domain.RateLimit) (domain.RateLimitResult, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	r.sweep(now)
+
+	entry, ok := r.buckets[key]
+	if !ok {
+		entry = &memoryBucket{bucket: domain.NewTokenBucket(limit, now)}
+		r.buckets[key] = entry
+	}
+	entry.limit = limit
+
+	return entry.bucket.Take(limit, now), nil
+}
+
+// sweep remove buckets que já teriam enchido de novo, equivalentes a um bucket novo
+func (r *MemoryRateLimitRepository) sweep(now time.Time) {
+	if now.Sub(r.lastSweep) < rateLimitSweepInterval {
+		return
+	}
+
+	for key, entry := range r.buckets {
+		if entry.bucket.Idle(entry.limit, now) {
+			delete(r.buckets, key)
+		}
+	}
+	r.lastSweep = now
+}
@@ -0,0 +1,60 @@
+package repository
+
+import (
+	"sync"
+	"time"
+)
+
+// MemoryVelocityRepository mantém as tentativas em memória (sliding log)
+// útil para desenvolvimento ou quando há uma única instância do gateway
+type MemoryVelocityRepository struct {
+	mu        sync.Mutex
+	attempts  map[string][]time.Time
+	maxWindow time.Duration // maior janela já usada, define quando uma chave pode ser descartada
+	lastSweep time.Time
+}
+
+func NewMemoryVelocityRepository() *MemoryVelocityRepository {
+	return &MemoryVelocityRepository{
+		attempts:  make(map[string][]time.Time),
+		lastSweep: time.Now(),
+	}
+}
+
+func (r *MemoryVelocityRepository) Increment(key string, window time.Duration) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	windowStart := now.Add(-window)
+
+	if window > r.maxWindow {
+		r.maxWindow = window
+	}
+	r.sweep(now)
+
+	// as tentativas ficam em ordem cronológica, basta descartar o início da lista
+	attempts := r.attempts[key]
+	i := 0
+	for i < len(attempts) && !attempts[i].After(windowStart) {
+		i++
+	}
+	attempts = append(attempts[i:], now)
+	r.attempts[key] = attempts
+
+	return len(attempts), nil
+}
+
+// sweep remove chaves sem tentativas recentes (ex: IPs que não voltaram), evitando crescimento infinito do map
+func (r *MemoryVelocityRepository) sweep(now time.Time) {
+	if now.Sub(r.lastSweep) < r.maxWindow {
+		return
+	}
+
+	for key, attempts := range r.attempts {
+		if len(attempts) == 0 || now.Sub(attempts[len(attempts)-1]) > r.maxWindow {
+			delete(r.attempts, key)
+		}
+	}
+	r.lastSweep = now
+}
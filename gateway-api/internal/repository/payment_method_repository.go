@@ -0,0 +1,121 @@
+package repository
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/j-ordep/gateway/go-gateway/internal/domain"
+)
+
+type PaymentMethodRepository struct {
+	db *sql.DB
+}
+
+func NewPaymentMethodRepository(db *sql.DB) *PaymentMethodRepository {
+	return &PaymentMethodRepository{db: db}
+}
+
+func (r *PaymentMethodRepository) Save(paymentMethod *domain.PaymentMethod) error {
+	_, err := r.db.Exec(`
+		INSERT INTO payment_methods (id, account_id, customer_id, brand, card_last_digits, expiry_month, expiry_year, cardholder_name, fingerprint, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+	`,
+		paymentMethod.ID,
+		paymentMethod.AccountID,
+		paymentMethod.CustomerID,
+		paymentMethod.Brand,
+		paymentMethod.LastDigits,
+		paymentMethod.ExpiryMonth,
+		paymentMethod.ExpiryYear,
+		paymentMethod.CardholderName,
+		paymentMethod.Fingerprint,
+		paymentMethod.CreatedAt,
+	)
+	return err
+}
+
+func (r *PaymentMethodRepository) FindByID(id string) (*domain.PaymentMethod, error) {
+	var paymentMethod domain.PaymentMethod
+
+	err := r.db.QueryRow(`
+		SELECT id, account_id, customer_id, brand, card_last_digits, expiry_month, expiry_year, cardholder_name, fingerprint, created_at
+		FROM payment_methods
+		WHERE id = $1 AND deleted_at IS NULL
+	`, id).Scan(
+		&paymentMethod.ID,
+		&paymentMethod.AccountID,
+		&paymentMethod.CustomerID,
+		&paymentMethod.Brand,
+		&paymentMethod.LastDigits,
+		&paymentMethod.ExpiryMonth,
+		&paymentMethod.ExpiryYear,
+		&paymentMethod.CardholderName,
+		&paymentMethod.Fingerprint,
+		&paymentMethod.CreatedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, domain.ErrPaymentMethodNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &paymentMethod, nil
+}
+
+func (r *PaymentMethodRepository) FindByCustomerID(customerID string) ([]*domain.PaymentMethod, error) {
+	rows, err := r.db.Query(`
+		SELECT id, account_id, customer_id, brand, card_last_digits, expiry_month, expiry_year, cardholder_name, fingerprint, created_at
+		FROM payment_methods
+		WHERE customer_id = $1 AND deleted_at IS NULL
+		ORDER BY created_at DESC
+	`, customerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var paymentMethods []*domain.PaymentMethod
+
+	for rows.Next() {
+		var paymentMethod domain.PaymentMethod
+		err := rows.Scan(
+			&paymentMethod.ID,
+			&paymentMethod.AccountID,
+			&paymentMethod.CustomerID,
+			&paymentMethod.Brand,
+			&paymentMethod.LastDigits,
+			&paymentMethod.ExpiryMonth,
+			&paymentMethod.ExpiryYear,
+			&paymentMethod.CardholderName,
+			&paymentMethod.Fingerprint,
+			&paymentMethod.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		paymentMethods = append(paymentMethods, &paymentMethod)
+	}
+
+	return paymentMethods, rows.Err()
+}
+
+// Delete marca o cartão como removido (soft delete), as faturas pagas com ele mantêm o payment_method_id
+func (r *PaymentMethodRepository) Delete(id string) error {
+	result, err := r.db.Exec(`UPDATE payment_methods SET deleted_at = $1 WHERE id = $2 AND deleted_at IS NULL`, time.Now(), id)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return domain.ErrPaymentMethodNotFound
+	}
+
+	return nil
+}
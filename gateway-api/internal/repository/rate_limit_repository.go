@@ -0,0 +1,67 @@
+package repository
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/j-ordep/gateway/go-gateway/internal/domain"
+)
+
+// RateLimitRepository guarda os token buckets no Postgres,
+// assim várias instâncias do gateway compartilham o mesmo limite por conta
+type RateLimitRepository struct {
+	db *sql.DB
+}
+
+func NewRateLimitRepository(db *sql.DB) *RateLimitRepository {
+	return &RateLimitRepository{db: db}
+}
+
+func (r *RateLimitRepository) Take(key string, limit domain.RateLimit) (domain.RateLimitResult, error) {
+	now := time.Now()
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return domain.RateLimitResult{}, err
+	}
+	defer tx.Rollback()
+
+	// primeiro acesso da chave: bucket cheio
+	_, err = tx.Exec(`
+		INSERT INTO rate_limit_buckets (key, tokens, updated_at)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (key) DO NOTHING
+	`, key, limit.Burst, now)
+	if err != nil {
+		return domain.RateLimitResult{}, err
+	}
+
+	// FOR UPDATE serializa requisições concorrentes da mesma chave
+	var bucket domain.TokenBucket
+	err = tx.QueryRow(`SELECT tokens, updated_at FROM rate_limit_buckets WHERE key = $1 FOR UPDATE`, key).
+		Scan(&bucket.Tokens, &bucket.UpdatedAt)
+	if err != nil {
+		return domain.RateLimitResult{}, err
+	}
+
+	result := bucket.Take(limit, now)
+
+	_, err = tx.Exec(`UPDATE rate_limit_buckets SET tokens = $1, updated_at = $2 WHERE key = $3`, bucket.Tokens, bucket.UpdatedAt, key)
+	if err != nil {
+		return domain.RateLimitResult{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return domain.RateLimitResult{}, err
+	}
+	return result, nil
+}
+
+// DeleteIdle remove buckets sem uso desde before, chamado periodicamente
+func (r *RateLimitRepository) DeleteIdle(before time.Time) (int64, error) {
+	result, err := r.db.Exec(`DELETE FROM rate_limit_buckets WHERE updated_at < $1`, before)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
@@ -0,0 +1,74 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/j-ordep/gateway/go-gateway/internal/domain"
+)
+
+type ThreeDSChallengeRepository struct {
+	db *sql.DB
+}
+
+func NewThreeDSChallengeRepository(db *sql.DB) *ThreeDSChallengeRepository {
+	return &ThreeDSChallengeRepository{db: db}
+}
+
+// insertThreeDSChallenge grava o desafio na transação da fatura (InvoiceRepository.Save)
+func insertThreeDSChallenge(ctx context.Context, db execer, challenge *domain.ThreeDSChallenge) error {
+	_, err := db.ExecContext(ctx, `
+		INSERT INTO three_ds_challenges (id, invoice_id, status, eci, created_at, completed_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+	`, challenge.ID, challenge.InvoiceID, challenge.Status, nullString(challenge.ECI), challenge.CreatedAt, challenge.CompletedAt)
+	return err
+}
+
+func (r *ThreeDSChallengeRepository) FindByID(id string) (*domain.ThreeDSChallenge, error) {
+	var challenge domain.ThreeDSChallenge
+
+	err := r.db.QueryRow(`
+		SELECT id, invoice_id, status, COALESCE(eci, ''), created_at, completed_at
+		FROM three_ds_challenges
+		WHERE id = $1
+	`, id).Scan(
+		&challenge.ID,
+		&challenge.InvoiceID,
+		&challenge.Status,
+		&challenge.ECI,
+		&challenge.CreatedAt,
+		&challenge.CompletedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, domain.ErrThreeDSChallengeNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &challenge, nil
+}
+
+// Update só grava a resposta de um desafio ainda pendente: duas respostas simultâneas não sobrescrevem uma à outra
+func (r *ThreeDSChallengeRepository) Update(challenge *domain.ThreeDSChallenge) error {
+	result, err := r.db.Exec(`
+		UPDATE three_ds_challenges
+		SET status = $1, eci = $2, completed_at = $3
+		WHERE id = $4 AND status = $5
+	`, challenge.Status, nullString(challenge.ECI), challenge.CompletedAt, challenge.ID, domain.ChallengeStatusPending)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	// o desafio foi lido antes da resposta, então nenhuma linha afetada é outra resposta que chegou primeiro
+	if rowsAffected == 0 {
+		return domain.ErrThreeDSChallengeCompleted
+	}
+
+	return nil
+}
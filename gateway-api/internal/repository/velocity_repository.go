@@ -0,0 +1,57 @@
+package repository
+
+import (
+	"database/sql"
+	"time"
+)
+
+// VelocityRepository guarda as tentativas no Postgres,
+// assim várias instâncias do gateway compartilham os mesmos contadores
+type VelocityRepository struct {
+	db *sql.DB
+}
+
+func NewVelocityRepository(db *sql.DB) *VelocityRepository {
+	return &VelocityRepository{db: db}
+}
+
+func (r *VelocityRepository) Increment(key string, window time.Duration) (int, error) {
+	now := time.Now()
+	windowStart := now.Add(-window)
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	// serializa as tentativas da mesma chave até o commit: sem o lock, duas tentativas simultâneas
+	// contariam só a própria inserção e as duas passariam do limite sem serem barradas
+	_, err = tx.Exec(`SELECT pg_advisory_xact_lock(hashtext($1))`, key)
+	if err != nil {
+		return 0, err
+	}
+
+	// remove o que já saiu da janela, mantém a tabela pequena sem precisar de job separado
+	_, err = tx.Exec(`DELETE FROM velocity_attempts WHERE key = $1 AND attempted_at <= $2`, key, windowStart)
+	if err != nil {
+		return 0, err
+	}
+
+	_, err = tx.Exec(`INSERT INTO velocity_attempts (key, attempted_at) VALUES ($1, $2)`, key, now)
+	if err != nil {
+		return 0, err
+	}
+
+	var count int
+	err = tx.QueryRow(`
+		SELECT COUNT(*)
+		FROM velocity_attempts
+		WHERE key = $1 AND attempted_at > $2
+	`, key, windowStart).Scan(&count)
+	if err != nil {
+		return 0, err
+	}
+
+	return count, tx.Commit()
+}
@@ -0,0 +1,43 @@
+package repository
+
+import (
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// tentativas simultâneas da mesma chave recebem contagens distintas (1..n), nenhuma deixa de ver as anteriores
+func TestVelocityIncrementConcurrent(t *testing.T) {
+	db := testDB(t)
+	repository := NewVelocityRepository(db)
+	key := "test:" + uuid.NewString()
+	t.Cleanup(func() { db.Exec(`DELETE FROM velocity_attempts WHERE key = $1`, key) })
+
+	const attempts = 20
+	counts := make([]int, attempts)
+	errs := make([]error, attempts)
+	var wg sync.WaitGroup
+	for i := range attempts {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			counts[i], errs[i] = repository.Increment(key, time.Hour)
+		}()
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	sort.Ints(counts)
+	for i, count := range counts {
+		if count != i+1 {
+			t.Fatalf("contagens = %v, esperado 1..%d sem repetição", counts, attempts)
+		}
+	}
+}
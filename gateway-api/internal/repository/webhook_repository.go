@@ -0,0 +1,296 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/j-ordep/gateway/go-gateway/internal/domain"
+	"github.com/j-ordep/gateway/go-gateway/internal/tracing"
+	"github.com/lib/pq"
+)
+
+type WebhookEndpointRepository struct {
+	db *sql.DB
+}
+
+func NewWebhookEndpointRepository(db *sql.DB) *WebhookEndpointRepository {
+	return &WebhookEndpointRepository{db: db}
+}
+
+func (r *WebhookEndpointRepository) Save(ctx context.Context, endpoint *domain.WebhookEndpoint) (err error) {
+	ctx, span := tracing.StartRepository(ctx, "WebhookEndpointRepository.Save", "INSERT", "webhook_endpoints")
+	defer func() { tracing.End(span, err) }()
+
+	_, err = r.db.ExecContext(ctx, `
+		INSERT INTO webhook_endpoints (id, account_id, url, event_types, secret, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+	`, endpoint.ID, endpoint.AccountID, endpoint.URL, pq.Array(eventTypesToStrings(endpoint.EventTypes)), endpoint.Secret, endpoint.CreatedAt, endpoint.UpdatedAt)
+	return err
+}
+
+func (r *WebhookEndpointRepository) FindByID(ctx context.Context, id string) (endpoint *domain.WebhookEndpoint, err error) {
+	ctx, span := tracing.StartRepository(ctx, "WebhookEndpointRepository.FindByID", "SELECT", "webhook_endpoints")
+	defer func() { tracing.End(span, err) }()
+
+	endpoint, err = scanWebhookEndpoint(r.db.QueryRowContext(ctx, `
+		SELECT id, account_id, url, event_types, secret, created_at, updated_at
+		FROM webhook_endpoints
+		WHERE id = $1
+	`, id))
+	if err == sql.ErrNoRows {
+		return nil, domain.ErrWebhookEndpointNotFound
+	}
+	return endpoint, err
+}
+
+func (r *WebhookEndpointRepository) FindByAccountID(ctx context.Context, accountID string) (endpoints []*domain.WebhookEndpoint, err error) {
+	ctx, span := tracing.StartRepository(ctx, "WebhookEndpointRepository.FindByAccountID", "SELECT", "webhook_endpoints")
+	defer func() { tracing.End(span, err) }()
+
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT id, account_id, url, event_types, secret, created_at, updated_at
+		FROM webhook_endpoints
+		WHERE account_id = $1
+		ORDER BY created_at
+	`, accountID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		endpoint, err := scanWebhookEndpoint(rows)
+		if err != nil {
+			return nil, err
+		}
+		endpoints = append(endpoints, endpoint)
+	}
+
+	return endpoints, rows.Err()
+}
+
+// Delete remove o endpoint junto com o log de entregas (ON DELETE CASCADE)
+func (r *WebhookEndpointRepository) Delete(ctx context.Context, id string) (err error) {
+	ctx, span := tracing.StartRepository(ctx, "WebhookEndpointRepository.Delete", "DELETE", "webhook_endpoints")
+	defer func() { tracing.End(span, err) }()
+
+	result, err := r.db.ExecContext(ctx, `DELETE FROM webhook_endpoints WHERE id = $1`, id)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return domain.ErrWebhookEndpointNotFound
+	}
+	return nil
+}
+
+func scanWebhookEndpoint(row interface{ Scan(...any) error }) (*domain.WebhookEndpoint, error) {
+	var endpoint domain.WebhookEndpoint
+	var eventTypes []string
+
+	err := row.Scan(
+		&endpoint.ID,
+		&endpoint.AccountID,
+		&endpoint.URL,
+		pq.Array(&eventTypes),
+		&endpoint.Secret,
+		&endpoint.CreatedAt,
+		&endpoint.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, eventType := range eventTypes {
+		endpoint.EventTypes = append(endpoint.EventTypes, domain.WebhookEventType(eventType))
+	}
+	return &endpoint, nil
+}
+
+func eventTypesToStrings(eventTypes []domain.WebhookEventType) []string {
+	values := make([]string, len(eventTypes))
+	for i, eventType := range eventTypes {
+		values[i] = string(eventType)
+	}
+	return values
+}
+
+type WebhookDeliveryRepository struct {
+	db *sql.DB
+}
+
+func NewWebhookDeliveryRepository(db *sql.DB) *WebhookDeliveryRepository {
+	return &WebhookDeliveryRepository{db: db}
+}
+
+const webhookDeliveryColumns = `id, endpoint_id, account_id, event_id, event_type, payload, status, attempts,
+		next_attempt_at, last_response_status, last_error, created_at, updated_at, delivered_at`
+
+// insertWebhookDelivery grava a entrega na transação da fatura (InvoiceRepository.Save/UpdateStatus)
+// o INSERT ... SELECT parte do endpoint: se ele foi removido depois de lido, a entrega é ignorada em vez de desfazer a fatura
+func insertWebhookDelivery(ctx context.Context, db execer, delivery *domain.WebhookDelivery) error {
+	_, err := db.ExecContext(ctx, `
+		INSERT INTO webhook_deliveries (`+webhookDeliveryColumns+`)
+		SELECT $1, id, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14
+		FROM webhook_endpoints
+		WHERE id = $2
+	`,
+		delivery.ID,
+		delivery.EndpointID,
+		delivery.AccountID,
+		delivery.EventID,
+		delivery.EventType,
+		delivery.Payload,
+		delivery.Status,
+		delivery.Attempts,
+		delivery.NextAttemptAt,
+		delivery.LastResponseStatus,
+		delivery.LastError,
+		delivery.CreatedAt,
+		delivery.UpdatedAt,
+		delivery.DeliveredAt,
+	)
+	return err
+}
+
+func (r *WebhookDeliveryRepository) FindByID(ctx context.Context, id string) (delivery *domain.WebhookDelivery, err error) {
+	ctx, span := tracing.StartRepository(ctx, "WebhookDeliveryRepository.FindByID", "SELECT", "webhook_deliveries")
+	defer func() { tracing.End(span, err) }()
+
+	delivery, err = scanWebhookDelivery(r.db.QueryRowContext(ctx, `
+		SELECT `+webhookDeliveryColumns+`
+		FROM webhook_deliveries
+		WHERE id = $1
+	`, id))
+	if err == sql.ErrNoRows {
+		return nil, domain.ErrWebhookDeliveryNotFound
+	}
+	return delivery, err
+}
+
+func (r *WebhookDeliveryRepository) FindByEndpointID(ctx context.Context, endpointID string, filter domain.WebhookDeliveryFilter) (deliveries []*domain.WebhookDelivery, err error) {
+	ctx, span := tracing.StartRepository(ctx, "WebhookDeliveryRepository.FindByEndpointID", "SELECT", "webhook_deliveries")
+	defer func() { tracing.End(span, err) }()
+
+	args := []any{endpointID}
+	query := `
+		SELECT ` + webhookDeliveryColumns + `
+		FROM webhook_deliveries
+		WHERE endpoint_id = $1`
+	if filter.Status != "" {
+		args = append(args, filter.Status)
+		query += fmt.Sprintf(" AND status = $%d", len(args))
+	}
+	query += `
+		ORDER BY created_at DESC, id DESC`
+	if filter.Limit > 0 {
+		args = append(args, filter.Limit)
+		query += fmt.Sprintf(" LIMIT $%d", len(args))
+	}
+
+	return r.query(ctx, query, args...)
+}
+
+// ClaimDue usa FOR UPDATE SKIP LOCKED: instâncias concorrentes pegam entregas diferentes
+func (r *WebhookDeliveryRepository) ClaimDue(ctx context.Context, lease time.Duration, limit int) (deliveries []*domain.WebhookDelivery, err error) {
+	ctx, span := tracing.StartRepository(ctx, "WebhookDeliveryRepository.ClaimDue", "UPDATE", "webhook_deliveries")
+	defer func() { tracing.End(span, err) }()
+
+	now := time.Now()
+	return r.query(ctx, `
+		UPDATE webhook_deliveries
+		SET next_attempt_at = $1
+		WHERE id IN (
+			SELECT id FROM webhook_deliveries
+			WHERE status = 'pending' AND next_attempt_at <= $2
+			ORDER BY next_attempt_at
+			LIMIT $3
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING `+webhookDeliveryColumns,
+		now.Add(lease), now, limit)
+}
+
+func (r *WebhookDeliveryRepository) RecordAttempt(ctx context.Context, delivery *domain.WebhookDelivery) (err error) {
+	ctx, span := tracing.StartRepository(ctx, "WebhookDeliveryRepository.RecordAttempt", "UPDATE", "webhook_deliveries")
+	defer func() { tracing.End(span, err) }()
+
+	result, err := r.db.ExecContext(ctx, `
+		UPDATE webhook_deliveries
+		SET status = $1, attempts = $2, next_attempt_at = $3, last_response_status = $4,
+			last_error = $5, updated_at = $6, delivered_at = $7
+		WHERE id = $8
+	`,
+		delivery.Status,
+		delivery.Attempts,
+		delivery.NextAttemptAt,
+		delivery.LastResponseStatus,
+		delivery.LastError,
+		delivery.UpdatedAt,
+		delivery.DeliveredAt,
+		delivery.ID,
+	)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return domain.ErrWebhookDeliveryNotFound
+	}
+	return nil
+}
+
+func (r *WebhookDeliveryRepository) query(ctx context.Context, query string, args ...any) ([]*domain.WebhookDelivery, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var deliveries []*domain.WebhookDelivery
+	for rows.Next() {
+		delivery, err := scanWebhookDelivery(rows)
+		if err != nil {
+			return nil, err
+		}
+		deliveries = append(deliveries, delivery)
+	}
+
+	return deliveries, rows.Err()
+}
+
+func scanWebhookDelivery(row interface{ Scan(...any) error }) (*domain.WebhookDelivery, error) {
+	var delivery domain.WebhookDelivery
+
+	err := row.Scan(
+		&delivery.ID,
+		&delivery.EndpointID,
+		&delivery.AccountID,
+		&delivery.EventID,
+		&delivery.EventType,
+		&delivery.Payload,
+		&delivery.Status,
+		&delivery.Attempts,
+		&delivery.NextAttemptAt,
+		&delivery.LastResponseStatus,
+		&delivery.LastError,
+		&delivery.CreatedAt,
+		&delivery.UpdatedAt,
+		&delivery.DeliveredAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &delivery, nil
+}
@@ -0,0 +1,68 @@
+package rpc
+
+import (
+	"context"
+
+	"github.com/j-ordep/gateway/go-gateway/internal/dto"
+	"github.com/j-ordep/gateway/go-gateway/internal/rpc/gatewayv1"
+	"github.com/j-ordep/gateway/go-gateway/internal/service"
+	"github.com/j-ordep/gateway/go-gateway/internal/validation"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+type accountServer struct {
+	gatewayv1.UnimplementedAccountServiceServer
+	accountService *service.AccountService
+}
+
+func newAccountServer(accountService *service.AccountService) *accountServer {
+	return &accountServer{accountService: accountService}
+}
+
+func (s *accountServer) CreateAccount(ctx context.Context, req *gatewayv1.CreateAccountRequest) (*gatewayv1.Account, error) {
+	input := dto.CreateAccountInput{
+		Name:  req.GetName(),
+		Email: req.GetEmail(),
+	}
+	if err := validation.Struct(&input); err != nil {
+		return nil, err
+	}
+
+	output, err := s.accountService.CreateAccount(input)
+	if err != nil {
+		return nil, err
+	}
+
+	return toAccount(output), nil
+}
+
+func (s *accountServer) GetAccount(ctx context.Context, req *gatewayv1.GetAccountRequest) (*gatewayv1.Account, error) {
+	principal, err := authenticated(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	output, err := s.accountService.Get(principal)
+	if err != nil {
+		return nil, err
+	}
+
+	return toAccount(output), nil
+}
+
+func toAccount(output *dto.AccountOutput) *gatewayv1.Account {
+	return &gatewayv1.Account{
+		Id:               output.ID,
+		Name:             output.Name,
+		Email:            output.Email,
+		ApiKey:           output.APIKey,
+		Balance:          output.Balance,
+		Status:           output.Status,
+		StatusReason:     output.StatusReason,
+		ThreeDsMode:      output.ThreeDSMode,
+		ThreeDsMinAmount: output.ThreeDSMinAmount,
+		Tier:             output.Tier,
+		CreatedAt:        timestamppb.New(output.CreatedAt),
+		UpdatedAt:        timestamppb.New(output.UpdatedAt),
+	}
+}
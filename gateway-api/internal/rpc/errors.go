@@ -0,0 +1,104 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/j-ordep/gateway/go-gateway/internal/logging"
+	"github.com/j-ordep/gateway/go-gateway/internal/validation"
+	"github.com/j-ordep/gateway/go-gateway/internal/web/response"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/protoadapt"
+)
+
+// errorDomain identifica a origem dos códigos em ErrorInfo.domain
+const errorDomain = "gateway"
+
+// toStatus converte o erro com o mesmo mapeamento da API REST (response.ToErrorBody)
+//   - o status HTTP vira o código gRPC equivalente
+//   - o código estável (ex: invoice_not_found) vai em ErrorInfo.reason, com o request_id na metadata
+//   - erros de validação trazem as violações em BadRequest.field_violations
+func toStatus(ctx context.Context, err error) error {
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return status.FromContextError(err).Err()
+	}
+
+	httpStatus, body := response.ToErrorBody(err)
+
+	info := &errdetails.ErrorInfo{
+		Reason:   body.Code,
+		Domain:   errorDomain,
+		Metadata: map[string]string{"request_id": logging.RequestID(ctx)},
+	}
+	details := []protoadapt.MessageV1{info}
+
+	switch extra := body.Details.(type) {
+	case validation.Errors:
+		badRequest := &errdetails.BadRequest{}
+		for _, fieldErr := range extra {
+			badRequest.FieldViolations = append(badRequest.FieldViolations, &errdetails.BadRequest_FieldViolation{
+				Field:       fieldErr.Field,
+				Description: fieldErr.Message,
+			})
+		}
+		details = append(details, badRequest)
+	case map[string]string:
+		for key, value := range extra {
+			info.Metadata[key] = value
+		}
+	}
+
+	st := status.New(grpcCode(httpStatus, body.Code), body.Message)
+	if withDetails, err := st.WithDetails(details...); err == nil {
+		st = withDetails
+	}
+	return st.Err()
+}
+
+// grpcCode traduz o status HTTP do mapeamento central para o código gRPC
+// o 409 é dividido entre recurso duplicado (AlreadyExists) e transição de estado inválida (FailedPrecondition)
+func grpcCode(httpStatus int, code string) codes.Code {
+	switch httpStatus {
+	case http.StatusBadRequest, http.StatusUnprocessableEntity:
+		return codes.InvalidArgument
+	case http.StatusUnauthorized:
+		return codes.Unauthenticated
+	case http.StatusForbidden:
+		return codes.PermissionDenied
+	case http.StatusNotFound:
+		return codes.NotFound
+	case http.StatusConflict:
+		if code == "conflict" || strings.HasSuffix(code, "_already_exists") {
+			return codes.AlreadyExists
+		}
+		if code == "idempotency_request_in_progress" {
+			return codes.Aborted
+		}
+		return codes.FailedPrecondition
+	case http.StatusRequestEntityTooLarge, http.StatusTooManyRequests:
+		return codes.ResourceExhausted
+	case http.StatusServiceUnavailable:
+		return codes.Unavailable
+	}
+
+	if httpStatus >= http.StatusInternalServerError {
+		return codes.Internal
+	}
+	return codes.Unknown
+}
+
+// isServerError indica os códigos que correspondem a um 5xx na API REST
+func isServerError(code codes.Code) bool {
+	switch code {
+	case codes.Internal, codes.Unknown, codes.Unavailable, codes.DataLoss, codes.Unimplemented:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,4 @@
+// Package gatewayv1 contém o código gerado a partir de proto/gateway/v1
+package gatewayv1
+
+//go:generate protoc -I ../../../proto --go_out=. --go_opt=module=github.com/j-ordep/gateway/go-gateway/internal/rpc/gatewayv1 --go-grpc_out=. --go-grpc_opt=module=github.com/j-ordep/gateway/go-gateway/internal/rpc/gatewayv1 gateway/v1/account.proto gateway/v1/invoice.proto
@@ -0,0 +1,271 @@
+package rpc
+
+import (
+	"context"
+	"log/slog"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/j-ordep/gateway/go-gateway/internal/domain"
+	"github.com/j-ordep/gateway/go-gateway/internal/logging"
+	"github.com/j-ordep/gateway/go-gateway/internal/metrics"
+	"github.com/j-ordep/gateway/go-gateway/internal/rpc/gatewayv1"
+	"github.com/j-ordep/gateway/go-gateway/internal/service"
+	"github.com/j-ordep/gateway/go-gateway/internal/tracing"
+	"github.com/j-ordep/gateway/go-gateway/internal/web/middleware"
+	"github.com/j-ordep/gateway/go-gateway/internal/web/request"
+	"github.com/j-ordep/gateway/go-gateway/internal/web/response"
+	"go.opentelemetry.io/otel"
+	otelcodes "go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// methodPolicy é o equivalente dos grupos de rotas da v1 (rota pública, Identify, Authenticate + RequireScope)
+type methodPolicy struct {
+	public        bool // sem apiKey
+	requireActive bool // true = Authenticate (conta ativa), false = Identify (qualquer status)
+	scope         domain.Scope
+}
+
+var methodPolicies = map[string]methodPolicy{
+	gatewayv1.AccountService_CreateAccount_FullMethodName:      {public: true},
+	gatewayv1.AccountService_GetAccount_FullMethodName:         {scope: domain.ScopeAccountRead},
+	gatewayv1.InvoiceService_CreateInvoice_FullMethodName:      {requireActive: true, scope: domain.ScopeInvoicesWrite},
+	gatewayv1.InvoiceService_GetInvoice_FullMethodName:         {requireActive: true, scope: domain.ScopeInvoicesRead},
+	gatewayv1.InvoiceService_ListInvoices_FullMethodName:       {requireActive: true, scope: domain.ScopeInvoicesRead},
+	gatewayv1.InvoiceService_WatchInvoiceStatus_FullMethodName: {requireActive: true, scope: domain.ScopeInvoicesRead},
+}
+
+// interceptor faz para o gRPC o papel da cadeia de middlewares da API REST:
+// request ID, tracing, access log, métricas, rate limit, autenticação e conversão dos erros
+type interceptor struct {
+	accountService   *service.AccountService
+	rateLimitService *service.RateLimitService
+	trustedProxies   request.TrustedProxies
+}
+
+func newInterceptor(accountService *service.AccountService, rateLimitService *service.RateLimitService, trustedProxies request.TrustedProxies) *interceptor {
+	return &interceptor{accountService: accountService, rateLimitService: rateLimitService, trustedProxies: trustedProxies}
+}
+
+func (i *interceptor) unary(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp any, err error) {
+	ctx, finish := i.start(ctx, info.FullMethod)
+	defer func() { err = finish(err) }()
+
+	ctx, err = i.authorize(ctx, info.FullMethod)
+	if err != nil {
+		return nil, err
+	}
+
+	return handler(ctx, req)
+}
+
+func (i *interceptor) stream(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
+	ctx, finish := i.start(ss.Context(), info.FullMethod)
+	defer func() { err = finish(err) }()
+
+	ctx, err = i.authorize(ctx, info.FullMethod)
+	if err != nil {
+		return err
+	}
+
+	return handler(srv, &contextStream{ServerStream: ss, ctx: ctx})
+}
+
+// start prepara o contexto da chamada (request ID, IP do cliente e span de servidor)
+// o finish devolvido converte o erro para status gRPC, registra o log/métricas e fecha o span
+func (i *interceptor) start(ctx context.Context, method string) (context.Context, func(error) error) {
+	begin := time.Now()
+
+	requestID := metadataValue(ctx, "x-request-id")
+	if !middleware.ValidRequestID(requestID) {
+		requestID = uuid.NewString()
+	}
+	ctx = logging.WithRequestID(ctx, requestID)
+	grpc.SetHeader(ctx, metadata.Pairs("x-request-id", requestID))
+
+	var peerAddr string
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		peerAddr = p.Addr.String()
+	}
+	ctx = request.WithClientIP(ctx, i.trustedProxies.ClientIP(peerAddr, incomingMetadata(ctx).Get("x-forwarded-for")))
+
+	service, rpcMethod := splitMethod(method)
+	ctx = otel.GetTextMapPropagator().Extract(ctx, metadataCarrier(incomingMetadata(ctx)))
+	ctx, span := tracing.Start(ctx, strings.TrimPrefix(method, "/"),
+		trace.WithSpanKind(trace.SpanKindServer),
+		trace.WithAttributes(
+			semconv.RPCSystemGRPC,
+			semconv.RPCService(service),
+			semconv.RPCMethod(rpcMethod),
+			tracing.RequestIDKey.String(requestID),
+		),
+	)
+
+	return ctx, func(err error) error {
+		if err != nil {
+			err = toStatus(ctx, err)
+		}
+		code := status.Code(err)
+
+		level := slog.LevelInfo
+		if isServerError(code) {
+			level = slog.LevelError
+			slog.ErrorContext(ctx, "erro interno na chamada grpc", "error", err, "method", method)
+		}
+		slog.LogAttrs(ctx, level, "requisição grpc",
+			slog.String("method", method),
+			slog.String("code", code.String()),
+			slog.String("client_ip", clientIP(ctx)),
+			slog.Duration("latency", time.Since(begin)),
+		)
+
+		metrics.GRPCRequestsTotal.WithLabelValues(method, code.String()).Inc()
+		metrics.GRPCRequestDuration.WithLabelValues(method, code.String()).Observe(time.Since(begin).Seconds())
+
+		span.SetAttributes(semconv.RPCGRPCStatusCodeKey.Int(int(code)))
+		if accountID := logging.AccountID(ctx); accountID != "" {
+			span.SetAttributes(tracing.AccountIDKey.String(accountID))
+		}
+		if isServerError(code) {
+			span.SetStatus(otelcodes.Error, code.String())
+		}
+		span.End()
+
+		return err
+	}
+}
+
+// authorize aplica o rate limit e a política do método, deixando o principal no contexto
+// mesma ordem da API REST: o rate limit vem antes, por conta (apiKey válida) ou por IP
+func (i *interceptor) authorize(ctx context.Context, method string) (context.Context, error) {
+	policy, ok := methodPolicies[method]
+	if !ok {
+		return ctx, status.Errorf(codes.Unimplemented, "method %s has no authorization policy", method)
+	}
+
+	var principal domain.Principal
+	var authErr error = response.ErrMissingAPIKey
+	if apiKey := metadataValue(ctx, "x-api-key"); apiKey != "" {
+		principal, authErr = i.accountService.Authenticate(apiKey)
+		if authErr != nil && authErr != domain.ErrInvalidAPIKey {
+			return ctx, authErr
+		}
+	}
+
+	if err := i.takeRateLimit(ctx, principal, authErr == nil); err != nil {
+		return ctx, err
+	}
+
+	if policy.public {
+		return ctx, nil
+	}
+	if authErr != nil {
+		return ctx, authErr
+	}
+
+	if policy.requireActive {
+		if err := principal.CanTransact(); err != nil {
+			return ctx, err
+		}
+	}
+	if policy.scope != "" && !principal.HasScope(policy.scope) {
+		return ctx, domain.ErrInsufficientScope
+	}
+
+	logging.SetAccountID(ctx, principal.AccountID)
+	trace.SpanFromContext(ctx).SetAttributes(tracing.AccountIDKey.String(principal.AccountID))
+
+	return request.WithPrincipal(ctx, principal), nil
+}
+
+// takeRateLimit usa os mesmos buckets da API REST, o limite do plano vale somando as duas APIs
+func (i *interceptor) takeRateLimit(ctx context.Context, principal domain.Principal, authenticated bool) error {
+	var result domain.RateLimitResult
+	var err error
+	if authenticated {
+		result, _, err = i.rateLimitService.AllowAccount(principal.AccountID, string(principal.Tier))
+	} else {
+		result, _, err = i.rateLimitService.AllowIP(clientIP(ctx))
+	}
+	if err != nil {
+		// falha no store não derruba a API: a chamada segue sem limite
+		slog.ErrorContext(ctx, "erro ao consultar o rate limit", "error", err)
+		return nil
+	}
+
+	if !result.Allowed {
+		retryAfter := int(math.Ceil(result.RetryAfter.Seconds()))
+		grpc.SetHeader(ctx, metadata.Pairs("retry-after", strconv.Itoa(retryAfter)))
+		return domain.ErrRateLimitExceeded
+	}
+	return nil
+}
+
+// contextStream troca o contexto do stream pelo contexto com o principal
+type contextStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *contextStream) Context() context.Context {
+	return s.ctx
+}
+
+func incomingMetadata(ctx context.Context) metadata.MD {
+	md, _ := metadata.FromIncomingContext(ctx)
+	return md
+}
+
+func metadataValue(ctx context.Context, key string) string {
+	if values := incomingMetadata(ctx).Get(key); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+// clientIP é o IP resolvido no start, com a mesma regra de proxies confiáveis da API REST
+func clientIP(ctx context.Context) string {
+	ip, _ := request.ClientIPFromContext(ctx)
+	return ip
+}
+
+// splitMethod separa "/gateway.v1.InvoiceService/GetInvoice" em serviço e método
+func splitMethod(fullMethod string) (string, string) {
+	service, method, _ := strings.Cut(strings.TrimPrefix(fullMethod, "/"), "/")
+	return service, method
+}
+
+// metadataCarrier adapta a metadata do gRPC para o propagador W3C (traceparent/tracestate)
+type metadataCarrier metadata.MD
+
+func (c metadataCarrier) Get(key string) string {
+	if values := metadata.MD(c).Get(key); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+func (c metadataCarrier) Set(key, value string) {
+	metadata.MD(c).Set(key, value)
+}
+
+func (c metadataCarrier) Keys() []string {
+	keys := make([]string, 0, len(c))
+	for key := range c {
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+var _ propagation.TextMapCarrier = metadataCarrier{}
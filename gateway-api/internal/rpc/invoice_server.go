@@ -0,0 +1,179 @@
+package rpc
+
+import (
+	"context"
+	"time"
+
+	"github.com/j-ordep/gateway/go-gateway/internal/dto"
+	"github.com/j-ordep/gateway/go-gateway/internal/rpc/gatewayv1"
+	"github.com/j-ordep/gateway/go-gateway/internal/service"
+	"github.com/j-ordep/gateway/go-gateway/internal/validation"
+	"github.com/j-ordep/gateway/go-gateway/internal/web/response"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+type invoiceServer struct {
+	gatewayv1.UnimplementedInvoiceServiceServer
+	invoiceService *service.InvoiceService
+	watchInterval  time.Duration
+}
+
+func newInvoiceServer(invoiceService *service.InvoiceService, watchInterval time.Duration) *invoiceServer {
+	return &invoiceServer{invoiceService: invoiceService, watchInterval: watchInterval}
+}
+
+func (s *invoiceServer) CreateInvoice(ctx context.Context, req *gatewayv1.CreateInvoiceRequest) (*gatewayv1.Invoice, error) {
+	principal, err := authenticated(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	input := dto.CreateInvoiceInput{
+		ClientIP:          clientIP(ctx),
+		Amount:            req.GetAmount(),
+		Description:       req.GetDescription(),
+		PaymentType:       req.GetPaymentType(),
+		CardNumber:        req.GetCardNumber(),
+		CVV:               req.GetCvv(),
+		ExpiryMonth:       int(req.GetExpiryMonth()),
+		ExpiryYear:        int(req.GetExpiryYear()),
+		CardholderName:    req.GetCardholderName(),
+		ExternalReference: req.GetExternalReference(),
+		Metadata:          req.GetMetadata(),
+		CustomerID:        req.GetCustomerId(),
+		PaymentMethodID:   req.GetPaymentMethodId(),
+	}
+	if err := validation.Struct(&input); err != nil {
+		return nil, err
+	}
+
+	output, err := s.invoiceService.Create(ctx, principal, input)
+	if err != nil {
+		return nil, err
+	}
+
+	return toInvoice(output), nil
+}
+
+func (s *invoiceServer) GetInvoice(ctx context.Context, req *gatewayv1.GetInvoiceRequest) (*gatewayv1.Invoice, error) {
+	if req.GetId() == "" {
+		return nil, response.BadRequest("missing_id", "ID is required")
+	}
+
+	principal, err := authenticated(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	output, err := s.invoiceService.GetById(ctx, principal, req.GetId())
+	if err != nil {
+		return nil, err
+	}
+
+	return toInvoice(output), nil
+}
+
+func (s *invoiceServer) ListInvoices(ctx context.Context, req *gatewayv1.ListInvoicesRequest) (*gatewayv1.ListInvoicesResponse, error) {
+	principal, err := authenticated(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	filter, err := toInvoiceFilter(req)
+	if err != nil {
+		return nil, err
+	}
+
+	output, err := s.invoiceService.ListByAccount(ctx, principal, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &gatewayv1.ListInvoicesResponse{Invoices: make([]*gatewayv1.Invoice, 0, len(output.Data))}
+	for _, invoice := range output.Data {
+		resp.Invoices = append(resp.Invoices, toInvoice(invoice))
+	}
+	if output.NextCursor != nil {
+		resp.NextCursor = *output.NextCursor
+	}
+
+	return resp, nil
+}
+
+// WatchInvoiceStatus mantém o stream aberto até a fatura ser decidida ou o cliente cancelar
+func (s *invoiceServer) WatchInvoiceStatus(req *gatewayv1.WatchInvoiceStatusRequest, stream gatewayv1.InvoiceService_WatchInvoiceStatusServer) error {
+	if req.GetId() == "" {
+		return response.BadRequest("missing_id", "ID is required")
+	}
+
+	principal, err := authenticated(stream.Context())
+	if err != nil {
+		return err
+	}
+
+	return s.invoiceService.WatchStatus(stream.Context(), principal, req.GetId(), s.watchInterval, func(output *dto.InvoiceOutput) error {
+		return stream.Send(toInvoice(output))
+	})
+}
+
+// toInvoiceFilter aplica as mesmas regras da query string de GET /v1/invoices
+func toInvoiceFilter(req *gatewayv1.ListInvoicesRequest) (dto.InvoiceFilterInput, error) {
+	filter := dto.InvoiceFilterInput{
+		ExternalReference: req.GetExternalReference(),
+		Metadata:          req.GetMetadata(),
+		CustomerID:        req.GetCustomerId(),
+		Status:            req.GetStatus(),
+		PaymentType:       req.GetPaymentType(),
+		MinAmount:         req.GetMinAmount(),
+		MaxAmount:         req.GetMaxAmount(),
+		CardLastDigits:    req.GetCardLastDigits(),
+		Limit:             int(req.GetLimit()),
+		Cursor:            req.GetCursor(),
+	}
+
+	if filter.Limit < 0 {
+		return filter, invalidArgument("limit", "must be a positive integer")
+	}
+	if createdFrom := req.GetCreatedFrom(); createdFrom != nil {
+		if err := createdFrom.CheckValid(); err != nil {
+			return filter, invalidArgument("created_from", err.Error())
+		}
+		filter.CreatedFrom = createdFrom.AsTime()
+	}
+	if createdTo := req.GetCreatedTo(); createdTo != nil {
+		if err := createdTo.CheckValid(); err != nil {
+			return filter, invalidArgument("created_to", err.Error())
+		}
+		filter.CreatedTo = createdTo.AsTime()
+	}
+
+	return filter, nil
+}
+
+// invalidArgument é o equivalente do invalid_query_parameter da API REST
+func invalidArgument(field, message string) error {
+	apiErr := response.BadRequest("invalid_argument", "invalid "+field+": "+message)
+	apiErr.Details = map[string]string{"field": field}
+	return apiErr
+}
+
+func toInvoice(output *dto.InvoiceOutput) *gatewayv1.Invoice {
+	return &gatewayv1.Invoice{
+		Id:                output.ID,
+		AccountId:         output.AccountId,
+		Amount:            output.Amount,
+		Status:            output.Status,
+		Description:       output.Description,
+		PaymentType:       output.PaymentType,
+		CardLastDigits:    output.CardLastDigits,
+		ExternalReference: output.ExternalReference,
+		Metadata:          output.Metadata,
+		CustomerId:        output.CustomerID,
+		PaymentMethodId:   output.PaymentMethodID,
+		ThreeDsResult:     output.ThreeDSResult,
+		ThreeDsEci:        output.ThreeDSECI,
+		ChallengeUrl:      output.ChallengeURL,
+		CreatedAt:         timestamppb.New(output.CreatedAt),
+		UpdatedAt:         timestamppb.New(output.UpdatedAt),
+	}
+}
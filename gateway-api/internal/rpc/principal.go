@@ -0,0 +1,18 @@
+package rpc
+
+import (
+	"context"
+
+	"github.com/j-ordep/gateway/go-gateway/internal/domain"
+	"github.com/j-ordep/gateway/go-gateway/internal/web/request"
+	"github.com/j-ordep/gateway/go-gateway/internal/web/response"
+)
+
+// authenticated lê o principal deixado pelo interceptor; ausente apenas se o método estiver sem política
+func authenticated(ctx context.Context) (domain.Principal, error) {
+	principal, ok := request.Principal(ctx)
+	if !ok {
+		return domain.Principal{}, response.ErrMissingAPIKey
+	}
+	return principal, nil
+}
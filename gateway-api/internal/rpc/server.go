@@ -0,0 +1,69 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+	"net"
+	"time"
+
+	"github.com/j-ordep/gateway/go-gateway/internal/rpc/gatewayv1"
+	"github.com/j-ordep/gateway/go-gateway/internal/service"
+	"github.com/j-ordep/gateway/go-gateway/internal/web/request"
+	"google.golang.org/grpc"
+)
+
+// Server expõe AccountService e InvoiceService via gRPC para os serviços internos,
+// no mesmo binário da API REST mas em uma porta separada
+type Server struct {
+	server *grpc.Server
+	port   string
+}
+
+// NewServer registra os serviços do gateway.v1; watchInterval é o intervalo de releitura do WatchInvoiceStatus
+func NewServer(accountService *service.AccountService, invoiceService *service.InvoiceService, rateLimitService *service.RateLimitService, port string, watchInterval time.Duration, trustedProxies request.TrustedProxies) *Server {
+	interceptor := newInterceptor(accountService, rateLimitService, trustedProxies)
+
+	server := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(interceptor.unary),
+		grpc.ChainStreamInterceptor(interceptor.stream),
+	)
+	gatewayv1.RegisterAccountServiceServer(server, newAccountServer(accountService))
+	gatewayv1.RegisterInvoiceServiceServer(server, newInvoiceServer(invoiceService, watchInterval))
+
+	return &Server{server: server, port: port}
+}
+
+// Start bloqueia até o servidor parar; após Shutdown retorna nil
+func (s *Server) Start() error {
+	listener, err := net.Listen("tcp", ":"+s.port)
+	if err != nil {
+		return err
+	}
+	return s.Serve(listener)
+}
+
+// Serve atende as conexões do listener informado (usado nos testes com bufconn)
+func (s *Server) Serve(listener net.Listener) error {
+	if err := s.server.Serve(listener); err != nil && !errors.Is(err, grpc.ErrServerStopped) {
+		return err
+	}
+	return nil
+}
+
+// Shutdown para de aceitar conexões e aguarda as chamadas em andamento até o prazo do ctx
+// depois disso as chamadas restantes (ex: streams de WatchInvoiceStatus) são encerradas
+func (s *Server) Shutdown(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		s.server.GracefulStop()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		s.server.Stop()
+		return ctx.Err()
+	}
+}
@@ -1,16 +1,19 @@
 package service
 
 import (
+	"context"
+
 	"github.com/j-ordep/gateway/go-gateway/internal/domain"
 	"github.com/j-ordep/gateway/go-gateway/internal/dto"
 )
 
 type AccountService struct {
-	repository domain.AccountRepository
+	repository  domain.AccountRepository
+	apiKeyCache *APIKeyCache // invalidado a cada mudança de status, plano ou apiKey
 }
 
-func NewAccountService(repository domain.AccountRepository) *AccountService {
-	return &AccountService{repository: repository,}
+func NewAccountService(repository domain.AccountRepository, apiKeyCache *APIKeyCache) *AccountService {
+	return &AccountService{repository: repository, apiKeyCache: apiKeyCache}
 }
 
 func (s *AccountService) CreateAccount(input dto.CreateAccountInput) (*dto.AccountOutput, error) {
@@ -33,15 +36,53 @@ func (s *AccountService) CreateAccount(input dto.CreateAccountInput) (*dto.Accou
 	return &output, nil
 }
 
-func (s *AccountService) FindByAPIKey(apiKey string) (*dto.AccountOutput, error) {
-	account, err := s.repository.FindByAPIKey(apiKey)
+// Authenticate resolve a apiKey no principal da requisição, passando pelo cache
+// apiKeys inválidas não são cacheadas, para que chaves aleatórias não expulsem as válidas do LRU
+func (s *AccountService) Authenticate(apiKey string) (domain.Principal, error) {
+	if principal, ok := s.apiKeyCache.Get(apiKey); ok {
+		return principal, nil
+	}
+
+	account, err := s.findByAPIKey(apiKey)
+	if err != nil {
+		return domain.Principal{}, err
+	}
+
+	principal := domain.NewPrincipal(account)
+	s.apiKeyCache.Set(apiKey, principal)
+	return principal, nil
+}
+
+// Get retorna a conta do principal autenticado
+func (s *AccountService) Get(principal domain.Principal) (*dto.AccountOutput, error) {
+	return s.FindByID(principal.AccountID)
+}
+
+// FindActiveByID recusa contas suspensas ou encerradas
+// usado por tudo que movimenta dinheiro (criação de invoice), direto no repositório e não no principal cacheado
+func (s *AccountService) FindActiveByID(id string) (*dto.AccountOutput, error) {
+	account, err := s.repository.FindByID(id)
 	if err != nil {
 		return nil, err
 	}
+
+	if err := account.CanTransact(); err != nil {
+		return nil, err
+	}
+
 	output := dto.FromAccount(account)
 	return &output, nil
 }
 
+// findByAPIKey diferencia "apiKey inexistente" (credencial inválida, 401) de "conta não encontrada" (404)
+func (s *AccountService) findByAPIKey(apiKey string) (*domain.Account, error) {
+	account, err := s.repository.FindByAPIKey(apiKey)
+	if err == domain.ErrAccountNotFound {
+		return nil, domain.ErrInvalidAPIKey
+	}
+	return account, err
+}
+
 func (s *AccountService) FindByID(id string) (*dto.AccountOutput, error) {
 	account, err := s.repository.FindByID(id)
 	if err != nil {
@@ -51,20 +92,172 @@ func (s *AccountService) FindByID(id string) (*dto.AccountOutput, error) {
 	return &output, nil
 }
 
+func (s *AccountService) UpdateAccount(principal domain.Principal, input dto.UpdateAccountInput) (*dto.AccountOutput, error) {
+	account, err := s.repository.FindByID(principal.AccountID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := account.Update(input.Name, input.Email); err != nil {
+		return nil, err
+	}
+
+	if input.ThreeDSMode != "" || input.ThreeDSMinAmount != nil {
+		mode := string(account.ThreeDSRule.Mode)
+		if input.ThreeDSMode != "" {
+			mode = input.ThreeDSMode
+		}
+
+		minAmount := account.ThreeDSRule.MinAmount
+		if input.ThreeDSMinAmount != nil {
+			minAmount = *input.ThreeDSMinAmount
+		}
+
+		rule, err := domain.NewThreeDSRule(mode, minAmount)
+		if err != nil {
+			return nil, err
+		}
+		account.SetThreeDSRule(rule)
+	}
+
+	if err := s.repository.UpdateProfile(account); err != nil {
+		return nil, err
+	}
+
+	output := dto.FromAccount(account)
+	return &output, nil
+}
+
+// Suspend é uma ação administrativa, por isso busca a conta pelo ID e não pela apiKey
+// a entrada de auditoria é gravada na mesma transação da mudança de status
+func (s *AccountService) Suspend(ctx context.Context, id string, reason string, entry *domain.AuditEntry) (*dto.AccountOutput, error) {
+	account, err := s.repository.FindByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	from := account.Status
+	if err := account.Suspend(reason); err != nil {
+		return nil, err
+	}
+
+	if err := s.repository.UpdateStatus(ctx, account, from, entry); err != nil {
+		return nil, err
+	}
+	s.apiKeyCache.InvalidateAccount(account.ID)
+
+	output := dto.FromAccount(account)
+	return &output, nil
+}
+
+// UpdateTier troca o plano da conta, o novo limite de requisições vale a partir da próxima chamada
+func (s *AccountService) UpdateTier(ctx context.Context, id string, tier string, entry *domain.AuditEntry) (*dto.AccountOutput, error) {
+	accountTier, err := domain.ParseAccountTier(tier)
+	if err != nil {
+		return nil, err
+	}
+
+	account, err := s.repository.FindByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	account.SetTier(accountTier)
+
+	if err := s.repository.UpdateTier(ctx, account, entry); err != nil {
+		return nil, err
+	}
+	s.apiKeyCache.InvalidateAccount(account.ID)
+
+	output := dto.FromAccount(account)
+	return &output, nil
+}
+
+func (s *AccountService) Reactivate(ctx context.Context, id string, entry *domain.AuditEntry) (*dto.AccountOutput, error) {
+	account, err := s.repository.FindByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	from := account.Status
+	if err := account.Reactivate(); err != nil {
+		return nil, err
+	}
+
+	if err := s.repository.UpdateStatus(ctx, account, from, entry); err != nil {
+		return nil, err
+	}
+	s.apiKeyCache.InvalidateAccount(account.ID)
+
+	output := dto.FromAccount(account)
+	return &output, nil
+}
+
+// CloseAccount encerra a conta do próprio lojista
+// bloqueado enquanto houver saldo ou faturas sem decisão (aguardando o antifraude ou o 3DS)
+func (s *AccountService) CloseAccount(ctx context.Context, principal domain.Principal, reason string) (*dto.AccountOutput, error) {
+	account, err := s.repository.FindByID(principal.AccountID)
+	if err != nil {
+		return nil, err
+	}
+
+	// a verificação das faturas e o encerramento acontecem na mesma transação do repository
+	if err := s.repository.Close(account, reason); err != nil {
+		return nil, err
+	}
+	s.apiKeyCache.InvalidateAccount(account.ID)
+
+	output := dto.FromAccount(account)
+	return &output, nil
+}
+
+// RotateAPIKey troca a apiKey do lojista (ex: chave vazada); a nova chave só aparece nesta resposta e no GET da conta
+func (s *AccountService) RotateAPIKey(principal domain.Principal) (*dto.AccountOutput, error) {
+	account, err := s.repository.FindByID(principal.AccountID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := account.RotateAPIKey(); err != nil {
+		return nil, err
+	}
+
+	if err := s.repository.UpdateAPIKey(account); err != nil {
+		return nil, err
+	}
+	s.apiKeyCache.InvalidateAccount(account.ID)
+
+	output := dto.FromAccount(account)
+	return &output, nil
+}
+
+// AdjustBalance é o ajuste manual do operador, gravado junto com a entrada de auditoria
+func (s *AccountService) AdjustBalance(ctx context.Context, accountID string, amount float64, entry *domain.AuditEntry) (*dto.AccountOutput, error) {
+	account, err := s.repository.FindByID(accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.repository.AdjustBalance(ctx, account, amount, entry); err != nil {
+		return nil, err
+	}
+
+	output := dto.FromAccount(account)
+	return &output, nil
+}
+
 // Balance é atualizado pelo invoice
-func (s *AccountService) UpdateBalance(apiKey string, amount float64) (*dto.AccountOutput, error) {
-	account, err := s.repository.FindByAPIKey(apiKey)
+func (s *AccountService) UpdateBalance(accountID string, amount float64) (*dto.AccountOutput, error) {
+	account, err := s.repository.FindByID(accountID)
 	if err != nil {
 		return nil, err
 	}
 	
-	account.AddBalance(amount)
-
-	err = s.repository.UpdateBalance(account)
+	err = s.repository.UpdateBalance(account, amount)
 	if err != nil {
 		return nil, err
 	}
 
 	output := dto.FromAccount(account)
 	return &output,nil
-}
\ No newline at end of file
+}
@@ -0,0 +1,162 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	"strconv"
+
+	"github.com/j-ordep/gateway/go-gateway/internal/domain"
+	"github.com/j-ordep/gateway/go-gateway/internal/dto"
+	"github.com/j-ordep/gateway/go-gateway/internal/logging"
+)
+
+// AdminService executa as ações de suporte reaproveitando AccountService/InvoiceService
+// toda ação bem sucedida é gravada na trilha de auditoria com o operador que a executou
+// as que alteram a conta ou a fatura gravam a auditoria na mesma transação: uma falha ao gravá-la desfaz a ação,
+// e o operador pode repetir sem aplicar duas vezes; as consultas são auditadas depois de executadas (audit)
+type AdminService struct {
+	accountService  *AccountService
+	invoiceService  *InvoiceService
+	auditRepository domain.AuditLogRepository
+}
+
+func NewAdminService(accountService *AccountService, invoiceService *InvoiceService, auditRepository domain.AuditLogRepository) *AdminService {
+	return &AdminService{
+		accountService:  accountService,
+		invoiceService:  invoiceService,
+		auditRepository: auditRepository,
+	}
+}
+
+func (s *AdminService) GetAccount(ctx context.Context, operator domain.Operator, id string) (*dto.AccountOutput, error) {
+	output, err := s.accountService.FindByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.audit(ctx, operator, domain.AuditActionAccountViewed, domain.AuditTargetAccount, id, "", nil); err != nil {
+		return nil, err
+	}
+	return withoutAPIKey(output), nil
+}
+
+func (s *AdminService) ListAccountInvoices(ctx context.Context, operator domain.Operator, accountID string, filter dto.InvoiceFilterInput) (*dto.InvoiceListOutput, error) {
+	// conta inexistente vira 404 em vez de uma lista vazia
+	if _, err := s.accountService.FindByID(accountID); err != nil {
+		return nil, err
+	}
+
+	output, err := s.invoiceService.ListByAccountId(ctx, accountID, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.audit(ctx, operator, domain.AuditActionAccountInvoicesListed, domain.AuditTargetAccount, accountID, "", nil); err != nil {
+		return nil, err
+	}
+	return output, nil
+}
+
+func (s *AdminService) SuspendAccount(ctx context.Context, operator domain.Operator, id, reason string) (*dto.AccountOutput, error) {
+	entry := newAuditEntry(ctx, operator, domain.AuditActionAccountSuspended, domain.AuditTargetAccount, id, reason, nil)
+
+	output, err := s.accountService.Suspend(ctx, id, reason, entry)
+	if err != nil {
+		return nil, err
+	}
+	return withoutAPIKey(output), nil
+}
+
+func (s *AdminService) ReactivateAccount(ctx context.Context, operator domain.Operator, id string) (*dto.AccountOutput, error) {
+	entry := newAuditEntry(ctx, operator, domain.AuditActionAccountReactivated, domain.AuditTargetAccount, id, "", nil)
+
+	output, err := s.accountService.Reactivate(ctx, id, entry)
+	if err != nil {
+		return nil, err
+	}
+	return withoutAPIKey(output), nil
+}
+
+func (s *AdminService) UpdateAccountTier(ctx context.Context, operator domain.Operator, id, tier string) (*dto.AccountOutput, error) {
+	details := map[string]string{"tier": tier}
+	entry := newAuditEntry(ctx, operator, domain.AuditActionAccountTierUpdated, domain.AuditTargetAccount, id, "", details)
+
+	output, err := s.accountService.UpdateTier(ctx, id, tier, entry)
+	if err != nil {
+		return nil, err
+	}
+	return withoutAPIKey(output), nil
+}
+
+// AdjustBalance credita ou debita o saldo com lock na linha da conta, como as faturas aprovadas
+func (s *AdminService) AdjustBalance(ctx context.Context, operator domain.Operator, accountID string, input dto.BalanceAdjustmentInput) (*dto.AccountOutput, error) {
+	details := map[string]string{"amount": strconv.FormatFloat(input.Amount, 'f', -1, 64)}
+	entry := newAuditEntry(ctx, operator, domain.AuditActionAccountBalanceAdjusted, domain.AuditTargetAccount, accountID, input.Reason, details)
+
+	output, err := s.accountService.AdjustBalance(ctx, accountID, input.Amount, entry)
+	if err != nil {
+		return nil, err
+	}
+	return withoutAPIKey(output), nil
+}
+
+// ForceInvoiceStatus aplica a decisão no lugar do antifraude, pelo mesmo caminho do consumer do kafka
+// só faturas pending podem ser decididas; o UPDATE condicionado ao status garante uma única decisão
+// mesmo em corrida com o antifraude, e quem chegar depois recebe ErrInvalidStatus (sem entrada de auditoria)
+func (s *AdminService) ForceInvoiceStatus(ctx context.Context, operator domain.Operator, invoiceID string, input dto.ForceInvoiceStatusInput) (*dto.InvoiceOutput, error) {
+	// a conta dona vai nos detalhes da auditoria
+	invoice, err := s.invoiceService.FindByID(ctx, invoiceID)
+	if err != nil {
+		return nil, err
+	}
+
+	details := map[string]string{"status": input.Status, "account_id": invoice.AccountId}
+	entry := newAuditEntry(ctx, operator, domain.AuditActionInvoiceStatusForced, domain.AuditTargetInvoice, invoiceID, input.Reason, details)
+
+	return s.invoiceService.ForceStatus(ctx, invoiceID, domain.Status(input.Status), entry)
+}
+
+func (s *AdminService) ListAuditLog(ctx context.Context, input dto.AuditLogFilterInput) (*dto.AuditLogOutput, error) {
+	entries, err := s.auditRepository.List(ctx, dto.ToAuditLogFilter(input))
+	if err != nil {
+		return nil, err
+	}
+
+	output := &dto.AuditLogOutput{Data: make([]*dto.AuditEntryOutput, len(entries))}
+	for i, entry := range entries {
+		output.Data[i] = dto.FromAuditEntry(entry)
+	}
+	return output, nil
+}
+
+// audit grava as consultas já executadas; se a gravação falhar a entrada vai para o log de erro
+// e a requisição responde 500, para o operador saber que a trilha ficou incompleta
+func (s *AdminService) audit(ctx context.Context, operator domain.Operator, action domain.AuditAction, targetType, targetID, reason string, details map[string]string) error {
+	entry := newAuditEntry(ctx, operator, action, targetType, targetID, reason, details)
+
+	if err := s.auditRepository.Save(ctx, entry); err != nil {
+		slog.ErrorContext(ctx, "erro ao gravar auditoria administrativa",
+			"error", err,
+			"operator_id", entry.OperatorID,
+			"action", entry.Action,
+			"target_type", entry.TargetType,
+			"target_id", entry.TargetID,
+			"reason", entry.Reason,
+			"details", entry.Details)
+		return err
+	}
+	return nil
+}
+
+// newAuditEntry monta a entrada com o request ID da requisição do operador
+func newAuditEntry(ctx context.Context, operator domain.Operator, action domain.AuditAction, targetType, targetID, reason string, details map[string]string) *domain.AuditEntry {
+	entry := domain.NewAuditEntry(operator, action, targetType, targetID, reason, details)
+	entry.RequestID = logging.RequestID(ctx)
+	return entry
+}
+
+// withoutAPIKey esconde a apiKey do lojista nas respostas administrativas
+func withoutAPIKey(output *dto.AccountOutput) *dto.AccountOutput {
+	output.APIKey = ""
+	return output
+}
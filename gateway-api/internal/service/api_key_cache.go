@@ -0,0 +1,120 @@
+package service
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"sync"
+	"time"
+
+	"github.com/j-ordep/gateway/go-gateway/internal/domain"
+)
+
+// APIKeyCache é um LRU com TTL curto na frente da resolução de apiKey (uma consulta ao Postgres por requisição)
+// a invalidação é local: em várias instâncias, as demais enxergam suspensão/rotação quando o TTL expira
+type APIKeyCache struct {
+	mu      sync.Mutex
+	size    int
+	ttl     time.Duration
+	entries map[string]*list.Element
+	order   *list.List // frente = usado mais recentemente
+	now     func() time.Time
+}
+
+type apiKeyCacheEntry struct {
+	key       string
+	principal domain.Principal
+	expiresAt time.Time
+}
+
+// NewAPIKeyCache com size ou ttl zerados desliga o cache (toda requisição consulta o repositório)
+func NewAPIKeyCache(size int, ttl time.Duration) *APIKeyCache {
+	return &APIKeyCache{
+		size:    size,
+		ttl:     ttl,
+		entries: make(map[string]*list.Element),
+		order:   list.New(),
+		now:     time.Now,
+	}
+}
+
+func (c *APIKeyCache) enabled() bool {
+	return c != nil && c.size > 0 && c.ttl > 0
+}
+
+func (c *APIKeyCache) Get(apiKey string) (domain.Principal, bool) {
+	if !c.enabled() {
+		return domain.Principal{}, false
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	element, ok := c.entries[cacheKey(apiKey)]
+	if !ok {
+		return domain.Principal{}, false
+	}
+
+	entry := element.Value.(*apiKeyCacheEntry)
+	if c.now().After(entry.expiresAt) {
+		c.remove(element)
+		return domain.Principal{}, false
+	}
+
+	c.order.MoveToFront(element)
+	return entry.principal, true
+}
+
+func (c *APIKeyCache) Set(apiKey string, principal domain.Principal) {
+	if !c.enabled() {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	key := cacheKey(apiKey)
+	if element, ok := c.entries[key]; ok {
+		c.remove(element)
+	}
+
+	c.entries[key] = c.order.PushFront(&apiKeyCacheEntry{
+		key:       key,
+		principal: principal,
+		expiresAt: c.now().Add(c.ttl),
+	})
+
+	for c.order.Len() > c.size {
+		c.remove(c.order.Back())
+	}
+}
+
+// InvalidateAccount descarta as entradas da conta (suspensão, encerramento, troca de plano, rotação da apiKey)
+// percorre a lista inteira: as mudanças de conta são raras perto das leituras
+func (c *APIKeyCache) InvalidateAccount(accountID string) {
+	if !c.enabled() {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for element := c.order.Front(); element != nil; {
+		next := element.Next()
+		if element.Value.(*apiKeyCacheEntry).principal.AccountID == accountID {
+			c.remove(element)
+		}
+		element = next
+	}
+}
+
+func (c *APIKeyCache) remove(element *list.Element) {
+	c.order.Remove(element)
+	delete(c.entries, element.Value.(*apiKeyCacheEntry).key)
+}
+
+// cacheKey evita manter as apiKeys em texto puro na memória do processo
+func cacheKey(apiKey string) string {
+	sum := sha256.Sum256([]byte(apiKey))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,164 @@
+package service
+
+import (
+	"context"
+
+	"github.com/j-ordep/gateway/go-gateway/internal/domain"
+	"github.com/j-ordep/gateway/go-gateway/internal/dto"
+)
+
+type CustomerService struct {
+	customerRepository      domain.CustomerRepository
+	paymentMethodRepository domain.PaymentMethodRepository
+	invoiceRepository       domain.InvoiceRepository // histórico de faturas do cliente
+}
+
+func NewCustomerService(customerRepository domain.CustomerRepository, paymentMethodRepository domain.PaymentMethodRepository, invoiceRepository domain.InvoiceRepository) *CustomerService {
+	return &CustomerService{
+		customerRepository:      customerRepository,
+		paymentMethodRepository: paymentMethodRepository,
+		invoiceRepository:       invoiceRepository,
+	}
+}
+
+// Create cadastra o cliente na conta do principal (a autenticação já recusou contas suspensas ou encerradas)
+func (s *CustomerService) Create(principal domain.Principal, input dto.CreateCustomerInput) (*dto.CustomerOutput, error) {
+	customer, err := dto.ToCustomer(input, principal.AccountID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.customerRepository.Save(customer); err != nil {
+		return nil, err
+	}
+
+	return dto.FromCustomer(customer), nil
+}
+
+func (s *CustomerService) GetById(principal domain.Principal, id string) (*dto.CustomerOutput, error) {
+	customer, err := s.findOwnedCustomer(principal, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return dto.FromCustomer(customer), nil
+}
+
+func (s *CustomerService) ListByAccount(principal domain.Principal) ([]*dto.CustomerOutput, error) {
+	customers, err := s.customerRepository.FindByAccountID(principal.AccountID)
+	if err != nil {
+		return nil, err
+	}
+
+	output := make([]*dto.CustomerOutput, len(customers))
+	for i, customer := range customers {
+		output[i] = dto.FromCustomer(customer)
+	}
+
+	return output, nil
+}
+
+func (s *CustomerService) Update(principal domain.Principal, id string, input dto.UpdateCustomerInput) (*dto.CustomerOutput, error) {
+	customer, err := s.findOwnedCustomer(principal, id)
+	if err != nil {
+		return nil, err
+	}
+
+	customer.Update(input.Name, input.Email, input.Document, input.Phone)
+
+	if err := s.customerRepository.Update(customer); err != nil {
+		return nil, err
+	}
+
+	return dto.FromCustomer(customer), nil
+}
+
+func (s *CustomerService) Delete(principal domain.Principal, id string) error {
+	customer, err := s.findOwnedCustomer(principal, id)
+	if err != nil {
+		return err
+	}
+
+	return s.customerRepository.Delete(customer.ID)
+}
+
+// AddPaymentMethod tokeniza o cartão e salva apenas os dados não sensíveis
+func (s *CustomerService) AddPaymentMethod(principal domain.Principal, customerID string, input dto.CreatePaymentMethodInput) (*dto.PaymentMethodOutput, error) {
+	customer, err := s.findOwnedCustomer(principal, customerID)
+	if err != nil {
+		return nil, err
+	}
+
+	paymentMethod, err := dto.ToPaymentMethod(input, customer.AccountID, customer.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.paymentMethodRepository.Save(paymentMethod); err != nil {
+		return nil, err
+	}
+
+	return dto.FromPaymentMethod(paymentMethod), nil
+}
+
+func (s *CustomerService) ListPaymentMethods(principal domain.Principal, customerID string) ([]*dto.PaymentMethodOutput, error) {
+	customer, err := s.findOwnedCustomer(principal, customerID)
+	if err != nil {
+		return nil, err
+	}
+
+	paymentMethods, err := s.paymentMethodRepository.FindByCustomerID(customer.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	output := make([]*dto.PaymentMethodOutput, len(paymentMethods))
+	for i, paymentMethod := range paymentMethods {
+		output[i] = dto.FromPaymentMethod(paymentMethod)
+	}
+
+	return output, nil
+}
+
+func (s *CustomerService) DeletePaymentMethod(principal domain.Principal, customerID, paymentMethodID string) error {
+	customer, err := s.findOwnedCustomer(principal, customerID)
+	if err != nil {
+		return err
+	}
+
+	paymentMethod, err := s.paymentMethodRepository.FindByID(paymentMethodID)
+	if err != nil {
+		return err
+	}
+
+	if paymentMethod.CustomerID != customer.ID {
+		return domain.ErrPaymentMethodNotFound
+	}
+
+	return s.paymentMethodRepository.Delete(paymentMethod.ID)
+}
+
+// ListInvoices retorna o histórico de faturas do cliente, com os mesmos filtros e paginação de GET /invoices
+func (s *CustomerService) ListInvoices(ctx context.Context, principal domain.Principal, customerID string, filter dto.InvoiceFilterInput) (*dto.InvoiceListOutput, error) {
+	customer, err := s.findOwnedCustomer(principal, customerID)
+	if err != nil {
+		return nil, err
+	}
+
+	filter.CustomerID = customer.ID
+	return listInvoices(ctx, s.invoiceRepository, customer.AccountID, filter)
+}
+
+// findOwnedCustomer busca o cliente e garante que ele pertence à conta do principal
+func (s *CustomerService) findOwnedCustomer(principal domain.Principal, id string) (*domain.Customer, error) {
+	customer, err := s.customerRepository.FindByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if customer.AccountID != principal.AccountID {
+		return nil, domain.ErrUnauthorizedAccess
+	}
+
+	return customer, nil
+}
@@ -0,0 +1,101 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/j-ordep/gateway/go-gateway/internal/dto"
+)
+
+const (
+	HealthStatusOK           = "ok"
+	HealthStatusUnavailable  = "unavailable"
+	HealthStatusShuttingDown = "shutting_down"
+
+	healthCheckUp   = "up"
+	healthCheckDown = "down"
+)
+
+// HealthCheckFunc verifica uma dependência; retornar erro marca a instância como não pronta
+type HealthCheckFunc func(ctx context.Context) error
+
+type healthCheck struct {
+	name  string
+	check HealthCheckFunc
+}
+
+// HealthService responde às probes do orquestrador
+// liveness só indica que o processo responde; readiness verifica as dependências registradas
+type HealthService struct {
+	checks       []healthCheck
+	timeout      time.Duration
+	shuttingDown atomic.Bool
+}
+
+// NewHealthService recebe o tempo máximo de cada verificação, para a probe não travar numa dependência lenta
+func NewHealthService(timeout time.Duration) *HealthService {
+	return &HealthService{timeout: timeout}
+}
+
+// AddCheck registra uma dependência verificada pelo readiness; deve ser chamado antes de servir requisições
+func (s *HealthService) AddCheck(name string, check HealthCheckFunc) {
+	s.checks = append(s.checks, healthCheck{name: name, check: check})
+}
+
+// SetShuttingDown faz o readiness falhar, tirando a instância do balanceamento durante o graceful shutdown
+func (s *HealthService) SetShuttingDown() {
+	s.shuttingDown.Store(true)
+}
+
+func (s *HealthService) Liveness() *dto.HealthOutput {
+	return &dto.HealthOutput{Status: HealthStatusOK}
+}
+
+// Readiness executa todas as verificações em paralelo e retorna o status de cada dependência
+func (s *HealthService) Readiness(ctx context.Context) *dto.HealthOutput {
+	output := &dto.HealthOutput{
+		Status: HealthStatusOK,
+		Checks: make(map[string]dto.HealthCheckOutput, len(s.checks)),
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, check := range s.checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			result := s.run(ctx, check.check)
+
+			mu.Lock()
+			defer mu.Unlock()
+			output.Checks[check.name] = result
+			if result.Status == healthCheckDown {
+				output.Status = HealthStatusUnavailable
+			}
+		}()
+	}
+	wg.Wait()
+
+	// as dependências continuam sendo reportadas, mas a instância não recebe mais tráfego
+	if s.shuttingDown.Load() {
+		output.Status = HealthStatusShuttingDown
+	}
+
+	return output
+}
+
+func (s *HealthService) run(ctx context.Context, check HealthCheckFunc) dto.HealthCheckOutput {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	start := time.Now()
+	err := check(ctx)
+	result := dto.HealthCheckOutput{Status: healthCheckUp, LatencyMs: time.Since(start).Milliseconds()}
+	if err != nil {
+		result.Status = healthCheckDown
+		result.Error = err.Error()
+	}
+	return result
+}
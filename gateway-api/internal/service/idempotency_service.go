@@ -0,0 +1,106 @@
+package service
+
+import (
+	"log/slog"
+	"time"
+
+	"github.com/j-ordep/gateway/go-gateway/internal/domain"
+)
+
+type IdempotencyService struct {
+	repository  domain.IdempotencyRepository
+	ttl         time.Duration // por quanto tempo uma chave fica guardada
+	lockTimeout time.Duration // prazo da requisição em processing antes de outra tentativa poder assumir a chave
+}
+
+func NewIdempotencyService(repository domain.IdempotencyRepository, ttl, lockTimeout time.Duration) *IdempotencyService {
+	return &IdempotencyService{repository: repository, ttl: ttl, lockTimeout: lockTimeout}
+}
+
+// Begin reserva a chave para a requisição atual
+// replay=true indica que a chave já foi concluída e o record traz a resposta original
+func (s *IdempotencyService) Begin(accountID, key, requestHash string) (record *domain.IdempotencyRecord, replay bool, err error) {
+	record = domain.NewIdempotencyRecord(accountID, key, requestHash, s.ttl, s.lockTimeout)
+
+	// no máximo duas tentativas: a segunda só acontece quando a chave existente já tinha expirado
+	for attempt := 0; attempt < 2; attempt++ {
+		created, err := s.repository.Create(record)
+		if err != nil {
+			return nil, false, err
+		}
+		if created {
+			return record, false, nil
+		}
+
+		existing, err := s.repository.Find(accountID, key)
+		if err == domain.ErrIdempotencyKeyNotFound {
+			continue // removida entre o insert e o select, tenta reservar de novo
+		}
+		if err != nil {
+			return nil, false, err
+		}
+
+		if existing.IsExpired() {
+			if err := s.repository.Delete(existing); err != nil && err != domain.ErrIdempotencyLockLost {
+				return nil, false, err
+			}
+			continue
+		}
+
+		if existing.RequestHash != requestHash {
+			return nil, false, domain.ErrIdempotencyKeyReused
+		}
+
+		if existing.Status == domain.IdempotencyStatusProcessing {
+			return s.takeOver(existing)
+		}
+
+		return existing, true, nil
+	}
+
+	return nil, false, domain.ErrIdempotencyRequestInProgress
+}
+
+// takeOver assume a chave de uma requisição que caiu sem concluir nem liberar (prazo vencido)
+// enquanto o prazo vale, a requisição original ainda está em andamento
+func (s *IdempotencyService) takeOver(existing *domain.IdempotencyRecord) (*domain.IdempotencyRecord, bool, error) {
+	if existing.IsLocked() {
+		return nil, false, domain.ErrIdempotencyRequestInProgress
+	}
+
+	previous := existing.LockedUntil
+	existing.Lock(s.lockTimeout)
+	taken, err := s.repository.TakeOver(existing, previous)
+	if err != nil {
+		return nil, false, err
+	}
+	if !taken {
+		return nil, false, domain.ErrIdempotencyRequestInProgress
+	}
+
+	return existing, false, nil
+}
+
+// Complete grava a resposta para ser devolvida nas próximas tentativas
+func (s *IdempotencyService) Complete(record *domain.IdempotencyRecord, responseCode int, responseBody []byte) error {
+	record.Complete(responseCode, responseBody)
+	return s.repository.Complete(record)
+}
+
+// Release libera a chave (ex: erro interno), permitindo que o cliente tente novamente
+func (s *IdempotencyService) Release(record *domain.IdempotencyRecord) error {
+	return s.repository.Delete(record)
+}
+
+// PurgeExpired remove as chaves vencidas, chamado periodicamente
+func (s *IdempotencyService) PurgeExpired() {
+	deleted, err := s.repository.DeleteExpired()
+	if err != nil {
+		slog.Error("erro ao remover idempotency keys expiradas", "error", err)
+		return
+	}
+
+	if deleted > 0 {
+		slog.Info("idempotency keys expiradas removidas", "total", deleted)
+	}
+}
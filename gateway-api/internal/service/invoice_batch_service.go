@@ -0,0 +1,253 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"sync"
+
+	"github.com/j-ordep/gateway/go-gateway/internal/domain"
+	"github.com/j-ordep/gateway/go-gateway/internal/domain/events"
+	"github.com/j-ordep/gateway/go-gateway/internal/dto"
+	"github.com/j-ordep/gateway/go-gateway/internal/tracing"
+	"github.com/j-ordep/gateway/go-gateway/internal/validation"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// InvoiceBatchConfig limita o tamanho e o paralelismo de POST /invoices/batch
+type InvoiceBatchConfig struct {
+	MaxItems    int // INVOICE_BATCH_MAX_ITEMS: itens por requisição
+	Concurrency int // INVOICE_BATCH_CONCURRENCY: itens processados ao mesmo tempo
+}
+
+func NewInvoiceBatchConfig() *InvoiceBatchConfig {
+	return &InvoiceBatchConfig{
+		MaxItems:    getEnvInt("INVOICE_BATCH_MAX_ITEMS", 100),
+		Concurrency: getEnvInt("INVOICE_BATCH_CONCURRENCY", 8),
+	}
+}
+
+// InvoiceBatchResult é o resultado de um item, na mesma posição do corpo: a fatura criada ou o erro
+type InvoiceBatchResult struct {
+	Output   *dto.InvoiceOutput
+	Replayed bool // devolvida pela Idempotency-Key do item, sem criar outra fatura
+	Err      error
+}
+
+// InvoiceBatchService cria várias faturas em uma requisição (POST /invoices/batch)
+// cada item passa pelo mesmo fluxo do POST /invoice; um item com erro não impede os demais
+type InvoiceBatchService struct {
+	invoiceService     *InvoiceService
+	idempotencyService *IdempotencyService
+	config             *InvoiceBatchConfig
+}
+
+func NewInvoiceBatchService(invoiceService *InvoiceService, idempotencyService *IdempotencyService, config *InvoiceBatchConfig) *InvoiceBatchService {
+	return &InvoiceBatchService{
+		invoiceService:     invoiceService,
+		idempotencyService: idempotencyService,
+		config:             config,
+	}
+}
+
+// batchItem acompanha um item entre as etapas do lote
+type batchItem struct {
+	record   *domain.IdempotencyRecord // chave reservada pelo item, nil sem idempotency_key
+	prepared *preparedInvoice          // nil quando o item já tem resultado (erro ou replay)
+	result   InvoiceBatchResult
+}
+
+// Create processa o lote em três etapas:
+//  0. o lote conta uma vez no velocity da conta e itens com external_reference repetida dentro dele são recusados
+//  1. em paralelo: reserva a chave de cada item e prepara a fatura (validações, velocity do cartão/IP, 3DS, aprovação)
+//  2. as transações pendentes de todos os itens vão para o kafka em um único envio
+//  3. em paralelo: grava as faturas e conclui as chaves
+func (s *InvoiceBatchService) Create(ctx context.Context, principal domain.Principal, input dto.BatchInvoiceInput) (results []InvoiceBatchResult, err error) {
+	ctx, span := tracing.Start(ctx, "InvoiceBatchService.Create",
+		trace.WithAttributes(tracing.AccountIDKey.String(principal.AccountID), attribute.Int("invoice_batch.items", len(input.Items))))
+	defer func() { tracing.End(span, err) }()
+
+	if len(input.Items) == 0 || len(input.Items) > s.config.MaxItems {
+		return nil, domain.ErrInvalidBatchSize
+	}
+
+	if err := s.invoiceService.velocityService.CheckAccount(ctx, principal.AccountID); err != nil {
+		return nil, err
+	}
+
+	duplicated := duplicatedReferences(input.Items)
+	items := make([]batchItem, len(input.Items))
+	s.forEach(len(items), func(i int) {
+		if duplicated[i] {
+			items[i].result.Err = domain.ErrDuplicatedExternalReference
+			return
+		}
+		items[i] = s.prepare(ctx, principal, i, input.Items[i])
+	})
+
+	s.publish(ctx, items)
+
+	s.forEach(len(items), func(i int) {
+		s.save(ctx, i, &items[i])
+	})
+
+	results = make([]InvoiceBatchResult, len(items))
+	for i := range items {
+		results[i] = items[i].result
+	}
+	return results, nil
+}
+
+// forEach chama fn para cada índice, com no máximo config.Concurrency chamadas ao mesmo tempo
+func (s *InvoiceBatchService) forEach(n int, fn func(i int)) {
+	semaphore := make(chan struct{}, max(s.config.Concurrency, 1))
+	var wg sync.WaitGroup
+	for i := range n {
+		semaphore <- struct{}{}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-semaphore }()
+			fn(i)
+		}()
+	}
+	wg.Wait()
+}
+
+func (s *InvoiceBatchService) prepare(ctx context.Context, principal domain.Principal, index int, input dto.BatchInvoiceItemInput) (item batchItem) {
+	ctx, span := tracing.Start(ctx, "InvoiceBatchService.prepare", trace.WithAttributes(attribute.Int("invoice_batch.index", index)))
+	defer func() { tracing.End(span, item.result.Err) }()
+
+	// o corpo só é validado no nível do lote, os campos de cada item viram erro do próprio item
+	if err := validation.Struct(input); err != nil {
+		item.result.Err = err
+		return item
+	}
+	if err := validation.Struct(input.Invoice); err != nil {
+		item.result.Err = err
+		return item
+	}
+
+	if input.IdempotencyKey != "" {
+		record, replay, err := s.idempotencyService.Begin(principal.AccountID, input.IdempotencyKey, batchItemHash(input.Invoice))
+		if err != nil {
+			item.result.Err = err
+			return item
+		}
+
+		if replay {
+			var output dto.InvoiceOutput
+			if err := json.Unmarshal(record.ResponseBody, &output); err != nil {
+				item.result.Err = err
+				return item
+			}
+			item.result = InvoiceBatchResult{Output: &output, Replayed: true}
+			return item
+		}
+		item.record = record
+	}
+
+	prepared, err := s.invoiceService.prepare(ctx, principal, input.Invoice, true)
+	if err != nil {
+		s.fail(&item, err)
+		return item
+	}
+	item.prepared = prepared
+
+	return item
+}
+
+// publish envia as transações pendentes do lote em uma única chamada ao kafka
+// se o envio falhar, nenhum desses itens é gravado: sem o evento o antifraude nunca decidiria a fatura
+func (s *InvoiceBatchService) publish(ctx context.Context, items []batchItem) {
+	var pending []events.PendingTransaction
+	for _, item := range items {
+		if item.prepared != nil && item.prepared.pending != nil {
+			pending = append(pending, *item.prepared.pending)
+		}
+	}
+	if len(pending) == 0 {
+		return
+	}
+
+	if err := s.invoiceService.kafkaProducer.SendingPendingTransactions(ctx, pending); err != nil {
+		for i := range items {
+			if items[i].prepared != nil && items[i].prepared.pending != nil {
+				items[i].prepared = nil
+				s.fail(&items[i], err)
+			}
+		}
+	}
+}
+
+func (s *InvoiceBatchService) save(ctx context.Context, index int, item *batchItem) {
+	if item.prepared == nil {
+		return
+	}
+
+	ctx, span := tracing.Start(ctx, "InvoiceBatchService.save", trace.WithAttributes(attribute.Int("invoice_batch.index", index)))
+	defer func() { tracing.End(span, item.result.Err) }()
+
+	output, err := s.invoiceService.save(ctx, item.prepared)
+	if err != nil {
+		s.fail(item, err)
+		return
+	}
+	item.result.Output = output
+
+	if item.record == nil {
+		return
+	}
+	body, err := json.Marshal(output)
+	if err == nil {
+		err = s.idempotencyService.Complete(item.record, http.StatusCreated, body)
+	}
+	if err != nil {
+		slog.ErrorContext(ctx, "erro ao concluir idempotency key do lote", "error", err, "invoice_id", output.ID)
+	}
+}
+
+// fail registra o erro do item e libera a chave reservada por ele
+// só as faturas criadas ficam gravadas: o item com erro pode ser corrigido e reenviado com a mesma chave
+func (s *InvoiceBatchService) fail(item *batchItem, err error) {
+	item.result.Err = err
+	if item.record == nil {
+		return
+	}
+	if err := s.idempotencyService.Release(item.record); err != nil {
+		slog.Error("erro ao liberar idempotency key do lote", "error", err, "key", item.record.Key)
+	}
+	item.record = nil
+}
+
+// duplicatedReferences marca os itens que repetem a external_reference de um item anterior do lote
+// a checagem do banco não pega esses itens, que seriam processados em paralelo antes de qualquer um ser gravado
+func duplicatedReferences(items []dto.BatchInvoiceItemInput) map[int]bool {
+	seen := make(map[string]bool, len(items))
+	duplicated := make(map[int]bool)
+	for i, item := range items {
+		reference := item.Invoice.ExternalReference
+		if reference == "" {
+			continue
+		}
+		if seen[reference] {
+			duplicated[i] = true
+		}
+		seen[reference] = true
+	}
+	return duplicated
+}
+
+// batchItemHash identifica o item (e não o lote inteiro) para detectar reuso da chave
+// a rota entra no hash para a mesma chave não ser aceita também no POST /invoice
+func batchItemHash(input dto.CreateInvoiceInput) string {
+	body, _ := json.Marshal(input)
+	hash := sha256.New()
+	hash.Write([]byte("POST /invoices/batch\n"))
+	hash.Write(body)
+	return hex.EncodeToString(hash.Sum(nil))
+}
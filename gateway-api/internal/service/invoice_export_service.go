@@ -0,0 +1,248 @@
+package service
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"os"
+	"time"
+
+	"github.com/j-ordep/gateway/go-gateway/internal/domain"
+	"github.com/j-ordep/gateway/go-gateway/internal/dto"
+	"github.com/j-ordep/gateway/go-gateway/internal/tracing"
+)
+
+// InvoiceExportConfig define o fuso padrão e a política das exportações assíncronas
+type InvoiceExportConfig struct {
+	Timezone  string        // fuso das datas quando a exportação não informa um
+	Retention time.Duration // por quanto tempo o arquivo de uma exportação assíncrona fica disponível
+	Lease     time.Duration // reserva de uma exportação pelo worker; vencida, outra instância a gera de novo
+}
+
+func NewInvoiceExportConfig() *InvoiceExportConfig {
+	timezone := os.Getenv("INVOICE_EXPORT_TIMEZONE")
+	if timezone == "" {
+		timezone = "UTC"
+	}
+
+	return &InvoiceExportConfig{
+		Timezone:  timezone,
+		Retention: getEnvDuration("INVOICE_EXPORT_RETENTION", 72*time.Hour),
+		Lease:     getEnvDuration("INVOICE_EXPORT_LEASE", 10*time.Minute),
+	}
+}
+
+// InvoiceExportService gera as exportações de faturas em CSV/NDJSON
+// o GET /invoices/export escreve direto na resposta; as assíncronas são geradas por ProcessPending, chamado periodicamente
+type InvoiceExportService struct {
+	invoiceRepository domain.InvoiceRepository
+	exportRepository  domain.InvoiceExportRepository
+	storage           domain.ExportStorage
+	config            *InvoiceExportConfig
+}
+
+func NewInvoiceExportService(invoiceRepository domain.InvoiceRepository, exportRepository domain.InvoiceExportRepository, storage domain.ExportStorage, config *InvoiceExportConfig) *InvoiceExportService {
+	return &InvoiceExportService{
+		invoiceRepository: invoiceRepository,
+		exportRepository:  exportRepository,
+		storage:           storage,
+		config:            config,
+	}
+}
+
+// Prepare valida os filtros antes de qualquer byte ser escrito, para os erros ainda saírem como JSON
+func (s *InvoiceExportService) Prepare(principal domain.Principal, input dto.InvoiceExportInput) (*domain.InvoiceExport, error) {
+	return dto.ToInvoiceExport(input, principal.AccountID, s.config.Timezone)
+}
+
+// Write escreve as faturas da exportação em w conforme são lidas do banco e retorna quantas linhas foram escritas
+func (s *InvoiceExportService) Write(ctx context.Context, export *domain.InvoiceExport, w io.Writer) (rows int, err error) {
+	ctx, span := tracing.Start(ctx, "InvoiceExportService.Write")
+	defer func() { tracing.End(span, err) }()
+
+	location, err := export.Location()
+	if err != nil {
+		return 0, err
+	}
+
+	encoder, err := newExportEncoder(export.Format, w)
+	if err != nil {
+		return 0, err
+	}
+
+	err = s.invoiceRepository.Each(ctx, export.AccountID, export.Filter, func(invoice *domain.Invoice) error {
+		rows++
+		return encoder.Encode(dto.ToInvoiceExportRow(invoice, location))
+	})
+	if err != nil {
+		return rows, err
+	}
+
+	return rows, encoder.Flush()
+}
+
+// CreateJob agenda uma exportação assíncrona; o arquivo é gerado pelo worker e baixado depois
+func (s *InvoiceExportService) CreateJob(ctx context.Context, principal domain.Principal, input dto.InvoiceExportInput) (*dto.InvoiceExportOutput, error) {
+	export, err := s.Prepare(principal, input)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.exportRepository.Save(ctx, export); err != nil {
+		return nil, err
+	}
+	return dto.FromInvoiceExport(export), nil
+}
+
+func (s *InvoiceExportService) GetJob(ctx context.Context, principal domain.Principal, id string) (*dto.InvoiceExportOutput, error) {
+	export, err := s.findOwnedExport(ctx, principal, id)
+	if err != nil {
+		return nil, err
+	}
+	return dto.FromInvoiceExport(export), nil
+}
+
+// Download abre o arquivo de uma exportação concluída e ainda não expirada; o chamador fecha o arquivo
+func (s *InvoiceExportService) Download(ctx context.Context, principal domain.Principal, id string) (*domain.InvoiceExport, io.ReadCloser, error) {
+	export, err := s.findOwnedExport(ctx, principal, id)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if export.Status != domain.ExportCompleted || export.ExpiresAt == nil || !time.Now().Before(*export.ExpiresAt) {
+		return nil, nil, domain.ErrInvoiceExportNotReady
+	}
+
+	file, err := s.storage.Open(export.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return export, file, nil
+}
+
+// ProcessPending gera a próxima exportação pendente, retorna quantas foram processadas (0 ou 1)
+// uma por vez: cada exportação pode levar minutos, e a reserva (Lease) precisa cobrir só ela
+func (s *InvoiceExportService) ProcessPending(ctx context.Context) (int, error) {
+	exports, err := s.exportRepository.ClaimPending(ctx, s.config.Lease, 1)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, export := range exports {
+		s.process(ctx, export)
+		if err := s.exportRepository.Update(ctx, export); err != nil {
+			return 0, err
+		}
+	}
+
+	return len(exports), nil
+}
+
+// process grava o arquivo no storage; em caso de erro o arquivo parcial é apagado e a exportação fica failed
+func (s *InvoiceExportService) process(ctx context.Context, export *domain.InvoiceExport) {
+	rows, err := s.writeFile(ctx, export)
+	if err != nil {
+		slog.ErrorContext(ctx, "erro ao gerar a exportação de faturas", "error", err, "export_id", export.ID, "account_id", export.AccountID)
+		if err := s.storage.Delete(export.ID); err != nil {
+			slog.ErrorContext(ctx, "erro ao apagar o arquivo da exportação", "error", err, "export_id", export.ID)
+		}
+		// o erro original (banco, disco) fica só no log
+		export.Fail("could not generate the export file", s.config.Retention)
+		return
+	}
+
+	export.Complete(rows, s.config.Retention)
+}
+
+func (s *InvoiceExportService) writeFile(ctx context.Context, export *domain.InvoiceExport) (int, error) {
+	file, err := s.storage.Create(export.ID)
+	if err != nil {
+		return 0, err
+	}
+
+	rows, err := s.Write(ctx, export, file)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	return rows, err
+}
+
+// PurgeExpired remove as exportações expiradas e os seus arquivos
+func (s *InvoiceExportService) PurgeExpired(ctx context.Context) {
+	ids, err := s.exportRepository.DeleteExpired(ctx, time.Now())
+	if err != nil {
+		slog.ErrorContext(ctx, "erro ao remover exportações expiradas", "error", err)
+		return
+	}
+
+	for _, id := range ids {
+		if err := s.storage.Delete(id); err != nil {
+			slog.ErrorContext(ctx, "erro ao apagar o arquivo da exportação", "error", err, "export_id", id)
+		}
+	}
+}
+
+func (s *InvoiceExportService) findOwnedExport(ctx context.Context, principal domain.Principal, id string) (*domain.InvoiceExport, error) {
+	export, err := s.exportRepository.FindByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if export.AccountID != principal.AccountID {
+		return nil, domain.ErrUnauthorizedAccess
+	}
+
+	return export, nil
+}
+
+// exportEncoder escreve as linhas de um formato; ambos bufferizam, então só Flush garante que tudo chegou ao writer
+type exportEncoder interface {
+	Encode(row dto.InvoiceExportRow) error
+	Flush() error
+}
+
+// newExportEncoder já escreve o cabeçalho do CSV; o NDJSON não tem cabeçalho
+func newExportEncoder(format domain.ExportFormat, w io.Writer) (exportEncoder, error) {
+	switch format {
+	case domain.ExportCSV:
+		writer := csv.NewWriter(w)
+		if err := writer.Write(dto.InvoiceExportColumns); err != nil {
+			return nil, err
+		}
+		return &csvExportEncoder{writer: writer}, nil
+	case domain.ExportNDJSON:
+		buffer := bufio.NewWriter(w)
+		return &ndjsonExportEncoder{buffer: buffer, encoder: json.NewEncoder(buffer)}, nil
+	}
+	return nil, domain.ErrInvalidExportFormat
+}
+
+type csvExportEncoder struct {
+	writer *csv.Writer
+}
+
+func (e *csvExportEncoder) Encode(row dto.InvoiceExportRow) error {
+	return e.writer.Write(row.Record())
+}
+
+func (e *csvExportEncoder) Flush() error {
+	e.writer.Flush()
+	return e.writer.Error()
+}
+
+type ndjsonExportEncoder struct {
+	buffer  *bufio.Writer
+	encoder *json.Encoder
+}
+
+// Encode escreve um objeto por linha (o json.Encoder termina cada valor com \n)
+func (e *ndjsonExportEncoder) Encode(row dto.InvoiceExportRow) error {
+	return e.encoder.Encode(row)
+}
+
+func (e *ndjsonExportEncoder) Flush() error {
+	return e.buffer.Flush()
+}
@@ -0,0 +1,83 @@
+package service
+
+import (
+	"context"
+	"os"
+	"time"
+
+	"github.com/j-ordep/gateway/go-gateway/internal/domain"
+	"github.com/j-ordep/gateway/go-gateway/internal/dto"
+	"github.com/j-ordep/gateway/go-gateway/internal/tracing"
+)
+
+// InvoiceReportConfig define de onde os relatórios são lidos
+type InvoiceReportConfig struct {
+	UseRollup bool // INVOICE_REPORT_SOURCE=rollup: totais diários pré-calculados em vez de agregar as faturas a cada chamada
+}
+
+func NewInvoiceReportConfig() *InvoiceReportConfig {
+	return &InvoiceReportConfig{
+		UseRollup: os.Getenv("INVOICE_REPORT_SOURCE") == "rollup",
+	}
+}
+
+// InvoiceReportService monta os relatórios da conta (GET /accounts/reports)
+// com o rollup ligado, RefreshRollup precisa ser chamado periodicamente
+type InvoiceReportService struct {
+	repository domain.InvoiceReportRepository
+	config     *InvoiceReportConfig
+}
+
+func NewInvoiceReportService(repository domain.InvoiceReportRepository, config *InvoiceReportConfig) *InvoiceReportService {
+	return &InvoiceReportService{
+		repository: repository,
+		config:     config,
+	}
+}
+
+func (s *InvoiceReportService) Report(ctx context.Context, principal domain.Principal, input dto.AccountReportInput) (output *dto.AccountReportOutput, err error) {
+	ctx, span := tracing.Start(ctx, "InvoiceReportService.Report")
+	defer func() { tracing.End(span, err) }()
+
+	report, err := dto.ToInvoiceReport(input, principal.AccountID, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	aggregates, err := s.aggregate(ctx, report)
+	if err != nil {
+		return nil, err
+	}
+	for _, aggregate := range aggregates {
+		report.Add(aggregate)
+	}
+
+	return dto.FromInvoiceReport(report), nil
+}
+
+// aggregate lê do rollup quando ele está ligado e já foi calculado ao menos uma vez;
+// logo após o deploy (antes do primeiro refresh) os totais vêm direto das faturas
+func (s *InvoiceReportService) aggregate(ctx context.Context, report *domain.InvoiceReport) ([]domain.InvoiceAggregate, error) {
+	if s.config.UseRollup {
+		refreshedUntil, err := s.repository.RollupRefreshedUntil(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if !refreshedUntil.IsZero() {
+			report.Source = domain.ReportFromRollup
+			report.RefreshedUntil = refreshedUntil
+			return s.repository.AggregateRollup(ctx, report.AccountID, report.Granularity, report.From, report.To)
+		}
+	}
+
+	return s.repository.Aggregate(ctx, report.AccountID, report.Granularity, report.From, report.To)
+}
+
+// RefreshRollup atualiza os totais diários com as faturas criadas ou alteradas desde a última execução
+// e retorna quantos dias foram recalculados
+func (s *InvoiceReportService) RefreshRollup(ctx context.Context) (days int, err error) {
+	ctx, span := tracing.Start(ctx, "InvoiceReportService.RefreshRollup")
+	defer func() { tracing.End(span, err) }()
+
+	return s.repository.RefreshRollup(ctx, time.Now())
+}
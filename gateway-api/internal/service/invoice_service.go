@@ -2,28 +2,73 @@ package service
 
 import (
 	"context"
+	"log/slog"
+	"time"
 
 	"github.com/j-ordep/gateway/go-gateway/internal/domain"
 	"github.com/j-ordep/gateway/go-gateway/internal/domain/events"
 	"github.com/j-ordep/gateway/go-gateway/internal/dto"
+	"github.com/j-ordep/gateway/go-gateway/internal/metrics"
+	"github.com/j-ordep/gateway/go-gateway/internal/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 type InvoiceService struct {
-	invoiceRepository domain.InvoiceRepository
-	accountService    AccountService
-	kafkaProducer     KafkaProducerInterface
+	invoiceRepository       domain.InvoiceRepository
+	accountService          AccountService
+	kafkaProducer           KafkaProducerInterface
+	velocityService         *VelocityService
+	customerRepository      domain.CustomerRepository
+	paymentMethodRepository domain.PaymentMethodRepository
+	threeDSService          *ThreeDSService
+	webhookService          *WebhookService
 }
 
-func NewInvoiceService(invoiceRepository domain.InvoiceRepository, accountService AccountService, kafkaProducer KafkaProducerInterface) *InvoiceService {
+func NewInvoiceService(invoiceRepository domain.InvoiceRepository, accountService AccountService, kafkaProducer KafkaProducerInterface, velocityService *VelocityService, customerRepository domain.CustomerRepository, paymentMethodRepository domain.PaymentMethodRepository, threeDSService *ThreeDSService, webhookService *WebhookService) *InvoiceService {
 	return &InvoiceService{
-		invoiceRepository: invoiceRepository,
-		accountService:    accountService,
-		kafkaProducer:     kafkaProducer,
+		invoiceRepository:       invoiceRepository,
+		accountService:          accountService,
+		kafkaProducer:           kafkaProducer,
+		velocityService:         velocityService,
+		customerRepository:      customerRepository,
+		paymentMethodRepository: paymentMethodRepository,
+		threeDSService:          threeDSService,
+		webhookService:          webhookService,
 	}
 }
 
-func (s *InvoiceService) Create(input dto.CreateInvoiceInput) (*dto.InvoiceOutput, error) {
-	accountOutput, err := s.accountService.FindByAPIKey(input.APIKey)
+func (s *InvoiceService) Create(ctx context.Context, principal domain.Principal, input dto.CreateInvoiceInput) (output *dto.InvoiceOutput, err error) {
+	ctx, span := tracing.Start(ctx, "InvoiceService.Create")
+	defer func() { tracing.End(span, err) }()
+
+	prepared, err := s.prepare(ctx, principal, input, false)
+	if err != nil {
+		return nil, err
+	}
+
+	if prepared.pending != nil {
+		if err = s.kafkaProducer.SendingPendingTransaction(ctx, *prepared.pending); err != nil {
+			return nil, err
+		}
+	}
+
+	return s.save(ctx, prepared)
+}
+
+// preparedInvoice é uma fatura já processada que ainda não foi gravada
+// pending é o evento da transação de alto valor, publicado por quem chamou (sozinho no Create, em lote no CreateBatch)
+type preparedInvoice struct {
+	invoice   *domain.Invoice
+	challenge *domain.ThreeDSChallenge
+	pending   *events.PendingTransaction
+}
+
+// prepare executa a criação até o ponto de gravar: validações, velocity, 3DS e aprovação
+// batch indica um item do POST /invoices/batch, cuja conta já foi contada uma vez pelo lote (VelocityService.CheckAccount)
+func (s *InvoiceService) prepare(ctx context.Context, principal domain.Principal, input dto.CreateInvoiceInput, batch bool) (*preparedInvoice, error) {
+	// contas suspensas ou encerradas não podem gerar novas cobranças
+	accountOutput, err := s.accountService.FindActiveByID(principal.AccountID)
 	if err != nil {
 		return nil, err
 	}
@@ -32,102 +77,378 @@ func (s *InvoiceService) Create(input dto.CreateInvoiceInput) (*dto.InvoiceOutpu
 	if err != nil {
 		return nil, err
 	}
+	trace.SpanFromContext(ctx).SetAttributes(tracing.AccountIDKey.String(accountOutput.ID), tracing.InvoiceIDKey.String(invoice.ID))
+
+	// verifica a referência antes de processar, para não movimentar saldo/kafka de uma fatura que não será salva
+	if invoice.ExternalReference != "" {
+		_, err := s.invoiceRepository.FindByExternalReference(ctx, accountOutput.ID, invoice.ExternalReference)
+		if err == nil {
+			return nil, domain.ErrDuplicatedExternalReference
+		}
+		if err != domain.ErrInvoiceNotFound {
+			return nil, err
+		}
+	}
 
-	if err = invoice.Process(); err != nil {
+	cardFingerprint, err := s.attachCustomer(invoice, input)
+	if err != nil {
 		return nil, err
 	}
 
-	if invoice.Status == domain.StatusPending {
-		// Criar e publicar evento de transação pendente
-		pendingTransaction := events.NewPendingTransaction(invoice.AccountID, invoice.ID, invoice.Amount)
+	// toda tentativa conta, mesmo as que forem rejeitadas depois (card testing)
+	if batch {
+		err = s.velocityService.CheckPayment(ctx, accountOutput.ID, cardFingerprint, input.ClientIP)
+	} else {
+		err = s.velocityService.Check(ctx, accountOutput.ID, cardFingerprint, input.ClientIP)
+	}
+	if err != nil {
+		return nil, err
+	}
 
-		if err := s.kafkaProducer.SendingPendingTransaction(context.Background(), *pendingTransaction); err != nil {
+	prepared := &preparedInvoice{invoice: invoice}
+
+	// a regra de 3DS é por conta; no desafio a fatura fica em requires_action até o callback
+	threeDSRule := domain.ThreeDSRule{Mode: domain.ThreeDSMode(accountOutput.ThreeDSMode), MinAmount: accountOutput.ThreeDSMinAmount}
+	if threeDSRule.Requires(invoice.Amount) {
+		prepared.challenge, err = s.threeDSService.Authenticate(invoice)
+		if err != nil {
 			return nil, err
 		}
 	}
 
-	if invoice.Status == domain.StatusApproved {
-		_, err := s.accountService.UpdateBalance(input.APIKey, invoice.Amount)
-		if err != nil {
+	if invoice.Status == domain.StatusPending {
+		if prepared.pending, err = s.process(ctx, invoice); err != nil {
 			return nil, err
 		}
 	}
 
-	if err = s.invoiceRepository.Save(invoice); err != nil {
+	return prepared, nil
+}
+
+// save grava a fatura preparada, o desafio 3DS (quando houver), o evento do stream e os webhooks na mesma transação
+// uma falha não deixa fatura em requires_action sem desafio, nem invoice.created de uma fatura que não existe
+func (s *InvoiceService) save(ctx context.Context, prepared *preparedInvoice) (*dto.InvoiceOutput, error) {
+	invoice := prepared.invoice
+	outbox := &domain.InvoiceOutbox{Challenge: prepared.challenge}
+	if err := s.addNotifications(ctx, outbox, invoice, true); err != nil {
 		return nil, err
 	}
 
-	return dto.FromInvoice(invoice), nil
+	// aprovada, o saldo é creditado na mesma transação do INSERT
+	if err := s.invoiceRepository.Save(ctx, invoice, outbox); err != nil {
+		return nil, err
+	}
+	metrics.InvoiceCreated(invoice)
 
+	output := dto.FromInvoice(invoice)
+	if prepared.challenge != nil {
+		output.ChallengeURL = s.threeDSService.ChallengeURL(prepared.challenge)
+	}
+
+	return output, nil
 }
 
-func (s *InvoiceService) GetById(id, apiKey string) (*dto.InvoiceOutput, error) {
-	invoice, err := s.invoiceRepository.FindByID(id)
+// process é o fluxo normal de aprovação: sorteio/antifraude
+// usado na criação e na retomada após o desafio 3DS; o saldo é creditado pelo repository junto com a gravação da fatura
+// para alto valor retorna o evento da transação pendente, que quem chamou publica no kafka
+func (s *InvoiceService) process(ctx context.Context, invoice *domain.Invoice) (pending *events.PendingTransaction, err error) {
+	_, span := tracing.Start(ctx, "InvoiceService.process")
+	defer func() { tracing.End(span, err) }()
+
+	if err := invoice.Process(); err != nil {
+		return nil, err
+	}
+	span.SetAttributes(attribute.String("invoice.status", string(invoice.Status)))
+
+	if invoice.Status == domain.StatusPending {
+		return events.NewPendingTransaction(invoice.AccountID, invoice.ID, invoice.Amount), nil
+	}
+
+	return nil, nil
+}
+
+// CompleteThreeDS é o callback do desafio 3DS: aplica o resultado e retoma o fluxo normal de aprovação
+// chamadas repetidas ou simultâneas apenas retornam a fatura no estado atual: só quem grava a transição
+// publica no kafka e credita o saldo
+func (s *InvoiceService) CompleteThreeDS(ctx context.Context, challengeID string) (output *dto.InvoiceOutput, err error) {
+	ctx, span := tracing.Start(ctx, "InvoiceService.CompleteThreeDS")
+	defer func() { tracing.End(span, err) }()
+
+	challenge, err := s.threeDSService.FindChallenge(challengeID)
 	if err != nil {
 		return nil, err
 	}
 
-	accountOutput, err := s.accountService.FindByAPIKey(apiKey)
+	if challenge.Status == domain.ChallengeStatusPending {
+		return nil, domain.ErrThreeDSChallengePending
+	}
+
+	invoice, err := s.invoiceRepository.FindByID(ctx, challenge.InvoiceID)
 	if err != nil {
 		return nil, err
 	}
 
-	if invoice.AccountID != accountOutput.ID {
-		return nil, domain.ErrUnauthorizedAccess
+	if invoice.Status != domain.StatusRequiresAction {
+		return dto.FromInvoice(invoice), nil
+	}
+
+	if err := invoice.CompleteThreeDS(challenge); err != nil {
+		return nil, err
+	}
+
+	var pending *events.PendingTransaction
+	if invoice.Status == domain.StatusPending {
+		if pending, err = s.process(ctx, invoice); err != nil {
+			return nil, err
+		}
+	}
+
+	outbox := &domain.InvoiceOutbox{}
+	if err := s.addNotifications(ctx, outbox, invoice, false); err != nil {
+		return nil, err
+	}
+
+	err = s.invoiceRepository.UpdateStatus(ctx, invoice, domain.StatusRequiresAction, outbox)
+	if err == domain.ErrInvalidStatus {
+		// outro callback gravou antes
+		return s.FindByID(ctx, invoice.ID)
 	}
+	if err != nil {
+		return nil, err
+	}
+
+	if pending != nil {
+		if err = s.kafkaProducer.SendingPendingTransaction(ctx, *pending); err != nil {
+			s.reopenThreeDS(ctx, invoice)
+			return nil, err
+		}
+	}
+
+	metrics.InvoiceDecided(invoice)
 
 	return dto.FromInvoice(invoice), nil
 }
 
-func (s *InvoiceService) ListByAccountApiKey(apiKey string) ([]*dto.InvoiceOutput, error) {
-	accountOutput, err := s.accountService.FindByAPIKey(apiKey)
+// reopenThreeDS desfaz a transição do callback quando a transação de alto valor não chegou ao kafka,
+// senão a fatura ficaria pending sem ninguém para decidi-la
+func (s *InvoiceService) reopenThreeDS(ctx context.Context, invoice *domain.Invoice) {
+	if err := invoice.ReopenThreeDS(); err != nil {
+		return
+	}
+	// o stream já recebeu o pending junto com a transição, então recebe também a volta para requires_action
+	outbox := &domain.InvoiceOutbox{Event: domain.NewInvoiceEvent(invoice)}
+	if err := s.invoiceRepository.UpdateStatus(ctx, invoice, domain.StatusPending, outbox); err != nil {
+		slog.ErrorContext(ctx, "erro ao reabrir o 3DS da fatura", "error", err, "invoice_id", invoice.ID)
+	}
+}
+
+// addNotifications acrescenta ao outbox o evento do stream SSE e os webhooks do novo status
+// (invoice.created na criação e approved/rejected quando é decidida)
+// tudo é gravado na transação da fatura: sem fatura não há aviso, e sem aviso a fatura não é gravada
+func (s *InvoiceService) addNotifications(ctx context.Context, outbox *domain.InvoiceOutbox, invoice *domain.Invoice, created bool) error {
+	outbox.Event = domain.NewInvoiceEvent(invoice)
+	data := dto.FromInvoice(invoice)
+
+	if created {
+		deliveries, err := s.webhookService.Deliveries(ctx, invoice.AccountID, domain.WebhookInvoiceCreated, data)
+		if err != nil {
+			return err
+		}
+		outbox.Deliveries = append(outbox.Deliveries, deliveries...)
+	}
+
+	if eventType, ok := domain.InvoiceWebhookEvent(invoice.Status); ok {
+		deliveries, err := s.webhookService.Deliveries(ctx, invoice.AccountID, eventType, data)
+		if err != nil {
+			return err
+		}
+		outbox.Deliveries = append(outbox.Deliveries, deliveries...)
+	}
+	return nil
+}
+
+// attachCustomer vincula o cliente e/ou cartão salvo informados na criação
+// retorna o fingerprint do cartão efetivamente cobrado (digitado ou salvo)
+func (s *InvoiceService) attachCustomer(invoice *domain.Invoice, input dto.CreateInvoiceInput) (string, error) {
+	if input.CustomerID != "" {
+		customer, err := s.customerRepository.FindByID(input.CustomerID)
+		if err != nil {
+			return "", err
+		}
+
+		if err := invoice.AttachCustomer(customer); err != nil {
+			return "", err
+		}
+	}
+
+	if input.PaymentMethodID != "" {
+		paymentMethod, err := s.paymentMethodRepository.FindByID(input.PaymentMethodID)
+		if err != nil {
+			return "", err
+		}
+
+		if err := invoice.UsePaymentMethod(paymentMethod); err != nil {
+			return "", err
+		}
+
+		return paymentMethod.Fingerprint, nil
+	}
+
+	// sem cartão salvo o número precisa vir na requisição
+	card := dto.ToCreditCard(input)
+	if len(card.Number) < 4 {
+		return "", domain.ErrInvalidCard
+	}
+
+	return card.Fingerprint(), nil
+}
+
+// FindByID busca a fatura sem verificar a conta dona, uso administrativo
+func (s *InvoiceService) FindByID(ctx context.Context, id string) (*dto.InvoiceOutput, error) {
+	invoice, err := s.invoiceRepository.FindByID(ctx, id)
 	if err != nil {
 		return nil, err
 	}
 
-	return s.ListByAccountId(accountOutput.ID)
+	return dto.FromInvoice(invoice), nil
 }
 
-// func auxiliar para ListByAccountApiKey
-func (s *InvoiceService) ListByAccountId(accountId string) ([]*dto.InvoiceOutput, error) {
-	invoices, err := s.invoiceRepository.FindByAccountID(accountId)
+func (s *InvoiceService) GetById(ctx context.Context, principal domain.Principal, id string) (*dto.InvoiceOutput, error) {
+	invoice, err := s.invoiceRepository.FindByID(ctx, id)
 	if err != nil {
 		return nil, err
 	}
 
-	invoiceOutput := make([]*dto.InvoiceOutput, len(invoices))
-	for i, invoice := range invoices {
-		invoiceOutput[i] = dto.FromInvoice(invoice)
+	if invoice.AccountID != principal.AccountID {
+		return nil, domain.ErrUnauthorizedAccess
 	}
 
-	return invoiceOutput, nil
+	return dto.FromInvoice(invoice), nil
+}
+
+func (s *InvoiceService) ListByAccount(ctx context.Context, principal domain.Principal, filter dto.InvoiceFilterInput) (*dto.InvoiceListOutput, error) {
+	return s.ListByAccountId(ctx, principal.AccountID, filter)
 }
 
-// ProcessTransactionResult processa o resultado de uma transação após análise de fraude
-func (s *InvoiceService) ProcessTransactionResult(invoiceID string, status domain.Status) error {
-	invoice, err := s.invoiceRepository.FindByID(invoiceID)
+// WatchStatus chama send com a fatura atual e depois a cada mudança de status, até ela ser decidida
+// o status é relido a cada interval: o resultado do antifraude pode ser processado por outra instância
+func (s *InvoiceService) WatchStatus(ctx context.Context, principal domain.Principal, id string, interval time.Duration, send func(*dto.InvoiceOutput) error) error {
+	output, err := s.GetById(ctx, principal, id)
 	if err != nil {
 		return err
 	}
-
-	if err := invoice.UpdateStatus(status); err != nil {
+	if err := send(output); err != nil {
 		return err
 	}
 
-	if err := s.invoiceRepository.UpdateStatus(invoice); err != nil {
-		return err
-	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
 
-	if status == domain.StatusApproved {
-		account, err := s.accountService.FindByID(invoice.AccountID)
+	for !domain.Status(output.Status).IsFinal() {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+
+		current, err := s.GetById(ctx, principal, id)
 		if err != nil {
 			return err
 		}
+		if current.Status == output.Status {
+			continue
+		}
 
-		if _, err := s.accountService.UpdateBalance(account.APIKey, invoice.Amount); err != nil {
+		output = current
+		if err := send(output); err != nil {
 			return err
 		}
 	}
 
 	return nil
 }
+
+// func auxiliar para ListByAccount
+func (s *InvoiceService) ListByAccountId(ctx context.Context, accountId string, filter dto.InvoiceFilterInput) (*dto.InvoiceListOutput, error) {
+	return listInvoices(ctx, s.invoiceRepository, accountId, filter)
+}
+
+// listInvoices busca uma página de faturas; pede 1 item a mais para saber se existe próxima página
+// compartilhado com o histórico de faturas do Customer
+func listInvoices(ctx context.Context, invoiceRepository domain.InvoiceRepository, accountId string, input dto.InvoiceFilterInput) (*dto.InvoiceListOutput, error) {
+	filter, err := dto.ToInvoiceFilter(input)
+	if err != nil {
+		return nil, err
+	}
+
+	pageSize := filter.Limit
+	filter.Limit = pageSize + 1
+
+	invoices, err := invoiceRepository.FindByAccountID(ctx, accountId, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	output := &dto.InvoiceListOutput{Data: make([]*dto.InvoiceOutput, 0, pageSize)}
+
+	if len(invoices) > pageSize {
+		invoices = invoices[:pageSize]
+		nextCursor := dto.EncodeInvoiceCursor(invoices[len(invoices)-1])
+		output.NextCursor = &nextCursor
+	}
+
+	for _, invoice := range invoices {
+		output.Data = append(output.Data, dto.FromInvoice(invoice))
+	}
+
+	return output, nil
+}
+
+// ProcessTransactionResult processa o resultado de uma transação após análise de fraude
+func (s *InvoiceService) ProcessTransactionResult(ctx context.Context, invoiceID string, status domain.Status) (err error) {
+	ctx, span := tracing.Start(ctx, "InvoiceService.ProcessTransactionResult",
+		trace.WithAttributes(tracing.InvoiceIDKey.String(invoiceID), attribute.String("invoice.status", string(status))))
+	defer func() { tracing.End(span, err) }()
+
+	_, err = s.decide(ctx, invoiceID, status, nil)
+	return err
+}
+
+// ForceStatus é a decisão do operador no lugar do antifraude, gravada junto com a entrada de auditoria
+func (s *InvoiceService) ForceStatus(ctx context.Context, invoiceID string, status domain.Status, entry *domain.AuditEntry) (output *dto.InvoiceOutput, err error) {
+	ctx, span := tracing.Start(ctx, "InvoiceService.ForceStatus",
+		trace.WithAttributes(tracing.InvoiceIDKey.String(invoiceID), attribute.String("invoice.status", string(status))))
+	defer func() { tracing.End(span, err) }()
+
+	invoice, err := s.decide(ctx, invoiceID, status, entry)
+	if err != nil {
+		return nil, err
+	}
+	return dto.FromInvoice(invoice), nil
+}
+
+// decide grava a decisão de uma fatura pending; audit (opcional) entra na transação da transição
+func (s *InvoiceService) decide(ctx context.Context, invoiceID string, status domain.Status, audit *domain.AuditEntry) (*domain.Invoice, error) {
+	invoice, err := s.invoiceRepository.FindByID(ctx, invoiceID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := invoice.UpdateStatus(status); err != nil {
+		return nil, err
+	}
+
+	outbox := &domain.InvoiceOutbox{Audit: audit}
+	if err := s.addNotifications(ctx, outbox, invoice, false); err != nil {
+		return nil, err
+	}
+
+	// retorna ErrInvalidStatus se outra decisão (kafka repetido ou admin) foi gravada antes
+	// aprovada, o saldo é creditado na mesma transação da transição
+	if err := s.invoiceRepository.UpdateStatus(ctx, invoice, domain.StatusPending, outbox); err != nil {
+		return nil, err
+	}
+	metrics.InvoiceDecided(invoice)
+
+	return invoice, nil
+}
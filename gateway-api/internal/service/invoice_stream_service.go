@@ -0,0 +1,228 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/j-ordep/gateway/go-gateway/internal/domain"
+	"github.com/j-ordep/gateway/go-gateway/internal/dto"
+)
+
+// InvoiceStreamConfig define o comportamento do stream SSE de status das faturas
+type InvoiceStreamConfig struct {
+	Heartbeat   time.Duration // intervalo dos comentários de keep-alive, abaixo do idle timeout de proxies
+	Retention   time.Duration // por quanto tempo um Last-Event-ID ainda pode ser retomado
+	BacklogPage int           // eventos lidos por consulta ao retomar
+	BufferSize  int           // eventos em espera por conexão antes de ela ser derrubada por lentidão
+}
+
+func NewInvoiceStreamConfig() *InvoiceStreamConfig {
+	return &InvoiceStreamConfig{
+		Heartbeat:   getEnvDuration("INVOICE_STREAM_HEARTBEAT", 15*time.Second),
+		Retention:   getEnvDuration("INVOICE_EVENTS_RETENTION", 24*time.Hour),
+		BacklogPage: 500,
+		BufferSize:  getEnvInt("INVOICE_STREAM_BUFFER", 64),
+	}
+}
+
+// InvoiceEventHub é o pub/sub em memória dos eventos de fatura, por conta
+// entre instâncias os eventos chegam pelo LISTEN/NOTIFY do Postgres, que chama Publish em todas (inclusive na que gravou)
+type InvoiceEventHub struct {
+	mu          sync.Mutex
+	subscribers map[string]map[*invoiceEventSubscriber]struct{}
+	bufferSize  int
+	closed      bool
+}
+
+type invoiceEventSubscriber struct {
+	events chan *domain.InvoiceEvent
+	closed bool
+}
+
+func NewInvoiceEventHub(bufferSize int) *InvoiceEventHub {
+	return &InvoiceEventHub{
+		subscribers: make(map[string]map[*invoiceEventSubscriber]struct{}),
+		bufferSize:  bufferSize,
+	}
+}
+
+// Subscribe recebe os eventos da conta até unsubscribe ser chamado
+// o canal é fechado se o assinante ficar para trás (buffer cheio) ou no shutdown do hub
+func (h *InvoiceEventHub) Subscribe(accountID string) (<-chan *domain.InvoiceEvent, func()) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	subscriber := &invoiceEventSubscriber{events: make(chan *domain.InvoiceEvent, h.bufferSize)}
+	if h.closed {
+		subscriber.closed = true
+		close(subscriber.events)
+		return subscriber.events, func() {}
+	}
+
+	if h.subscribers[accountID] == nil {
+		h.subscribers[accountID] = make(map[*invoiceEventSubscriber]struct{})
+	}
+	h.subscribers[accountID][subscriber] = struct{}{}
+
+	return subscriber.events, func() {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		h.remove(accountID, subscriber)
+	}
+}
+
+// Publish nunca bloqueia: um assinante lento é desconectado e retoma pelo Last-Event-ID
+func (h *InvoiceEventHub) Publish(event *domain.InvoiceEvent) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for subscriber := range h.subscribers[event.AccountID] {
+		select {
+		case subscriber.events <- event:
+		default:
+			slog.Warn("assinante do stream de faturas lento, desconectando", "account_id", event.AccountID)
+			h.remove(event.AccountID, subscriber)
+		}
+	}
+}
+
+// Close encerra todos os streams abertos (shutdown), para que o servidor HTTP consiga drenar as conexões
+func (h *InvoiceEventHub) Close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.closed = true
+	for accountID, subscribers := range h.subscribers {
+		for subscriber := range subscribers {
+			h.remove(accountID, subscriber)
+		}
+	}
+}
+
+func (h *InvoiceEventHub) remove(accountID string, subscriber *invoiceEventSubscriber) {
+	if subscriber.closed {
+		return
+	}
+	subscriber.closed = true
+	close(subscriber.events)
+
+	delete(h.subscribers[accountID], subscriber)
+	if len(h.subscribers[accountID]) == 0 {
+		delete(h.subscribers, accountID)
+	}
+}
+
+// InvoiceEventWriter é o transporte do stream (SSE no handler HTTP)
+type InvoiceEventWriter interface {
+	// Open é chamado depois das validações e antes do primeiro evento: a partir daqui os erros não viram mais JSON
+	Open() error
+	Send(event *dto.InvoiceEventOutput) error
+	Heartbeat() error
+}
+
+type InvoiceStreamService struct {
+	invoiceRepository domain.InvoiceRepository
+	eventRepository   domain.InvoiceEventRepository
+	hub               *InvoiceEventHub
+	config            *InvoiceStreamConfig
+}
+
+func NewInvoiceStreamService(invoiceRepository domain.InvoiceRepository, eventRepository domain.InvoiceEventRepository, hub *InvoiceEventHub, config *InvoiceStreamConfig) *InvoiceStreamService {
+	return &InvoiceStreamService{
+		invoiceRepository: invoiceRepository,
+		eventRepository:   eventRepository,
+		hub:               hub,
+		config:            config,
+	}
+}
+
+// Stream envia os eventos da conta do principal até o cliente desconectar (ctx) ou ser derrubado pelo hub
+// com LastEventID os eventos gravados depois dele são reenviados antes dos novos
+func (s *InvoiceStreamService) Stream(ctx context.Context, principal domain.Principal, input dto.InvoiceStreamInput, writer InvoiceEventWriter) error {
+	if input.InvoiceID != "" {
+		invoice, err := s.invoiceRepository.FindByID(ctx, input.InvoiceID)
+		if err != nil {
+			return err
+		}
+		if invoice.AccountID != principal.AccountID {
+			return domain.ErrUnauthorizedAccess
+		}
+	}
+
+	// inscreve antes de ler o histórico, para não perder o que for publicado entre a consulta e a inscrição
+	events, unsubscribe := s.hub.Subscribe(principal.AccountID)
+	defer unsubscribe()
+
+	if err := writer.Open(); err != nil {
+		return err
+	}
+
+	// eventos até resumedUpTo já saíram pelo histórico (ou antes da reconexão) e são ignorados quando chegam ao vivo
+	resumedUpTo := input.LastEventID
+	if input.LastEventID > 0 {
+		afterID := input.LastEventID
+		for {
+			backlog, err := s.eventRepository.FindAfter(ctx, principal.AccountID, afterID, s.config.BacklogPage)
+			if err != nil {
+				return err
+			}
+			for _, event := range backlog {
+				if err := s.send(writer, input, event); err != nil {
+					return err
+				}
+				afterID = event.ID
+			}
+			if len(backlog) < s.config.BacklogPage {
+				break
+			}
+		}
+		resumedUpTo = afterID
+	}
+
+	heartbeat := time.NewTicker(s.config.Heartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case event, ok := <-events:
+			if !ok {
+				// derrubado pelo hub (lentidão ou shutdown): o cliente reconecta com o Last-Event-ID
+				return nil
+			}
+			if event.ID <= resumedUpTo {
+				continue
+			}
+			if err := s.send(writer, input, event); err != nil {
+				return err
+			}
+		case <-heartbeat.C:
+			if err := writer.Heartbeat(); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func (s *InvoiceStreamService) send(writer InvoiceEventWriter, input dto.InvoiceStreamInput, event *domain.InvoiceEvent) error {
+	if input.InvoiceID != "" && event.InvoiceID != input.InvoiceID {
+		return nil
+	}
+	return writer.Send(dto.FromInvoiceEvent(event))
+}
+
+// PurgeExpired remove os eventos mais antigos que a retenção, chamado periodicamente
+func (s *InvoiceStreamService) PurgeExpired(ctx context.Context) {
+	deleted, err := s.eventRepository.DeleteBefore(ctx, time.Now().Add(-s.config.Retention))
+	if err != nil {
+		slog.Error("erro ao remover eventos de fatura antigos", "error", err)
+		return
+	}
+
+	if deleted > 0 {
+		slog.Info("eventos de fatura antigos removidos", "total", deleted)
+	}
+}
@@ -3,12 +3,23 @@ package service
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"log/slog"
 	"os"
 	"strings"
+	"sync/atomic"
+	"time"
 
+	"github.com/google/uuid"
+	"github.com/j-ordep/gateway/go-gateway/internal/domain"
 	"github.com/j-ordep/gateway/go-gateway/internal/domain/events"
+	"github.com/j-ordep/gateway/go-gateway/internal/logging"
+	"github.com/j-ordep/gateway/go-gateway/internal/metrics"
+	"github.com/j-ordep/gateway/go-gateway/internal/tracing"
 	"github.com/segmentio/kafka-go"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // Anotação: por que existe `WithTopic`?
@@ -27,8 +38,13 @@ import (
 //     consumerResult := NewKafkaConsumer(cfgResult, "group-result", invoiceService)
 //   Assim, reaproveitamos os mesmos brokers, variando somente o tópico para separar responsabilidades.
 
+// requestIDHeader é o header das mensagens do kafka que carrega o X-Request-ID da requisição HTTP
+const requestIDHeader = "X-Request-ID"
+
 type KafkaProducerInterface interface {
 	SendingPendingTransaction(ctx context.Context, event events.PendingTransaction) error
+	// SendingPendingTransactions publica vários eventos em uma única chamada ao broker (POST /invoices/batch)
+	SendingPendingTransactions(ctx context.Context, pending []events.PendingTransaction) error
 	Close() error
 }
 
@@ -49,6 +65,54 @@ func (c *KafkaConfig) WithTopic(topic string) *KafkaConfig {
 	}
 }
 
+// CheckTopics verifica se algum dos brokers responde e se os tópicos existem (usado pelo /readyz)
+// basta um broker acessível: o client do kafka descobre os demais pelos metadados do cluster
+func (c *KafkaConfig) CheckTopics(ctx context.Context, topics ...string) error {
+	var dialer kafka.Dialer
+
+	var conn *kafka.Conn
+	var errs []error
+	for _, broker := range c.Brokers {
+		var err error
+		conn, err = dialer.DialContext(ctx, "tcp", broker)
+		if err == nil {
+			break
+		}
+		errs = append(errs, err)
+	}
+	if conn == nil {
+		return fmt.Errorf("no kafka broker reachable: %w", errors.Join(errs...))
+	}
+	defer conn.Close()
+
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	// sem argumentos lista as partições de todos os tópicos, sem disparar a criação automática
+	partitions, err := conn.ReadPartitions()
+	if err != nil {
+		return fmt.Errorf("read kafka metadata: %w", err)
+	}
+
+	existing := make(map[string]bool, len(partitions))
+	for _, partition := range partitions {
+		existing[partition.Topic] = true
+	}
+
+	var missing []string
+	for _, topic := range topics {
+		if !existing[topic] {
+			missing = append(missing, topic)
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("kafka topics not found: %s", strings.Join(missing, ", "))
+	}
+
+	return nil
+}
+
 func NewKafkaConfig() *KafkaConfig {
 	broker := os.Getenv("KAFKA_BROKER")
 	if broker == "" {
@@ -90,41 +154,118 @@ func NewKafkaProducer(config *KafkaConfig) *KafkaProducer {
 	}
 }
 
-func (p *KafkaProducer) SendingPendingTransaction(ctx context.Context, event events.PendingTransaction) error {
-	value, err := json.Marshal(event)
+func (p *KafkaProducer) SendingPendingTransaction(ctx context.Context, event events.PendingTransaction) (err error) {
+	ctx, span := tracing.Start(ctx, p.topic+" publish",
+		trace.WithSpanKind(trace.SpanKindProducer),
+		trace.WithAttributes(
+			semconv.MessagingSystemKafka,
+			semconv.MessagingDestinationName(p.topic),
+			semconv.MessagingOperationTypePublish,
+			tracing.InvoiceIDKey.String(event.InvoiceID),
+		),
+	)
+	defer func() { tracing.End(span, err) }()
+
+	msg, err := pendingTransactionMessage(ctx, event)
 	if err != nil {
-		slog.Error("erro ao converter evento para json", "error", err)
 		return err
 	}
 
-	msg := kafka.Message{
-		Value: value,
+	slog.InfoContext(ctx, "enviando mensagem para o kafka",
+		"topic", p.topic,
+		"message", string(msg.Value))
+
+	start := time.Now()
+	err = p.writer.WriteMessages(ctx, msg)
+	metrics.KafkaProduceDuration.WithLabelValues(p.topic).Observe(time.Since(start).Seconds())
+	if err != nil {
+		metrics.KafkaProduceErrorsTotal.WithLabelValues(p.topic).Inc()
+		slog.ErrorContext(ctx, "erro ao enviar mensagem para o kafka", "error", err)
+		return err
 	}
 
-	slog.Info("enviando mensagem para o kafka",
-		"topic", p.topic,
-		"message", string(value))
+	slog.InfoContext(ctx, "mensagem enviada com sucesso para o kafka", "topic", p.topic)
+	return nil
+}
+
+func (p *KafkaProducer) SendingPendingTransactions(ctx context.Context, pending []events.PendingTransaction) (err error) {
+	ctx, span := tracing.Start(ctx, p.topic+" publish",
+		trace.WithSpanKind(trace.SpanKindProducer),
+		trace.WithAttributes(
+			semconv.MessagingSystemKafka,
+			semconv.MessagingDestinationName(p.topic),
+			semconv.MessagingOperationTypePublish,
+			semconv.MessagingBatchMessageCount(len(pending)),
+		),
+	)
+	defer func() { tracing.End(span, err) }()
+
+	msgs := make([]kafka.Message, 0, len(pending))
+	for _, event := range pending {
+		msg, err := pendingTransactionMessage(ctx, event)
+		if err != nil {
+			return err
+		}
+		msgs = append(msgs, msg)
+	}
 
-	if err := p.writer.WriteMessages(ctx, msg); err != nil {
-		slog.Error("erro ao enviar mensagem para o kafka", "error", err)
+	slog.InfoContext(ctx, "enviando lote de mensagens para o kafka", "topic", p.topic, "messages", len(msgs))
+
+	// o writer só retorna depois que todas foram confirmadas; se alguma falhar o lote inteiro é tratado como falho
+	start := time.Now()
+	err = p.writer.WriteMessages(ctx, msgs...)
+	metrics.KafkaProduceDuration.WithLabelValues(p.topic).Observe(time.Since(start).Seconds())
+	if err != nil {
+		metrics.KafkaProduceErrorsTotal.WithLabelValues(p.topic).Inc()
+		slog.ErrorContext(ctx, "erro ao enviar lote de mensagens para o kafka", "error", err)
 		return err
 	}
 
-	slog.Info("mensagem enviada com sucesso para o kafka", "topic", p.topic)
+	slog.InfoContext(ctx, "lote enviado com sucesso para o kafka", "topic", p.topic, "messages", len(msgs))
 	return nil
 }
 
+// pendingTransactionMessage monta a mensagem com o X-Request-ID e o contexto de trace da requisição
+func pendingTransactionMessage(ctx context.Context, event events.PendingTransaction) (kafka.Message, error) {
+	value, err := json.Marshal(event)
+	if err != nil {
+		slog.ErrorContext(ctx, "erro ao converter evento para json", "error", err)
+		return kafka.Message{}, err
+	}
+
+	msg := kafka.Message{
+		Value:   value,
+		Headers: requestIDHeaders(ctx),
+	}
+	// traceparent/tracestate seguem para o antifraude, que os devolve no transactions_result
+	tracing.InjectKafka(ctx, &msg.Headers)
+
+	return msg, nil
+}
+
+// Close aguarda o envio das mensagens pendentes (flush) antes de fechar a conexão
 func (s *KafkaProducer) Close() error {
 	slog.Info("fechando conexao com o kafka")
 	return s.writer.Close()
 }
 
+// o resultado que falha por erro transitório (banco fora do ar, fatura ainda não gravada) é reprocessado
+// com backoff a partir de consumerRetryBaseDelay, dobrando até consumerRetryMaxDelay
+// a fatura é publicada no kafka antes de ser gravada, então o resultado pode chegar antes do commit; se ela continuar
+// inexistente depois de consumerNotFoundAttempts tentativas, a gravação falhou e o resultado é descartado
+const (
+	consumerRetryBaseDelay   = time.Second
+	consumerRetryMaxDelay    = 30 * time.Second
+	consumerNotFoundAttempts = 6
+)
+
 type KafkaConsumer struct {
 	reader         *kafka.Reader
 	topic          string
 	brokers        []string
 	groupID        string
 	invoiceService *InvoiceService
+	running        atomic.Bool
 }
 
 func NewKafkaConsumer(config *KafkaConfig, groupID string, invoiceService *InvoiceService) *KafkaConsumer {
@@ -148,38 +289,163 @@ func NewKafkaConsumer(config *KafkaConfig, groupID string, invoiceService *Invoi
 	}
 }
 
+// Consume lê as mensagens até o ctx ser cancelado; o cancelamento só é observado entre mensagens e entre retries,
+// a tentativa em andamento é processada até o fim. O offset só é commitado depois que a mensagem foi processada
+// (ou descartada, ver handleMessage): no shutdown durante os retries ela fica sem commit e é reentregue.
+// Retorna nil quando parou por cancelamento do ctx (shutdown)
 func (c *KafkaConsumer) Consume(ctx context.Context) error {
+	c.running.Store(true)
+	defer c.running.Store(false)
+
 	for {
-		msg, err := c.reader.ReadMessage(ctx)
+		// FetchMessage não commita o offset: uma mensagem lida e não processada é reentregue
+		msg, err := c.reader.FetchMessage(ctx)
 		if err != nil {
+			if ctx.Err() != nil {
+				slog.Info("kafka consumer encerrado", "topic", c.topic)
+				return nil
+			}
 			slog.Error("erro ao ler mensagem do kafka", "error", err)
 			return err
 		}
 
-		var result events.TransactionResult
-		if err := json.Unmarshal(msg.Value, &result); err != nil {
-			slog.Error("erro ao converter mensagem para TransactionResult", "error", err)
-			continue
+		// o processamento e o commit não herdam o cancelamento do shutdown
+		msgCtx := context.WithoutCancel(ctx)
+		if err := c.process(ctx, msgCtx, msg); err != nil {
+			slog.Info("kafka consumer encerrado com mensagem sem commit", "topic", c.topic, "offset", msg.Offset)
+			return nil
 		}
 
-		slog.Info("mensagem recebida do kafka",
-			"topic", c.topic,
-			"invoice_id", result.InvoiceID,
-			"status", result.Status)
+		if err := c.reader.CommitMessages(msgCtx, msg); err != nil {
+			slog.Error("erro ao commitar mensagem do kafka", "error", err, "offset", msg.Offset)
+			return err
+		}
+	}
+}
+
+// Stats expõe as estatísticas do reader (lag) para as métricas
+func (c *KafkaConsumer) Stats() kafka.ReaderStats {
+	return c.reader.Stats()
+}
 
-		// Processa o resultado da transação
-		if err := c.invoiceService.ProcessTransactionResult(result.InvoiceID, result.ToDomainStatus()); err != nil {
-			slog.Error("erro ao processar resultado da transação",
-				"error", err,
-				"invoice_id", result.InvoiceID,
-				"status", result.Status)
-			continue
+// Running indica se o loop do Consume está ativo (reportado pelo /readyz)
+func (c *KafkaConsumer) Running() bool {
+	return c.running.Load()
+}
+
+// process repete handleMessage até a mensagem poder ser commitada; retorna o erro do ctx se o shutdown chegar antes
+// a mensagem seguinte espera: os resultados de uma partição são aplicados em ordem
+func (c *KafkaConsumer) process(ctx, msgCtx context.Context, msg kafka.Message) error {
+	delay := consumerRetryBaseDelay
+	for attempt := 1; ; attempt++ {
+		err := c.handleMessage(msgCtx, msg)
+		if err == nil {
+			return nil
+		}
+		if err == domain.ErrInvoiceNotFound && attempt >= consumerNotFoundAttempts {
+			slog.ErrorContext(msgCtx, "resultado descartado, a fatura não existe", "offset", msg.Offset, "attempts", attempt)
+			metrics.KafkaConsumerMessagesTotal.WithLabelValues(c.topic, "discarded").Inc()
+			return nil
 		}
 
-		slog.Info("transação processada com sucesso",
+		slog.WarnContext(msgCtx, "resultado da transação será reprocessado",
+			"error", err,
+			"offset", msg.Offset,
+			"retry_in", delay)
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(delay):
+		}
+		delay = min(delay*2, consumerRetryMaxDelay)
+	}
+}
+
+// handleMessage processa um resultado do antifraude e retorna erro quando vale tentar de novo
+// só são descartadas (commitadas sem efeito) as mensagens que nunca vão ser processadas: as que não podem ser lidas
+// e as de faturas que já foram decididas (ErrInvalidStatus: resultado repetido ou decisão do operador);
+// a fatura inexistente é descartada por process depois de algumas tentativas
+func (c *KafkaConsumer) handleMessage(ctx context.Context, msg kafka.Message) (err error) {
+	// o request ID e o trace da requisição que originou a cobrança voltam nos headers (quando o antifraude repassa)
+	msgCtx := logging.WithRequestID(ctx, messageRequestID(msg))
+	msgCtx = tracing.ExtractKafka(msgCtx, msg.Headers)
+
+	msgCtx, span := tracing.Start(msgCtx, c.topic+" process",
+		trace.WithSpanKind(trace.SpanKindConsumer),
+		trace.WithAttributes(
+			semconv.MessagingSystemKafka,
+			semconv.MessagingDestinationName(c.topic),
+			semconv.MessagingOperationTypeDeliver,
+			semconv.MessagingKafkaConsumerGroup(c.groupID),
+			semconv.MessagingKafkaMessageOffset(int(msg.Offset)),
+		),
+	)
+	defer func() { tracing.End(span, err) }()
+
+	var result events.TransactionResult
+	if err := json.Unmarshal(msg.Value, &result); err != nil {
+		metrics.KafkaConsumerMessagesTotal.WithLabelValues(c.topic, "invalid").Inc()
+		tracing.RecordError(span, err)
+		slog.ErrorContext(msgCtx, "erro ao converter mensagem para TransactionResult", "error", err)
+		return nil
+	}
+
+	// ID malformado não existe no banco, repetir não adianta
+	if _, err := uuid.Parse(result.InvoiceID); err != nil {
+		metrics.KafkaConsumerMessagesTotal.WithLabelValues(c.topic, "invalid").Inc()
+		slog.ErrorContext(msgCtx, "resultado com invoice_id inválido", "error", err, "invoice_id", result.InvoiceID)
+		return nil
+	}
+
+	slog.InfoContext(msgCtx, "mensagem recebida do kafka",
+		"topic", c.topic,
+		"invoice_id", result.InvoiceID,
+		"status", result.Status)
+
+	// Processa o resultado da transação
+	err = c.invoiceService.ProcessTransactionResult(msgCtx, result.InvoiceID, result.ToDomainStatus())
+	if err == domain.ErrInvalidStatus {
+		slog.WarnContext(msgCtx, "resultado descartado, a fatura já foi decidida",
+			"invoice_id", result.InvoiceID,
+			"status", result.Status)
+		metrics.KafkaConsumerMessagesTotal.WithLabelValues(c.topic, "discarded").Inc()
+		return nil
+	}
+	if err != nil {
+		slog.ErrorContext(msgCtx, "erro ao processar resultado da transação",
+			"error", err,
 			"invoice_id", result.InvoiceID,
 			"status", result.Status)
+		metrics.KafkaConsumerMessagesTotal.WithLabelValues(c.topic, "failed").Inc()
+		return err
+	}
+	metrics.KafkaConsumerMessagesTotal.WithLabelValues(c.topic, "processed").Inc()
+
+	slog.InfoContext(msgCtx, "transação processada com sucesso",
+		"invoice_id", result.InvoiceID,
+		"status", result.Status)
+	return nil
+}
+
+// requestIDHeaders propaga o request ID do contexto para o antifraude e de volta para o consumer
+func requestIDHeaders(ctx context.Context) []kafka.Header {
+	requestID := logging.RequestID(ctx)
+	if requestID == "" {
+		return nil
+	}
+	return []kafka.Header{{Key: requestIDHeader, Value: []byte(requestID)}}
+}
+
+// messageRequestID lê o request ID do header da mensagem; sem header um novo ID é gerado
+// para que ao menos os logs do processamento daquela mensagem fiquem correlacionados
+func messageRequestID(msg kafka.Message) string {
+	for _, header := range msg.Headers {
+		if header.Key == requestIDHeader && len(header.Value) > 0 {
+			return string(header.Value)
+		}
 	}
+	return uuid.NewString()
 }
 
 func (c *KafkaConsumer) Close() error {
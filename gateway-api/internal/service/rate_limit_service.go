@@ -0,0 +1,80 @@
+package service
+
+import (
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/j-ordep/gateway/go-gateway/internal/domain"
+)
+
+// RateLimitConfig define o token bucket de cada plano e o das requisições sem apiKey (por IP)
+type RateLimitConfig struct {
+	Tiers     map[domain.AccountTier]domain.RateLimit
+	Anonymous domain.RateLimit
+}
+
+// NewRateLimitConfig lê RATE_LIMIT_<PLANO>_RATE (req/s) e RATE_LIMIT_<PLANO>_BURST
+// ex: RATE_LIMIT_PREMIUM_RATE=50, RATE_LIMIT_ANONYMOUS_BURST=10
+func NewRateLimitConfig() *RateLimitConfig {
+	return &RateLimitConfig{
+		Tiers: map[domain.AccountTier]domain.RateLimit{
+			domain.AccountTierStandard:   rateLimitFromEnv(string(domain.AccountTierStandard), 10, 20),
+			domain.AccountTierPremium:    rateLimitFromEnv(string(domain.AccountTierPremium), 50, 100),
+			domain.AccountTierEnterprise: rateLimitFromEnv(string(domain.AccountTierEnterprise), 200, 400),
+		},
+		Anonymous: rateLimitFromEnv("anonymous", 1, 10),
+	}
+}
+
+func rateLimitFromEnv(name string, defaultRate float64, defaultBurst int) domain.RateLimit {
+	prefix := "RATE_LIMIT_" + strings.ToUpper(name)
+
+	rate, err := strconv.ParseFloat(os.Getenv(prefix+"_RATE"), 64)
+	if err != nil {
+		rate = defaultRate
+	}
+
+	return domain.RateLimit{
+		Rate:  rate,
+		Burst: getEnvInt(prefix+"_BURST", defaultBurst),
+	}
+}
+
+// RateLimitService decide qual bucket e qual limite usar para cada requisição
+type RateLimitService struct {
+	store  domain.RateLimitStore
+	config *RateLimitConfig
+}
+
+func NewRateLimitService(store domain.RateLimitStore, config *RateLimitConfig) *RateLimitService {
+	return &RateLimitService{store: store, config: config}
+}
+
+// AllowAccount consome um token do bucket da conta, com o limite do plano dela
+// plano desconhecido cai no limite do standard
+func (s *RateLimitService) AllowAccount(accountID string, tier string) (domain.RateLimitResult, bool, error) {
+	limit, ok := s.config.Tiers[domain.AccountTier(tier)]
+	if !ok {
+		limit = s.config.Tiers[domain.AccountTierStandard]
+	}
+	return s.take("account:"+accountID, limit)
+}
+
+// AllowIP é usado nas rotas sem apiKey (ex: POST /accounts) ou com apiKey inválida
+func (s *RateLimitService) AllowIP(ip string) (domain.RateLimitResult, bool, error) {
+	return s.take("ip:"+ip, s.config.Anonymous)
+}
+
+// take retorna enabled=false quando o limite está desligado (rate ou burst = 0)
+func (s *RateLimitService) take(key string, limit domain.RateLimit) (domain.RateLimitResult, bool, error) {
+	if limit.Disabled() {
+		return domain.RateLimitResult{Allowed: true}, false, nil
+	}
+
+	result, err := s.store.Take(key, limit)
+	if err != nil {
+		return domain.RateLimitResult{}, true, err
+	}
+	return result, true, nil
+}
@@ -0,0 +1,94 @@
+package service
+
+import (
+	"crypto/subtle"
+	"os"
+	"strconv"
+
+	"github.com/j-ordep/gateway/go-gateway/internal/domain"
+)
+
+// ThreeDSConfig configura o ACS (Access Control Server) simulado
+type ThreeDSConfig struct {
+	BaseURL               string  // URL pública do gateway, usada para montar o challenge_url
+	FrictionlessMaxAmount float64 // até esse valor o "emissor" autentica sem desafio
+	ChallengeCode         string  // código que o comprador precisa digitar para passar no desafio
+}
+
+func NewThreeDSConfig() *ThreeDSConfig {
+	frictionlessMaxAmount, err := strconv.ParseFloat(os.Getenv("THREE_DS_FRICTIONLESS_MAX_AMOUNT"), 64)
+	if err != nil {
+		frictionlessMaxAmount = 1000
+	}
+
+	baseURL := os.Getenv("THREE_DS_BASE_URL")
+	if baseURL == "" {
+		baseURL = "http://localhost:8081"
+	}
+
+	challengeCode := os.Getenv("THREE_DS_CHALLENGE_CODE")
+	if challengeCode == "" {
+		challengeCode = "123456"
+	}
+
+	return &ThreeDSConfig{
+		BaseURL:               baseURL,
+		FrictionlessMaxAmount: frictionlessMaxAmount,
+		ChallengeCode:         challengeCode,
+	}
+}
+
+// ThreeDSService simula o emissor/ACS do 3-D Secure
+// decide entre fluxo frictionless e desafio, e valida a resposta do comprador
+type ThreeDSService struct {
+	challengeRepository domain.ThreeDSChallengeRepository
+	config              *ThreeDSConfig
+}
+
+func NewThreeDSService(challengeRepository domain.ThreeDSChallengeRepository, config *ThreeDSConfig) *ThreeDSService {
+	return &ThreeDSService{challengeRepository: challengeRepository, config: config}
+}
+
+// Authenticate inicia o 3DS da fatura
+// frictionless: marca a fatura como autenticada e retorna nil
+// desafio: coloca a fatura em requires_action e retorna o desafio (ainda não salvo, depende da fatura existir no banco)
+func (s *ThreeDSService) Authenticate(invoice *domain.Invoice) (*domain.ThreeDSChallenge, error) {
+	if invoice.Amount <= s.config.FrictionlessMaxAmount {
+		invoice.ApplyFrictionless()
+		return nil, nil
+	}
+
+	if err := invoice.RequireAction(); err != nil {
+		return nil, err
+	}
+
+	return domain.NewThreeDSChallenge(invoice.ID), nil
+}
+
+func (s *ThreeDSService) ChallengeURL(challenge *domain.ThreeDSChallenge) string {
+	return s.config.BaseURL + "/3ds/challenge/" + challenge.ID
+}
+
+func (s *ThreeDSService) FindChallenge(id string) (*domain.ThreeDSChallenge, error) {
+	return s.challengeRepository.FindByID(id)
+}
+
+// SubmitChallenge registra a resposta do comprador na página do ACS
+func (s *ThreeDSService) SubmitChallenge(id, code string) (*domain.ThreeDSChallenge, error) {
+	challenge, err := s.challengeRepository.FindByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	authenticated := subtle.ConstantTimeCompare([]byte(code), []byte(s.config.ChallengeCode)) == 1
+
+	if err := challenge.Complete(authenticated); err != nil {
+		return nil, err
+	}
+
+	if err := s.challengeRepository.Update(challenge); err != nil {
+		return nil, err
+	}
+
+	return challenge, nil
+}
@@ -0,0 +1,131 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/j-ordep/gateway/go-gateway/internal/domain"
+	"github.com/j-ordep/gateway/go-gateway/internal/tracing"
+)
+
+// VelocityLimit define quantas tentativas são aceitas dentro da janela
+// Limit <= 0 desabilita a verificação daquela dimensão
+type VelocityLimit struct {
+	Limit  int
+	Window time.Duration
+}
+
+type VelocityConfig struct {
+	Account VelocityLimit // tentativas por conta
+	Card    VelocityLimit // tentativas por fingerprint do cartão (card testing)
+	IP      VelocityLimit // tentativas por IP de origem
+}
+
+func NewVelocityConfig() *VelocityConfig {
+	return &VelocityConfig{
+		Account: VelocityLimit{
+			Limit:  getEnvInt("VELOCITY_ACCOUNT_LIMIT", 100),
+			Window: getEnvDuration("VELOCITY_ACCOUNT_WINDOW", time.Minute),
+		},
+		Card: VelocityLimit{
+			Limit:  getEnvInt("VELOCITY_CARD_LIMIT", 5),
+			Window: getEnvDuration("VELOCITY_CARD_WINDOW", 10*time.Minute),
+		},
+		IP: VelocityLimit{
+			Limit:  getEnvInt("VELOCITY_IP_LIMIT", 20),
+			Window: getEnvDuration("VELOCITY_IP_WINDOW", time.Minute),
+		},
+	}
+}
+
+// VelocityService é consultado antes do Invoice.Process para barrar ataques de card testing
+type VelocityService struct {
+	counter domain.VelocityCounter
+	config  *VelocityConfig
+}
+
+func NewVelocityService(counter domain.VelocityCounter, config *VelocityConfig) *VelocityService {
+	return &VelocityService{counter: counter, config: config}
+}
+
+type velocityCheck struct {
+	dimension string
+	value     string
+	limit     VelocityLimit
+}
+
+// Check registra a tentativa em todas as dimensões e retorna ErrVelocityLimitExceeded se alguma estourar
+func (s *VelocityService) Check(ctx context.Context, accountID, cardFingerprint, clientIP string) (err error) {
+	ctx, span := tracing.Start(ctx, "VelocityService.Check")
+	defer func() { tracing.End(span, err) }()
+
+	return s.check(ctx, accountID, []velocityCheck{
+		{"account", accountID, s.config.Account},
+		{"card", cardFingerprint, s.config.Card},
+		{"ip", clientIP, s.config.IP},
+	})
+}
+
+// CheckAccount registra uma tentativa só na dimensão da conta: o POST /invoices/batch conta o lote inteiro uma vez
+// (senão um lote de INVOICE_BATCH_MAX_ITEMS itens esgotaria sozinho o VELOCITY_ACCOUNT_LIMIT)
+func (s *VelocityService) CheckAccount(ctx context.Context, accountID string) (err error) {
+	ctx, span := tracing.Start(ctx, "VelocityService.CheckAccount")
+	defer func() { tracing.End(span, err) }()
+
+	return s.check(ctx, accountID, []velocityCheck{{"account", accountID, s.config.Account}})
+}
+
+// CheckPayment registra a tentativa de um item do lote nas dimensões do cartão e do IP, a conta já foi contada pelo lote
+func (s *VelocityService) CheckPayment(ctx context.Context, accountID, cardFingerprint, clientIP string) (err error) {
+	ctx, span := tracing.Start(ctx, "VelocityService.CheckPayment")
+	defer func() { tracing.End(span, err) }()
+
+	return s.check(ctx, accountID, []velocityCheck{
+		{"card", cardFingerprint, s.config.Card},
+		{"ip", clientIP, s.config.IP},
+	})
+}
+
+func (s *VelocityService) check(ctx context.Context, accountID string, checks []velocityCheck) error {
+	for _, check := range checks {
+		if check.value == "" || check.limit.Limit <= 0 {
+			continue
+		}
+
+		count, err := s.counter.Increment(check.dimension+":"+check.value, check.limit.Window)
+		if err != nil {
+			return err
+		}
+
+		if count > check.limit.Limit {
+			slog.WarnContext(ctx, "limite de velocidade excedido",
+				"dimension", check.dimension,
+				"account_id", accountID,
+				"count", count,
+				"limit", check.limit.Limit,
+				"window", check.limit.Window.String())
+			return domain.ErrVelocityLimitExceeded
+		}
+	}
+
+	return nil
+}
+
+func getEnvInt(key string, defaultValue int) int {
+	value, err := strconv.Atoi(os.Getenv(key))
+	if err != nil {
+		return defaultValue
+	}
+	return value
+}
+
+func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
+	value, err := time.ParseDuration(os.Getenv(key))
+	if err != nil {
+		return defaultValue
+	}
+	return value
+}
@@ -0,0 +1,80 @@
+package service
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"syscall"
+
+	"github.com/j-ordep/gateway/go-gateway/internal/domain"
+)
+
+// newWebhookClient monta o client que chama as URLs cadastradas pelos lojistas (SSRF)
+//   - o dial recusa endereços internos depois da resolução do DNS, então um host público que resolve
+//     para 127.0.0.1 ou 169.254.169.254 também é barrado
+//   - redirects não são seguidos: o 3xx conta como falha da entrega
+//   - sem proxy, para o dial ser sempre para o endereço do próprio endpoint
+//
+// AllowInsecure (desenvolvimento local) libera os endereços internos
+func newWebhookClient(config *WebhookConfig) *http.Client {
+	dialer := &net.Dialer{Timeout: config.Timeout}
+	if !config.AllowInsecure {
+		dialer.Control = denyInternalAddress
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+
+	return &http.Client{
+		Timeout:   config.Timeout,
+		Transport: transport,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+func denyInternalAddress(network, address string, conn syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+
+	addr, err := netip.ParseAddr(host)
+	if err != nil {
+		return err
+	}
+
+	if internalAddress(addr) {
+		return fmt.Errorf("webhook address %s is not allowed", addr)
+	}
+	return nil
+}
+
+// internalAddress cobre loopback, redes privadas (inclusive IPv6 ULA), link-local (metadados da nuvem) e 0.0.0.0
+func internalAddress(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	return addr.IsLoopback() || addr.IsPrivate() || addr.IsLinkLocalUnicast() || addr.IsLinkLocalMulticast() ||
+		addr.IsInterfaceLocalMulticast() || addr.IsMulticast() || addr.IsUnspecified()
+}
+
+// checkWebhookURL completa a validação do domínio com a política da instalação:
+// fora do desenvolvimento só https, e IPs internos escritos direto na URL já são recusados no cadastro
+func checkWebhookURL(config *WebhookConfig, endpointURL string) error {
+	if config.AllowInsecure {
+		return nil
+	}
+
+	parsed, err := url.Parse(endpointURL)
+	if err != nil || parsed.Scheme != "https" {
+		return domain.ErrInvalidWebhookURL
+	}
+
+	if addr, err := netip.ParseAddr(parsed.Hostname()); err == nil && internalAddress(addr) {
+		return domain.ErrInvalidWebhookURL
+	}
+	return nil
+}
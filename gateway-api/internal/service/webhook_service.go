@@ -0,0 +1,280 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/j-ordep/gateway/go-gateway/internal/domain"
+	"github.com/j-ordep/gateway/go-gateway/internal/dto"
+	"github.com/j-ordep/gateway/go-gateway/internal/metrics"
+	"github.com/j-ordep/gateway/go-gateway/internal/tracing"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// WebhookConfig define a política de entrega dos webhooks
+type WebhookConfig struct {
+	MaxAttempts    int           // tentativas até a entrega ficar failed
+	RetryBaseDelay time.Duration // atraso do primeiro retry, dobra a cada tentativa
+	Timeout        time.Duration // tempo máximo de resposta do endpoint do lojista
+	BatchSize      int           // entregas enviadas por rodada do dispatcher
+	AllowInsecure  bool          // desenvolvimento local: aceita http e endereços internos (loopback, redes privadas)
+}
+
+func NewWebhookConfig() *WebhookConfig {
+	return &WebhookConfig{
+		MaxAttempts:    getEnvInt("WEBHOOK_MAX_ATTEMPTS", 8),
+		RetryBaseDelay: getEnvDuration("WEBHOOK_RETRY_BASE_DELAY", 30*time.Second),
+		Timeout:        getEnvDuration("WEBHOOK_TIMEOUT", 10*time.Second),
+		BatchSize:      getEnvInt("WEBHOOK_BATCH_SIZE", 50),
+		AllowInsecure:  os.Getenv("WEBHOOK_ALLOW_INSECURE") == "true",
+	}
+}
+
+// WebhookService cadastra os endpoints do lojista e entrega os eventos assinados
+// as entregas pendentes (Deliveries) são gravadas junto com a fatura; o envio (e os retries) ficam com DispatchDue, chamado periodicamente
+type WebhookService struct {
+	endpointRepository domain.WebhookEndpointRepository
+	deliveryRepository domain.WebhookDeliveryRepository
+	client             *http.Client
+	config             *WebhookConfig
+}
+
+func NewWebhookService(endpointRepository domain.WebhookEndpointRepository, deliveryRepository domain.WebhookDeliveryRepository, config *WebhookConfig) *WebhookService {
+	return &WebhookService{
+		endpointRepository: endpointRepository,
+		deliveryRepository: deliveryRepository,
+		client:             newWebhookClient(config),
+		config:             config,
+	}
+}
+
+// CreateEndpoint é a única resposta que traz o secret usado na assinatura
+func (s *WebhookService) CreateEndpoint(ctx context.Context, principal domain.Principal, input dto.CreateWebhookEndpointInput) (*dto.WebhookEndpointOutput, error) {
+	if err := checkWebhookURL(s.config, input.URL); err != nil {
+		return nil, err
+	}
+
+	endpoint, err := domain.NewWebhookEndpoint(principal.AccountID, input.URL, input.EventTypes)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.endpointRepository.Save(ctx, endpoint); err != nil {
+		return nil, err
+	}
+
+	output := dto.FromWebhookEndpoint(endpoint)
+	output.Secret = endpoint.Secret
+	return output, nil
+}
+
+func (s *WebhookService) ListEndpoints(ctx context.Context, principal domain.Principal) ([]*dto.WebhookEndpointOutput, error) {
+	endpoints, err := s.endpointRepository.FindByAccountID(ctx, principal.AccountID)
+	if err != nil {
+		return nil, err
+	}
+
+	output := make([]*dto.WebhookEndpointOutput, len(endpoints))
+	for i, endpoint := range endpoints {
+		output[i] = dto.FromWebhookEndpoint(endpoint)
+	}
+	return output, nil
+}
+
+func (s *WebhookService) GetEndpoint(ctx context.Context, principal domain.Principal, id string) (*dto.WebhookEndpointOutput, error) {
+	endpoint, err := s.findOwnedEndpoint(ctx, principal, id)
+	if err != nil {
+		return nil, err
+	}
+	return dto.FromWebhookEndpoint(endpoint), nil
+}
+
+// DeleteEndpoint remove o endpoint e o log de entregas, as pendentes deixam de ser enviadas
+func (s *WebhookService) DeleteEndpoint(ctx context.Context, principal domain.Principal, id string) error {
+	endpoint, err := s.findOwnedEndpoint(ctx, principal, id)
+	if err != nil {
+		return err
+	}
+	return s.endpointRepository.Delete(ctx, endpoint.ID)
+}
+
+// ListDeliveries é o log de entregas do endpoint, mais recentes primeiro
+func (s *WebhookService) ListDeliveries(ctx context.Context, principal domain.Principal, endpointID string, input dto.WebhookDeliveryFilterInput) (*dto.WebhookDeliveryListOutput, error) {
+	endpoint, err := s.findOwnedEndpoint(ctx, principal, endpointID)
+	if err != nil {
+		return nil, err
+	}
+
+	deliveries, err := s.deliveryRepository.FindByEndpointID(ctx, endpoint.ID, dto.ToWebhookDeliveryFilter(input))
+	if err != nil {
+		return nil, err
+	}
+
+	output := &dto.WebhookDeliveryListOutput{Data: make([]*dto.WebhookDeliveryOutput, len(deliveries))}
+	for i, delivery := range deliveries {
+		output.Data[i] = dto.FromWebhookDelivery(delivery)
+	}
+	return output, nil
+}
+
+// Redeliver reenvia agora o mesmo corpo (inclusive entregas failed ou já entregues) e devolve o resultado
+// uma entrega que já esgotou as tentativas continua failed se o reenvio falhar
+func (s *WebhookService) Redeliver(ctx context.Context, principal domain.Principal, endpointID, deliveryID string) (*dto.WebhookDeliveryOutput, error) {
+	endpoint, err := s.findOwnedEndpoint(ctx, principal, endpointID)
+	if err != nil {
+		return nil, err
+	}
+
+	delivery, err := s.deliveryRepository.FindByID(ctx, deliveryID)
+	if err != nil {
+		return nil, err
+	}
+	if delivery.EndpointID != endpoint.ID {
+		return nil, domain.ErrWebhookDeliveryNotFound
+	}
+
+	if err := s.attempt(ctx, endpoint, delivery); err != nil {
+		return nil, err
+	}
+	return dto.FromWebhookDelivery(delivery), nil
+}
+
+// Deliveries monta uma entrega pendente para cada endpoint da conta que assinou o evento
+// quem chama grava as entregas na mesma transação da mudança que gerou o evento (InvoiceOutbox)
+func (s *WebhookService) Deliveries(ctx context.Context, accountID string, eventType domain.WebhookEventType, data any) (deliveries []*domain.WebhookDelivery, err error) {
+	ctx, span := tracing.Start(ctx, "WebhookService.Deliveries")
+	defer func() { tracing.End(span, err) }()
+
+	endpoints, err := s.endpointRepository.FindByAccountID(ctx, accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	var payload []byte
+	event := dto.WebhookEvent{ID: uuid.New().String(), Type: string(eventType), CreatedAt: time.Now(), Data: data}
+
+	for _, endpoint := range endpoints {
+		if !endpoint.Subscribes(eventType) {
+			continue
+		}
+
+		// o mesmo evento (e ID) para todos os endpoints, serializado uma única vez
+		if payload == nil {
+			if payload, err = json.Marshal(event); err != nil {
+				return nil, err
+			}
+		}
+
+		deliveries = append(deliveries, domain.NewWebhookDelivery(endpoint, event.ID, eventType, payload))
+	}
+
+	return deliveries, nil
+}
+
+// DispatchDue envia as entregas pendentes vencidas (primeiras tentativas e retries), retorna quantas foram tentadas
+// as entregas são reservadas uma a uma, com reserva do dobro do timeout: como o envio é sequencial, reservar a rodada
+// inteira de uma vez deixaria as últimas do lote vencerem e serem reenviadas por outra instância
+func (s *WebhookService) DispatchDue(ctx context.Context) (int, error) {
+	attempted := 0
+	for attempted < s.config.BatchSize {
+		deliveries, err := s.deliveryRepository.ClaimDue(ctx, 2*s.config.Timeout, 1)
+		if err != nil {
+			return attempted, err
+		}
+		if len(deliveries) == 0 {
+			break
+		}
+		delivery := deliveries[0]
+
+		endpoint, err := s.endpointRepository.FindByID(ctx, delivery.EndpointID)
+		if err != nil {
+			return attempted, err
+		}
+
+		if err := s.attempt(ctx, endpoint, delivery); err != nil {
+			slog.ErrorContext(ctx, "erro ao registrar a entrega do webhook", "error", err, "delivery_id", delivery.ID)
+		}
+		attempted++
+	}
+
+	return attempted, nil
+}
+
+// attempt faz uma tentativa de envio e grava o resultado (sucesso, próximo retry ou failed)
+func (s *WebhookService) attempt(ctx context.Context, endpoint *domain.WebhookEndpoint, delivery *domain.WebhookDelivery) error {
+	responseStatus, err := s.send(ctx, endpoint, delivery)
+	if err != nil {
+		delivery.RecordFailure(responseStatus, err.Error(), s.config.RetryBaseDelay, s.config.MaxAttempts)
+		slog.WarnContext(ctx, "falha na entrega do webhook",
+			"error", err,
+			"delivery_id", delivery.ID,
+			"event_type", delivery.EventType,
+			"attempts", delivery.Attempts,
+			"status", delivery.Status)
+	} else {
+		delivery.RecordSuccess(responseStatus)
+	}
+	metrics.WebhookAttempt(delivery)
+
+	return s.deliveryRepository.RecordAttempt(ctx, delivery)
+}
+
+// send faz o POST assinado; qualquer resposta fora de 2xx conta como falha
+func (s *WebhookService) send(ctx context.Context, endpoint *domain.WebhookEndpoint, delivery *domain.WebhookDelivery) (responseStatus int, err error) {
+	ctx, span := tracing.Start(ctx, "WebhookService.send", trace.WithSpanKind(trace.SpanKindClient))
+	defer func() { tracing.End(span, err) }()
+
+	// endpoints cadastrados antes da política atual também passam pela checagem
+	if err := checkWebhookURL(s.config, endpoint.URL); err != nil {
+		return 0, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "go-gateway-webhooks/1.0")
+	req.Header.Set("X-Gateway-Event-Id", delivery.EventID)
+	req.Header.Set("X-Gateway-Event-Type", string(delivery.EventType))
+	req.Header.Set("X-Gateway-Delivery-Id", delivery.ID)
+	req.Header.Set("X-Gateway-Signature", endpoint.Sign(time.Now(), delivery.Payload))
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(req.Header))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	// descarta o corpo (limitado) para a conexão poder ser reaproveitada
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("endpoint responded with status %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
+
+func (s *WebhookService) findOwnedEndpoint(ctx context.Context, principal domain.Principal, id string) (*domain.WebhookEndpoint, error) {
+	endpoint, err := s.endpointRepository.FindByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if endpoint.AccountID != principal.AccountID {
+		return nil, domain.ErrUnauthorizedAccess
+	}
+
+	return endpoint, nil
+}
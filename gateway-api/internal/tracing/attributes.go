@@ -0,0 +1,11 @@
+package tracing
+
+import "go.opentelemetry.io/otel/attribute"
+
+// atributos próprios do gateway, para filtrar os traces por conta, fatura, operador ou request ID
+const (
+	RequestIDKey  = attribute.Key("gateway.request_id")
+	AccountIDKey  = attribute.Key("gateway.account_id")
+	InvoiceIDKey  = attribute.Key("gateway.invoice_id")
+	OperatorIDKey = attribute.Key("gateway.operator_id")
+)
@@ -0,0 +1,197 @@
+package validation
+
+import (
+	"fmt"
+	"net/mail"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+)
+
+// FieldError descreve uma violação de regra em um campo do corpo da requisição
+// field usa o nome do JSON (não o nome do campo Go), que é o que o cliente enxerga
+type FieldError struct {
+	Field   string `json:"field"`
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+// Errors agrega todas as violações encontradas, o cliente recebe a lista completa de uma vez
+type Errors []FieldError
+
+func (e Errors) Error() string {
+	messages := make([]string, len(e))
+	for i, fieldErr := range e {
+		messages[i] = fmt.Sprintf("%s: %s", fieldErr.Field, fieldErr.Message)
+	}
+	return "validation failed: " + strings.Join(messages, "; ")
+}
+
+// Struct valida um DTO a partir das tags `validate` declaradas na própria struct
+//
+// regras suportadas (separadas por vírgula):
+//   - required: o campo não pode ter valor zero
+//   - required_without=campo: obrigatório quando o outro campo (nome JSON) estiver vazio
+//   - min=n / max=n: tamanho da string (em caracteres), quantidade de chaves do map ou valor numérico
+//   - gt=n: valor numérico estritamente maior que n
+//   - oneof=a b c: valor deve ser um dos listados
+//   - email, uuid, numeric: formato da string
+//
+// com exceção de required/required_without, campos com valor zero (omitidos) não são validados
+func Struct(v any) error {
+	value := reflect.ValueOf(v)
+	for value.Kind() == reflect.Pointer {
+		if value.IsNil() {
+			return nil
+		}
+		value = value.Elem()
+	}
+	if value.Kind() != reflect.Struct {
+		return nil
+	}
+
+	var errs Errors
+	structType := value.Type()
+
+	for i := 0; i < structType.NumField(); i++ {
+		field := structType.Field(i)
+		tag := field.Tag.Get("validate")
+		if tag == "" || !field.IsExported() {
+			continue
+		}
+
+		fieldValue := value.Field(i)
+		name := jsonName(field)
+
+		// ponteiro nil equivale a campo omitido
+		if fieldValue.Kind() == reflect.Pointer {
+			if fieldValue.IsNil() {
+				fieldValue = reflect.Zero(field.Type.Elem())
+			} else {
+				fieldValue = fieldValue.Elem()
+			}
+		}
+
+		for _, rule := range strings.Split(tag, ",") {
+			ruleName, param, _ := strings.Cut(rule, "=")
+			if message, ok := check(ruleName, param, fieldValue, value); !ok {
+				errs = append(errs, FieldError{Field: name, Rule: ruleName, Message: message})
+				// uma violação por campo é suficiente, as demais seriam ruído
+				break
+			}
+		}
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+func check(rule, param string, value reflect.Value, parent reflect.Value) (string, bool) {
+	switch rule {
+	case "required":
+		return "is required", !value.IsZero()
+	case "required_without":
+		other, ok := fieldByJSONName(parent, param)
+		if ok && other.IsZero() {
+			return fmt.Sprintf("is required when %s is not provided", param), !value.IsZero()
+		}
+		return "", true
+	}
+
+	if value.IsZero() {
+		return "", true
+	}
+
+	switch rule {
+	case "min", "max", "gt":
+		limit, err := strconv.ParseFloat(param, 64)
+		if err != nil {
+			panic(fmt.Sprintf("validation: invalid %s parameter %q", rule, param))
+		}
+		return checkBound(rule, limit, value)
+	case "oneof":
+		options := strings.Fields(param)
+		current := fmt.Sprint(value.Interface())
+		for _, option := range options {
+			if current == option {
+				return "", true
+			}
+		}
+		return "must be one of: " + strings.Join(options, ", "), false
+	case "email":
+		address, err := mail.ParseAddress(value.String())
+		return "must be a valid email address", err == nil && address.Address == value.String()
+	case "uuid":
+		_, err := uuid.Parse(value.String())
+		return "must be a valid UUID", err == nil
+	case "numeric":
+		for _, r := range value.String() {
+			if r < '0' || r > '9' {
+				return "must contain only digits", false
+			}
+		}
+		return "", true
+	}
+
+	panic(fmt.Sprintf("validation: unknown rule %q", rule))
+}
+
+// checkBound aplica min/max/gt conforme o tipo: tamanho para string e map, valor para números
+func checkBound(rule string, limit float64, value reflect.Value) (string, bool) {
+	var current float64
+	unit := ""
+
+	switch value.Kind() {
+	case reflect.String:
+		current = float64(utf8.RuneCountInString(value.String()))
+		unit = " characters"
+	case reflect.Map, reflect.Slice:
+		current = float64(value.Len())
+		unit = " items"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		current = float64(value.Int())
+	case reflect.Float32, reflect.Float64:
+		current = value.Float()
+	default:
+		panic(fmt.Sprintf("validation: rule %s not supported for %s", rule, value.Kind()))
+	}
+
+	formatted := strconv.FormatFloat(limit, 'f', -1, 64)
+	switch rule {
+	case "min":
+		if unit != "" {
+			return "must have at least " + formatted + unit, current >= limit
+		}
+		return "must be greater than or equal to " + formatted, current >= limit
+	case "max":
+		if unit != "" {
+			return "must have at most " + formatted + unit, current <= limit
+		}
+		return "must be less than or equal to " + formatted, current <= limit
+	default:
+		return "must be greater than " + formatted, current > limit
+	}
+}
+
+func jsonName(field reflect.StructField) string {
+	name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+	if name == "" || name == "-" {
+		return field.Name
+	}
+	return name
+}
+
+func fieldByJSONName(parent reflect.Value, name string) (reflect.Value, bool) {
+	parentType := parent.Type()
+	for i := 0; i < parentType.NumField(); i++ {
+		if jsonName(parentType.Field(i)) == name {
+			return parent.Field(i), true
+		}
+	}
+	return reflect.Value{}, false
+}
@@ -1,11 +1,11 @@
 package handler
 
 import (
-	"encoding/json"
 	"net/http"
 
 	"github.com/j-ordep/gateway/go-gateway/internal/dto"
 	"github.com/j-ordep/gateway/go-gateway/internal/service"
+	"github.com/j-ordep/gateway/go-gateway/internal/web/response"
 )
 
 type AccountHandler struct {
@@ -19,39 +19,95 @@ func NewAccountHandler(accountService *service.AccountService) *AccountHandler {
 func (h *AccountHandler) Create(w http.ResponseWriter, r *http.Request) {
 	var input dto.CreateAccountInput
 	
-	err := json.NewDecoder(r.Body).Decode(&input)
+	err := decodeJSON(w, r, &input)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		response.Error(w, r, err)
+		return
 	}
 
 	output, err := h.accountService.CreateAccount(input)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		response.Error(w, r, err)
+		return
 	}
 
-	w.Header().Set("Content-type", "application/json")
-	w.WriteHeader(http.StatusOK)
+	response.JSON(w, http.StatusOK, output)
+}
 
-	json.NewEncoder(w).Encode(output)
+func (h *AccountHandler) Get(w http.ResponseWriter, r *http.Request) {
+	principal, err := authenticated(r)
+	if err != nil {
+		response.Error(w, r, err)
+		return
+	}
+
+	output, err := h.accountService.Get(principal)
+	if err != nil {
+		response.Error(w, r, err)
+		return
+	}
 
+	response.JSON(w, http.StatusOK, output)
 }
 
-func (h *AccountHandler) Get(w http.ResponseWriter, r *http.Request) {
-	apiKey := r.Header.Get("X-API-KEY")
-	if apiKey == "" {
-		http.Error(w, "X-API-KEY header is required", http.StatusUnauthorized)
-		return 
+func (h *AccountHandler) Update(w http.ResponseWriter, r *http.Request) {
+	principal, err := authenticated(r)
+	if err != nil {
+		response.Error(w, r, err)
+		return
+	}
+
+	var input dto.UpdateAccountInput
+	if err := decodeJSON(w, r, &input); err != nil {
+		response.Error(w, r, err)
+		return
 	}
 
-	output, err := h.accountService.FindByAPIKey(apiKey)
+	output, err := h.accountService.UpdateAccount(principal, input)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		response.Error(w, r, err)
 		return
 	}
 
-	w.Header().Set("Content-type", "application/json")
-	w.WriteHeader(http.StatusOK)
+	response.JSON(w, http.StatusOK, output)
+}
 
-	json.NewEncoder(w).Encode(output)
-	
-}
\ No newline at end of file
+func (h *AccountHandler) Close(w http.ResponseWriter, r *http.Request) {
+	principal, err := authenticated(r)
+	if err != nil {
+		response.Error(w, r, err)
+		return
+	}
+
+	// o motivo é opcional, body vazio é aceito
+	var input dto.AccountStatusInput
+	if err := decodeJSON(w, r, &input); err != nil {
+		response.Error(w, r, err)
+		return
+	}
+
+	output, err := h.accountService.CloseAccount(r.Context(), principal, input.Reason)
+	if err != nil {
+		response.Error(w, r, err)
+		return
+	}
+
+	response.JSON(w, http.StatusOK, output)
+}
+
+// RotateAPIKey gera uma nova apiKey; a anterior para de funcionar imediatamente
+func (h *AccountHandler) RotateAPIKey(w http.ResponseWriter, r *http.Request) {
+	principal, err := authenticated(r)
+	if err != nil {
+		response.Error(w, r, err)
+		return
+	}
+
+	output, err := h.accountService.RotateAPIKey(principal)
+	if err != nil {
+		response.Error(w, r, err)
+		return
+	}
+
+	response.JSON(w, http.StatusOK, output)
+}
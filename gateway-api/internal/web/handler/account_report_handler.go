@@ -0,0 +1,40 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/j-ordep/gateway/go-gateway/internal/dto"
+	"github.com/j-ordep/gateway/go-gateway/internal/service"
+	"github.com/j-ordep/gateway/go-gateway/internal/web/response"
+)
+
+type AccountReportHandler struct {
+	service *service.InvoiceReportService
+}
+
+func NewAccountReportHandler(service *service.InvoiceReportService) *AccountReportHandler {
+	return &AccountReportHandler{service: service}
+}
+
+// Get é o GET /accounts/reports: totais das faturas da conta por período
+// ex: /accounts/reports?granularity=month&from=2025-01-01&to=2025-06-30
+func (h *AccountReportHandler) Get(w http.ResponseWriter, r *http.Request) {
+	principal, err := authenticated(r)
+	if err != nil {
+		response.Error(w, r, err)
+		return
+	}
+
+	query := r.URL.Query()
+	output, err := h.service.Report(r.Context(), principal, dto.AccountReportInput{
+		Granularity: query.Get("granularity"),
+		From:        query.Get("from"),
+		To:          query.Get("to"),
+	})
+	if err != nil {
+		response.Error(w, r, err)
+		return
+	}
+
+	response.JSON(w, http.StatusOK, output)
+}
@@ -0,0 +1,201 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/j-ordep/gateway/go-gateway/internal/domain"
+	"github.com/j-ordep/gateway/go-gateway/internal/dto"
+	"github.com/j-ordep/gateway/go-gateway/internal/service"
+	"github.com/j-ordep/gateway/go-gateway/internal/web/request"
+	"github.com/j-ordep/gateway/go-gateway/internal/web/response"
+)
+
+// AdminHandler concentra as ações de suporte/operação, protegidas pelo AdminMiddleware
+type AdminHandler struct {
+	adminService *service.AdminService
+}
+
+func NewAdminHandler(adminService *service.AdminService) *AdminHandler {
+	return &AdminHandler{adminService: adminService}
+}
+
+func (h *AdminHandler) GetAccount(w http.ResponseWriter, r *http.Request) {
+	operator, err := operatorFrom(r)
+	if err != nil {
+		response.Error(w, r, err)
+		return
+	}
+
+	output, err := h.adminService.GetAccount(r.Context(), operator, chi.URLParam(r, "id"))
+	if err != nil {
+		response.Error(w, r, err)
+		return
+	}
+
+	response.JSON(w, http.StatusOK, output)
+}
+
+// ListAccountInvoices aceita os mesmos filtros e paginação de GET /invoices
+func (h *AdminHandler) ListAccountInvoices(w http.ResponseWriter, r *http.Request) {
+	operator, err := operatorFrom(r)
+	if err != nil {
+		response.Error(w, r, err)
+		return
+	}
+
+	filter, err := parseInvoiceFilter(r)
+	if err != nil {
+		response.Error(w, r, err)
+		return
+	}
+
+	output, err := h.adminService.ListAccountInvoices(r.Context(), operator, chi.URLParam(r, "id"), filter)
+	if err != nil {
+		response.Error(w, r, err)
+		return
+	}
+
+	response.JSON(w, http.StatusOK, output)
+}
+
+func (h *AdminHandler) SuspendAccount(w http.ResponseWriter, r *http.Request) {
+	operator, err := operatorFrom(r)
+	if err != nil {
+		response.Error(w, r, err)
+		return
+	}
+
+	var input dto.SuspendAccountInput
+	if err := decodeJSON(w, r, &input); err != nil {
+		response.Error(w, r, err)
+		return
+	}
+
+	output, err := h.adminService.SuspendAccount(r.Context(), operator, chi.URLParam(r, "id"), input.Reason)
+	if err != nil {
+		response.Error(w, r, err)
+		return
+	}
+
+	response.JSON(w, http.StatusOK, output)
+}
+
+func (h *AdminHandler) ReactivateAccount(w http.ResponseWriter, r *http.Request) {
+	operator, err := operatorFrom(r)
+	if err != nil {
+		response.Error(w, r, err)
+		return
+	}
+
+	output, err := h.adminService.ReactivateAccount(r.Context(), operator, chi.URLParam(r, "id"))
+	if err != nil {
+		response.Error(w, r, err)
+		return
+	}
+
+	response.JSON(w, http.StatusOK, output)
+}
+
+func (h *AdminHandler) UpdateAccountTier(w http.ResponseWriter, r *http.Request) {
+	operator, err := operatorFrom(r)
+	if err != nil {
+		response.Error(w, r, err)
+		return
+	}
+
+	var input dto.AccountTierInput
+	if err := decodeJSON(w, r, &input); err != nil {
+		response.Error(w, r, err)
+		return
+	}
+
+	output, err := h.adminService.UpdateAccountTier(r.Context(), operator, chi.URLParam(r, "id"), input.Tier)
+	if err != nil {
+		response.Error(w, r, err)
+		return
+	}
+
+	response.JSON(w, http.StatusOK, output)
+}
+
+func (h *AdminHandler) AdjustBalance(w http.ResponseWriter, r *http.Request) {
+	operator, err := operatorFrom(r)
+	if err != nil {
+		response.Error(w, r, err)
+		return
+	}
+
+	var input dto.BalanceAdjustmentInput
+	if err := decodeJSON(w, r, &input); err != nil {
+		response.Error(w, r, err)
+		return
+	}
+
+	output, err := h.adminService.AdjustBalance(r.Context(), operator, chi.URLParam(r, "id"), input)
+	if err != nil {
+		response.Error(w, r, err)
+		return
+	}
+
+	response.JSON(w, http.StatusOK, output)
+}
+
+func (h *AdminHandler) ForceInvoiceStatus(w http.ResponseWriter, r *http.Request) {
+	operator, err := operatorFrom(r)
+	if err != nil {
+		response.Error(w, r, err)
+		return
+	}
+
+	var input dto.ForceInvoiceStatusInput
+	if err := decodeJSON(w, r, &input); err != nil {
+		response.Error(w, r, err)
+		return
+	}
+
+	output, err := h.adminService.ForceInvoiceStatus(r.Context(), operator, chi.URLParam(r, "id"), input)
+	if err != nil {
+		response.Error(w, r, err)
+		return
+	}
+
+	response.JSON(w, http.StatusOK, output)
+}
+
+// ListAuditLog filtra por ?operator_id=, ?target_type=, ?target_id= e ?limit=, mais recentes primeiro
+func (h *AdminHandler) ListAuditLog(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	input := dto.AuditLogFilterInput{
+		OperatorID: query.Get("operator_id"),
+		TargetType: query.Get("target_type"),
+		TargetID:   query.Get("target_id"),
+	}
+
+	if limit := query.Get("limit"); limit != "" {
+		var err error
+		if input.Limit, err = strconv.Atoi(limit); err != nil || input.Limit <= 0 {
+			response.Error(w, r, invalidQueryParam("limit", errors.New("must be a positive integer")))
+			return
+		}
+	}
+
+	output, err := h.adminService.ListAuditLog(r.Context(), input)
+	if err != nil {
+		response.Error(w, r, err)
+		return
+	}
+
+	response.JSON(w, http.StatusOK, output)
+}
+
+// operatorFrom lê o operador resolvido pelo AdminMiddleware
+func operatorFrom(r *http.Request) (domain.Operator, error) {
+	operator, ok := request.Operator(r.Context())
+	if !ok {
+		return domain.Operator{}, response.Unauthorized("missing_admin_key", "X-ADMIN-KEY is required")
+	}
+	return operator, nil
+}
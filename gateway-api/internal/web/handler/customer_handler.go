@@ -0,0 +1,186 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/j-ordep/gateway/go-gateway/internal/dto"
+	"github.com/j-ordep/gateway/go-gateway/internal/service"
+	"github.com/j-ordep/gateway/go-gateway/internal/web/response"
+)
+
+type CustomerHandler struct {
+	service *service.CustomerService
+}
+
+func NewCustomerHandler(service *service.CustomerService) *CustomerHandler {
+	return &CustomerHandler{service: service}
+}
+
+func (h *CustomerHandler) Create(w http.ResponseWriter, r *http.Request) {
+	principal, err := authenticated(r)
+	if err != nil {
+		response.Error(w, r, err)
+		return
+	}
+
+	var input dto.CreateCustomerInput
+	if err := decodeJSON(w, r, &input); err != nil {
+		response.Error(w, r, err)
+		return
+	}
+
+	output, err := h.service.Create(principal, input)
+	if err != nil {
+		response.Error(w, r, err)
+		return
+	}
+
+	response.JSON(w, http.StatusCreated, output)
+}
+
+func (h *CustomerHandler) List(w http.ResponseWriter, r *http.Request) {
+	principal, err := authenticated(r)
+	if err != nil {
+		response.Error(w, r, err)
+		return
+	}
+
+	output, err := h.service.ListByAccount(principal)
+	if err != nil {
+		response.Error(w, r, err)
+		return
+	}
+
+	response.JSON(w, http.StatusOK, output)
+}
+
+func (h *CustomerHandler) GetById(w http.ResponseWriter, r *http.Request) {
+	principal, err := authenticated(r)
+	if err != nil {
+		response.Error(w, r, err)
+		return
+	}
+
+	output, err := h.service.GetById(principal, chi.URLParam(r, "id"))
+	if err != nil {
+		response.Error(w, r, err)
+		return
+	}
+
+	response.JSON(w, http.StatusOK, output)
+}
+
+func (h *CustomerHandler) Update(w http.ResponseWriter, r *http.Request) {
+	principal, err := authenticated(r)
+	if err != nil {
+		response.Error(w, r, err)
+		return
+	}
+
+	var input dto.UpdateCustomerInput
+	if err := decodeJSON(w, r, &input); err != nil {
+		response.Error(w, r, err)
+		return
+	}
+
+	output, err := h.service.Update(principal, chi.URLParam(r, "id"), input)
+	if err != nil {
+		response.Error(w, r, err)
+		return
+	}
+
+	response.JSON(w, http.StatusOK, output)
+}
+
+func (h *CustomerHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	principal, err := authenticated(r)
+	if err != nil {
+		response.Error(w, r, err)
+		return
+	}
+
+	if err := h.service.Delete(principal, chi.URLParam(r, "id")); err != nil {
+		response.Error(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ListInvoices retorna o histórico de faturas do cliente
+func (h *CustomerHandler) ListInvoices(w http.ResponseWriter, r *http.Request) {
+	principal, err := authenticated(r)
+	if err != nil {
+		response.Error(w, r, err)
+		return
+	}
+
+	filter, err := parseInvoiceFilter(r)
+	if err != nil {
+		response.Error(w, r, err)
+		return
+	}
+
+	output, err := h.service.ListInvoices(r.Context(), principal, chi.URLParam(r, "id"), filter)
+	if err != nil {
+		response.Error(w, r, err)
+		return
+	}
+
+	response.JSON(w, http.StatusOK, output)
+}
+
+func (h *CustomerHandler) AddPaymentMethod(w http.ResponseWriter, r *http.Request) {
+	principal, err := authenticated(r)
+	if err != nil {
+		response.Error(w, r, err)
+		return
+	}
+
+	var input dto.CreatePaymentMethodInput
+	if err := decodeJSON(w, r, &input); err != nil {
+		response.Error(w, r, err)
+		return
+	}
+
+	output, err := h.service.AddPaymentMethod(principal, chi.URLParam(r, "id"), input)
+	if err != nil {
+		response.Error(w, r, err)
+		return
+	}
+
+	response.JSON(w, http.StatusCreated, output)
+}
+
+func (h *CustomerHandler) ListPaymentMethods(w http.ResponseWriter, r *http.Request) {
+	principal, err := authenticated(r)
+	if err != nil {
+		response.Error(w, r, err)
+		return
+	}
+
+	output, err := h.service.ListPaymentMethods(principal, chi.URLParam(r, "id"))
+	if err != nil {
+		response.Error(w, r, err)
+		return
+	}
+
+	response.JSON(w, http.StatusOK, output)
+}
+
+func (h *CustomerHandler) DeletePaymentMethod(w http.ResponseWriter, r *http.Request) {
+	principal, err := authenticated(r)
+	if err != nil {
+		response.Error(w, r, err)
+		return
+	}
+
+	err = h.service.DeletePaymentMethod(principal, chi.URLParam(r, "id"), chi.URLParam(r, "paymentMethodId"))
+	if err != nil {
+		response.Error(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,64 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/j-ordep/gateway/go-gateway/internal/validation"
+	"github.com/j-ordep/gateway/go-gateway/internal/web/response"
+)
+
+// maxBodySize limita o corpo das requisições JSON, nenhum payload da API chega perto disso
+const maxBodySize = 1 << 20 // 1MB
+
+// decodeJSON faz a leitura estrita do corpo e valida o DTO com as regras declaradas na struct
+// - campos desconhecidos são recusados (ex: erro de digitação no nome do campo)
+// - corpo maior que maxBodySize retorna 413
+// - corpo vazio é tratado como objeto vazio, as regras de required decidem se é aceito
+func decodeJSON(w http.ResponseWriter, r *http.Request, dst any) error {
+	r.Body = http.MaxBytesReader(w, r.Body, maxBodySize)
+
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+
+	if err := decoder.Decode(dst); err != nil && err != io.EOF {
+		return decodeError(err)
+	}
+
+	// apenas um objeto JSON por requisição
+	if decoder.More() {
+		return response.BadRequest("invalid_json", "request body must contain a single JSON object")
+	}
+
+	return validation.Struct(dst)
+}
+
+func decodeError(err error) error {
+	var maxBytesErr *http.MaxBytesError
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+
+	switch {
+	case errors.As(err, &maxBytesErr):
+		return response.NewError(http.StatusRequestEntityTooLarge, "request_too_large",
+			fmt.Sprintf("request body must not exceed %d bytes", maxBytesErr.Limit))
+	case errors.As(err, &syntaxErr):
+		return response.InvalidJSON(fmt.Errorf("malformed JSON at position %d", syntaxErr.Offset))
+	case errors.As(err, &typeErr):
+		return validation.Errors{{
+			Field:   typeErr.Field,
+			Rule:    "type",
+			Message: "must be of type " + typeErr.Type.String(),
+		}}
+	case strings.HasPrefix(err.Error(), "json: unknown field "):
+		// o encoding/json não exporta um tipo para esse erro
+		field := strings.Trim(strings.TrimPrefix(err.Error(), "json: unknown field "), `"`)
+		return validation.Errors{{Field: field, Rule: "unknown", Message: "is not a recognized field"}}
+	}
+
+	return response.InvalidJSON(err)
+}
@@ -0,0 +1,36 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/j-ordep/gateway/go-gateway/internal/service"
+	"github.com/j-ordep/gateway/go-gateway/internal/web/response"
+)
+
+// HealthHandler atende as probes do orquestrador (sem apiKey e fora do rate limit)
+type HealthHandler struct {
+	healthService *service.HealthService
+}
+
+func NewHealthHandler(healthService *service.HealthService) *HealthHandler {
+	return &HealthHandler{healthService: healthService}
+}
+
+// Liveness responde 200 enquanto o processo estiver de pé, sem consultar dependências
+func (h *HealthHandler) Liveness(w http.ResponseWriter, r *http.Request) {
+	response.JSON(w, http.StatusOK, h.healthService.Liveness())
+}
+
+// Readiness responde 503 quando alguma dependência está fora ou durante o graceful shutdown
+func (h *HealthHandler) Readiness(w http.ResponseWriter, r *http.Request) {
+	output := h.healthService.Readiness(r.Context())
+
+	status := http.StatusOK
+	if output.Status != service.HealthStatusOK {
+		status = http.StatusServiceUnavailable
+	}
+
+	// a probe sempre precisa do estado atual
+	w.Header().Set("Cache-Control", "no-store")
+	response.JSON(w, status, output)
+}
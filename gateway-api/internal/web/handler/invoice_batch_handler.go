@@ -0,0 +1,76 @@
+package handler
+
+import (
+	"log/slog"
+	"net/http"
+
+	"github.com/j-ordep/gateway/go-gateway/internal/dto"
+	"github.com/j-ordep/gateway/go-gateway/internal/logging"
+	"github.com/j-ordep/gateway/go-gateway/internal/service"
+	"github.com/j-ordep/gateway/go-gateway/internal/web/request"
+	"github.com/j-ordep/gateway/go-gateway/internal/web/response"
+)
+
+type InvoiceBatchHandler struct {
+	service *service.InvoiceBatchService
+}
+
+func NewInvoiceBatchHandler(service *service.InvoiceBatchService) *InvoiceBatchHandler {
+	return &InvoiceBatchHandler{service: service}
+}
+
+// Create é o POST /invoices/batch: a resposta é 200 sempre que o lote foi processado,
+// o status de cada item (201 ou o erro que o POST /invoice retornaria) vem em results
+func (h *InvoiceBatchHandler) Create(w http.ResponseWriter, r *http.Request) {
+	principal, err := authenticated(r)
+	if err != nil {
+		response.Error(w, r, err)
+		return
+	}
+
+	var input dto.BatchInvoiceInput
+	err = decodeJSON(w, r, &input)
+	if err != nil {
+		response.Error(w, r, err)
+		return
+	}
+
+	clientIP := request.ClientIP(r)
+	for i := range input.Items {
+		input.Items[i].Invoice.ClientIP = clientIP
+	}
+
+	results, err := h.service.Create(r.Context(), principal, input)
+	if err != nil {
+		response.Error(w, r, err)
+		return
+	}
+
+	output := dto.BatchInvoiceOutput{Results: make([]dto.BatchInvoiceResultOutput, len(results))}
+	for i, result := range results {
+		item := dto.BatchInvoiceResultOutput{
+			Index:          i,
+			Status:         http.StatusCreated,
+			IdempotencyKey: input.Items[i].IdempotencyKey,
+			Replayed:       result.Replayed,
+			Invoice:        result.Output,
+		}
+
+		if result.Err != nil {
+			status, body := response.ToErrorBody(result.Err)
+			body.RequestID = logging.RequestID(r.Context())
+			if status >= http.StatusInternalServerError {
+				slog.ErrorContext(r.Context(), "erro interno em item do lote", "error", result.Err, "index", i)
+			}
+			item.Status = status
+			item.Error = body
+			output.Failed++
+		} else {
+			output.Created++
+		}
+
+		output.Results[i] = item
+	}
+
+	response.JSON(w, http.StatusOK, output)
+}
@@ -0,0 +1,131 @@
+package handler
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/j-ordep/gateway/go-gateway/internal/domain"
+	"github.com/j-ordep/gateway/go-gateway/internal/dto"
+	"github.com/j-ordep/gateway/go-gateway/internal/service"
+	"github.com/j-ordep/gateway/go-gateway/internal/web/response"
+)
+
+type InvoiceExportHandler struct {
+	service *service.InvoiceExportService
+}
+
+func NewInvoiceExportHandler(service *service.InvoiceExportService) *InvoiceExportHandler {
+	return &InvoiceExportHandler{service: service}
+}
+
+// Export é o GET /invoices/export: o arquivo é escrito na resposta conforme as faturas são lidas do banco
+// ex: /invoices/export?format=csv&status=approved&created_from=2025-01-01&created_to=2025-01-31&timezone=America/Sao_Paulo
+func (h *InvoiceExportHandler) Export(w http.ResponseWriter, r *http.Request) {
+	principal, err := authenticated(r)
+	if err != nil {
+		response.Error(w, r, err)
+		return
+	}
+
+	query := r.URL.Query()
+	export, err := h.service.Prepare(principal, dto.InvoiceExportInput{
+		Format:      query.Get("format"),
+		Status:      query.Get("status"),
+		PaymentType: query.Get("payment_type"),
+		CreatedFrom: query.Get("created_from"),
+		CreatedTo:   query.Get("created_to"),
+		Timezone:    query.Get("timezone"),
+	})
+	if err != nil {
+		response.Error(w, r, err)
+		return
+	}
+
+	setExportHeaders(w, export, "invoices-"+time.Now().UTC().Format("20060102T150405Z"))
+	w.WriteHeader(http.StatusOK)
+
+	if _, err := h.service.Write(r.Context(), export, w); err != nil {
+		// o 200 já foi enviado: aborta a conexão para o cliente não tomar o arquivo truncado como completo
+		slog.ErrorContext(r.Context(), "erro ao exportar faturas", "error", err)
+		panic(http.ErrAbortHandler)
+	}
+}
+
+// CreateJob agenda uma exportação assíncrona (202); acompanhe pelo GET /invoices/exports/{id}
+func (h *InvoiceExportHandler) CreateJob(w http.ResponseWriter, r *http.Request) {
+	principal, err := authenticated(r)
+	if err != nil {
+		response.Error(w, r, err)
+		return
+	}
+
+	var input dto.InvoiceExportInput
+	if err := decodeJSON(w, r, &input); err != nil {
+		response.Error(w, r, err)
+		return
+	}
+
+	output, err := h.service.CreateJob(r.Context(), principal, input)
+	if err != nil {
+		response.Error(w, r, err)
+		return
+	}
+
+	response.JSON(w, http.StatusAccepted, output)
+}
+
+func (h *InvoiceExportHandler) GetJob(w http.ResponseWriter, r *http.Request) {
+	principal, err := authenticated(r)
+	if err != nil {
+		response.Error(w, r, err)
+		return
+	}
+
+	output, err := h.service.GetJob(r.Context(), principal, chi.URLParam(r, "id"))
+	if err != nil {
+		response.Error(w, r, err)
+		return
+	}
+
+	response.JSON(w, http.StatusOK, output)
+}
+
+// Download devolve o arquivo gerado; 409 enquanto a exportação não estiver completed
+func (h *InvoiceExportHandler) Download(w http.ResponseWriter, r *http.Request) {
+	principal, err := authenticated(r)
+	if err != nil {
+		response.Error(w, r, err)
+		return
+	}
+
+	export, file, err := h.service.Download(r.Context(), principal, chi.URLParam(r, "id"))
+	if err != nil {
+		response.Error(w, r, err)
+		return
+	}
+	defer file.Close()
+
+	setExportHeaders(w, export, "invoices-"+export.ID)
+	w.WriteHeader(http.StatusOK)
+
+	if _, err := io.Copy(w, file); err != nil {
+		slog.ErrorContext(r.Context(), "erro ao enviar o arquivo da exportação", "error", err, "export_id", export.ID)
+	}
+}
+
+func setExportHeaders(w http.ResponseWriter, export *domain.InvoiceExport, filename string) {
+	header := w.Header()
+	switch export.Format {
+	case domain.ExportNDJSON:
+		header.Set("Content-Type", "application/x-ndjson")
+	default:
+		header.Set("Content-Type", "text/csv; charset=utf-8")
+	}
+	header.Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.%s"`, filename, export.Format))
+	// o arquivo tem dados financeiros da conta, não deve ficar em caches intermediários
+	header.Set("Cache-Control", "no-store")
+}
@@ -1,13 +1,18 @@
 package handler
 
 import (
-	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/go-chi/chi/v5"
-	"github.com/j-ordep/gateway/go-gateway/internal/domain"
 	"github.com/j-ordep/gateway/go-gateway/internal/dto"
 	"github.com/j-ordep/gateway/go-gateway/internal/service"
+	"github.com/j-ordep/gateway/go-gateway/internal/web/request"
+	"github.com/j-ordep/gateway/go-gateway/internal/web/response"
 )
 
 type InvoiceHandler struct {
@@ -19,82 +24,144 @@ func NewInvoiceHandler(service *service.InvoiceService) *InvoiceHandler {
 }
 
 func (h *InvoiceHandler) Create(w http.ResponseWriter, r *http.Request) {
+	principal, err := authenticated(r)
+	if err != nil {
+		response.Error(w, r, err)
+		return
+	}
+
 	var input dto.CreateInvoiceInput
-	err := json.NewDecoder(r.Body).Decode(&input)
+	err = decodeJSON(w, r, &input)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		response.Error(w, r, err)
 		return
 	}
 
-	input.APIKey = r.Header.Get("X-API-KEY")
+	input.ClientIP = request.ClientIP(r)
 
-	output, err := h.service.Create(input)
+	output, err := h.service.Create(r.Context(), principal, input)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		response.Error(w, r, err)
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusCreated)
-	json.NewEncoder(w).Encode(output)
+	response.JSON(w, http.StatusCreated, output)
 }
 
 func (h *InvoiceHandler) GetById(w http.ResponseWriter, r *http.Request) {
 	id := chi.URLParam(r, "id")
 	if id == "" {
-		http.Error(w, "ID is required", http.StatusBadRequest)
+		response.Error(w, r, response.BadRequest("missing_id", "ID is required"))
 		return
 	}
 
-	apiKey := r.Header.Get("X-API-KEY")
-	if apiKey == "" {
-		http.Error(w, "X-API-KEY is required", http.StatusBadRequest)
+	principal, err := authenticated(r)
+	if err != nil {
+		response.Error(w, r, err)
 		return
 	}
 
-	output, err := h.service.GetById(id, apiKey)
+	output, err := h.service.GetById(r.Context(), principal, id)
 	if err != nil {
-        switch err {
-        case domain.ErrInvoiceNotFound:
-            http.Error(w, err.Error(), http.StatusNotFound)
-            return
-        case domain.ErrAccountNotFound:
-            http.Error(w, err.Error(), http.StatusUnauthorized)
-            return
-        case domain.ErrUnauthorizedAccess:
-            http.Error(w, err.Error(), http.StatusForbidden)
-            return
-        default:
-            http.Error(w, err.Error(), http.StatusInternalServerError)
-            return
-        }
-    }
-
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(output)
+		response.Error(w, r, err)
+		return
+	}
+
+	response.JSON(w, http.StatusOK, output)
 }
 
 func (h *InvoiceHandler) ListByAccount(w http.ResponseWriter, r *http.Request) {
-	apiKey := r.Header.Get("X-API-KEY")
-	if apiKey == "" {
-		http.Error(w, "X-API-KEY is required", http.StatusUnauthorized)
+	principal, err := authenticated(r)
+	if err != nil {
+		response.Error(w, r, err)
+		return
+	}
+
+	filter, err := parseInvoiceFilter(r)
+	if err != nil {
+		response.Error(w, r, err)
 		return
 	}
 
-	output, err := h.service.ListByAccountApiKey(apiKey)
+	output, err := h.service.ListByAccount(r.Context(), principal, filter)
 	if err != nil {
-        switch err {
-        case domain.ErrAccountNotFound:
-            http.Error(w, err.Error(), http.StatusUnauthorized)
-            return
-        default:
-            http.Error(w, err.Error(), http.StatusInternalServerError)
-            return
-        }
-    }
-
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(output)
+		response.Error(w, r, err)
+		return
+	}
+
+	response.JSON(w, http.StatusOK, output)
+}
+
+// parseInvoiceFilter lê os filtros e a paginação da query string
+// metadata usa o formato metadata[chave]=valor, ex: /invoices?metadata[order_id]=123
+// datas em RFC3339, ex: /invoices?created_from=2025-01-01T00:00:00Z
+func parseInvoiceFilter(r *http.Request) (dto.InvoiceFilterInput, error) {
+	query := r.URL.Query()
+
+	filter := dto.InvoiceFilterInput{
+		ExternalReference: query.Get("external_reference"),
+		CustomerID:        query.Get("customer_id"),
+		Status:            query.Get("status"),
+		PaymentType:       query.Get("payment_type"),
+		CardLastDigits:    query.Get("card_last_digits"),
+		Cursor:            query.Get("cursor"),
+	}
+
+	var err error
+	if filter.MinAmount, err = parseFloatParam(query.Get("min_amount")); err != nil {
+		return filter, invalidQueryParam("min_amount", err)
+	}
+	if filter.MaxAmount, err = parseFloatParam(query.Get("max_amount")); err != nil {
+		return filter, invalidQueryParam("max_amount", err)
+	}
+	if filter.CreatedFrom, err = parseTimeParam(query.Get("created_from")); err != nil {
+		return filter, invalidQueryParam("created_from", err)
+	}
+	if filter.CreatedTo, err = parseTimeParam(query.Get("created_to")); err != nil {
+		return filter, invalidQueryParam("created_to", err)
+	}
+	if limit := query.Get("limit"); limit != "" {
+		if filter.Limit, err = strconv.Atoi(limit); err != nil || filter.Limit <= 0 {
+			return filter, invalidQueryParam("limit", errors.New("must be a positive integer"))
+		}
+	}
+
+	for param, values := range query {
+		if !strings.HasPrefix(param, "metadata[") || !strings.HasSuffix(param, "]") {
+			continue
+		}
+
+		key := strings.TrimSuffix(strings.TrimPrefix(param, "metadata["), "]")
+		if key == "" || len(values) == 0 {
+			continue
+		}
+
+		if filter.Metadata == nil {
+			filter.Metadata = make(map[string]string)
+		}
+		filter.Metadata[key] = values[0]
+	}
+
+	return filter, nil
+}
+
+// invalidQueryParam gera o erro 400 indicando qual parâmetro da query string é inválido
+func invalidQueryParam(param string, err error) error {
+	apiErr := response.BadRequest("invalid_query_parameter", fmt.Sprintf("invalid %s: %s", param, err))
+	apiErr.Details = map[string]string{"parameter": param}
+	return apiErr
+}
+
+func parseFloatParam(value string) (float64, error) {
+	if value == "" {
+		return 0, nil
+	}
+	return strconv.ParseFloat(value, 64)
+}
+
+func parseTimeParam(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	return time.Parse(time.RFC3339, value)
 }
@@ -0,0 +1,96 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/j-ordep/gateway/go-gateway/internal/dto"
+	"github.com/j-ordep/gateway/go-gateway/internal/service"
+	"github.com/j-ordep/gateway/go-gateway/internal/web/response"
+)
+
+type InvoiceStreamHandler struct {
+	service *service.InvoiceStreamService
+}
+
+func NewInvoiceStreamHandler(service *service.InvoiceStreamService) *InvoiceStreamHandler {
+	return &InvoiceStreamHandler{service: service}
+}
+
+// Stream é o GET /invoices/stream (Server-Sent Events) com as mudanças de status das faturas da conta
+// o EventSource do navegador reenvia o último id no header Last-Event-ID ao reconectar; ?last_event_id= serve para a primeira conexão
+func (h *InvoiceStreamHandler) Stream(w http.ResponseWriter, r *http.Request) {
+	principal, err := authenticated(r)
+	if err != nil {
+		response.Error(w, r, err)
+		return
+	}
+
+	input := dto.InvoiceStreamInput{InvoiceID: r.URL.Query().Get("invoice_id")}
+
+	lastEventID := r.Header.Get("Last-Event-ID")
+	if lastEventID == "" {
+		lastEventID = r.URL.Query().Get("last_event_id")
+	}
+	if lastEventID != "" {
+		if input.LastEventID, err = strconv.ParseInt(lastEventID, 10, 64); err != nil || input.LastEventID < 0 {
+			response.Error(w, r, invalidQueryParam("last_event_id", errors.New("must be a non-negative integer")))
+			return
+		}
+	}
+
+	writer := &sseWriter{w: w, controller: http.NewResponseController(w)}
+	if err := h.service.Stream(r.Context(), principal, input, writer); err != nil {
+		// depois do Open o status 200 já foi enviado; o erro só encerra o stream
+		if !writer.opened {
+			response.Error(w, r, err)
+		}
+		return
+	}
+}
+
+// sseWriter escreve no formato text/event-stream, com flush a cada evento
+type sseWriter struct {
+	w          http.ResponseWriter
+	controller *http.ResponseController
+	opened     bool
+}
+
+func (s *sseWriter) Open() error {
+	header := s.w.Header()
+	header.Set("Content-Type", "text/event-stream")
+	header.Set("Cache-Control", "no-cache")
+	header.Set("Connection", "keep-alive")
+	// desliga o buffer de proxies como o nginx
+	header.Set("X-Accel-Buffering", "no")
+	s.w.WriteHeader(http.StatusOK)
+	s.opened = true
+
+	// retry: intervalo de reconexão sugerido ao EventSource
+	if _, err := fmt.Fprint(s.w, "retry: 3000\n\n"); err != nil {
+		return err
+	}
+	return s.controller.Flush()
+}
+
+func (s *sseWriter) Send(event *dto.InvoiceEventOutput) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(s.w, "id: %d\nevent: invoice.status\ndata: %s\n\n", event.ID, data); err != nil {
+		return err
+	}
+	return s.controller.Flush()
+}
+
+// Heartbeat é um comentário SSE: mantém a conexão viva em proxies sem disparar eventos no cliente
+func (s *sseWriter) Heartbeat() error {
+	if _, err := fmt.Fprint(s.w, ": heartbeat\n\n"); err != nil {
+		return err
+	}
+	return s.controller.Flush()
+}
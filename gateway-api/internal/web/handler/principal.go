@@ -0,0 +1,19 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/j-ordep/gateway/go-gateway/internal/domain"
+	"github.com/j-ordep/gateway/go-gateway/internal/web/request"
+	"github.com/j-ordep/gateway/go-gateway/internal/web/response"
+)
+
+// authenticated lê a conta resolvida pelo AuthMiddleware
+// sem principal a rota foi registrada fora do grupo autenticado, a requisição é tratada como não autenticada
+func authenticated(r *http.Request) (domain.Principal, error) {
+	principal, ok := request.Principal(r.Context())
+	if !ok {
+		return domain.Principal{}, response.ErrMissingAPIKey
+	}
+	return principal, nil
+}
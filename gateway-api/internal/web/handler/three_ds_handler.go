@@ -0,0 +1,88 @@
+package handler
+
+import (
+	"html/template"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/j-ordep/gateway/go-gateway/internal/domain"
+	"github.com/j-ordep/gateway/go-gateway/internal/service"
+	"github.com/j-ordep/gateway/go-gateway/internal/web/response"
+)
+
+// página do ACS simulado, o comprador é redirecionado para cá pelo challenge_url
+var challengePage = template.Must(template.New("challenge").Parse(`<!DOCTYPE html>
+<html lang="pt-BR">
+<head><meta charset="utf-8"><title>Autenticação 3-D Secure</title></head>
+<body>
+	<h1>Autenticação da compra</h1>
+	{{if .Pending}}
+	<p>Digite o código enviado pelo seu banco para confirmar a compra.</p>
+	<form method="POST" action="/3ds/challenge/{{.ID}}">
+		<input type="text" name="code" autocomplete="one-time-code" autofocus>
+		<button type="submit">Confirmar</button>
+	</form>
+	{{else}}
+	<p>Este desafio já foi respondido.</p>
+	{{end}}
+</body>
+</html>`))
+
+// ThreeDSHandler serve o ACS simulado e o callback que retoma o processamento da fatura
+// rotas públicas: o comprador não tem a apiKey, o ID do desafio (UUID) funciona como credencial
+type ThreeDSHandler struct {
+	threeDSService *service.ThreeDSService
+	invoiceService *service.InvoiceService
+}
+
+func NewThreeDSHandler(threeDSService *service.ThreeDSService, invoiceService *service.InvoiceService) *ThreeDSHandler {
+	return &ThreeDSHandler{threeDSService: threeDSService, invoiceService: invoiceService}
+}
+
+func (h *ThreeDSHandler) ChallengePage(w http.ResponseWriter, r *http.Request) {
+	challenge, err := h.threeDSService.FindChallenge(chi.URLParam(r, "id"))
+	if err != nil {
+		response.Error(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	challengePage.Execute(w, struct {
+		ID      string
+		Pending bool
+	}{challenge.ID, challenge.Status == domain.ChallengeStatusPending})
+}
+
+// SubmitChallenge recebe o código digitado, já retoma o processamento da fatura
+// e redireciona de volta para a página do desafio (que passa a mostrar que ele foi respondido)
+func (h *ThreeDSHandler) SubmitChallenge(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		response.Error(w, r, response.BadRequest("invalid_form", err.Error()))
+		return
+	}
+
+	challenge, err := h.threeDSService.SubmitChallenge(chi.URLParam(r, "id"), r.PostForm.Get("code"))
+	if err != nil {
+		response.Error(w, r, err)
+		return
+	}
+
+	if _, err := h.invoiceService.CompleteThreeDS(r.Context(), challenge.ID); err != nil {
+		response.Error(w, r, err)
+		return
+	}
+
+	http.Redirect(w, r, "/3ds/challenge/"+challenge.ID, http.StatusSeeOther)
+}
+
+// Callback retoma o fluxo normal de aprovação com o resultado do desafio
+// é um POST porque muda a fatura; serve para repetir a conclusão quando o envio do desafio falhou no meio
+func (h *ThreeDSHandler) Callback(w http.ResponseWriter, r *http.Request) {
+	output, err := h.invoiceService.CompleteThreeDS(r.Context(), chi.URLParam(r, "id"))
+	if err != nil {
+		response.Error(w, r, err)
+		return
+	}
+
+	response.JSON(w, http.StatusOK, output)
+}
@@ -0,0 +1,142 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/j-ordep/gateway/go-gateway/internal/domain"
+	"github.com/j-ordep/gateway/go-gateway/internal/dto"
+	"github.com/j-ordep/gateway/go-gateway/internal/service"
+	"github.com/j-ordep/gateway/go-gateway/internal/web/response"
+)
+
+type WebhookHandler struct {
+	service *service.WebhookService
+}
+
+func NewWebhookHandler(service *service.WebhookService) *WebhookHandler {
+	return &WebhookHandler{service: service}
+}
+
+// Create devolve o secret da assinatura, que não aparece em nenhuma outra resposta
+func (h *WebhookHandler) Create(w http.ResponseWriter, r *http.Request) {
+	principal, err := authenticated(r)
+	if err != nil {
+		response.Error(w, r, err)
+		return
+	}
+
+	var input dto.CreateWebhookEndpointInput
+	if err := decodeJSON(w, r, &input); err != nil {
+		response.Error(w, r, err)
+		return
+	}
+
+	output, err := h.service.CreateEndpoint(r.Context(), principal, input)
+	if err != nil {
+		response.Error(w, r, err)
+		return
+	}
+
+	response.JSON(w, http.StatusCreated, output)
+}
+
+func (h *WebhookHandler) List(w http.ResponseWriter, r *http.Request) {
+	principal, err := authenticated(r)
+	if err != nil {
+		response.Error(w, r, err)
+		return
+	}
+
+	output, err := h.service.ListEndpoints(r.Context(), principal)
+	if err != nil {
+		response.Error(w, r, err)
+		return
+	}
+
+	response.JSON(w, http.StatusOK, output)
+}
+
+func (h *WebhookHandler) GetById(w http.ResponseWriter, r *http.Request) {
+	principal, err := authenticated(r)
+	if err != nil {
+		response.Error(w, r, err)
+		return
+	}
+
+	output, err := h.service.GetEndpoint(r.Context(), principal, chi.URLParam(r, "id"))
+	if err != nil {
+		response.Error(w, r, err)
+		return
+	}
+
+	response.JSON(w, http.StatusOK, output)
+}
+
+func (h *WebhookHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	principal, err := authenticated(r)
+	if err != nil {
+		response.Error(w, r, err)
+		return
+	}
+
+	if err := h.service.DeleteEndpoint(r.Context(), principal, chi.URLParam(r, "id")); err != nil {
+		response.Error(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ListDeliveries filtra por ?status= (pending, succeeded, failed) e ?limit=, mais recentes primeiro
+func (h *WebhookHandler) ListDeliveries(w http.ResponseWriter, r *http.Request) {
+	principal, err := authenticated(r)
+	if err != nil {
+		response.Error(w, r, err)
+		return
+	}
+
+	query := r.URL.Query()
+	input := dto.WebhookDeliveryFilterInput{Status: query.Get("status")}
+
+	switch domain.DeliveryStatus(input.Status) {
+	case "", domain.DeliveryPending, domain.DeliverySucceeded, domain.DeliveryFailed:
+	default:
+		response.Error(w, r, invalidQueryParam("status", errors.New("must be one of pending, succeeded, failed")))
+		return
+	}
+
+	if limit := query.Get("limit"); limit != "" {
+		if input.Limit, err = strconv.Atoi(limit); err != nil || input.Limit <= 0 {
+			response.Error(w, r, invalidQueryParam("limit", errors.New("must be a positive integer")))
+			return
+		}
+	}
+
+	output, err := h.service.ListDeliveries(r.Context(), principal, chi.URLParam(r, "id"), input)
+	if err != nil {
+		response.Error(w, r, err)
+		return
+	}
+
+	response.JSON(w, http.StatusOK, output)
+}
+
+// Redeliver faz uma nova tentativa na hora e responde com a entrega atualizada (mesmo se o endpoint falhar)
+func (h *WebhookHandler) Redeliver(w http.ResponseWriter, r *http.Request) {
+	principal, err := authenticated(r)
+	if err != nil {
+		response.Error(w, r, err)
+		return
+	}
+
+	output, err := h.service.Redeliver(r.Context(), principal, chi.URLParam(r, "id"), chi.URLParam(r, "deliveryId"))
+	if err != nil {
+		response.Error(w, r, err)
+		return
+	}
+
+	response.JSON(w, http.StatusOK, output)
+}
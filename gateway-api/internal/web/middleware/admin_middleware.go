@@ -0,0 +1,111 @@
+package middleware
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/j-ordep/gateway/go-gateway/internal/domain"
+	"github.com/j-ordep/gateway/go-gateway/internal/logging"
+	"github.com/j-ordep/gateway/go-gateway/internal/tracing"
+	"github.com/j-ordep/gateway/go-gateway/internal/web/request"
+	"github.com/j-ordep/gateway/go-gateway/internal/web/response"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// AdminMiddleware protege as rotas administrativas com tokens de operador (X-ADMIN-KEY),
+// separados das apiKeys dos lojistas; cada token identifica um operador, que vai para a auditoria
+type AdminMiddleware struct {
+	operators []operatorToken
+}
+
+// operatorToken guarda apenas o sha256 do token, o valor em si nunca fica na configuração
+type operatorToken struct {
+	operatorID string
+	hash       []byte
+}
+
+// ParseOperatorTokens lê a configuração no formato "operador:sha256hex,operador2:sha256hex"
+// o hash é gerado com: echo -n "$TOKEN" | sha256sum
+func ParseOperatorTokens(value string) (map[string]string, error) {
+	tokens := make(map[string]string)
+	for _, item := range strings.Split(value, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+
+		operatorID, hash, ok := strings.Cut(item, ":")
+		if !ok || operatorID == "" {
+			return nil, fmt.Errorf("invalid operator token %q: expected operator:sha256", item)
+		}
+		if decoded, err := hex.DecodeString(hash); err != nil || len(decoded) != sha256.Size {
+			return nil, fmt.Errorf("invalid hash for operator %q: expected 64 hex characters", operatorID)
+		}
+		if _, exists := tokens[operatorID]; exists {
+			return nil, fmt.Errorf("duplicated operator %q", operatorID)
+		}
+
+		tokens[operatorID] = strings.ToLower(hash)
+	}
+	return tokens, nil
+}
+
+// NewAdminMiddleware recebe operador -> sha256 do token (ver ParseOperatorTokens)
+func NewAdminMiddleware(tokens map[string]string) *AdminMiddleware {
+	m := &AdminMiddleware{}
+	for operatorID, hash := range tokens {
+		decoded, err := hex.DecodeString(hash)
+		if err != nil {
+			continue
+		}
+		m.operators = append(m.operators, operatorToken{operatorID: operatorID, hash: decoded})
+	}
+	return m
+}
+
+func (m *AdminMiddleware) Authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// sem operadores configurados as rotas admin ficam desabilitadas
+		if len(m.operators) == 0 {
+			response.Error(w, r, response.NewError(http.StatusForbidden, "admin_api_disabled", "admin api is disabled"))
+			return
+		}
+
+		adminKey := r.Header.Get("X-ADMIN-KEY")
+		if adminKey == "" {
+			response.Error(w, r, response.Unauthorized("missing_admin_key", "X-ADMIN-KEY is required"))
+			return
+		}
+
+		operator, ok := m.identify(adminKey)
+		if !ok {
+			response.Error(w, r, response.Unauthorized("invalid_admin_key", "invalid admin key"))
+			return
+		}
+
+		logging.SetOperatorID(r.Context(), operator.ID)
+		trace.SpanFromContext(r.Context()).SetAttributes(tracing.OperatorIDKey.String(operator.ID))
+
+		next.ServeHTTP(w, r.WithContext(request.WithOperator(r.Context(), operator)))
+	})
+}
+
+// identify compara o hash com todos os operadores em tempo constante, sem parar no primeiro que bater,
+// para não vazar por timing qual token (nem quantos) existem
+func (m *AdminMiddleware) identify(token string) (domain.Operator, bool) {
+	sum := sha256.Sum256([]byte(token))
+
+	var operator domain.Operator
+	found := false
+	for _, candidate := range m.operators {
+		if subtle.ConstantTimeCompare(sum[:], candidate.hash) == 1 {
+			operator = domain.Operator{ID: candidate.operatorID}
+			found = true
+		}
+	}
+	return operator, found
+}
@@ -4,7 +4,12 @@ import (
 	"net/http"
 
 	"github.com/j-ordep/gateway/go-gateway/internal/domain"
+	"github.com/j-ordep/gateway/go-gateway/internal/logging"
 	"github.com/j-ordep/gateway/go-gateway/internal/service"
+	"github.com/j-ordep/gateway/go-gateway/internal/tracing"
+	"github.com/j-ordep/gateway/go-gateway/internal/web/request"
+	"github.com/j-ordep/gateway/go-gateway/internal/web/response"
+	"go.opentelemetry.io/otel/trace"
 )
 
 type AuthMiddleware struct {
@@ -15,24 +20,61 @@ func NewAuthMiddleware(accountService *service.AccountService) *AuthMiddleware {
 	return &AuthMiddleware{accountService: accountService}
 }
 
+// Authenticate exige uma conta ativa: apiKey inválida vira 401, conta suspensa/encerrada vira 403 (mapeamento em response)
 func (m *AuthMiddleware) Authenticate(next http.Handler) http.Handler {
-	return  http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	return m.authenticate(next, true)
+}
+
+// Identify aceita contas suspensas ou encerradas, que ainda consultam e encerram o próprio cadastro
+func (m *AuthMiddleware) Identify(next http.Handler) http.Handler {
+	return m.authenticate(next, false)
+}
+
+func (m *AuthMiddleware) authenticate(next http.Handler, requireActive bool) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		apiKey := r.Header.Get("X-API-KEY")
 		if apiKey == "" {
-			http.Error(w, "X-API-KEY is required", http.StatusUnauthorized)
-			return 
+			response.Error(w, r, response.ErrMissingAPIKey)
+			return
 		}
 
-		_, err := m.accountService.FindByAPIKey(apiKey)
+		principal, err := m.accountService.Authenticate(apiKey)
 		if err != nil {
-			if err == domain.ErrUnauthorizedAccess {
-				http.Error(w, err.Error(), http.StatusUnauthorized)
-				return 
-			}
+			response.Error(w, r, err)
+			return
+		}
 
-			http.Error(w, err.Error(), http.StatusInternalServerError)
-			return 
+		if requireActive {
+			if err := principal.CanTransact(); err != nil {
+				response.Error(w, r, err)
+				return
+			}
 		}
-		next.ServeHTTP(w, r)
+
+		// conta autenticada aparece no access log, nos logs dos services e no span da requisição
+		logging.SetAccountID(r.Context(), principal.AccountID)
+		trace.SpanFromContext(r.Context()).SetAttributes(tracing.AccountIDKey.String(principal.AccountID))
+
+		next.ServeHTTP(w, r.WithContext(request.WithPrincipal(r.Context(), principal)))
 	})
-}
\ No newline at end of file
+}
+
+// RequireScope recusa com 403 as credenciais sem o escopo, deve ser usado depois de Authenticate/Identify
+func RequireScope(scope domain.Scope) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			principal, ok := request.Principal(r.Context())
+			if !ok {
+				response.Error(w, r, response.ErrMissingAPIKey)
+				return
+			}
+
+			if !principal.HasScope(scope) {
+				response.Error(w, r, domain.ErrInsufficientScope)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
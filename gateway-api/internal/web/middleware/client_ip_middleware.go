@@ -0,0 +1,24 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/j-ordep/gateway/go-gateway/internal/web/request"
+)
+
+// ClientIPMiddleware resolve o IP do cliente uma vez por requisição, para o rate limit, o controle de velocidade,
+// o tracing e os logs enxergarem o mesmo valor
+type ClientIPMiddleware struct {
+	trustedProxies request.TrustedProxies
+}
+
+func NewClientIPMiddleware(trustedProxies request.TrustedProxies) *ClientIPMiddleware {
+	return &ClientIPMiddleware{trustedProxies: trustedProxies}
+}
+
+func (m *ClientIPMiddleware) Handle(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ip := m.trustedProxies.ClientIP(r.RemoteAddr, r.Header.Values("X-Forwarded-For"))
+		next.ServeHTTP(w, r.WithContext(request.WithClientIP(r.Context(), ip)))
+	})
+}
@@ -0,0 +1,32 @@
+package middleware
+
+import (
+	"net/http"
+	"time"
+)
+
+// DeprecationMiddleware marca as rotas legadas (sem prefixo de versão) como depreciadas
+// - Deprecation: true (draft-ietf-httpapi-deprecation-header)
+// - Sunset: data em que a rota deixa de existir (RFC 8594)
+// - Link: aponta para a rota equivalente na versão atual
+type DeprecationMiddleware struct {
+	sunset        time.Time
+	successorBase string
+}
+
+// NewDeprecationMiddleware recebe a data de remoção (zero omite o Sunset) e o prefixo da versão sucessora, ex: /v1
+func NewDeprecationMiddleware(sunset time.Time, successorBase string) *DeprecationMiddleware {
+	return &DeprecationMiddleware{sunset: sunset, successorBase: successorBase}
+}
+
+func (m *DeprecationMiddleware) Handle(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Deprecation", "true")
+		if !m.sunset.IsZero() {
+			w.Header().Set("Sunset", m.sunset.UTC().Format(http.TimeFormat))
+		}
+		w.Header().Set("Link", "<"+m.successorBase+r.URL.Path+`>; rel="successor-version"`)
+
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,135 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/j-ordep/gateway/go-gateway/internal/service"
+	"github.com/j-ordep/gateway/go-gateway/internal/web/request"
+	"github.com/j-ordep/gateway/go-gateway/internal/web/response"
+)
+
+const (
+	maxIdempotencyKeyLength = 255
+	maxIdempotentBodySize   = 1 << 20 // 1MB
+)
+
+// IdempotencyMiddleware evita cobranças duplicadas quando o cliente repete uma requisição (ex: timeout)
+// a primeira resposta é gravada e devolvida em todas as tentativas com o mesmo Idempotency-Key
+// roda depois da autenticação, as chaves são escopadas pela conta do principal
+type IdempotencyMiddleware struct {
+	idempotencyService *service.IdempotencyService
+	versionPrefix      string
+}
+
+// NewIdempotencyMiddleware recebe o prefixo da versão (ex: /v1), removido da rota no hash
+// para que a rota legada e a /v1 compartilhem as chaves
+func NewIdempotencyMiddleware(idempotencyService *service.IdempotencyService, versionPrefix string) *IdempotencyMiddleware {
+	return &IdempotencyMiddleware{idempotencyService: idempotencyService, versionPrefix: versionPrefix}
+}
+
+func (m *IdempotencyMiddleware) Handle(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get("Idempotency-Key")
+		principal, authenticated := request.Principal(r.Context())
+
+		// header opcional; fora de uma rota autenticada não há conta para escopar a chave
+		if key == "" || !authenticated {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if len(key) > maxIdempotencyKeyLength {
+			response.Error(w, r, response.BadRequest("invalid_idempotency_key", "Idempotency-Key is too long"))
+			return
+		}
+
+		// lê um byte além do limite: um corpo truncado teria outro hash e chegaria cortado ao handler
+		body, err := io.ReadAll(io.LimitReader(r.Body, maxIdempotentBodySize+1))
+		if err != nil {
+			response.Error(w, r, response.BadRequest("invalid_body", err.Error()))
+			return
+		}
+		if len(body) > maxIdempotentBodySize {
+			response.Error(w, r, response.NewError(http.StatusRequestEntityTooLarge, "request_too_large",
+				fmt.Sprintf("request body must not exceed %d bytes", maxIdempotentBodySize)))
+			return
+		}
+		// devolve o body para o handler
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		record, replay, err := m.idempotencyService.Begin(principal.AccountID, key, m.requestHash(r, body))
+		if err != nil {
+			response.Error(w, r, err)
+			return
+		}
+
+		if replay {
+			w.Header().Set("Content-Type", "application/json")
+			w.Header().Set("Idempotent-Replayed", "true")
+			w.WriteHeader(record.ResponseCode)
+			w.Write(record.ResponseBody)
+			return
+		}
+
+		recorder := &responseRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(recorder, r)
+
+		// erro interno não é gravado: o cliente pode tentar de novo com a mesma chave
+		// a resposta já foi enviada, falhas ao liberar/concluir ficam no log (a chave se resolve pelo locked_until)
+		if recorder.status >= http.StatusInternalServerError {
+			if err := m.idempotencyService.Release(record); err != nil {
+				slog.ErrorContext(r.Context(), "erro ao liberar idempotency key", "error", err, "key", key)
+			}
+			return
+		}
+
+		if err := m.idempotencyService.Complete(record, recorder.status, recorder.body.Bytes()); err != nil {
+			slog.ErrorContext(r.Context(), "erro ao concluir idempotency key", "error", err, "key", key)
+		}
+	})
+}
+
+// requestHash identifica a requisição (método + rota + parâmetros + body) para detectar reuso da chave
+// usa o padrão da rota sem o prefixo de versão: POST /invoice e POST /v1/invoice são a mesma requisição
+func (m *IdempotencyMiddleware) requestHash(r *http.Request, body []byte) string {
+	hash := sha256.New()
+	routeContext := chi.RouteContext(r.Context())
+	if routeContext == nil || routeContext.RoutePattern() == "" {
+		hash.Write([]byte(r.Method + " " + r.URL.Path + "\n"))
+	} else {
+		hash.Write([]byte(r.Method + " " + strings.TrimPrefix(routeContext.RoutePattern(), m.versionPrefix) + "\n"))
+		// o "*" é o curinga da montagem do subrouter (/v1/*), não um parâmetro da rota
+		for i, key := range routeContext.URLParams.Keys {
+			if key != "*" {
+				hash.Write([]byte(key + "=" + routeContext.URLParams.Values[i] + "\n"))
+			}
+		}
+	}
+	hash.Write(body)
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// responseRecorder repassa a resposta ao cliente e guarda uma cópia do status e do body
+type responseRecorder struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (r *responseRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *responseRecorder) Write(b []byte) (int, error) {
+	r.body.Write(b)
+	return r.ResponseWriter.Write(b)
+}
@@ -0,0 +1,23 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/j-ordep/gateway/go-gateway/internal/metrics"
+)
+
+// Metrics conta as requisições e mede a latência por método, padrão de rota e status
+func Metrics(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		writer := &statusWriter{ResponseWriter: w, status: http.StatusOK}
+
+		next.ServeHTTP(writer, r)
+
+		labels := []string{r.Method, routePattern(r), strconv.Itoa(writer.status)}
+		metrics.HTTPRequestsTotal.WithLabelValues(labels...).Inc()
+		metrics.HTTPRequestDuration.WithLabelValues(labels...).Observe(time.Since(start).Seconds())
+	})
+}
@@ -0,0 +1,74 @@
+package middleware
+
+import (
+	"log/slog"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/j-ordep/gateway/go-gateway/internal/domain"
+	"github.com/j-ordep/gateway/go-gateway/internal/logging"
+	"github.com/j-ordep/gateway/go-gateway/internal/service"
+	"github.com/j-ordep/gateway/go-gateway/internal/web/request"
+	"github.com/j-ordep/gateway/go-gateway/internal/web/response"
+)
+
+// RateLimitMiddleware limita as requisições por apiKey (token bucket com o limite do plano da conta)
+// sem apiKey, ou com apiKey inválida, o limite é aplicado por IP
+type RateLimitMiddleware struct {
+	accountService   *service.AccountService
+	rateLimitService *service.RateLimitService
+}
+
+func NewRateLimitMiddleware(accountService *service.AccountService, rateLimitService *service.RateLimitService) *RateLimitMiddleware {
+	return &RateLimitMiddleware{accountService: accountService, rateLimitService: rateLimitService}
+}
+
+func (m *RateLimitMiddleware) Handle(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		result, enabled, err := m.take(r)
+		if err != nil {
+			// falha no store não derruba a API: a requisição segue sem limite
+			slog.ErrorContext(r.Context(), "erro ao consultar o rate limit", "error", err, "path", r.URL.Path)
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if enabled {
+			// headers do draft-ietf-httpapi-ratelimit-headers
+			w.Header().Set("RateLimit-Limit", strconv.Itoa(result.Limit))
+			w.Header().Set("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+			w.Header().Set("RateLimit-Reset", strconv.Itoa(ceilSeconds(result.ResetAfter)))
+		}
+
+		if !result.Allowed {
+			w.Header().Set("Retry-After", strconv.Itoa(ceilSeconds(result.RetryAfter)))
+			response.Error(w, r, domain.ErrRateLimitExceeded)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (m *RateLimitMiddleware) take(r *http.Request) (domain.RateLimitResult, bool, error) {
+	if apiKey := r.Header.Get("X-API-KEY"); apiKey != "" {
+		// passa pelo cache de apiKeys, a autenticação logo depois reaproveita a mesma entrada
+		principal, err := m.accountService.Authenticate(apiKey)
+		if err == nil {
+			logging.SetAccountID(r.Context(), principal.AccountID)
+			return m.rateLimitService.AllowAccount(principal.AccountID, string(principal.Tier))
+		}
+		if err != domain.ErrInvalidAPIKey {
+			return domain.RateLimitResult{}, false, err
+		}
+	}
+
+	return m.rateLimitService.AllowIP(request.ClientIP(r))
+}
+
+// os headers trabalham com segundos inteiros, arredondando para cima para o cliente não voltar cedo demais
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
@@ -0,0 +1,41 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/j-ordep/gateway/go-gateway/internal/logging"
+)
+
+const maxRequestIDLength = 128
+
+// RequestID aceita o X-Request-ID do cliente (ou do proxy) e gera um novo quando ausente ou inválido
+// o ID vai para o contexto (logs, envelope de erro, headers do kafka) e volta no header da resposta
+func RequestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestID := r.Header.Get("X-Request-ID")
+		if !ValidRequestID(requestID) {
+			requestID = uuid.NewString()
+		}
+
+		w.Header().Set("X-Request-ID", requestID)
+		next.ServeHTTP(w, r.WithContext(logging.WithRequestID(r.Context(), requestID)))
+	})
+}
+
+// ValidRequestID restringe o ID a caracteres seguros para logs e headers (sem espaços/quebras de linha)
+// também usado pela API gRPC para a metadata x-request-id
+func ValidRequestID(requestID string) bool {
+	if requestID == "" || len(requestID) > maxRequestIDLength {
+		return false
+	}
+	for _, c := range requestID {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+		case c == '-', c == '_', c == '.', c == ':':
+		default:
+			return false
+		}
+	}
+	return true
+}
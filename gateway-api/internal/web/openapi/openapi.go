@@ -0,0 +1,43 @@
+package openapi
+
+import (
+	_ "embed"
+	"net/http"
+)
+
+// Spec é o contrato da API (OpenAPI 3.1), mantido à mão junto com as rotas e DTOs
+// qualquer divergência é apontada pelo teste de contrato em internal/web/server
+//
+//go:embed openapi.json
+var Spec []byte
+
+// página do Swagger UI carregada via CDN, apenas lê o /openapi.json
+const docsPage = `<!DOCTYPE html>
+<html lang="pt-BR">
+<head>
+	<meta charset="utf-8">
+	<title>Go Gateway API</title>
+	<link rel="stylesheet" href="https://unpkg.com/swagger-ui-dist@5/swagger-ui.css">
+</head>
+<body>
+	<div id="swagger-ui"></div>
+	<script src="https://unpkg.com/swagger-ui-dist@5/swagger-ui-bundle.js" crossorigin></script>
+	<script>
+		window.onload = () => {
+			window.ui = SwaggerUIBundle({ url: "/openapi.json", dom_id: "#swagger-ui" });
+		};
+	</script>
+</body>
+</html>`
+
+func SpecHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(Spec)
+}
+
+func DocsHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte(docsPage))
+}
@@ -0,0 +1,111 @@
+package request
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+)
+
+// TrustedProxies são os proxies/load balancers (TRUSTED_PROXIES) cujo X-Forwarded-For é aceito
+type TrustedProxies []netip.Prefix
+
+// ParseTrustedProxies lê IPs ou CIDRs separados por vírgula; vazio não confia em nenhum proxy
+func ParseTrustedProxies(value string) (TrustedProxies, error) {
+	var proxies TrustedProxies
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		if strings.Contains(entry, "/") {
+			prefix, err := netip.ParsePrefix(entry)
+			if err != nil {
+				return nil, fmt.Errorf("invalid trusted proxy %q: %w", entry, err)
+			}
+			proxies = append(proxies, prefix.Masked())
+			continue
+		}
+
+		addr, err := netip.ParseAddr(entry)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted proxy %q: %w", entry, err)
+		}
+		addr = addr.Unmap()
+		proxies = append(proxies, netip.PrefixFrom(addr, addr.BitLen()))
+	}
+	return proxies, nil
+}
+
+func (p TrustedProxies) contains(addr netip.Addr) bool {
+	for _, prefix := range p {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// ClientIP resolve o IP do cliente a partir do endereço da conexão (RemoteAddr ou peer do gRPC)
+// o X-Forwarded-For só vale quando a conexão vem de um proxy confiável: a lista é lida da direita para a esquerda
+// e o primeiro hop não confiável é o cliente (as entradas à esquerda dele podem ter sido forjadas)
+func (p TrustedProxies) ClientIP(remoteAddr string, forwardedFor []string) string {
+	host := hostOf(remoteAddr)
+	addr, err := netip.ParseAddr(host)
+	if err != nil || !p.contains(addr.Unmap()) {
+		return host
+	}
+
+	var hops []string
+	for _, header := range forwardedFor {
+		hops = append(hops, strings.Split(header, ",")...)
+	}
+
+	client := host
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop, err := netip.ParseAddr(strings.TrimSpace(hops[i]))
+		if err != nil {
+			// entrada inválida: fica com o último hop que um proxy confiável informou
+			break
+		}
+		hop = hop.Unmap()
+		client = hop.String()
+		if !p.contains(hop) {
+			break
+		}
+	}
+	return client
+}
+
+func hostOf(remoteAddr string) string {
+	host, _, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		return remoteAddr
+	}
+	return host
+}
+
+type clientIPKey struct{}
+
+// WithClientIP guarda o IP resolvido no contexto (chamado pelo ClientIPMiddleware e pelo interceptor gRPC)
+func WithClientIP(ctx context.Context, ip string) context.Context {
+	return context.WithValue(ctx, clientIPKey{}, ip)
+}
+
+// ClientIPFromContext retorna o IP resolvido; ok é false se nenhum middleware o resolveu
+func ClientIPFromContext(ctx context.Context) (string, bool) {
+	ip, ok := ctx.Value(clientIPKey{}).(string)
+	return ip, ok
+}
+
+// ClientIP retorna o IP resolvido pelo ClientIPMiddleware
+// sem o middleware vale o endereço da conexão, nunca o X-Forwarded-For
+func ClientIP(r *http.Request) string {
+	if ip, ok := ClientIPFromContext(r.Context()); ok {
+		return ip
+	}
+	return hostOf(r.RemoteAddr)
+}
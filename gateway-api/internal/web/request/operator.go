@@ -0,0 +1,20 @@
+package request
+
+import (
+	"context"
+
+	"github.com/j-ordep/gateway/go-gateway/internal/domain"
+)
+
+type operatorKey struct{}
+
+// WithOperator guarda o operador autenticado no contexto da requisição (chamado pelo AdminMiddleware)
+func WithOperator(ctx context.Context, operator domain.Operator) context.Context {
+	return context.WithValue(ctx, operatorKey{}, operator)
+}
+
+// Operator retorna o operador autenticado; ok é false fora das rotas /admin
+func Operator(ctx context.Context) (domain.Operator, bool) {
+	operator, ok := ctx.Value(operatorKey{}).(domain.Operator)
+	return operator, ok
+}
@@ -0,0 +1,20 @@
+package request
+
+import (
+	"context"
+
+	"github.com/j-ordep/gateway/go-gateway/internal/domain"
+)
+
+type principalKey struct{}
+
+// WithPrincipal guarda a conta autenticada no contexto da requisição (chamado pelo AuthMiddleware)
+func WithPrincipal(ctx context.Context, principal domain.Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, principal)
+}
+
+// Principal retorna a conta autenticada; ok é false em rotas fora do grupo autenticado
+func Principal(ctx context.Context) (domain.Principal, bool) {
+	principal, ok := ctx.Value(principalKey{}).(domain.Principal)
+	return principal, ok
+}
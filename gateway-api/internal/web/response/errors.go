@@ -0,0 +1,190 @@
+package response
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+
+	"github.com/j-ordep/gateway/go-gateway/internal/domain"
+	"github.com/j-ordep/gateway/go-gateway/internal/logging"
+	"github.com/j-ordep/gateway/go-gateway/internal/validation"
+	"github.com/lib/pq"
+)
+
+// ErrorBody é o envelope padrão de erro da API
+// code é estável (clientes podem tratar por ele), message é apenas informativa
+type ErrorBody struct {
+	Code      string `json:"code"`
+	Message   string `json:"message"`
+	Details   any    `json:"details,omitempty"`
+	RequestID string `json:"request_id,omitempty"`
+}
+
+// APIError representa erros da camada HTTP (header ausente, JSON inválido, query inválida...)
+// erros de domínio não precisam ser convertidos, o mapeamento abaixo cuida deles
+type APIError struct {
+	Status  int
+	Code    string
+	Message string
+	Details any
+}
+
+func (e *APIError) Error() string {
+	return e.Message
+}
+
+func NewError(status int, code, message string) *APIError {
+	return &APIError{Status: status, Code: code, Message: message}
+}
+
+func BadRequest(code, message string) *APIError {
+	return NewError(http.StatusBadRequest, code, message)
+}
+
+func Unauthorized(code, message string) *APIError {
+	return NewError(http.StatusUnauthorized, code, message)
+}
+
+// ErrMissingAPIKey é usado por handlers e middlewares quando o header X-API-KEY não é enviado
+var ErrMissingAPIKey = Unauthorized("missing_api_key", "X-API-KEY header is required")
+
+// InvalidJSON embrulha erros de decodificação do corpo da requisição
+func InvalidJSON(err error) *APIError {
+	return BadRequest("invalid_json", err.Error())
+}
+
+// mapeamento central: erro de domínio -> status HTTP + código estável
+var domainErrors = []struct {
+	err    error
+	status int
+	code   string
+}{
+	// 400
+	{domain.ErrInvalidCursor, http.StatusBadRequest, "invalid_cursor"},
+
+	// 401
+	{domain.ErrInvalidAPIKey, http.StatusUnauthorized, "invalid_api_key"},
+
+	// 403
+	{domain.ErrUnauthorizedAccess, http.StatusForbidden, "forbidden"},
+	{domain.ErrAccountSuspended, http.StatusForbidden, "account_suspended"},
+	{domain.ErrAccountClosed, http.StatusForbidden, "account_closed"},
+	{domain.ErrInsufficientScope, http.StatusForbidden, "insufficient_scope"},
+
+	// 404
+	{domain.ErrAccountNotFound, http.StatusNotFound, "account_not_found"},
+	{domain.ErrInvoiceNotFound, http.StatusNotFound, "invoice_not_found"},
+	{domain.ErrCustomerNotFound, http.StatusNotFound, "customer_not_found"},
+	{domain.ErrPaymentMethodNotFound, http.StatusNotFound, "payment_method_not_found"},
+	{domain.ErrThreeDSChallengeNotFound, http.StatusNotFound, "three_ds_challenge_not_found"},
+	{domain.ErrWebhookEndpointNotFound, http.StatusNotFound, "webhook_endpoint_not_found"},
+	{domain.ErrWebhookDeliveryNotFound, http.StatusNotFound, "webhook_delivery_not_found"},
+	{domain.ErrInvoiceExportNotFound, http.StatusNotFound, "invoice_export_not_found"},
+
+	// 409
+	{domain.ErrDuplicatedAPIKey, http.StatusConflict, "api_key_already_exists"},
+	{domain.ErrDuplicatedEmail, http.StatusConflict, "email_already_exists"},
+	{domain.ErrDuplicatedExternalReference, http.StatusConflict, "external_reference_already_exists"},
+	{domain.ErrInvalidStatus, http.StatusConflict, "invalid_status"},
+	{domain.ErrInvalidAccountStatus, http.StatusConflict, "invalid_account_status"},
+	{domain.ErrAccountHasBalance, http.StatusConflict, "account_has_balance"},
+	{domain.ErrAccountHasPendingInvoices, http.StatusConflict, "account_has_pending_invoices"},
+	{domain.ErrThreeDSChallengeCompleted, http.StatusConflict, "three_ds_challenge_completed"},
+	{domain.ErrThreeDSChallengePending, http.StatusConflict, "three_ds_challenge_pending"},
+	{domain.ErrIdempotencyRequestInProgress, http.StatusConflict, "idempotency_request_in_progress"},
+	{domain.ErrInvoiceExportNotReady, http.StatusConflict, "invoice_export_not_ready"},
+
+	// 422
+	{domain.ErrInvalidAmount, http.StatusUnprocessableEntity, "invalid_amount"},
+	{domain.ErrInvalidMetadata, http.StatusUnprocessableEntity, "invalid_metadata"},
+	{domain.ErrInvalidCard, http.StatusUnprocessableEntity, "invalid_card"},
+	{domain.ErrInvalidCustomer, http.StatusUnprocessableEntity, "invalid_customer"},
+	{domain.ErrInvalidThreeDSRule, http.StatusUnprocessableEntity, "invalid_three_ds_rule"},
+	{domain.ErrIdempotencyKeyReused, http.StatusUnprocessableEntity, "idempotency_key_reused"},
+	{domain.ErrInvalidAccountTier, http.StatusUnprocessableEntity, "invalid_account_tier"},
+	{domain.ErrInvalidWebhookURL, http.StatusUnprocessableEntity, "invalid_webhook_url"},
+	{domain.ErrInvalidWebhookEventType, http.StatusUnprocessableEntity, "invalid_webhook_event_type"},
+	{domain.ErrInvalidExportFormat, http.StatusUnprocessableEntity, "invalid_export_format"},
+	{domain.ErrInvalidTimezone, http.StatusUnprocessableEntity, "invalid_timezone"},
+	{domain.ErrInvalidExportPeriod, http.StatusUnprocessableEntity, "invalid_export_period"},
+	{domain.ErrInvalidReportGranularity, http.StatusUnprocessableEntity, "invalid_report_granularity"},
+	{domain.ErrInvalidReportPeriod, http.StatusUnprocessableEntity, "invalid_report_period"},
+	{domain.ErrInvalidBatchSize, http.StatusUnprocessableEntity, "invalid_batch_size"},
+
+	// 429
+	{domain.ErrVelocityLimitExceeded, http.StatusTooManyRequests, "velocity_limit_exceeded"},
+	{domain.ErrRateLimitExceeded, http.StatusTooManyRequests, "rate_limit_exceeded"},
+}
+
+// Error converte qualquer erro no envelope JSON com o status correto
+// erros desconhecidos viram 500 sem expor a mensagem original (que fica apenas no log)
+func Error(w http.ResponseWriter, r *http.Request, err error) {
+	status, body := ToErrorBody(err)
+	// o mesmo ID do header X-Request-ID e dos logs, para o cliente reportar o erro
+	body.RequestID = logging.RequestID(r.Context())
+
+	if status >= http.StatusInternalServerError {
+		slog.ErrorContext(r.Context(), "erro interno na requisição",
+			"error", err,
+			"method", r.Method,
+			"path", r.URL.Path)
+	}
+
+	JSON(w, status, body)
+}
+
+// ToErrorBody resolve o status HTTP e o código estável do erro
+// a API gRPC usa o mesmo mapeamento, convertendo o status HTTP no código gRPC equivalente
+func ToErrorBody(err error) (int, ErrorBody) {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.Status, ErrorBody{Code: apiErr.Code, Message: apiErr.Message, Details: apiErr.Details}
+	}
+
+	var validationErrs validation.Errors
+	if errors.As(err, &validationErrs) {
+		return http.StatusUnprocessableEntity, ErrorBody{Code: "validation_failed", Message: "request validation failed", Details: validationErrs}
+	}
+
+	for _, mapped := range domainErrors {
+		if errors.Is(err, mapped.err) {
+			return mapped.status, ErrorBody{Code: mapped.code, Message: mapped.err.Error()}
+		}
+	}
+
+	// violações de constraint que o repositório não traduziu para um erro de domínio
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		switch pqErr.Code {
+		case "22P02": // invalid_text_representation, ex: ID da rota que não é um UUID
+			return http.StatusBadRequest, ErrorBody{
+				Code:    "invalid_parameter",
+				Message: "a parameter has an invalid format",
+			}
+		case "23505": // unique_violation
+			return http.StatusConflict, ErrorBody{
+				Code:    "conflict",
+				Message: "resource already exists",
+				Details: map[string]string{"constraint": pqErr.Constraint},
+			}
+		case "23503": // foreign_key_violation
+			return http.StatusUnprocessableEntity, ErrorBody{
+				Code:    "invalid_reference",
+				Message: "referenced resource does not exist",
+				Details: map[string]string{"constraint": pqErr.Constraint},
+			}
+		}
+	}
+
+	return http.StatusInternalServerError, ErrorBody{Code: "internal_error", Message: http.StatusText(http.StatusInternalServerError)}
+}
+
+// NotFound e MethodNotAllowed substituem as respostas padrão do router (texto puro)
+func NotFound(w http.ResponseWriter, r *http.Request) {
+	Error(w, r, NewError(http.StatusNotFound, "route_not_found", fmt.Sprintf("route %s %s not found", r.Method, r.URL.Path)))
+}
+
+func MethodNotAllowed(w http.ResponseWriter, r *http.Request) {
+	Error(w, r, NewError(http.StatusMethodNotAllowed, "method_not_allowed", fmt.Sprintf("method %s not allowed on %s", r.Method, r.URL.Path)))
+}
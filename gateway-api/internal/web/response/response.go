@@ -0,0 +1,13 @@
+package response
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// JSON escreve o status e o corpo em JSON, usado por todos os handlers
+func JSON(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
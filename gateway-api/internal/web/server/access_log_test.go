@@ -0,0 +1,63 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/j-ordep/gateway/go-gateway/internal/logging"
+)
+
+func TestRequestIDAndAccessLog(t *testing.T) {
+	var logs bytes.Buffer
+	previous := slog.Default()
+	slog.SetDefault(logging.NewLogger(&logs, "json", "info"))
+	defer slog.SetDefault(previous)
+
+	srv := newTestServer()
+
+	// ID enviado pelo cliente volta no header, no envelope de erro e no access log
+	recorder := httptest.NewRecorder()
+	req := httptest.NewRequest("GET", "/v1/invoice/inexistente", nil)
+	req.Header.Set("X-Request-ID", "req-123")
+	srv.router.ServeHTTP(recorder, req)
+
+	if got := recorder.Header().Get("X-Request-ID"); got != "req-123" {
+		t.Errorf("X-Request-ID = %q, esperado req-123", got)
+	}
+	var body map[string]any
+	json.Unmarshal(recorder.Body.Bytes(), &body)
+	if body["request_id"] != "req-123" {
+		t.Errorf("request_id = %v, esperado req-123", body["request_id"])
+	}
+
+	var line map[string]any
+	if err := json.Unmarshal(bytes.TrimSpace(logs.Bytes()), &line); err != nil {
+		t.Fatalf("access log não é uma linha JSON: %v (%s)", err, logs.String())
+	}
+	expected := map[string]any{"msg": "requisição http", "method": "GET", "route": "/v1/invoice/{id}", "status": float64(http.StatusUnauthorized), "request_id": "req-123"}
+	for key, value := range expected {
+		if line[key] != value {
+			t.Errorf("access log %s = %v, esperado %v", key, line[key], value)
+		}
+	}
+	for _, key := range []string{"latency", "bytes"} {
+		if _, ok := line[key]; !ok {
+			t.Errorf("access log sem o campo %s", key)
+		}
+	}
+
+	// sem header (ou com valor inválido) o gateway gera um UUID
+	recorder = httptest.NewRecorder()
+	req = httptest.NewRequest("GET", "/openapi.json", nil)
+	req.Header.Set("X-Request-ID", "valor inválido\r\n")
+	srv.router.ServeHTTP(recorder, req)
+
+	if _, err := uuid.Parse(recorder.Header().Get("X-Request-ID")); err != nil {
+		t.Errorf("X-Request-ID gerado = %q, esperado UUID", recorder.Header().Get("X-Request-ID"))
+	}
+}
@@ -0,0 +1,129 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/j-ordep/gateway/go-gateway/internal/domain"
+	"github.com/j-ordep/gateway/go-gateway/internal/dto"
+	"github.com/j-ordep/gateway/go-gateway/internal/service"
+)
+
+// o relatório soma as faturas do dia por status, tipo de pagamento e bandeira, com taxa de aprovação e ticket médio
+func TestAccountReport(t *testing.T) {
+	srv := newTestServer()
+	ctx := context.Background()
+
+	client := newTestClient(t, srv)
+	apiKey := client.createAccount("Relatório", "report@example.com")
+
+	// acima de 10000 a fatura fica pendente, a decisão vem do antifraude
+	for _, invoice := range []struct {
+		amount float64
+		status domain.Status
+	}{
+		{15000, domain.StatusApproved},
+		{20000, domain.StatusApproved},
+		{12000, domain.StatusRejected},
+		{11000, domain.StatusPending},
+	} {
+		created := decodeBody[map[string]any](client.do("POST", "/v1/invoice", apiKey, invoiceInput(invoice.amount, nil), http.StatusCreated))
+		if invoice.status != domain.StatusPending {
+			if err := srv.invoiceService.ProcessTransactionResult(ctx, created["id"].(string), invoice.status); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	report := decodeBody[dto.AccountReportOutput](client.do("GET", "/v1/accounts/reports", apiKey, nil, http.StatusOK))
+	if report.Granularity != "day" || report.Source != "invoices" || len(report.Periods) != 30 {
+		t.Fatalf("padrão deveria ser 30 dias lidos das faturas: %s %s %d", report.Granularity, report.Source, len(report.Periods))
+	}
+	today := report.Periods[len(report.Periods)-1]
+	if today.PeriodStart != time.Now().UTC().Format(time.DateOnly) || today.Count != 4 || today.Amount != 58000 {
+		t.Fatalf("totais de hoje inesperados: %+v", today)
+	}
+	if today.ApprovalRate == nil || *today.ApprovalRate != 0.6667 || today.AverageTicket == nil || *today.AverageTicket != 17500 {
+		t.Fatalf("taxa de aprovação/ticket médio inesperados: %+v", today)
+	}
+	if today.ByStatus["approved"] != (dto.AccountReportTotalsOutput{Count: 2, Amount: 35000}) || today.ByStatus["pending"].Count != 1 ||
+		today.ByPaymentType["credit_card"].Count != 4 || today.ByCardBrand["visa"].Count != 4 {
+		t.Fatalf("quebras inesperadas: %+v", today)
+	}
+	if yesterday := report.Periods[len(report.Periods)-2]; yesterday.Count != 0 || yesterday.ApprovalRate != nil || yesterday.AverageTicket != nil {
+		t.Fatalf("dias sem faturas também aparecem, zerados: %+v", yesterday)
+	}
+
+	report = decodeBody[dto.AccountReportOutput](client.do("GET", "/v1/accounts/reports?granularity=week", apiKey, nil, http.StatusOK))
+	for _, period := range report.Periods {
+		if start, _ := time.Parse(time.DateOnly, period.PeriodStart); start.Weekday() != time.Monday {
+			t.Fatalf("semana deveria começar na segunda: %s", period.PeriodStart)
+		}
+	}
+
+	for _, query := range []string{"granularity=year", "from=2025-02-01&to=2025-01-01", "from=2000-01-01&to=2025-01-01", "to=ontem"} {
+		if code := client.request("GET", "/v1/accounts/reports?"+query, apiKey, nil).Code; code != http.StatusUnprocessableEntity {
+			t.Fatalf("%s deveria retornar 422, retornou %d", query, code)
+		}
+	}
+}
+
+// com o rollup ligado o relatório só enxerga o que já foi agregado; antes do primeiro refresh lê direto das faturas
+func TestAccountReportRollup(t *testing.T) {
+	ctx := context.Background()
+	invoiceRepository := newFakeInvoiceRepository()
+	reportRepository := &fakeInvoiceReportRepository{invoices: invoiceRepository}
+	reportService := service.NewInvoiceReportService(reportRepository, &service.InvoiceReportConfig{UseRollup: true})
+	principal := domain.Principal{AccountID: "account-1"}
+
+	save := func(id string, createdAt time.Time, status domain.Status, amount float64, brand string) {
+		invoiceRepository.Save(ctx, &domain.Invoice{
+			ID: id, AccountID: principal.AccountID, Amount: amount, Status: status,
+			PaymentType: "credit_card", CardBrand: brand, CreatedAt: createdAt, UpdatedAt: createdAt,
+		}, nil)
+	}
+	save("jan", time.Date(2025, 1, 31, 23, 0, 0, 0, time.UTC), domain.StatusApproved, 100, "mastercard")
+	save("feb-1", time.Date(2025, 2, 3, 10, 0, 0, 0, time.UTC), domain.StatusRejected, 50, "visa")
+	save("feb-2", time.Date(2025, 2, 10, 10, 0, 0, 0, time.UTC), domain.StatusApproved, 300, "")
+
+	input := dto.AccountReportInput{Granularity: "month", From: "2025-01-15", To: "2025-02-20"}
+	report := func(source string, february int) *dto.AccountReportOutput {
+		t.Helper()
+		output, err := reportService.Report(ctx, principal, input)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// os períodos são completos: de 01/01 até o fim de fevereiro
+		if output.Source != source || output.From != "2025-01-01" || output.To != "2025-02-28" || len(output.Periods) != 2 {
+			t.Fatalf("relatório inesperado: %+v", output)
+		}
+		if output.Periods[0].Count != 1 || output.Periods[1].Count != february {
+			t.Fatalf("esperadas 1 e %d faturas, vieram %d e %d", february, output.Periods[0].Count, output.Periods[1].Count)
+		}
+		return output
+	}
+
+	output := report("invoices", 2)
+	if output.RefreshedUntil != nil {
+		t.Fatalf("refreshed_until só existe no rollup: %v", output.RefreshedUntil)
+	}
+
+	if days, err := reportService.RefreshRollup(ctx); err != nil || days != 3 {
+		t.Fatalf("refresh deveria recalcular 3 dias: %d %v", days, err)
+	}
+	output = report("rollup", 2)
+	february := output.Periods[1]
+	if output.RefreshedUntil == nil || february.ByCardBrand["unknown"].Amount != 300 || *february.ApprovalRate != 0.5 || *february.AverageTicket != 300 {
+		t.Fatalf("totais do rollup inesperados: %+v", output)
+	}
+
+	// a fatura nova só entra no próximo refresh
+	save("feb-3", time.Date(2025, 2, 11, 10, 0, 0, 0, time.UTC), domain.StatusPending, 10, "visa")
+	report("rollup", 2)
+	if _, err := reportService.RefreshRollup(ctx); err != nil {
+		t.Fatal(err)
+	}
+	report("rollup", 3)
+}
@@ -0,0 +1,95 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/j-ordep/gateway/go-gateway/internal/domain"
+	"github.com/j-ordep/gateway/go-gateway/internal/dto"
+)
+
+// a conta só encerra sem faturas aguardando decisão (antifraude ou 3DS); depois de encerrada não autentica mais
+func TestAccountLifecycle(t *testing.T) {
+	srv := newTestServer()
+	client := newTestClient(t, srv)
+	ctx := context.Background()
+
+	apiKey := client.createAccount("Ciclo", "lifecycle@example.com")
+	updated := decodeBody[map[string]any](client.do("PATCH", "/v1/accounts", apiKey, map[string]any{"name": "Ciclo de vida", "three_ds_mode": "always"}, http.StatusOK))
+	if updated["name"] != "Ciclo de vida" || updated["three_ds_mode"] != "always" || updated["status"] != "active" {
+		t.Fatalf("conta não foi atualizada: %v", updated)
+	}
+
+	// com 3DS sempre, a fatura fica em requires_action até o comprador concluir o desafio
+	challenged := decodeBody[map[string]any](client.do("POST", "/v1/invoice", apiKey, invoiceInput(5000, nil), http.StatusCreated))
+	if challenged["status"] != "requires_action" {
+		t.Fatalf("fatura deveria aguardar o 3DS: %v", challenged)
+	}
+	closed := decodeBody[map[string]any](client.do("POST", "/v1/accounts/close", apiKey, nil, http.StatusConflict))
+	if closed["code"] != "account_has_pending_invoices" {
+		t.Fatalf("fatura em requires_action deveria bloquear o encerramento: %v", closed)
+	}
+
+	// acima de 10000 a fatura fica pendente aguardando o antifraude
+	otherKey := client.createAccount("Pendente", "lifecycle-pending@example.com")
+	pending := decodeBody[map[string]any](client.do("POST", "/v1/invoice", otherKey, invoiceInput(15000, nil), http.StatusCreated))
+	client.do("POST", "/v1/accounts/close", otherKey, nil, http.StatusConflict)
+
+	if err := srv.invoiceService.ProcessTransactionResult(ctx, pending["id"].(string), domain.StatusRejected); err != nil {
+		t.Fatal(err)
+	}
+	closed = decodeBody[map[string]any](client.do("POST", "/v1/accounts/close", otherKey, map[string]any{"reason": "encerrando"}, http.StatusOK))
+	if closed["status"] != "closed" || closed["status_reason"] != "encerrando" {
+		t.Fatalf("conta sem faturas em aberto deveria encerrar: %v", closed)
+	}
+
+	denied := decodeBody[map[string]any](client.do("PATCH", "/v1/accounts", otherKey, map[string]any{"name": "Reaberta"}, http.StatusForbidden))
+	if denied["code"] != "account_closed" {
+		t.Fatalf("conta encerrada não deveria autenticar: %v", denied)
+	}
+}
+
+// o PATCH grava só o cadastro: em corrida com a suspensão, a conta termina suspensa e não volta a active
+func TestAccountUpdateKeepsStatus(t *testing.T) {
+	srv := newTestServer()
+	client := newTestClient(t, srv)
+	ctx := context.Background()
+
+	apiKey := client.createAccount("Corrida", "update-race@example.com")
+	account := decodeBody[map[string]any](client.do("GET", "/v1/accounts", apiKey, nil, http.StatusOK))
+	accountID := account["id"].(string)
+
+	var wg sync.WaitGroup
+	for i := range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if i == 5 {
+				if _, err := srv.adminService.SuspendAccount(ctx, domain.Operator{ID: testOperatorID}, accountID, "lojista comprometido"); err != nil {
+					t.Error(err)
+				}
+				return
+			}
+			if _, err := srv.accountService.UpdateAccount(domain.Principal{AccountID: accountID}, dto.UpdateAccountInput{Name: "Corrida " + strconv.Itoa(i)}); err != nil && err != domain.ErrAccountSuspended {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	stored, err := srv.accountService.FindByID(accountID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored.Status != string(domain.AccountStatusSuspended) || stored.StatusReason != "lojista comprometido" {
+		t.Fatalf("a suspensão foi desfeita pelo PATCH: %+v", stored)
+	}
+
+	_, err = srv.accountService.UpdateAccount(domain.Principal{AccountID: accountID}, dto.UpdateAccountInput{Name: "Suspensa"})
+	if err != domain.ErrAccountSuspended {
+		t.Fatalf("conta suspensa não deveria alterar o cadastro: %v", err)
+	}
+}
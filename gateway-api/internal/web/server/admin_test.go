@@ -0,0 +1,136 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/j-ordep/gateway/go-gateway/internal/domain"
+	"github.com/j-ordep/gateway/go-gateway/internal/dto"
+)
+
+// a decisão forçada pelo operador e a resposta do antifraude disputam a mesma fatura pending:
+// só uma delas é gravada, o saldo é creditado uma vez e só a decisão aplicada fica na auditoria
+func TestAdminForceInvoiceStatusRace(t *testing.T) {
+	srv := newTestServer()
+	client := newTestClient(t, srv)
+	ctx := context.Background()
+	operator := domain.Operator{ID: testOperatorID}
+
+	apiKey := client.createAccount("Disputa", "admin-race@example.com")
+	invoice := decodeBody[dto.InvoiceOutput](client.do("POST", "/v1/invoice", apiKey, invoiceInput(15000, nil), http.StatusCreated))
+
+	var wg sync.WaitGroup
+	var decided, forced atomic.Int32
+	for i := range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var err error
+			if i%2 == 0 {
+				err = srv.invoiceService.ProcessTransactionResult(ctx, invoice.ID, domain.StatusApproved)
+			} else {
+				_, err = srv.adminService.ForceInvoiceStatus(ctx, operator, invoice.ID, dto.ForceInvoiceStatusInput{Status: "approved", Reason: "antifraude fora do ar"})
+				if err == nil {
+					forced.Add(1)
+				}
+			}
+			if err == nil {
+				decided.Add(1)
+			} else if err != domain.ErrInvalidStatus {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if decided.Load() != 1 {
+		t.Fatalf("só uma decisão deveria ser gravada, foram %d", decided.Load())
+	}
+	account := decodeBody[dto.AccountOutput](client.do("GET", "/v1/accounts", apiKey, nil, http.StatusOK))
+	if account.Balance != 15000 {
+		t.Fatalf("o saldo deveria ser creditado uma vez: %.2f", account.Balance)
+	}
+
+	audit, err := srv.adminService.ListAuditLog(ctx, dto.AuditLogFilterInput{TargetID: invoice.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(audit.Data) != int(forced.Load()) {
+		t.Fatalf("auditoria com %d entradas para %d decisões forçadas", len(audit.Data), forced.Load())
+	}
+}
+
+// o ajuste de saldo e a auditoria são gravados juntos, com o saldo resultante na entrada
+func TestAdminAdjustBalanceAudit(t *testing.T) {
+	srv := newTestServer()
+	client := newTestClient(t, srv)
+	ctx := context.Background()
+
+	apiKey := client.createAccount("Ajuste", "admin-adjust@example.com")
+	account := decodeBody[dto.AccountOutput](client.do("GET", "/v1/accounts", apiKey, nil, http.StatusOK))
+
+	output, err := srv.adminService.AdjustBalance(ctx, domain.Operator{ID: testOperatorID}, account.ID, dto.BalanceAdjustmentInput{Amount: 250.5, Reason: "estorno manual"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if output.Balance != 250.5 || output.APIKey != "" {
+		t.Fatalf("ajuste inesperado: %+v", output)
+	}
+
+	audit, err := srv.adminService.ListAuditLog(ctx, dto.AuditLogFilterInput{TargetID: account.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(audit.Data) != 1 {
+		t.Fatalf("o ajuste deveria ter uma entrada de auditoria, tem %d", len(audit.Data))
+	}
+	entry := audit.Data[0]
+	if entry.Action != string(domain.AuditActionAccountBalanceAdjusted) || entry.Reason != "estorno manual" ||
+		entry.Details["amount"] != "250.5" || entry.Details["balance"] != "250.5" {
+		t.Fatalf("entrada de auditoria do ajuste: %+v", entry)
+	}
+}
+
+// suspensão, plano e reativação gravam a auditoria junto com a mudança; a ação recusada não deixa entrada
+func TestAdminAccountActionsAudit(t *testing.T) {
+	srv := newTestServer()
+	client := newTestClient(t, srv)
+	ctx := context.Background()
+	operator := domain.Operator{ID: testOperatorID}
+
+	apiKey := client.createAccount("Auditada", "admin-actions@example.com")
+	account := decodeBody[dto.AccountOutput](client.do("GET", "/v1/accounts", apiKey, nil, http.StatusOK))
+
+	if _, err := srv.adminService.SuspendAccount(ctx, operator, account.ID, "chargebacks"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := srv.adminService.SuspendAccount(ctx, operator, account.ID, "de novo"); err != domain.ErrInvalidAccountStatus {
+		t.Fatalf("conta já suspensa deveria recusar a suspensão: %v", err)
+	}
+	if _, err := srv.adminService.UpdateAccountTier(ctx, operator, account.ID, "premium"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := srv.adminService.ReactivateAccount(ctx, operator, account.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	audit, err := srv.adminService.ListAuditLog(ctx, dto.AuditLogFilterInput{TargetID: account.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []domain.AuditAction{domain.AuditActionAccountReactivated, domain.AuditActionAccountTierUpdated, domain.AuditActionAccountSuspended}
+	if len(audit.Data) != len(want) {
+		t.Fatalf("esperadas %d entradas de auditoria, vieram %d", len(want), len(audit.Data))
+	}
+	for i, action := range want {
+		if audit.Data[i].Action != string(action) || audit.Data[i].OperatorID != testOperatorID {
+			t.Fatalf("entrada %d: %+v", i, audit.Data[i])
+		}
+	}
+	if audit.Data[1].Details["tier"] != "premium" || audit.Data[2].Reason != "chargebacks" {
+		t.Fatalf("detalhes da auditoria: %+v %+v", audit.Data[1], audit.Data[2])
+	}
+}
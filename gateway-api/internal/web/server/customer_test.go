@@ -0,0 +1,64 @@
+package server
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/j-ordep/gateway/go-gateway/internal/dto"
+)
+
+// o cartão salvo não expõe o número completo, cobra sem reenviar os dados e,
+// depois que o cliente é removido, as faturas continuam vinculadas a ele
+func TestCustomerPaymentMethodsAndSoftDelete(t *testing.T) {
+	srv := newTestServer()
+	client := newTestClient(t, srv)
+
+	apiKey := client.createAccount("Clientes", "customers@example.com")
+	otherKey := client.createAccount("Outra", "customers-other@example.com")
+
+	customer := decodeBody[dto.CustomerOutput](client.do("POST", "/v1/customers", apiKey, map[string]any{"name": "Maria", "email": "maria@example.com"}, http.StatusCreated))
+	updated := decodeBody[dto.CustomerOutput](client.do("PATCH", "/v1/customers/"+customer.ID, apiKey, map[string]any{"phone": "+5511999999999"}, http.StatusOK))
+	if updated.Name != "Maria" || updated.Phone != "+5511999999999" {
+		t.Fatalf("PATCH só altera os campos enviados: %+v", updated)
+	}
+
+	paymentMethod := client.do("POST", "/v1/customers/"+customer.ID+"/payment-methods", apiKey, testCard, http.StatusCreated)
+	if strings.Contains(paymentMethod.Body.String(), "4111111111111111") || strings.Contains(paymentMethod.Body.String(), `"cvv"`) {
+		t.Fatalf("o cartão salvo não pode expor o número nem o CVV: %s", paymentMethod.Body.String())
+	}
+	saved := decodeBody[dto.PaymentMethodOutput](paymentMethod)
+	if saved.CardLastDigits != "1111" || saved.Brand != "visa" || saved.CustomerID != customer.ID {
+		t.Fatalf("cartão salvo inesperado: %+v", saved)
+	}
+
+	// outra conta não enxerga o cliente
+	client.do("GET", "/v1/customers/"+customer.ID, otherKey, nil, http.StatusForbidden)
+
+	invoice := decodeBody[dto.InvoiceOutput](client.do("POST", "/v1/invoice", apiKey, map[string]any{
+		"amount": 100, "payment_type": "credit_card", "customer_id": customer.ID, "payment_method_id": saved.ID,
+	}, http.StatusCreated))
+	if invoice.CustomerID != customer.ID || invoice.PaymentMethodID != saved.ID || invoice.CardLastDigits != "1111" {
+		t.Fatalf("fatura deveria usar o cartão salvo: %+v", invoice)
+	}
+
+	history := decodeBody[dto.InvoiceListOutput](client.do("GET", "/v1/customers/"+customer.ID+"/invoices", apiKey, nil, http.StatusOK))
+	if len(history.Data) != 1 || history.Data[0].ID != invoice.ID {
+		t.Fatalf("histórico do cliente deveria ter a fatura: %+v", history.Data)
+	}
+
+	// a remoção tira o cliente e os cartões da API, mas não o vínculo das faturas
+	client.do("DELETE", "/v1/customers/"+customer.ID, apiKey, nil, http.StatusNoContent)
+	client.do("GET", "/v1/customers/"+customer.ID, apiKey, nil, http.StatusNotFound)
+	client.do("POST", "/v1/invoice", apiKey, map[string]any{
+		"amount": 100, "payment_type": "credit_card", "customer_id": customer.ID, "payment_method_id": saved.ID,
+	}, http.StatusNotFound)
+
+	kept := decodeBody[dto.InvoiceOutput](client.do("GET", "/v1/invoice/"+invoice.ID, apiKey, nil, http.StatusOK))
+	if kept.CustomerID != customer.ID || kept.PaymentMethodID != saved.ID {
+		t.Fatalf("a fatura deveria continuar vinculada ao cliente removido: %+v", kept)
+	}
+	if list := decodeBody[dto.InvoiceListOutput](client.do("GET", "/v1/invoices?customer_id="+customer.ID, apiKey, nil, http.StatusOK)); len(list.Data) != 1 {
+		t.Fatalf("o filtro por customer_id ainda acha a fatura: %d", len(list.Data))
+	}
+}
@@ -0,0 +1,67 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/j-ordep/gateway/go-gateway/internal/domain"
+	"github.com/j-ordep/gateway/go-gateway/internal/validation"
+	"github.com/j-ordep/gateway/go-gateway/internal/web/response"
+	"github.com/lib/pq"
+)
+
+// todo erro vira o envelope {code, message, details, request_id} pelo mapeamento central
+func TestErrorMapping(t *testing.T) {
+	cases := []struct {
+		name   string
+		err    error
+		status int
+		code   string
+	}{
+		{"erro de domínio", domain.ErrInvoiceNotFound, http.StatusNotFound, "invoice_not_found"},
+		{"erro de domínio embrulhado", fmt.Errorf("buscando fatura: %w", domain.ErrInvalidStatus), http.StatusConflict, "invalid_status"},
+		{"erro da camada http", response.BadRequest("invalid_json", "malformed JSON"), http.StatusBadRequest, "invalid_json"},
+		{"validação", validation.Errors{{Field: "email", Rule: "required", Message: "is required"}}, http.StatusUnprocessableEntity, "validation_failed"},
+		{"unique violation", &pq.Error{Code: "23505", Constraint: "accounts_email_key"}, http.StatusConflict, "conflict"},
+		{"foreign key violation", &pq.Error{Code: "23503"}, http.StatusUnprocessableEntity, "invalid_reference"},
+		{"id que não é uuid", fmt.Errorf("find invoice: %w", &pq.Error{Code: "22P02"}), http.StatusBadRequest, "invalid_parameter"},
+		{"erro desconhecido", errors.New("dial tcp: connection refused"), http.StatusInternalServerError, "internal_error"},
+	}
+	for _, c := range cases {
+		status, body := response.ToErrorBody(c.err)
+		if status != c.status || body.Code != c.code {
+			t.Errorf("%s: esperado %d %s, veio %d %s", c.name, c.status, c.code, status, body.Code)
+		}
+	}
+
+	// o erro desconhecido não expõe a mensagem original
+	if _, body := response.ToErrorBody(errors.New("dial tcp: connection refused")); body.Message != http.StatusText(http.StatusInternalServerError) {
+		t.Errorf("erro interno vazou a mensagem: %s", body.Message)
+	}
+
+	srv := newTestServer()
+	client := newTestClient(t, srv)
+	client.createAccount("Erros", "errors@example.com")
+
+	// o request_id do envelope é o mesmo do header X-Request-ID
+	duplicated := client.do("POST", "/v1/accounts", "", map[string]any{"name": "Erros", "email": "errors@example.com"}, http.StatusConflict)
+	body := decodeBody[response.ErrorBody](duplicated)
+	if body.Code != "email_already_exists" || body.RequestID == "" || body.RequestID != duplicated.Header().Get("X-Request-ID") {
+		t.Fatalf("envelope do e-mail duplicado: %+v", body)
+	}
+	if contentType := duplicated.Header().Get("Content-Type"); contentType != "application/json" {
+		t.Fatalf("erro deveria ser JSON, veio %s", contentType)
+	}
+
+	if missing := decodeBody[response.ErrorBody](client.do("GET", "/v1/accounts", "", nil, http.StatusUnauthorized)); missing.Code != "missing_api_key" {
+		t.Fatalf("sem apiKey: %+v", missing)
+	}
+	if notFound := decodeBody[response.ErrorBody](client.do("GET", "/v1/unknown", "", nil, http.StatusNotFound)); notFound.Code != "route_not_found" {
+		t.Fatalf("rota inexistente: %+v", notFound)
+	}
+	if notAllowed := decodeBody[response.ErrorBody](client.do("DELETE", "/v1/invoices", "", nil, http.StatusMethodNotAllowed)); notAllowed.Code != "method_not_allowed" {
+		t.Fatalf("método não permitido: %+v", notAllowed)
+	}
+}
@@ -0,0 +1,1019 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/j-ordep/gateway/go-gateway/internal/domain"
+	"github.com/j-ordep/gateway/go-gateway/internal/domain/events"
+	"github.com/j-ordep/gateway/go-gateway/internal/service"
+	"github.com/j-ordep/gateway/go-gateway/internal/tracing"
+	"github.com/segmentio/kafka-go"
+)
+
+// repositórios em memória, suficientes para exercitar os handlers sem Postgres/Kafka
+
+type fakeAccountRepository struct {
+	mu       sync.Mutex
+	accounts map[string]*domain.Account
+	invoices *fakeInvoiceRepository  // consultado no Close, como a transação do repository real
+	audit    *fakeAuditLogRepository // recebe a auditoria do AdjustBalance
+}
+
+func newFakeAccountRepository(invoices *fakeInvoiceRepository, audit *fakeAuditLogRepository) *fakeAccountRepository {
+	return &fakeAccountRepository{accounts: make(map[string]*domain.Account), invoices: invoices, audit: audit}
+}
+
+// copyAccount copia campo a campo (Account tem um mutex): como o banco, mudar a conta lida não altera a gravada
+func copyAccount(account *domain.Account) *domain.Account {
+	return &domain.Account{
+		ID:           account.ID,
+		Name:         account.Name,
+		Email:        account.Email,
+		APIKey:       account.APIKey,
+		Balance:      account.Balance,
+		Status:       account.Status,
+		StatusReason: account.StatusReason,
+		ThreeDSRule:  account.ThreeDSRule,
+		Tier:         account.Tier,
+		CreatedAt:    account.CreatedAt,
+		UpdatedAt:    account.UpdatedAt,
+	}
+}
+
+func (r *fakeAccountRepository) Save(account *domain.Account) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, existing := range r.accounts {
+		if existing.Email == account.Email {
+			return domain.ErrDuplicatedEmail
+		}
+	}
+	r.accounts[account.ID] = copyAccount(account)
+	return nil
+}
+
+func (r *fakeAccountRepository) FindByAPIKey(apiKey string) (*domain.Account, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, account := range r.accounts {
+		if account.APIKey == apiKey {
+			return copyAccount(account), nil
+		}
+	}
+	return nil, domain.ErrAccountNotFound
+}
+
+func (r *fakeAccountRepository) FindByID(id string) (*domain.Account, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	account, ok := r.accounts[id]
+	if !ok {
+		return nil, domain.ErrAccountNotFound
+	}
+	return copyAccount(account), nil
+}
+
+// update aplica change na conta gravada com o lock do fake, como um UPDATE de colunas específicas
+func (r *fakeAccountRepository) update(account *domain.Account, change func(stored *domain.Account) error) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	stored, ok := r.accounts[account.ID]
+	if !ok {
+		return domain.ErrAccountNotFound
+	}
+	return change(stored)
+}
+
+func (r *fakeAccountRepository) UpdateBalance(account *domain.Account, amount float64) error {
+	return r.update(account, func(stored *domain.Account) error {
+		stored.AddBalance(amount)
+		account.Balance = stored.Balance
+		return nil
+	})
+}
+
+func (r *fakeAccountRepository) AdjustBalance(ctx context.Context, account *domain.Account, amount float64, entry *domain.AuditEntry) error {
+	if err := r.UpdateBalance(account, amount); err != nil {
+		return err
+	}
+	if entry.Details == nil {
+		entry.Details = make(map[string]string)
+	}
+	entry.Details["balance"] = strconv.FormatFloat(account.Balance, 'f', -1, 64)
+	return r.audit.Save(ctx, entry)
+}
+
+func (r *fakeAccountRepository) UpdateProfile(account *domain.Account) error {
+	return r.update(account, func(stored *domain.Account) error {
+		if err := stored.CanTransact(); err != nil {
+			return err
+		}
+		for _, existing := range r.accounts {
+			if existing.ID != account.ID && existing.Email == account.Email {
+				return domain.ErrDuplicatedEmail
+			}
+		}
+		stored.Name = account.Name
+		stored.Email = account.Email
+		stored.ThreeDSRule = account.ThreeDSRule
+		stored.UpdatedAt = account.UpdatedAt
+		return nil
+	})
+}
+
+func (r *fakeAccountRepository) UpdateTier(ctx context.Context, account *domain.Account, entry *domain.AuditEntry) error {
+	err := r.update(account, func(stored *domain.Account) error {
+		stored.Tier = account.Tier
+		stored.UpdatedAt = account.UpdatedAt
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	return r.audit.Save(ctx, entry)
+}
+
+func (r *fakeAccountRepository) UpdateStatus(ctx context.Context, account *domain.Account, from domain.AccountStatus, entry *domain.AuditEntry) error {
+	err := r.update(account, func(stored *domain.Account) error {
+		if stored.Status != from {
+			return domain.ErrInvalidAccountStatus
+		}
+		stored.Status = account.Status
+		stored.StatusReason = account.StatusReason
+		stored.UpdatedAt = account.UpdatedAt
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	return r.audit.Save(ctx, entry)
+}
+
+func (r *fakeAccountRepository) UpdateAPIKey(account *domain.Account) error {
+	return r.update(account, func(stored *domain.Account) error {
+		stored.APIKey = account.APIKey
+		stored.UpdatedAt = account.UpdatedAt
+		return nil
+	})
+}
+
+func (r *fakeAccountRepository) Close(account *domain.Account, reason string) error {
+	r.invoices.mu.Lock()
+	defer r.invoices.mu.Unlock()
+	return r.update(account, func(stored *domain.Account) error {
+		for _, invoice := range r.invoices.invoices {
+			if invoice.AccountID == account.ID && slices.Contains(domain.UnresolvedStatuses, invoice.Status) {
+				return domain.ErrAccountHasPendingInvoices
+			}
+		}
+
+		// o status e o saldo validados são os gravados, não os do account em memória
+		account.Balance = stored.Balance
+		account.Status = stored.Status
+		if err := account.Close(reason); err != nil {
+			return err
+		}
+		stored.Status = account.Status
+		stored.StatusReason = account.StatusReason
+		stored.UpdatedAt = account.UpdatedAt
+		return nil
+	})
+}
+
+type fakeInvoiceRepository struct {
+	mu         sync.Mutex
+	invoices   map[string]*domain.Invoice
+	accounts   *fakeAccountRepository          // recebe o crédito das faturas aprovadas, como a transação do repository real
+	challenges *fakeThreeDSChallengeRepository // challenges, audit, deliveries e events recebem o outbox, como a transação do repository real
+	audit      *fakeAuditLogRepository
+	deliveries *fakeWebhookDeliveryRepository
+	events     *fakeInvoiceEventRepository
+}
+
+func newFakeInvoiceRepository() *fakeInvoiceRepository {
+	return &fakeInvoiceRepository{invoices: make(map[string]*domain.Invoice)}
+}
+
+// guarda e devolve cópias, como o banco: mudar a fatura lida não altera a gravada
+func (r *fakeInvoiceRepository) Save(ctx context.Context, invoice *domain.Invoice, outbox *domain.InvoiceOutbox) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	copied := *invoice
+	r.invoices[invoice.ID] = &copied
+	if err := r.creditApproved(invoice); err != nil {
+		return err
+	}
+	return r.writeOutbox(ctx, outbox)
+}
+
+func (r *fakeInvoiceRepository) writeOutbox(ctx context.Context, outbox *domain.InvoiceOutbox) error {
+	if outbox == nil {
+		return nil
+	}
+	if outbox.Challenge != nil {
+		r.challenges.save(outbox.Challenge)
+	}
+	if len(outbox.Deliveries) > 0 {
+		r.deliveries.save(outbox.Deliveries...)
+	}
+	if outbox.Event != nil {
+		r.events.save(outbox.Event)
+	}
+	if outbox.Audit != nil {
+		return r.audit.Save(ctx, outbox.Audit)
+	}
+	return nil
+}
+
+func (r *fakeInvoiceRepository) creditApproved(invoice *domain.Invoice) error {
+	if invoice.Status != domain.StatusApproved || r.accounts == nil {
+		return nil
+	}
+	account, err := r.accounts.FindByID(invoice.AccountID)
+	if err != nil {
+		return err
+	}
+	return r.accounts.UpdateBalance(account, invoice.Amount)
+}
+
+func (r *fakeInvoiceRepository) FindByID(ctx context.Context, id string) (*domain.Invoice, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	invoice, ok := r.invoices[id]
+	if !ok {
+		return nil, domain.ErrInvoiceNotFound
+	}
+	copied := *invoice
+	return &copied, nil
+}
+
+func (r *fakeInvoiceRepository) FindByAccountID(ctx context.Context, accountID string, filter domain.InvoiceFilter) ([]*domain.Invoice, error) {
+	var invoices []*domain.Invoice
+	err := r.Each(ctx, accountID, filter, func(invoice *domain.Invoice) error {
+		invoices = append(invoices, invoice)
+		return nil
+	})
+	return invoices, err
+}
+
+func (r *fakeInvoiceRepository) FindByExternalReference(ctx context.Context, accountID, externalReference string) (*domain.Invoice, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, invoice := range r.invoices {
+		if invoice.AccountID == accountID && invoice.ExternalReference == externalReference {
+			return invoice, nil
+		}
+	}
+	return nil, domain.ErrInvoiceNotFound
+}
+
+func (r *fakeInvoiceRepository) UpdateStatus(ctx context.Context, invoice *domain.Invoice, from domain.Status, outbox *domain.InvoiceOutbox) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	current, ok := r.invoices[invoice.ID]
+	if !ok {
+		return domain.ErrInvoiceNotFound
+	}
+	if current.Status != from {
+		return domain.ErrInvalidStatus
+	}
+	copied := *invoice
+	r.invoices[invoice.ID] = &copied
+	if err := r.creditApproved(invoice); err != nil {
+		return err
+	}
+	return r.writeOutbox(ctx, outbox)
+}
+
+// Each aplica os mesmos filtros, a ordem e o keyset do invoiceFilterQuery do repository real
+func (r *fakeInvoiceRepository) Each(ctx context.Context, accountID string, filter domain.InvoiceFilter, fn func(*domain.Invoice) error) error {
+	r.mu.Lock()
+	var invoices []*domain.Invoice
+	for _, invoice := range r.invoices {
+		if invoice.AccountID == accountID && matchesInvoiceFilter(invoice, filter) {
+			copied := *invoice
+			invoices = append(invoices, &copied)
+		}
+	}
+	r.mu.Unlock()
+
+	sort.Slice(invoices, func(i, j int) bool {
+		if invoices[i].CreatedAt.Equal(invoices[j].CreatedAt) {
+			return invoices[i].ID > invoices[j].ID
+		}
+		return invoices[i].CreatedAt.After(invoices[j].CreatedAt)
+	})
+	if filter.Limit > 0 && len(invoices) > filter.Limit {
+		invoices = invoices[:filter.Limit]
+	}
+	for _, invoice := range invoices {
+		if err := fn(invoice); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func matchesInvoiceFilter(invoice *domain.Invoice, filter domain.InvoiceFilter) bool {
+	if (filter.ExternalReference != "" && invoice.ExternalReference != filter.ExternalReference) ||
+		(filter.CustomerID != "" && invoice.CustomerID != filter.CustomerID) ||
+		(filter.Status != "" && invoice.Status != filter.Status) ||
+		(filter.PaymentType != "" && invoice.PaymentType != filter.PaymentType) ||
+		(filter.MinAmount > 0 && invoice.Amount < filter.MinAmount) ||
+		(filter.MaxAmount > 0 && invoice.Amount > filter.MaxAmount) ||
+		(!filter.CreatedFrom.IsZero() && invoice.CreatedAt.Before(filter.CreatedFrom)) ||
+		(!filter.CreatedTo.IsZero() && !invoice.CreatedAt.Before(filter.CreatedTo)) ||
+		(filter.CardLastDigits != "" && invoice.CardLastDigits != filter.CardLastDigits) {
+		return false
+	}
+	for key, value := range filter.Metadata {
+		if actual, ok := invoice.Metadata[key]; !ok || actual != value {
+			return false
+		}
+	}
+	// (created_at, id) < (cursor.created_at, cursor.id)
+	if cursor := filter.Cursor; cursor != nil {
+		if invoice.CreatedAt.After(cursor.CreatedAt) || (invoice.CreatedAt.Equal(cursor.CreatedAt) && invoice.ID >= cursor.ID) {
+			return false
+		}
+	}
+	return true
+}
+
+type fakeCustomerRepository struct {
+	mu             sync.Mutex
+	customers      map[string]*domain.Customer
+	paymentMethods *fakePaymentMethodRepository // os cartões saem junto com o cliente, como no soft delete real
+}
+
+func newFakeCustomerRepository(paymentMethods *fakePaymentMethodRepository) *fakeCustomerRepository {
+	return &fakeCustomerRepository{customers: make(map[string]*domain.Customer), paymentMethods: paymentMethods}
+}
+
+func (r *fakeCustomerRepository) Save(customer *domain.Customer) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.customers[customer.ID] = customer
+	return nil
+}
+
+func (r *fakeCustomerRepository) FindByID(id string) (*domain.Customer, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	customer, ok := r.customers[id]
+	if !ok {
+		return nil, domain.ErrCustomerNotFound
+	}
+	return customer, nil
+}
+
+func (r *fakeCustomerRepository) FindByAccountID(accountID string) ([]*domain.Customer, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var customers []*domain.Customer
+	for _, customer := range r.customers {
+		if customer.AccountID == accountID {
+			customers = append(customers, customer)
+		}
+	}
+	return customers, nil
+}
+
+func (r *fakeCustomerRepository) Update(customer *domain.Customer) error {
+	return r.Save(customer)
+}
+
+func (r *fakeCustomerRepository) Delete(id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.customers[id]; !ok {
+		return domain.ErrCustomerNotFound
+	}
+	delete(r.customers, id)
+
+	r.paymentMethods.mu.Lock()
+	defer r.paymentMethods.mu.Unlock()
+	for paymentMethodID, paymentMethod := range r.paymentMethods.paymentMethods {
+		if paymentMethod.CustomerID == id {
+			delete(r.paymentMethods.paymentMethods, paymentMethodID)
+		}
+	}
+	return nil
+}
+
+type fakePaymentMethodRepository struct {
+	mu             sync.Mutex
+	paymentMethods map[string]*domain.PaymentMethod
+}
+
+func newFakePaymentMethodRepository() *fakePaymentMethodRepository {
+	return &fakePaymentMethodRepository{paymentMethods: make(map[string]*domain.PaymentMethod)}
+}
+
+func (r *fakePaymentMethodRepository) Save(paymentMethod *domain.PaymentMethod) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.paymentMethods[paymentMethod.ID] = paymentMethod
+	return nil
+}
+
+func (r *fakePaymentMethodRepository) FindByID(id string) (*domain.PaymentMethod, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	paymentMethod, ok := r.paymentMethods[id]
+	if !ok {
+		return nil, domain.ErrPaymentMethodNotFound
+	}
+	return paymentMethod, nil
+}
+
+func (r *fakePaymentMethodRepository) FindByCustomerID(customerID string) ([]*domain.PaymentMethod, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var paymentMethods []*domain.PaymentMethod
+	for _, paymentMethod := range r.paymentMethods {
+		if paymentMethod.CustomerID == customerID {
+			paymentMethods = append(paymentMethods, paymentMethod)
+		}
+	}
+	return paymentMethods, nil
+}
+
+func (r *fakePaymentMethodRepository) Delete(id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.paymentMethods[id]; !ok {
+		return domain.ErrPaymentMethodNotFound
+	}
+	delete(r.paymentMethods, id)
+	return nil
+}
+
+type fakeThreeDSChallengeRepository struct {
+	mu         sync.Mutex
+	challenges map[string]*domain.ThreeDSChallenge
+}
+
+func newFakeThreeDSChallengeRepository() *fakeThreeDSChallengeRepository {
+	return &fakeThreeDSChallengeRepository{challenges: make(map[string]*domain.ThreeDSChallenge)}
+}
+
+func (r *fakeThreeDSChallengeRepository) save(challenge *domain.ThreeDSChallenge) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	copied := *challenge
+	r.challenges[challenge.ID] = &copied
+}
+
+func (r *fakeThreeDSChallengeRepository) FindByID(id string) (*domain.ThreeDSChallenge, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	challenge, ok := r.challenges[id]
+	if !ok {
+		return nil, domain.ErrThreeDSChallengeNotFound
+	}
+	copied := *challenge
+	return &copied, nil
+}
+
+func (r *fakeThreeDSChallengeRepository) Update(challenge *domain.ThreeDSChallenge) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	current, ok := r.challenges[challenge.ID]
+	if !ok {
+		return domain.ErrThreeDSChallengeNotFound
+	}
+	if current.Status != domain.ChallengeStatusPending {
+		return domain.ErrThreeDSChallengeCompleted
+	}
+	copied := *challenge
+	r.challenges[challenge.ID] = &copied
+	return nil
+}
+
+type fakeIdempotencyRepository struct {
+	mu      sync.Mutex
+	records map[string]*domain.IdempotencyRecord
+}
+
+func newFakeIdempotencyRepository() *fakeIdempotencyRepository {
+	return &fakeIdempotencyRepository{records: make(map[string]*domain.IdempotencyRecord)}
+}
+
+// guarda cópias e confere o locked_until como o repository real
+func (r *fakeIdempotencyRepository) Create(record *domain.IdempotencyRecord) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.records[record.AccountID+"|"+record.Key]; ok {
+		return false, nil
+	}
+	copied := *record
+	r.records[record.AccountID+"|"+record.Key] = &copied
+	return true, nil
+}
+
+func (r *fakeIdempotencyRepository) Find(accountID, key string) (*domain.IdempotencyRecord, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	record, ok := r.records[accountID+"|"+key]
+	if !ok {
+		return nil, domain.ErrIdempotencyKeyNotFound
+	}
+	copied := *record
+	return &copied, nil
+}
+
+func (r *fakeIdempotencyRepository) TakeOver(record *domain.IdempotencyRecord, previous time.Time) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	current, ok := r.records[record.AccountID+"|"+record.Key]
+	if !ok || current.Status != domain.IdempotencyStatusProcessing || !current.LockedUntil.Equal(previous) {
+		return false, nil
+	}
+	current.LockedUntil = record.LockedUntil
+	return true, nil
+}
+
+func (r *fakeIdempotencyRepository) Complete(record *domain.IdempotencyRecord) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	current, ok := r.records[record.AccountID+"|"+record.Key]
+	if !ok || !current.LockedUntil.Equal(record.LockedUntil) {
+		return domain.ErrIdempotencyLockLost
+	}
+	copied := *record
+	r.records[record.AccountID+"|"+record.Key] = &copied
+	return nil
+}
+
+func (r *fakeIdempotencyRepository) Delete(record *domain.IdempotencyRecord) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	current, ok := r.records[record.AccountID+"|"+record.Key]
+	if !ok || !current.LockedUntil.Equal(record.LockedUntil) {
+		return domain.ErrIdempotencyLockLost
+	}
+	delete(r.records, record.AccountID+"|"+record.Key)
+	return nil
+}
+
+func (r *fakeIdempotencyRepository) DeleteExpired() (int64, error) {
+	return 0, nil
+}
+
+type fakeAuditLogRepository struct {
+	mu      sync.Mutex
+	entries []*domain.AuditEntry
+}
+
+func (r *fakeAuditLogRepository) Save(ctx context.Context, entry *domain.AuditEntry) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.entries = append(r.entries, entry)
+	return nil
+}
+
+func (r *fakeAuditLogRepository) List(ctx context.Context, filter domain.AuditLogFilter) ([]*domain.AuditEntry, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var entries []*domain.AuditEntry
+	for i := len(r.entries) - 1; i >= 0; i-- {
+		entry := r.entries[i]
+		if filter.OperatorID != "" && entry.OperatorID != filter.OperatorID {
+			continue
+		}
+		if filter.TargetType != "" && entry.TargetType != filter.TargetType {
+			continue
+		}
+		if filter.TargetID != "" && entry.TargetID != filter.TargetID {
+			continue
+		}
+		entries = append(entries, entry)
+		if filter.Limit > 0 && len(entries) == filter.Limit {
+			break
+		}
+	}
+	return entries, nil
+}
+
+type fakeWebhookEndpointRepository struct {
+	mu        sync.Mutex
+	endpoints []*domain.WebhookEndpoint
+}
+
+func (r *fakeWebhookEndpointRepository) Save(ctx context.Context, endpoint *domain.WebhookEndpoint) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.endpoints = append(r.endpoints, endpoint)
+	return nil
+}
+
+func (r *fakeWebhookEndpointRepository) FindByID(ctx context.Context, id string) (*domain.WebhookEndpoint, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, endpoint := range r.endpoints {
+		if endpoint.ID == id {
+			return endpoint, nil
+		}
+	}
+	return nil, domain.ErrWebhookEndpointNotFound
+}
+
+func (r *fakeWebhookEndpointRepository) FindByAccountID(ctx context.Context, accountID string) ([]*domain.WebhookEndpoint, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var endpoints []*domain.WebhookEndpoint
+	for _, endpoint := range r.endpoints {
+		if endpoint.AccountID == accountID {
+			endpoints = append(endpoints, endpoint)
+		}
+	}
+	return endpoints, nil
+}
+
+func (r *fakeWebhookEndpointRepository) Delete(ctx context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i, endpoint := range r.endpoints {
+		if endpoint.ID == id {
+			r.endpoints = append(r.endpoints[:i], r.endpoints[i+1:]...)
+			return nil
+		}
+	}
+	return domain.ErrWebhookEndpointNotFound
+}
+
+// fakeWebhookDeliveryRepository mantém a ordem de criação; o cascade do Postgres não é simulado
+type fakeWebhookDeliveryRepository struct {
+	mu         sync.Mutex
+	deliveries []*domain.WebhookDelivery
+}
+
+func (r *fakeWebhookDeliveryRepository) save(deliveries ...*domain.WebhookDelivery) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.deliveries = append(r.deliveries, deliveries...)
+}
+
+func (r *fakeWebhookDeliveryRepository) FindByID(ctx context.Context, id string) (*domain.WebhookDelivery, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, delivery := range r.deliveries {
+		if delivery.ID == id {
+			return delivery, nil
+		}
+	}
+	return nil, domain.ErrWebhookDeliveryNotFound
+}
+
+func (r *fakeWebhookDeliveryRepository) FindByEndpointID(ctx context.Context, endpointID string, filter domain.WebhookDeliveryFilter) ([]*domain.WebhookDelivery, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var deliveries []*domain.WebhookDelivery
+	for i := len(r.deliveries) - 1; i >= 0; i-- {
+		delivery := r.deliveries[i]
+		if delivery.EndpointID != endpointID || (filter.Status != "" && delivery.Status != filter.Status) {
+			continue
+		}
+		deliveries = append(deliveries, delivery)
+		if filter.Limit > 0 && len(deliveries) == filter.Limit {
+			break
+		}
+	}
+	return deliveries, nil
+}
+
+func (r *fakeWebhookDeliveryRepository) ClaimDue(ctx context.Context, lease time.Duration, limit int) ([]*domain.WebhookDelivery, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := time.Now()
+	leasedUntil := now.Add(lease)
+	var deliveries []*domain.WebhookDelivery
+	for _, delivery := range r.deliveries {
+		if delivery.Status != domain.DeliveryPending || delivery.NextAttemptAt == nil || delivery.NextAttemptAt.After(now) {
+			continue
+		}
+		delivery.NextAttemptAt = &leasedUntil
+		deliveries = append(deliveries, delivery)
+		if len(deliveries) == limit {
+			break
+		}
+	}
+	return deliveries, nil
+}
+
+func (r *fakeWebhookDeliveryRepository) RecordAttempt(ctx context.Context, delivery *domain.WebhookDelivery) error {
+	return nil
+}
+
+// fakeInvoiceEventRepository publica no hub ao gravar, como o NOTIFY faz em produção
+type fakeInvoiceEventRepository struct {
+	mu     sync.Mutex
+	events []*domain.InvoiceEvent
+	hub    *service.InvoiceEventHub
+}
+
+func (r *fakeInvoiceEventRepository) save(event *domain.InvoiceEvent) {
+	r.mu.Lock()
+	event.ID = int64(len(r.events) + 1)
+	r.events = append(r.events, event)
+	r.mu.Unlock()
+
+	r.hub.Publish(event)
+}
+
+func (r *fakeInvoiceEventRepository) FindAfter(ctx context.Context, accountID string, afterID int64, limit int) ([]*domain.InvoiceEvent, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var events []*domain.InvoiceEvent
+	for _, event := range r.events {
+		if event.AccountID != accountID || event.ID <= afterID {
+			continue
+		}
+		events = append(events, event)
+		if len(events) == limit {
+			break
+		}
+	}
+	return events, nil
+}
+
+func (r *fakeInvoiceEventRepository) DeleteBefore(ctx context.Context, before time.Time) (int64, error) {
+	return 0, nil
+}
+
+type fakeInvoiceExportRepository struct {
+	mu      sync.Mutex
+	exports []*domain.InvoiceExport
+}
+
+func (r *fakeInvoiceExportRepository) Save(ctx context.Context, export *domain.InvoiceExport) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.exports = append(r.exports, export)
+	return nil
+}
+
+func (r *fakeInvoiceExportRepository) FindByID(ctx context.Context, id string) (*domain.InvoiceExport, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, export := range r.exports {
+		if export.ID == id {
+			copied := *export
+			return &copied, nil
+		}
+	}
+	return nil, domain.ErrInvoiceExportNotFound
+}
+
+func (r *fakeInvoiceExportRepository) ClaimPending(ctx context.Context, lease time.Duration, limit int) ([]*domain.InvoiceExport, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := time.Now()
+	lockedUntil := now.Add(lease)
+	var exports []*domain.InvoiceExport
+	for _, export := range r.exports {
+		if export.Status != domain.ExportPending && (export.Status != domain.ExportProcessing || export.LockedUntil.After(now)) {
+			continue
+		}
+		export.Status = domain.ExportProcessing
+		export.LockedUntil = &lockedUntil
+		copied := *export
+		exports = append(exports, &copied)
+		if len(exports) == limit {
+			break
+		}
+	}
+	return exports, nil
+}
+
+func (r *fakeInvoiceExportRepository) Update(ctx context.Context, export *domain.InvoiceExport) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i, existing := range r.exports {
+		if existing.ID == export.ID {
+			copied := *export
+			r.exports[i] = &copied
+			return nil
+		}
+	}
+	return domain.ErrInvoiceExportNotFound
+}
+
+func (r *fakeInvoiceExportRepository) DeleteExpired(ctx context.Context, now time.Time) ([]string, error) {
+	return nil, nil
+}
+
+// fakeExportStorage guarda os arquivos em memória; o conteúdo só aparece no Close, como no FileExportStorage
+type fakeExportStorage struct {
+	mu    sync.Mutex
+	files map[string][]byte
+}
+
+type fakeExportFile struct {
+	bytes.Buffer
+	storage *fakeExportStorage
+	id      string
+}
+
+func (f *fakeExportFile) Close() error {
+	f.storage.mu.Lock()
+	defer f.storage.mu.Unlock()
+	f.storage.files[f.id] = f.Bytes()
+	return nil
+}
+
+func (s *fakeExportStorage) Create(id string) (io.WriteCloser, error) {
+	return &fakeExportFile{storage: s, id: id}, nil
+}
+
+func (s *fakeExportStorage) Open(id string) (io.ReadCloser, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	file, ok := s.files[id]
+	if !ok {
+		return nil, domain.ErrInvoiceExportNotReady
+	}
+	return io.NopCloser(bytes.NewReader(file)), nil
+}
+
+func (s *fakeExportStorage) Delete(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.files, id)
+	return nil
+}
+
+// fakeVelocityCounter nunca estoura os limites
+type fakeVelocityCounter struct{}
+
+func (fakeVelocityCounter) Increment(key string, window time.Duration) (int, error) {
+	return 1, nil
+}
+
+// countingVelocityCounter conta as tentativas por chave (sem janela), para os testes verem o que foi registrado
+type countingVelocityCounter struct {
+	mu     sync.Mutex
+	counts map[string]int
+}
+
+func (c *countingVelocityCounter) Increment(key string, window time.Duration) (int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.counts == nil {
+		c.counts = make(map[string]int)
+	}
+	c.counts[key]++
+	return c.counts[key], nil
+}
+
+func (c *countingVelocityCounter) count(prefix string) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	total := 0
+	for key, count := range c.counts {
+		if strings.HasPrefix(key, prefix) {
+			total += count
+		}
+	}
+	return total
+}
+
+// fakeKafkaProducer guarda os eventos de transações pendentes e os headers que seriam enviados
+// batches conta as chamadas de SendingPendingTransactions; failBatch simula o broker recusando o lote
+type fakeKafkaProducer struct {
+	mu        sync.Mutex
+	events    []events.PendingTransaction
+	headers   [][]kafka.Header
+	batches   int
+	failBatch error
+}
+
+func (p *fakeKafkaProducer) SendingPendingTransaction(ctx context.Context, event events.PendingTransaction) error {
+	var headers []kafka.Header
+	tracing.InjectKafka(ctx, &headers)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.events = append(p.events, event)
+	p.headers = append(p.headers, headers)
+	return nil
+}
+
+func (p *fakeKafkaProducer) SendingPendingTransactions(ctx context.Context, pending []events.PendingTransaction) error {
+	var headers []kafka.Header
+	tracing.InjectKafka(ctx, &headers)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.failBatch != nil {
+		return p.failBatch
+	}
+	p.batches++
+	for _, event := range pending {
+		p.events = append(p.events, event)
+		p.headers = append(p.headers, headers)
+	}
+	return nil
+}
+
+func (p *fakeKafkaProducer) Close() error {
+	return nil
+}
+
+// fakeInvoiceReportRepository agrega as faturas do fakeInvoiceRepository; o rollup é uma cópia dos totais diários
+// tirada no RefreshRollup, então faturas novas só aparecem nele depois do próximo refresh
+type fakeInvoiceReportRepository struct {
+	invoices *fakeInvoiceRepository
+
+	mu             sync.Mutex
+	rollup         map[string][]domain.InvoiceAggregate // conta -> totais diários
+	refreshedUntil time.Time
+}
+
+// aggregate agrupa as faturas por conta, período, status, tipo de pagamento e bandeira
+func (r *fakeInvoiceReportRepository) aggregate(granularity domain.ReportGranularity, from, to time.Time) map[string][]domain.InvoiceAggregate {
+	type group struct {
+		accountID   string
+		periodStart time.Time
+		status      domain.Status
+		paymentType string
+		cardBrand   string
+	}
+
+	r.invoices.mu.Lock()
+	totals := make(map[group]*domain.InvoiceAggregate)
+	for _, invoice := range r.invoices.invoices {
+		if (!from.IsZero() && invoice.CreatedAt.Before(from)) || (!to.IsZero() && !invoice.CreatedAt.Before(to)) {
+			continue
+		}
+		key := group{invoice.AccountID, granularity.Truncate(invoice.CreatedAt), invoice.Status, invoice.PaymentType, invoice.CardBrand}
+		if totals[key] == nil {
+			totals[key] = &domain.InvoiceAggregate{PeriodStart: key.periodStart, Status: key.status, PaymentType: key.paymentType, CardBrand: key.cardBrand}
+		}
+		totals[key].Count++
+		totals[key].Amount += invoice.Amount
+	}
+	r.invoices.mu.Unlock()
+
+	aggregates := make(map[string][]domain.InvoiceAggregate)
+	for key, aggregate := range totals {
+		aggregates[key.accountID] = append(aggregates[key.accountID], *aggregate)
+	}
+	return aggregates
+}
+
+func (r *fakeInvoiceReportRepository) Aggregate(ctx context.Context, accountID string, granularity domain.ReportGranularity, from, to time.Time) ([]domain.InvoiceAggregate, error) {
+	return r.aggregate(granularity, from, to)[accountID], nil
+}
+
+func (r *fakeInvoiceReportRepository) AggregateRollup(ctx context.Context, accountID string, granularity domain.ReportGranularity, from, to time.Time) ([]domain.InvoiceAggregate, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var aggregates []domain.InvoiceAggregate
+	for _, daily := range r.rollup[accountID] {
+		if daily.PeriodStart.Before(from) || !daily.PeriodStart.Before(to) {
+			continue
+		}
+		daily.PeriodStart = granularity.Truncate(daily.PeriodStart)
+		aggregates = append(aggregates, daily)
+	}
+	return aggregates, nil
+}
+
+func (r *fakeInvoiceReportRepository) RollupRefreshedUntil(ctx context.Context) (time.Time, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.refreshedUntil, nil
+}
+
+func (r *fakeInvoiceReportRepository) RefreshRollup(ctx context.Context, until time.Time) (int, error) {
+	rollup := r.aggregate(domain.ReportDay, time.Time{}, until)
+
+	days := 0
+	for _, daily := range rollup {
+		seen := make(map[time.Time]bool)
+		for _, aggregate := range daily {
+			if !seen[aggregate.PeriodStart] {
+				seen[aggregate.PeriodStart] = true
+				days++
+			}
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.rollup = rollup
+	r.refreshedUntil = until
+	return days, nil
+}
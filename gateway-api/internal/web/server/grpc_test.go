@@ -0,0 +1,156 @@
+package server
+
+import (
+	"context"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/j-ordep/gateway/go-gateway/internal/domain"
+	"github.com/j-ordep/gateway/go-gateway/internal/rpc"
+	"github.com/j-ordep/gateway/go-gateway/internal/rpc/gatewayv1"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// a API gRPC usa os mesmos services da REST: mesma autenticação, escopos e códigos de erro
+func TestGRPCMirrorsREST(t *testing.T) {
+	srv := newTestServer()
+	grpcSrv := rpc.NewServer(srv.accountService, srv.invoiceService, srv.rateLimitService, "0", 10*time.Millisecond, srv.trustedProxies)
+
+	listener := bufconn.Listen(1 << 20)
+	go grpcSrv.Serve(listener)
+	defer grpcSrv.Shutdown(context.Background())
+
+	conn, err := grpc.NewClient("passthrough:///bufconn",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return listener.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	accounts := gatewayv1.NewAccountServiceClient(conn)
+	invoices := gatewayv1.NewInvoiceServiceClient(conn)
+	ctx := context.Background()
+
+	// código gRPC + código estável em ErrorInfo.reason, o mesmo do envelope JSON
+	expectError := func(t *testing.T, err error, code codes.Code, reason string) *status.Status {
+		t.Helper()
+		st := status.Convert(err)
+		if st.Code() != code {
+			t.Fatalf("esperado %s, recebido %s (%s)", code, st.Code(), st.Message())
+		}
+		for _, detail := range st.Details() {
+			if info, ok := detail.(*errdetails.ErrorInfo); ok {
+				if info.Reason != reason {
+					t.Fatalf("esperado reason %q, recebido %q", reason, info.Reason)
+				}
+				if info.Metadata["request_id"] == "" {
+					t.Fatal("ErrorInfo sem request_id")
+				}
+				return st
+			}
+		}
+		t.Fatalf("erro sem ErrorInfo: %v", st.Details())
+		return nil
+	}
+
+	_, err = accounts.CreateAccount(ctx, &gatewayv1.CreateAccountRequest{Name: "gRPC", Email: "invalido"})
+	st := expectError(t, err, codes.InvalidArgument, "validation_failed")
+	violations := 0
+	for _, detail := range st.Details() {
+		if badRequest, ok := detail.(*errdetails.BadRequest); ok {
+			violations = len(badRequest.FieldViolations)
+		}
+	}
+	if violations != 1 {
+		t.Fatalf("esperada 1 violação (email), recebidas %d", violations)
+	}
+
+	account, err := accounts.CreateAccount(ctx, &gatewayv1.CreateAccountRequest{Name: "gRPC", Email: "grpc@example.com"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := accounts.CreateAccount(ctx, &gatewayv1.CreateAccountRequest{Name: "Outra", Email: "outra@example.com"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = accounts.GetAccount(ctx, &gatewayv1.GetAccountRequest{})
+	expectError(t, err, codes.Unauthenticated, "missing_api_key")
+
+	_, err = accounts.GetAccount(metadata.AppendToOutgoingContext(ctx, "x-api-key", "invalida"), &gatewayv1.GetAccountRequest{})
+	expectError(t, err, codes.Unauthenticated, "invalid_api_key")
+
+	authCtx := metadata.AppendToOutgoingContext(ctx, "x-api-key", account.ApiKey)
+	got, err := accounts.GetAccount(authCtx, &gatewayv1.GetAccountRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Id != account.Id {
+		t.Fatalf("esperada a conta %s, recebida %s", account.Id, got.Id)
+	}
+
+	// acima de 10000 a fatura fica pendente aguardando o antifraude
+	invoice, err := invoices.CreateInvoice(authCtx, &gatewayv1.CreateInvoiceRequest{
+		Amount: 15000, Description: "Alto valor", PaymentType: "credit_card",
+		CardNumber: "4111111111111111", Cvv: "123", ExpiryMonth: 12, ExpiryYear: 2030, CardholderName: "John Doe",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if invoice.Status != string(domain.StatusPending) {
+		t.Fatalf("esperado pending, recebido %s", invoice.Status)
+	}
+
+	otherCtx := metadata.AppendToOutgoingContext(ctx, "x-api-key", other.ApiKey)
+	_, err = invoices.GetInvoice(otherCtx, &gatewayv1.GetInvoiceRequest{Id: invoice.Id})
+	expectError(t, err, codes.PermissionDenied, "forbidden")
+
+	_, err = invoices.GetInvoice(authCtx, &gatewayv1.GetInvoiceRequest{Id: "inexistente"})
+	expectError(t, err, codes.NotFound, "invoice_not_found")
+
+	list, err := invoices.ListInvoices(authCtx, &gatewayv1.ListInvoicesRequest{Status: "pending"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list.Invoices) != 1 || list.NextCursor != "" {
+		t.Fatalf("esperada 1 fatura sem próxima página, recebidas %d (cursor %q)", len(list.Invoices), list.NextCursor)
+	}
+
+	// o stream envia o status atual, a mudança e termina quando a fatura é decidida
+	stream, err := invoices.WatchInvoiceStatus(authCtx, &gatewayv1.WatchInvoiceStatusRequest{Id: invoice.Id})
+	if err != nil {
+		t.Fatal(err)
+	}
+	first, err := stream.Recv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first.Status != string(domain.StatusPending) {
+		t.Fatalf("primeira mensagem deveria ser pending, recebido %s", first.Status)
+	}
+
+	if err := srv.invoiceService.ProcessTransactionResult(ctx, invoice.Id, domain.StatusApproved); err != nil {
+		t.Fatal(err)
+	}
+
+	second, err := stream.Recv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if second.Status != string(domain.StatusApproved) {
+		t.Fatalf("segunda mensagem deveria ser approved, recebido %s", second.Status)
+	}
+	if _, err := stream.Recv(); err != io.EOF {
+		t.Fatalf("o stream deveria terminar após a decisão, recebido %v", err)
+	}
+}
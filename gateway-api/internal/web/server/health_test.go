@@ -0,0 +1,47 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/j-ordep/gateway/go-gateway/internal/dto"
+	"github.com/j-ordep/gateway/go-gateway/internal/service"
+)
+
+func TestReadiness(t *testing.T) {
+	srv := newTestServer()
+	srv.healthService.AddCheck("kafka", func(ctx context.Context) error { return errors.New("no kafka broker reachable") })
+
+	readyz := func() (int, dto.HealthOutput) {
+		recorder := httptest.NewRecorder()
+		srv.router.ServeHTTP(recorder, httptest.NewRequest("GET", "/readyz", nil))
+		var body dto.HealthOutput
+		json.Unmarshal(recorder.Body.Bytes(), &body)
+		return recorder.Code, body
+	}
+
+	status, body := readyz()
+	if status != http.StatusServiceUnavailable || body.Status != service.HealthStatusUnavailable {
+		t.Fatalf("readyz = %d %q, esperado 503 unavailable", status, body.Status)
+	}
+	if body.Checks["postgres"].Status != "up" || body.Checks["kafka"].Status != "down" || body.Checks["kafka"].Error == "" {
+		t.Errorf("checks = %+v, esperado postgres up e kafka down com erro", body.Checks)
+	}
+
+	// durante o graceful shutdown o readiness falha mesmo com as dependências de pé, o liveness continua ok
+	srv = newTestServer()
+	srv.healthService.SetShuttingDown()
+	if status, body := readyz(); status != http.StatusServiceUnavailable || body.Status != service.HealthStatusShuttingDown {
+		t.Errorf("readyz durante shutdown = %d %q, esperado 503 shutting_down", status, body.Status)
+	}
+
+	recorder := httptest.NewRecorder()
+	srv.router.ServeHTTP(recorder, httptest.NewRequest("GET", "/healthz", nil))
+	if recorder.Code != http.StatusOK {
+		t.Errorf("healthz durante shutdown = %d, esperado 200", recorder.Code)
+	}
+}
@@ -0,0 +1,191 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/j-ordep/gateway/go-gateway/internal/domain"
+	"github.com/j-ordep/gateway/go-gateway/internal/repository"
+	"github.com/j-ordep/gateway/go-gateway/internal/service"
+	"github.com/j-ordep/gateway/go-gateway/internal/web/request"
+)
+
+const (
+	testAdminKey   = "admin-secret"
+	testOperatorID = "support-1"
+	// RemoteAddr das requisições do httptest, tratado como o load balancer à frente do gateway
+	testProxyIP = "192.0.2.1"
+)
+
+var testSunset = time.Date(2027, 4, 30, 0, 0, 0, 0, time.UTC)
+
+// testServerOptions troca as dependências do servidor de teste; os campos zerados usam o padrão
+type testServerOptions struct {
+	rateLimit       domain.RateLimit               // padrão: 100 req/s, burst 100
+	kafkaProducer   service.KafkaProducerInterface // padrão: fakeKafkaProducer
+	velocityCounter domain.VelocityCounter         // padrão: fakeVelocityCounter, sem limite
+}
+
+func newTestServer() *Server {
+	return newTestServerWith(testServerOptions{})
+}
+
+func newTestServerWith(options testServerOptions) *Server {
+	limit := options.rateLimit
+	if limit == (domain.RateLimit{}) {
+		limit = domain.RateLimit{Rate: 100, Burst: 100}
+	}
+	kafkaProducer := options.kafkaProducer
+	if kafkaProducer == nil {
+		kafkaProducer = &fakeKafkaProducer{}
+	}
+	velocityCounter := options.velocityCounter
+	if velocityCounter == nil {
+		velocityCounter = fakeVelocityCounter{}
+	}
+
+	invoiceRepository := newFakeInvoiceRepository()
+	auditRepository := &fakeAuditLogRepository{}
+	accountRepository := newFakeAccountRepository(invoiceRepository, auditRepository)
+	invoiceRepository.accounts = accountRepository
+	challengeRepository := newFakeThreeDSChallengeRepository()
+	invoiceRepository.challenges = challengeRepository
+	invoiceRepository.audit = auditRepository
+	deliveryRepository := &fakeWebhookDeliveryRepository{}
+	invoiceRepository.deliveries = deliveryRepository
+	paymentMethodRepository := newFakePaymentMethodRepository()
+	customerRepository := newFakeCustomerRepository(paymentMethodRepository)
+
+	accountService := service.NewAccountService(accountRepository, service.NewAPIKeyCache(100, time.Minute))
+	velocityService := service.NewVelocityService(velocityCounter, service.NewVelocityConfig())
+	threeDSService := service.NewThreeDSService(challengeRepository, &service.ThreeDSConfig{
+		BaseURL:               "http://localhost:8081",
+		FrictionlessMaxAmount: 1000,
+		ChallengeCode:         "123456",
+	})
+	webhookService := service.NewWebhookService(&fakeWebhookEndpointRepository{}, deliveryRepository, &service.WebhookConfig{
+		MaxAttempts:    3,
+		RetryBaseDelay: time.Millisecond,
+		Timeout:        time.Second,
+		BatchSize:      10,
+		AllowInsecure:  true, // os receivers do httptest são http em 127.0.0.1
+	})
+	// página de 2 para o Last-Event-ID exercitar a leitura paginada do histórico
+	invoiceStreamConfig := &service.InvoiceStreamConfig{
+		Heartbeat:   50 * time.Millisecond,
+		Retention:   time.Hour,
+		BacklogPage: 2,
+		BufferSize:  16,
+	}
+	invoiceEventHub := service.NewInvoiceEventHub(invoiceStreamConfig.BufferSize)
+	invoiceEventRepository := &fakeInvoiceEventRepository{hub: invoiceEventHub}
+	invoiceRepository.events = invoiceEventRepository
+	invoiceStreamService := service.NewInvoiceStreamService(invoiceRepository, invoiceEventRepository, invoiceEventHub, invoiceStreamConfig)
+	invoiceExportService := service.NewInvoiceExportService(invoiceRepository, &fakeInvoiceExportRepository{}, &fakeExportStorage{files: make(map[string][]byte)}, &service.InvoiceExportConfig{
+		Timezone:  "UTC",
+		Retention: time.Hour,
+		Lease:     time.Minute,
+	})
+	invoiceReportService := service.NewInvoiceReportService(&fakeInvoiceReportRepository{invoices: invoiceRepository}, &service.InvoiceReportConfig{})
+	invoiceService := service.NewInvoiceService(invoiceRepository, *accountService, kafkaProducer, velocityService, customerRepository, paymentMethodRepository, threeDSService, webhookService)
+	customerService := service.NewCustomerService(customerRepository, paymentMethodRepository, invoiceRepository)
+	idempotencyService := service.NewIdempotencyService(newFakeIdempotencyRepository(), time.Hour, time.Minute)
+	invoiceBatchService := service.NewInvoiceBatchService(invoiceService, idempotencyService, &service.InvoiceBatchConfig{MaxItems: 5, Concurrency: 2})
+	rateLimitService := service.NewRateLimitService(repository.NewMemoryRateLimitRepository(), &service.RateLimitConfig{
+		Tiers:     map[domain.AccountTier]domain.RateLimit{domain.AccountTierStandard: limit},
+		Anonymous: limit,
+	})
+	healthService := service.NewHealthService(time.Second)
+	healthService.AddCheck("postgres", func(ctx context.Context) error { return nil })
+
+	adminService := service.NewAdminService(accountService, invoiceService, auditRepository)
+
+	// a configuração guarda só o sha256 do token do operador
+	tokenHash := sha256.Sum256([]byte(testAdminKey))
+	operatorTokens := map[string]string{testOperatorID: hex.EncodeToString(tokenHash[:])}
+
+	trustedProxies, _ := request.ParseTrustedProxies(testProxyIP)
+
+	srv := NewServer(accountService, invoiceService, invoiceBatchService, customerService, threeDSService, idempotencyService, rateLimitService, healthService, adminService, webhookService, invoiceStreamService, invoiceExportService, invoiceReportService, "0", operatorTokens, testSunset, trustedProxies)
+	srv.ConfigureRoutes()
+	return srv
+}
+
+// testCard é o cartão de teste usado nas faturas de cartão de crédito
+var testCard = map[string]any{
+	"card_number":     "4111111111111111",
+	"cvv":             "123",
+	"expiry_month":    12,
+	"expiry_year":     2030,
+	"cardholder_name": "John Doe",
+}
+
+// invoiceInput monta o corpo do POST /invoice com o cartão de teste; extra complementa ou sobrescreve os campos
+// acima de 10000 a fatura fica pendente aguardando o antifraude
+func invoiceInput(amount float64, extra map[string]any) map[string]any {
+	body := map[string]any{"amount": amount, "description": "Teste", "payment_type": "credit_card"}
+	for key, value := range testCard {
+		body[key] = value
+	}
+	for key, value := range extra {
+		body[key] = value
+	}
+	return body
+}
+
+// testClient envia requisições ao router do servidor de teste autenticadas pela apiKey
+type testClient struct {
+	t   *testing.T
+	srv *Server
+}
+
+func newTestClient(t *testing.T, srv *Server) *testClient {
+	return &testClient{t: t, srv: srv}
+}
+
+// request envia body como JSON (nil envia sem corpo) e devolve a resposta sem conferir o status
+func (c *testClient) request(method, path, apiKey string, body any) *httptest.ResponseRecorder {
+	c.t.Helper()
+	var payload []byte
+	if body != nil {
+		payload, _ = json.Marshal(body)
+	}
+	req := httptest.NewRequest(method, path, bytes.NewReader(payload))
+	if apiKey != "" {
+		req.Header.Set("X-API-KEY", apiKey)
+	}
+	recorder := httptest.NewRecorder()
+	c.srv.router.ServeHTTP(recorder, req)
+	return recorder
+}
+
+// do é o request que falha o teste quando o status difere do esperado
+func (c *testClient) do(method, path, apiKey string, body any, status int) *httptest.ResponseRecorder {
+	c.t.Helper()
+	recorder := c.request(method, path, apiKey, body)
+	if recorder.Code != status {
+		c.t.Fatalf("%s %s retornou %d, esperado %d: %s", method, path, recorder.Code, status, recorder.Body.String())
+	}
+	return recorder
+}
+
+// createAccount cria uma conta e devolve a apiKey
+func (c *testClient) createAccount(name, email string) string {
+	c.t.Helper()
+	account := decodeBody[map[string]any](c.do("POST", "/v1/accounts", "", map[string]any{"name": name, "email": email}, http.StatusOK))
+	return account["api_key"].(string)
+}
+
+// decodeBody decodifica o corpo JSON da resposta
+func decodeBody[T any](recorder *httptest.ResponseRecorder) T {
+	var output T
+	json.Unmarshal(recorder.Body.Bytes(), &output)
+	return output
+}
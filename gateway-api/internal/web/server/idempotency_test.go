@@ -0,0 +1,131 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/j-ordep/gateway/go-gateway/internal/domain"
+	"github.com/j-ordep/gateway/go-gateway/internal/dto"
+	"github.com/j-ordep/gateway/go-gateway/internal/service"
+)
+
+// a retentativa com a mesma Idempotency-Key devolve a resposta original sem cobrar de novo
+// e a chave não pode ser reaproveitada com outro corpo
+func TestIdempotencyKey(t *testing.T) {
+	srv := newTestServer()
+	client := newTestClient(t, srv)
+	apiKey := client.createAccount("Idempotência", "idempotency@example.com")
+
+	post := func(key string, body []byte, status int) *httptest.ResponseRecorder {
+		t.Helper()
+		req := httptest.NewRequest("POST", "/v1/invoice", bytes.NewReader(body))
+		req.Header.Set("X-API-KEY", apiKey)
+		req.Header.Set("Idempotency-Key", key)
+		recorder := httptest.NewRecorder()
+		srv.router.ServeHTTP(recorder, req)
+		if recorder.Code != status {
+			t.Fatalf("POST /v1/invoice retornou %d, esperado %d: %s", recorder.Code, status, recorder.Body.String())
+		}
+		return recorder
+	}
+	body, _ := json.Marshal(invoiceInput(100, nil))
+
+	first := post("order-1", body, http.StatusCreated)
+	if first.Header().Get("Idempotent-Replayed") != "" {
+		t.Fatal("a primeira requisição não é um replay")
+	}
+	replayed := post("order-1", body, http.StatusCreated)
+	if replayed.Header().Get("Idempotent-Replayed") != "true" || replayed.Body.String() != first.Body.String() {
+		t.Fatalf("retentativa deveria devolver a resposta original: %s", replayed.Body.String())
+	}
+	invoices := decodeBody[dto.InvoiceListOutput](client.do("GET", "/v1/invoices", apiKey, nil, http.StatusOK))
+	if len(invoices.Data) != 1 {
+		t.Fatalf("a retentativa não pode criar outra fatura: %d", len(invoices.Data))
+	}
+
+	other, _ := json.Marshal(invoiceInput(200, nil))
+	if reused := decodeBody[map[string]any](post("order-1", other, http.StatusUnprocessableEntity)); reused["code"] != "idempotency_key_reused" {
+		t.Fatalf("chave reaproveitada com outro corpo: %v", reused)
+	}
+
+	// o corpo não é truncado para calcular o hash: acima do limite a requisição é recusada
+	large, _ := json.Marshal(invoiceInput(100, map[string]any{"description": strings.Repeat("a", 1<<20)}))
+	if tooLarge := decodeBody[map[string]any](post("order-2", large, http.StatusRequestEntityTooLarge)); tooLarge["code"] != "request_too_large" {
+		t.Fatalf("corpo acima do limite: %v", tooLarge)
+	}
+}
+
+// a rota legada e a /v1 são a mesma requisição para a chave; já o mesmo padrão com outro recurso não é
+func TestIdempotencyKeyAcrossVersions(t *testing.T) {
+	srv := newTestServer()
+	client := newTestClient(t, srv)
+	apiKey := client.createAccount("Idempotência", "idempotency-versions@example.com")
+
+	send := func(method, path, key string, body []byte, status int) *httptest.ResponseRecorder {
+		t.Helper()
+		req := httptest.NewRequest(method, path, bytes.NewReader(body))
+		req.Header.Set("X-API-KEY", apiKey)
+		req.Header.Set("Idempotency-Key", key)
+		recorder := httptest.NewRecorder()
+		srv.router.ServeHTTP(recorder, req)
+		if recorder.Code != status {
+			t.Fatalf("%s %s retornou %d, esperado %d: %s", method, path, recorder.Code, status, recorder.Body.String())
+		}
+		return recorder
+	}
+	body, _ := json.Marshal(invoiceInput(100, nil))
+
+	first := send("POST", "/invoice", "order-1", body, http.StatusCreated)
+	replayed := send("POST", "/v1/invoice", "order-1", body, http.StatusCreated)
+	if replayed.Header().Get("Idempotent-Replayed") != "true" || replayed.Body.String() != first.Body.String() {
+		t.Fatalf("a /v1 deveria devolver a resposta da rota legada: %s", replayed.Body.String())
+	}
+	invoices := decodeBody[dto.InvoiceListOutput](client.do("GET", "/v1/invoices", apiKey, nil, http.StatusOK))
+	if len(invoices.Data) != 1 {
+		t.Fatalf("a retentativa pela outra versão não pode criar outra fatura: %d", len(invoices.Data))
+	}
+
+	// mesmo padrão (/customers/{id}) e mesmo corpo, mas outro cliente: a chave não vale para ele
+	maria := decodeBody[dto.CustomerOutput](client.do("POST", "/v1/customers", apiKey, map[string]any{"name": "Maria", "email": "maria@example.com"}, http.StatusCreated))
+	joao := decodeBody[dto.CustomerOutput](client.do("POST", "/v1/customers", apiKey, map[string]any{"name": "João", "email": "joao@example.com"}, http.StatusCreated))
+	send("DELETE", "/v1/customers/"+maria.ID, "delete-1", nil, http.StatusNoContent)
+	if reused := decodeBody[map[string]any](send("DELETE", "/customers/"+joao.ID, "delete-1", nil, http.StatusUnprocessableEntity)); reused["code"] != "idempotency_key_reused" {
+		t.Fatalf("chave reaproveitada para outro cliente: %v", reused)
+	}
+}
+
+// uma requisição que caiu sem concluir segura a chave só até o locked_until; depois a retentativa assume
+// e a requisição original não consegue mais gravar a resposta por cima
+func TestIdempotencyLockTakeOver(t *testing.T) {
+	idempotencyService := service.NewIdempotencyService(newFakeIdempotencyRepository(), time.Hour, 20*time.Millisecond)
+
+	original, replay, err := idempotencyService.Begin("account-1", "order-1", "hash")
+	if err != nil || replay {
+		t.Fatalf("a primeira requisição reserva a chave: %v %v", replay, err)
+	}
+	if _, _, err := idempotencyService.Begin("account-1", "order-1", "hash"); err != domain.ErrIdempotencyRequestInProgress {
+		t.Fatalf("dentro do prazo a chave continua em andamento: %v", err)
+	}
+
+	time.Sleep(30 * time.Millisecond)
+	retry, replay, err := idempotencyService.Begin("account-1", "order-1", "hash")
+	if err != nil || replay {
+		t.Fatalf("com o prazo vencido a retentativa assume a chave: %v %v", replay, err)
+	}
+	if err := idempotencyService.Complete(original, http.StatusCreated, []byte(`{"id":"original"}`)); err != domain.ErrIdempotencyLockLost {
+		t.Fatalf("a requisição original perdeu a chave: %v", err)
+	}
+	if err := idempotencyService.Complete(retry, http.StatusCreated, []byte(`{"id":"retry"}`)); err != nil {
+		t.Fatal(err)
+	}
+
+	record, replay, err := idempotencyService.Begin("account-1", "order-1", "hash")
+	if err != nil || !replay || string(record.ResponseBody) != `{"id":"retry"}` {
+		t.Fatalf("a resposta gravada é a da retentativa: %v %v %+v", replay, err, record)
+	}
+}
@@ -0,0 +1,168 @@
+package server
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/j-ordep/gateway/go-gateway/internal/dto"
+)
+
+// cada item tem o próprio resultado; as transações pendentes do lote vão para o kafka em um único envio
+// e a retentativa do lote devolve as faturas já criadas pelas chaves dos itens
+func TestInvoiceBatch(t *testing.T) {
+	producer := &fakeKafkaProducer{}
+	srv := newTestServerWith(testServerOptions{kafkaProducer: producer})
+
+	client := newTestClient(t, srv)
+	do := func(apiKey string, body any, status int) *httptest.ResponseRecorder {
+		t.Helper()
+		return client.do("POST", "/v1/invoices/batch", apiKey, body, status)
+	}
+	invoice := func(amount float64) map[string]any {
+		return invoiceInput(amount, map[string]any{"description": "Lote"})
+	}
+	apiKey := client.createAccount("Lote", "batch@example.com")
+
+	batch := map[string]any{"items": []any{
+		map[string]any{"idempotency_key": "batch-a", "invoice": invoice(20000)},
+		map[string]any{"idempotency_key": "batch-b", "invoice": invoice(15000)},
+		map[string]any{"invoice": invoice(100)},
+		map[string]any{"idempotency_key": "batch-c", "invoice": invoice(-1)},
+	}}
+
+	output := decodeBody[dto.BatchInvoiceOutput](do(apiKey, batch, http.StatusOK))
+	if output.Created != 3 || output.Failed != 1 || len(output.Results) != 4 {
+		t.Fatalf("lote inesperado: %+v", output)
+	}
+	for i, result := range output.Results[:3] {
+		if result.Index != i || result.Status != http.StatusCreated || result.Invoice == nil || result.Replayed {
+			t.Fatalf("item %d deveria ser criado: %+v", i, result)
+		}
+	}
+	if output.Results[0].Invoice.Status != dto.StatusPending || output.Results[0].IdempotencyKey != "batch-a" {
+		t.Fatalf("acima de 10000 a fatura fica pendente: %+v", output.Results[0])
+	}
+	invalid := output.Results[3]
+	if invalid.Status != http.StatusUnprocessableEntity || invalid.Invoice != nil || invalid.Error.(map[string]any)["code"] != "validation_failed" {
+		t.Fatalf("item inválido deveria ter o próprio erro: %+v", invalid)
+	}
+	if producer.batches != 1 || len(producer.events) != 2 {
+		t.Fatalf("esperado 1 envio com 2 transações pendentes, foram %d envios e %d eventos", producer.batches, len(producer.events))
+	}
+
+	// na retentativa os itens com chave são devolvidos sem cobrar de novo; o item inválido pode ser corrigido com a mesma chave
+	batch["items"].([]any)[3] = map[string]any{"idempotency_key": "batch-c", "invoice": invoice(50)}
+	retry := decodeBody[dto.BatchInvoiceOutput](do(apiKey, batch, http.StatusOK))
+	if retry.Created != 4 || !retry.Results[0].Replayed || retry.Results[0].Invoice.ID != output.Results[0].Invoice.ID ||
+		retry.Results[1].Invoice.ID != output.Results[1].Invoice.ID {
+		t.Fatalf("retentativa deveria devolver as faturas já criadas: %+v", retry)
+	}
+	if retry.Results[2].Replayed || retry.Results[2].Invoice.ID == output.Results[2].Invoice.ID || retry.Results[3].Status != http.StatusCreated {
+		t.Fatalf("itens sem chave ou que falharam são processados de novo: %+v", retry)
+	}
+	if producer.batches != 1 {
+		t.Fatalf("nenhuma transação pendente nova, o kafka não deveria ser chamado: %d envios", producer.batches)
+	}
+
+	// a chave é do item: outro conteúdo com a mesma chave é recusado
+	output = decodeBody[dto.BatchInvoiceOutput](do(apiKey, map[string]any{"items": []any{
+		map[string]any{"idempotency_key": "batch-a", "invoice": invoice(30000)},
+	}}, http.StatusOK))
+	if output.Results[0].Status != http.StatusUnprocessableEntity || output.Results[0].Error.(map[string]any)["code"] != "idempotency_key_reused" {
+		t.Fatalf("chave reutilizada com outro item: %+v", output.Results[0])
+	}
+
+	// o servidor de teste aceita até 5 itens
+	tooMany := make([]any, 6)
+	for i := range tooMany {
+		tooMany[i] = map[string]any{"invoice": invoice(100)}
+	}
+	body := decodeBody[map[string]any](do(apiKey, map[string]any{"items": tooMany}, http.StatusUnprocessableEntity))
+	if body["code"] != "invalid_batch_size" {
+		t.Fatalf("lote acima do limite: %v", body)
+	}
+}
+
+// se o envio do lote ao kafka falhar, as faturas pendentes não são gravadas e as chaves ficam livres para a retentativa
+func TestInvoiceBatchKafkaFailure(t *testing.T) {
+	producer := &fakeKafkaProducer{failBatch: errors.New("broker indisponível")}
+	srv := newTestServerWith(testServerOptions{kafkaProducer: producer})
+
+	client := newTestClient(t, srv)
+	apiKey := client.createAccount("Lote", "batch-kafka@example.com")
+
+	batch := map[string]any{"items": []any{
+		map[string]any{"idempotency_key": "pending-1", "invoice": invoiceInput(20000, nil)},
+	}}
+	send := func() dto.BatchInvoiceResultOutput {
+		t.Helper()
+		output := decodeBody[dto.BatchInvoiceOutput](client.do("POST", "/v1/invoices/batch", apiKey, batch, http.StatusOK))
+		if len(output.Results) != 1 {
+			t.Fatalf("lote deveria ter 1 resultado: %+v", output)
+		}
+		return output.Results[0]
+	}
+
+	if result := send(); result.Status != http.StatusInternalServerError || result.Invoice != nil {
+		t.Fatalf("falha no kafka deveria ser erro do item: %+v", result)
+	}
+
+	list := decodeBody[dto.InvoiceListOutput](client.do("GET", "/v1/invoices", apiKey, nil, http.StatusOK))
+	if len(list.Data) != 0 {
+		t.Fatalf("fatura sem o evento no kafka não deveria ser gravada: %d faturas", len(list.Data))
+	}
+
+	producer.mu.Lock()
+	producer.failBatch = nil
+	producer.mu.Unlock()
+	if result := send(); result.Status != http.StatusCreated || result.Replayed {
+		t.Fatalf("a chave deveria ter sido liberada: %+v", result)
+	}
+}
+
+// external_reference repetida dentro do lote é recusada antes do processamento, o saldo só recebe as faturas gravadas
+// e o lote conta uma vez no velocity da conta (cartão e IP continuam por item)
+func TestInvoiceBatchDuplicatesAndVelocity(t *testing.T) {
+	counter := &countingVelocityCounter{}
+	srv := newTestServerWith(testServerOptions{velocityCounter: counter})
+
+	client := newTestClient(t, srv)
+	apiKey := client.createAccount("Lote", "batch-duplicates@example.com")
+	account := decodeBody[dto.AccountOutput](client.do("GET", "/v1/accounts", apiKey, nil, http.StatusOK))
+
+	reference := func(amount float64, externalReference string) map[string]any {
+		return map[string]any{"invoice": invoiceInput(amount, map[string]any{"external_reference": externalReference})}
+	}
+	output := decodeBody[dto.BatchInvoiceOutput](client.do("POST", "/v1/invoices/batch", apiKey, map[string]any{"items": []any{
+		reference(100, "order-1"),
+		reference(200, "order-1"),
+		reference(300, "order-2"),
+	}}, http.StatusOK))
+
+	if output.Created != 2 || output.Results[0].Status != http.StatusCreated || output.Results[2].Status != http.StatusCreated {
+		t.Fatalf("as referências distintas deveriam ser criadas: %+v", output)
+	}
+	if duplicated := output.Results[1]; duplicated.Status != http.StatusConflict || duplicated.Error.(map[string]any)["code"] != "external_reference_already_exists" {
+		t.Fatalf("referência repetida no lote: %+v", duplicated)
+	}
+
+	expected := 0.0
+	for _, result := range output.Results {
+		if result.Invoice != nil && result.Invoice.Status == dto.StatusApproved {
+			expected += result.Invoice.Amount
+		}
+	}
+	account = decodeBody[dto.AccountOutput](client.do("GET", "/v1/accounts", apiKey, nil, http.StatusOK))
+	if account.Balance != expected {
+		t.Fatalf("saldo deveria ser %.2f (aprovadas gravadas), é %.2f", expected, account.Balance)
+	}
+
+	if accountCount := counter.count("account:" + account.ID); accountCount != 1 {
+		t.Fatalf("o lote deveria contar uma vez para a conta, contou %d", accountCount)
+	}
+	if cardCount := counter.count("card:"); cardCount != 2 {
+		t.Fatalf("cada item processado conta para o cartão: %d", cardCount)
+	}
+}
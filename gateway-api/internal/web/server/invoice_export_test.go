@@ -0,0 +1,118 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"net/http"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/j-ordep/gateway/go-gateway/internal/domain"
+	"github.com/j-ordep/gateway/go-gateway/internal/dto"
+)
+
+// a exportação síncrona e a assíncrona geram o mesmo arquivo, com colunas fixas, filtros e fuso aplicados
+func TestInvoiceExportSyncAndAsync(t *testing.T) {
+	srv := newTestServer()
+	ctx := context.Background()
+
+	client := newTestClient(t, srv)
+
+	// acima de 10000 a fatura fica pendente aguardando o antifraude
+	pendingInvoice := func(apiKey, description string) string {
+		t.Helper()
+		return decodeBody[map[string]any](client.do("POST", "/v1/invoice", apiKey, invoiceInput(15000, map[string]any{"description": description}), http.StatusCreated))["id"].(string)
+	}
+
+	apiKey := client.createAccount("Export", "export@example.com")
+	otherKey := client.createAccount("Outra", "outra-export@example.com")
+
+	pendingID := pendingInvoice(apiKey, "=HYPERLINK(\"http://evil\")")
+	approvedID := pendingInvoice(apiKey, "Aprovada")
+	if err := srv.invoiceService.ProcessTransactionResult(ctx, approvedID, domain.StatusApproved); err != nil {
+		t.Fatal(err)
+	}
+	pendingInvoice(otherKey, "Outra conta")
+
+	// CSV: cabeçalho fixo, só as pendentes da conta, fórmula neutralizada
+	exported := client.do("GET", "/v1/invoices/export?status=pending", apiKey, nil, http.StatusOK)
+	if contentType := exported.Header().Get("Content-Type"); contentType != "text/csv; charset=utf-8" {
+		t.Fatalf("content-type do CSV = %q", contentType)
+	}
+	if disposition := exported.Header().Get("Content-Disposition"); !strings.HasPrefix(disposition, "attachment;") || !strings.HasSuffix(disposition, `.csv"`) {
+		t.Fatalf("content-disposition do CSV = %q", disposition)
+	}
+	records, err := csv.NewReader(bytes.NewReader(exported.Body.Bytes())).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 2 || !slices.Equal(records[0], dto.InvoiceExportColumns) {
+		t.Fatalf("CSV deveria ter o cabeçalho e 1 linha: %v", records)
+	}
+	if row := records[1]; row[0] != pendingID || row[2] != "pending" || row[3] != "15000.00" || row[6] != "'=HYPERLINK(\"http://evil\")" {
+		t.Fatalf("linha do CSV inesperada: %v", row)
+	}
+
+	// NDJSON: um objeto por linha, campos na ordem das colunas e datas no fuso pedido
+	exported = client.do("GET", "/v1/invoices/export?format=ndjson&timezone=America/Sao_Paulo", apiKey, nil, http.StatusOK)
+	if contentType := exported.Header().Get("Content-Type"); contentType != "application/x-ndjson" {
+		t.Fatalf("content-type do NDJSON = %q", contentType)
+	}
+	lines := strings.Split(strings.TrimSuffix(exported.Body.String(), "\n"), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("NDJSON deveria ter 2 linhas, tem %d: %s", len(lines), exported.Body.String())
+	}
+	for _, line := range lines {
+		if !strings.HasPrefix(line, `{"id":"`) || !strings.Contains(line, `"external_reference":"","status":`) {
+			t.Fatalf("campos fora da ordem das colunas: %s", line)
+		}
+		var row dto.InvoiceExportRow
+		if err := json.Unmarshal([]byte(line), &row); err != nil {
+			t.Fatal(err)
+		}
+		// São Paulo está em UTC-3 o ano todo desde 2019
+		if !strings.HasSuffix(row.CreatedAt, "-03:00") {
+			t.Fatalf("created_at deveria estar em America/Sao_Paulo: %s", row.CreatedAt)
+		}
+	}
+
+	if code := client.request("GET", "/v1/invoices/export?created_from=ontem", apiKey, nil).Code; code != http.StatusUnprocessableEntity {
+		t.Fatalf("período inválido deveria retornar 422, retornou %d", code)
+	}
+
+	// assíncrona: fica pendente até o worker gerar o arquivo, que é igual ao da exportação síncrona
+	job := decodeBody[map[string]any](client.do("POST", "/v1/invoices/exports", apiKey, map[string]any{"status": "pending"}, http.StatusAccepted))
+	jobID := job["id"].(string)
+	if job["status"] != "pending" || job["download_url"] != nil {
+		t.Fatalf("exportação recém-criada deveria estar pending: %v", job)
+	}
+	client.do("GET", "/v1/invoices/exports/"+jobID+"/download", apiKey, nil, http.StatusConflict)
+
+	for expected := 1; expected >= 0; expected-- {
+		processed, err := srv.invoiceExportService.ProcessPending(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if processed != expected {
+			t.Fatalf("esperadas %d exportações processadas, processadas %d", expected, processed)
+		}
+	}
+
+	job = decodeBody[map[string]any](client.do("GET", "/v1/invoices/exports/"+jobID, apiKey, nil, http.StatusOK))
+	if job["status"] != "completed" || job["row_count"] != float64(1) || job["download_url"] != "/v1/invoices/exports/"+jobID+"/download" || job["expires_at"] == nil {
+		t.Fatalf("exportação deveria estar completed com 1 linha: %v", job)
+	}
+
+	downloaded := client.do("GET", job["download_url"].(string), apiKey, nil, http.StatusOK)
+	synchronous := client.do("GET", "/v1/invoices/export?status=pending", apiKey, nil, http.StatusOK)
+	if !bytes.Equal(downloaded.Body.Bytes(), synchronous.Body.Bytes()) {
+		t.Fatalf("arquivo baixado difere da exportação síncrona:\n%s\n---\n%s", downloaded.Body.String(), synchronous.Body.String())
+	}
+
+	// a exportação pertence à conta que a criou
+	client.do("GET", "/v1/invoices/exports/"+jobID, otherKey, nil, http.StatusForbidden)
+	client.do("GET", "/v1/invoices/exports/"+jobID+"/download", otherKey, nil, http.StatusForbidden)
+}
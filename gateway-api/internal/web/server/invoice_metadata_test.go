@@ -0,0 +1,65 @@
+package server
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/j-ordep/gateway/go-gateway/internal/dto"
+)
+
+// external_reference é única por conta e, junto com o metadata, volta na fatura e filtra a listagem
+func TestInvoiceMetadataAndExternalReference(t *testing.T) {
+	srv := newTestServer()
+	client := newTestClient(t, srv)
+
+	apiKey := client.createAccount("Pedidos", "metadata@example.com")
+	otherKey := client.createAccount("Outra", "metadata-other@example.com")
+
+	created := decodeBody[dto.InvoiceOutput](client.do("POST", "/v1/invoice", apiKey, invoiceInput(100, map[string]any{
+		"external_reference": "order-1",
+		"metadata":           map[string]string{"order_id": "1", "channel": "web"},
+	}), http.StatusCreated))
+	if created.ExternalReference != "order-1" || created.Metadata["order_id"] != "1" || created.Metadata["channel"] != "web" {
+		t.Fatalf("referência e metadata deveriam voltar na fatura: %+v", created)
+	}
+	client.do("POST", "/v1/invoice", apiKey, invoiceInput(100, map[string]any{
+		"external_reference": "order-2",
+		"metadata":           map[string]string{"order_id": "2", "channel": "web"},
+	}), http.StatusCreated)
+
+	plain := decodeBody[map[string]any](client.do("POST", "/v1/invoice", apiKey, invoiceInput(100, nil), http.StatusCreated))
+	if metadata, ok := plain["metadata"].(map[string]any); !ok || len(metadata) != 0 {
+		t.Fatalf("sem metadata a fatura devolve {}, não null: %v", plain["metadata"])
+	}
+
+	// a referência é única só dentro da conta
+	conflict := decodeBody[map[string]any](client.do("POST", "/v1/invoice", apiKey, invoiceInput(100, map[string]any{"external_reference": "order-1"}), http.StatusConflict))
+	if conflict["code"] != "external_reference_already_exists" {
+		t.Fatalf("referência repetida na conta: %v", conflict)
+	}
+	client.do("POST", "/v1/invoice", otherKey, invoiceInput(100, map[string]any{"external_reference": "order-1"}), http.StatusCreated)
+
+	list := func(query string) []*dto.InvoiceOutput {
+		t.Helper()
+		return decodeBody[dto.InvoiceListOutput](client.do("GET", "/v1/invoices?"+query, apiKey, nil, http.StatusOK)).Data
+	}
+	if invoices := list("external_reference=order-1"); len(invoices) != 1 || invoices[0].ID != created.ID {
+		t.Fatalf("filtro por external_reference deveria achar só a fatura da conta: %+v", invoices)
+	}
+	if invoices := list("metadata[channel]=web"); len(invoices) != 2 {
+		t.Fatalf("metadata[channel]=web deveria achar 2 faturas, achou %d", len(invoices))
+	}
+	// todas as chaves do filtro precisam bater
+	if invoices := list("metadata[channel]=web&metadata[order_id]=2"); len(invoices) != 1 || invoices[0].ExternalReference != "order-2" {
+		t.Fatalf("filtro com duas chaves deveria achar só order-2: %+v", invoices)
+	}
+
+	// limites do metadata: chave de até 40 caracteres
+	invalid := decodeBody[map[string]any](client.do("POST", "/v1/invoice", apiKey, invoiceInput(100, map[string]any{
+		"metadata": map[string]string{strings.Repeat("k", 41): "v"},
+	}), http.StatusUnprocessableEntity))
+	if invalid["code"] != "invalid_metadata" {
+		t.Fatalf("chave longa demais no metadata: %v", invalid)
+	}
+}
@@ -0,0 +1,71 @@
+package server
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/j-ordep/gateway/go-gateway/internal/dto"
+)
+
+// a listagem é paginada por keyset em (created_at, id): as páginas seguem a ordem mais recentes primeiro,
+// não repetem nem pulam faturas mesmo com novas faturas criadas entre uma página e outra
+func TestInvoiceKeysetPagination(t *testing.T) {
+	srv := newTestServer()
+	client := newTestClient(t, srv)
+	apiKey := client.createAccount("Paginação", "pagination@example.com")
+
+	var created []string
+	for range 5 {
+		invoice := decodeBody[dto.InvoiceOutput](client.do("POST", "/v1/invoice", apiKey, invoiceInput(100, nil), http.StatusCreated))
+		created = append(created, invoice.ID)
+	}
+
+	page := func(query url.Values) dto.InvoiceListOutput {
+		t.Helper()
+		return decodeBody[dto.InvoiceListOutput](client.do("GET", "/v1/invoices?"+query.Encode(), apiKey, nil, http.StatusOK))
+	}
+
+	var listed []string
+	query := url.Values{"limit": {"2"}}
+	for pages := 1; ; pages++ {
+		current := page(query)
+		if len(current.Data) > 2 {
+			t.Fatalf("página maior que o limit: %d", len(current.Data))
+		}
+		for _, invoice := range current.Data {
+			listed = append(listed, invoice.ID)
+		}
+
+		if pages == 1 {
+			// uma fatura nova é mais recente que o cursor, fica fora das próximas páginas
+			client.do("POST", "/v1/invoice", apiKey, invoiceInput(100, nil), http.StatusCreated)
+		}
+		if current.NextCursor == nil {
+			if pages != 3 {
+				t.Fatalf("5 faturas com limit 2 deveriam dar 3 páginas, deram %d", pages)
+			}
+			break
+		}
+		query.Set("cursor", *current.NextCursor)
+	}
+
+	if len(listed) != len(created) {
+		t.Fatalf("as páginas deveriam trazer as %d faturas iniciais, trouxeram %v", len(created), listed)
+	}
+	for i, id := range listed {
+		if expected := created[len(created)-1-i]; id != expected {
+			t.Fatalf("posição %d: esperado %s, veio %s", i, expected, id)
+		}
+	}
+
+	// limit acima do máximo é reduzido, não recusado
+	if all := page(url.Values{"limit": {"1000"}}); len(all.Data) != 6 || all.NextCursor != nil {
+		t.Fatalf("limit acima do máximo deveria trazer todas as faturas em uma página: %d", len(all.Data))
+	}
+
+	invalid := decodeBody[map[string]any](client.do("GET", "/v1/invoices?cursor=invalido", apiKey, nil, http.StatusBadRequest))
+	if invalid["code"] != "invalid_cursor" {
+		t.Fatalf("cursor inválido: %v", invalid)
+	}
+}
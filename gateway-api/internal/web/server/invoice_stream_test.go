@@ -0,0 +1,179 @@
+package server
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/j-ordep/gateway/go-gateway/internal/domain"
+)
+
+type sseEvent struct {
+	id        string
+	event     string
+	data      map[string]any
+	heartbeat bool
+}
+
+// o stream entrega as mudanças de status das faturas da conta, com heartbeat e retomada pelo Last-Event-ID
+func TestInvoiceStreamDeliversAndResumes(t *testing.T) {
+	srv := newTestServer()
+	ts := httptest.NewServer(srv.router)
+	defer ts.Close()
+
+	do := func(method, path, apiKey string, body any) map[string]any {
+		t.Helper()
+		payload, _ := json.Marshal(body)
+		req, _ := http.NewRequest(method, ts.URL+path, bytes.NewReader(payload))
+		req.Header.Set("X-API-KEY", apiKey)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode >= 300 {
+			t.Fatalf("%s %s retornou %d", method, path, resp.StatusCode)
+		}
+		var output map[string]any
+		json.NewDecoder(resp.Body).Decode(&output)
+		return output
+	}
+	pendingInvoice := func(apiKey string) string {
+		t.Helper()
+		// acima de 10000 a fatura fica pendente aguardando o antifraude
+		return do("POST", "/v1/invoice", apiKey, map[string]any{
+			"amount": 15000, "description": "Alto valor", "payment_type": "credit_card",
+			"card_number": "4111111111111111", "cvv": "123", "expiry_month": 12, "expiry_year": 2030, "cardholder_name": "John Doe",
+		})["id"].(string)
+	}
+
+	// open devolve os eventos lidos do stream e a função que encerra a conexão
+	open := func(apiKey, lastEventID string) (<-chan sseEvent, func()) {
+		t.Helper()
+		ctx, cancel := context.WithCancel(context.Background())
+		req, _ := http.NewRequestWithContext(ctx, "GET", ts.URL+"/v1/invoices/stream", nil)
+		req.Header.Set("X-API-KEY", apiKey)
+		if lastEventID != "" {
+			req.Header.Set("Last-Event-ID", lastEventID)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.StatusCode != http.StatusOK || resp.Header.Get("Content-Type") != "text/event-stream" {
+			t.Fatalf("stream retornou %d (%s)", resp.StatusCode, resp.Header.Get("Content-Type"))
+		}
+
+		events := make(chan sseEvent, 32)
+		go func() {
+			defer close(events)
+			defer resp.Body.Close()
+			scanner := bufio.NewScanner(resp.Body)
+			var current sseEvent
+			for scanner.Scan() {
+				line := scanner.Text()
+				switch {
+				case line == "":
+					if current.event != "" || current.heartbeat {
+						events <- current
+					}
+					current = sseEvent{}
+				case strings.HasPrefix(line, ":"):
+					current.heartbeat = true
+				case strings.HasPrefix(line, "id: "):
+					current.id = strings.TrimPrefix(line, "id: ")
+				case strings.HasPrefix(line, "event: "):
+					current.event = strings.TrimPrefix(line, "event: ")
+				case strings.HasPrefix(line, "data: "):
+					json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &current.data)
+				}
+			}
+		}()
+		return events, cancel
+	}
+	next := func(events <-chan sseEvent) sseEvent {
+		t.Helper()
+		for {
+			select {
+			case event, ok := <-events:
+				if !ok {
+					t.Fatal("stream encerrado antes do evento")
+				}
+				if !event.heartbeat {
+					return event
+				}
+			case <-time.After(2 * time.Second):
+				t.Fatal("nenhum evento recebido")
+			}
+		}
+	}
+	expect := func(event sseEvent, id, invoiceID string, status domain.Status) {
+		t.Helper()
+		if event.event != "invoice.status" || event.id != id || event.data["invoice_id"] != invoiceID || event.data["status"] != string(status) {
+			t.Fatalf("esperado evento %s da fatura %s (%s), recebido %+v", id, invoiceID, status, event)
+		}
+	}
+
+	account := do("POST", "/v1/accounts", "", map[string]any{"name": "Stream", "email": "stream@example.com"})
+	apiKey := account["api_key"].(string)
+	other := do("POST", "/v1/accounts", "", map[string]any{"name": "Outra", "email": "outra-stream@example.com"})
+	otherKey := other["api_key"].(string)
+
+	events, closeStream := open(apiKey, "")
+
+	invoiceID := pendingInvoice(apiKey)
+	expect(next(events), "1", invoiceID, domain.StatusPending)
+
+	// eventos de outra conta não aparecem no stream
+	otherInvoiceID := pendingInvoice(otherKey)
+
+	if err := srv.invoiceService.ProcessTransactionResult(context.Background(), invoiceID, domain.StatusApproved); err != nil {
+		t.Fatal(err)
+	}
+	expect(next(events), "3", invoiceID, domain.StatusApproved)
+
+	heartbeat := false
+	for !heartbeat {
+		select {
+		case event := <-events:
+			heartbeat = event.heartbeat
+		case <-time.After(time.Second):
+			t.Fatal("nenhum heartbeat recebido")
+		}
+	}
+	closeStream()
+
+	// desconectado: os eventos seguintes ficam no histórico (página de 2, então a retomada lê duas páginas)
+	missed := []string{pendingInvoice(apiKey), pendingInvoice(apiKey), pendingInvoice(apiKey)}
+	pendingInvoice(otherKey)
+
+	events, closeStream = open(apiKey, "3")
+	defer closeStream()
+	for i, id := range []string{"4", "5", "6"} {
+		expect(next(events), id, missed[i], domain.StatusPending)
+	}
+
+	// depois do histórico o stream segue ao vivo
+	if err := srv.invoiceService.ProcessTransactionResult(context.Background(), missed[0], domain.StatusRejected); err != nil {
+		t.Fatal(err)
+	}
+	expect(next(events), "8", missed[0], domain.StatusRejected)
+
+	// filtro por fatura de outra conta
+	req, _ := http.NewRequest("GET", ts.URL+"/v1/invoices/stream?invoice_id="+otherInvoiceID, nil)
+	req.Header.Set("X-API-KEY", apiKey)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusForbidden {
+		t.Fatalf("stream de fatura de outra conta deveria retornar 403, retornou %d", resp.StatusCode)
+	}
+}
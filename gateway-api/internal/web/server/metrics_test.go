@@ -0,0 +1,28 @@
+package server
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+// o /metrics expõe as séries HTTP (pela rota, não pelo path) e as de negócio das faturas
+func TestMetrics(t *testing.T) {
+	client := newTestClient(t, newTestServer())
+	apiKey := client.createAccount("Metrics", "metrics@example.com")
+	client.do("POST", "/v1/invoice", apiKey, invoiceInput(10, nil), http.StatusCreated)
+	recorder := client.do("GET", "/metrics", "", nil, http.StatusOK)
+
+	body := recorder.Body.String()
+	for _, expected := range []string{
+		`gateway_http_requests_total{method="POST",route="/v1/invoice",status="201"}`,
+		`gateway_http_request_duration_seconds_bucket{method="POST",route="/v1/accounts",status="200"`,
+		`gateway_invoices_created_total{payment_type="credit_card",status=`,
+		`gateway_invoice_decisions_total{payment_type="credit_card",status=`,
+		`gateway_invoice_amount_total{payment_type="credit_card",status=`,
+	} {
+		if !strings.Contains(body, expected) {
+			t.Errorf("/metrics não contém %s", expected)
+		}
+	}
+}
@@ -0,0 +1,717 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/j-ordep/gateway/go-gateway/internal/dto"
+	"github.com/j-ordep/gateway/go-gateway/internal/web/openapi"
+)
+
+// Testes de contrato: garantem que internal/web/openapi/openapi.json descreve o que os handlers
+// realmente aceitam e devolvem. Quando uma rota, DTO ou status mudar, o spec precisa mudar junto.
+
+func loadSpec(t *testing.T) map[string]any {
+	t.Helper()
+	var spec map[string]any
+	if err := json.Unmarshal(openapi.Spec, &spec); err != nil {
+		t.Fatalf("openapi.json inválido: %v", err)
+	}
+	return spec
+}
+
+// toda rota registrada precisa estar no spec, e todo path do spec precisa existir no router
+func TestOpenAPICoversAllRoutes(t *testing.T) {
+	spec := loadSpec(t)
+	srv := newTestServer()
+
+	routes := map[string]bool{}
+	err := chi.Walk(srv.router, func(method, route string, handler http.Handler, middlewares ...func(http.Handler) http.Handler) error {
+		route = strings.ReplaceAll(route, "/*", "")
+		if len(route) > 1 {
+			route = strings.TrimSuffix(route, "/")
+		}
+		routes[strings.ToLower(method)+" "+route] = true
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	documented := map[string]bool{}
+	for path, item := range spec["paths"].(map[string]any) {
+		for method := range item.(map[string]any) {
+			documented[method+" "+path] = true
+		}
+	}
+
+	for _, route := range sortedKeys(routes) {
+		// aliases legados (sem /v1) são descritos no info.description, não como paths
+		method, path, _ := strings.Cut(route, " ")
+		if routes[method+" /v1"+path] && documented[method+" /v1"+path] {
+			continue
+		}
+		if !documented[route] {
+			t.Errorf("rota %q não está documentada no openapi.json", route)
+		}
+	}
+	for _, operation := range sortedKeys(documented) {
+		if !routes[operation] {
+			t.Errorf("operação %q do openapi.json não existe no router", operation)
+		}
+	}
+}
+
+// os schemas de componentes precisam ter exatamente os campos JSON dos DTOs
+func TestOpenAPISchemasMatchDTOs(t *testing.T) {
+	spec := loadSpec(t)
+	schemas := spec["components"].(map[string]any)["schemas"].(map[string]any)
+
+	dtos := map[string]any{
+		"CreateAccountInput":         dto.CreateAccountInput{},
+		"UpdateAccountInput":         dto.UpdateAccountInput{},
+		"AccountStatusInput":         dto.AccountStatusInput{},
+		"SuspendAccountInput":        dto.SuspendAccountInput{},
+		"AccountTierInput":           dto.AccountTierInput{},
+		"ForceInvoiceStatusInput":    dto.ForceInvoiceStatusInput{},
+		"BalanceAdjustmentInput":     dto.BalanceAdjustmentInput{},
+		"AuditEntryOutput":           dto.AuditEntryOutput{},
+		"AuditLogOutput":             dto.AuditLogOutput{},
+		"AccountOutput":              dto.AccountOutput{},
+		"AccountReportOutput":        dto.AccountReportOutput{},
+		"AccountReportPeriodOutput":  dto.AccountReportPeriodOutput{},
+		"AccountReportTotalsOutput":  dto.AccountReportTotalsOutput{},
+		"CreateInvoiceInput":         dto.CreateInvoiceInput{},
+		"InvoiceOutput":              dto.InvoiceOutput{},
+		"InvoiceListOutput":          dto.InvoiceListOutput{},
+		"BatchInvoiceInput":          dto.BatchInvoiceInput{},
+		"BatchInvoiceItemInput":      dto.BatchInvoiceItemInput{},
+		"BatchInvoiceOutput":         dto.BatchInvoiceOutput{},
+		"BatchInvoiceResultOutput":   dto.BatchInvoiceResultOutput{},
+		"InvoiceEventOutput":         dto.InvoiceEventOutput{},
+		"InvoiceExportInput":         dto.InvoiceExportInput{},
+		"InvoiceExportFilters":       dto.InvoiceExportFilters{},
+		"InvoiceExportOutput":        dto.InvoiceExportOutput{},
+		"CreateCustomerInput":        dto.CreateCustomerInput{},
+		"UpdateCustomerInput":        dto.UpdateCustomerInput{},
+		"CustomerOutput":             dto.CustomerOutput{},
+		"CreatePaymentMethodInput":   dto.CreatePaymentMethodInput{},
+		"PaymentMethodOutput":        dto.PaymentMethodOutput{},
+		"CreateWebhookEndpointInput": dto.CreateWebhookEndpointInput{},
+		"WebhookEndpointOutput":      dto.WebhookEndpointOutput{},
+		"WebhookDeliveryOutput":      dto.WebhookDeliveryOutput{},
+		"WebhookDeliveryListOutput":  dto.WebhookDeliveryListOutput{},
+		"HealthOutput":               dto.HealthOutput{},
+		"HealthCheckOutput":          dto.HealthCheckOutput{},
+	}
+
+	for name, value := range dtos {
+		schema, ok := schemas[name].(map[string]any)
+		if !ok {
+			t.Errorf("schema %s não existe no openapi.json", name)
+			continue
+		}
+
+		properties := schema["properties"].(map[string]any)
+		fields := jsonFields(reflect.TypeOf(value))
+
+		for field := range fields {
+			if _, ok := properties[field]; !ok {
+				t.Errorf("%s: campo %q do DTO não está no schema", name, field)
+			}
+		}
+		for property := range properties {
+			if _, ok := fields[property]; !ok {
+				t.Errorf("%s: propriedade %q do schema não existe no DTO", name, property)
+			}
+		}
+
+		required := map[string]bool{}
+		for _, field := range asSlice(schema["required"]) {
+			required[field.(string)] = true
+		}
+		for field, requiredInDTO := range fields {
+			if requiredInDTO != required[field] {
+				t.Errorf("%s: campo %q required no DTO=%v, no schema=%v", name, field, requiredInDTO, required[field])
+			}
+		}
+	}
+}
+
+// jsonFields retorna os campos JSON do DTO e se cada um é obrigatório
+// inputs: obrigatório quando tem validate:"required"; outputs: quando não tem omitempty
+func jsonFields(structType reflect.Type) map[string]bool {
+	isInput := strings.HasSuffix(structType.Name(), "Input")
+	fields := map[string]bool{}
+
+	for i := 0; i < structType.NumField(); i++ {
+		field := structType.Field(i)
+		name, options, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" || !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		if isInput {
+			rules := strings.Split(field.Tag.Get("validate"), ",")
+			fields[name] = len(rules) > 0 && rules[0] == "required"
+		} else {
+			fields[name] = !strings.Contains(options, "omitempty")
+		}
+	}
+	return fields
+}
+
+type contractStep struct {
+	name       string
+	method     string
+	route      string // path como está no spec, ex: /invoice/{id}
+	path       func() string
+	headers    map[string]string
+	body       any // valor fixo ou func() any, avaliada na hora (depende de IDs capturados antes)
+	form       url.Values
+	status     int
+	badRequest bool // corpo propositalmente fora do contrato, não é validado contra o spec
+	capture    func(body map[string]any)
+}
+
+// executa um fluxo completo pelo router e valida requisição e resposta contra o spec
+func TestOpenAPIContract(t *testing.T) {
+	spec := loadSpec(t)
+	srv := newTestServer()
+
+	var apiKey, previousAPIKey, accountID, customerID, paymentMethodID, invoiceID, challengeID, webhookID, deliveryID, exportID string
+	auth := func() map[string]string { return map[string]string{"X-API-KEY": apiKey} }
+	static := func(path string) func() string { return func() string { return path } }
+
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer receiver.Close()
+
+	steps := []contractStep{
+		{name: "cria conta", method: "POST", route: "/v1/accounts", path: static("/v1/accounts"),
+			body: map[string]any{"name": "John", "email": "john@example.com"}, status: http.StatusOK,
+			capture: func(body map[string]any) {
+				apiKey = body["api_key"].(string)
+				accountID = body["id"].(string)
+			}},
+		{name: "email duplicado", method: "POST", route: "/v1/accounts", path: static("/v1/accounts"),
+			body: map[string]any{"name": "John", "email": "john@example.com"}, status: http.StatusConflict},
+		{name: "email inválido", method: "POST", route: "/v1/accounts", path: static("/v1/accounts"),
+			body: map[string]any{"name": "John", "email": "john"}, status: http.StatusUnprocessableEntity, badRequest: true},
+		{name: "campo desconhecido", method: "POST", route: "/v1/accounts", path: static("/v1/accounts"),
+			body: map[string]any{"name": "John", "email": "x@example.com", "mail": "x"}, status: http.StatusUnprocessableEntity, badRequest: true},
+		{name: "busca conta", method: "GET", route: "/v1/accounts", path: static("/v1/accounts"), headers: nil, status: http.StatusUnauthorized},
+		{name: "busca conta autenticada", method: "GET", route: "/v1/accounts", path: static("/v1/accounts"), status: http.StatusOK},
+		{name: "atualiza conta", method: "PATCH", route: "/v1/accounts", path: static("/v1/accounts"),
+			body: map[string]any{"name": "John Store"}, status: http.StatusOK},
+		// a apiKey antiga está no cache depois das chamadas acima, a rotação precisa invalidá-la
+		{name: "rotaciona apiKey", method: "POST", route: "/v1/accounts/api-key/rotate", path: static("/v1/accounts/api-key/rotate"), status: http.StatusOK,
+			capture: func(body map[string]any) {
+				previousAPIKey = apiKey
+				apiKey = body["api_key"].(string)
+			}},
+		{name: "apiKey rotacionada", method: "GET", route: "/v1/accounts", path: static("/v1/accounts"), status: http.StatusUnauthorized},
+
+		{name: "cria cliente", method: "POST", route: "/v1/customers", path: static("/v1/customers"),
+			headers: map[string]string{"Idempotency-Key": "customer-1"},
+			body:    map[string]any{"name": "Maria", "email": "maria@example.com"}, status: http.StatusCreated,
+			capture: func(body map[string]any) { customerID = body["id"].(string) }},
+		{name: "replay idempotente", method: "POST", route: "/v1/customers", path: static("/v1/customers"),
+			headers: map[string]string{"Idempotency-Key": "customer-1"},
+			body:    map[string]any{"name": "Maria", "email": "maria@example.com"}, status: http.StatusCreated},
+		{name: "chave reutilizada", method: "POST", route: "/v1/customers", path: static("/v1/customers"),
+			headers: map[string]string{"Idempotency-Key": "customer-1"},
+			body:    map[string]any{"name": "Outra"}, status: http.StatusUnprocessableEntity},
+		{name: "lista clientes", method: "GET", route: "/v1/customers", path: static("/v1/customers"), status: http.StatusOK},
+		{name: "busca cliente", method: "GET", route: "/v1/customers/{id}", path: func() string { return "/v1/customers/" + customerID }, status: http.StatusOK},
+		{name: "cliente inexistente", method: "GET", route: "/v1/customers/{id}", path: func() string { return "/v1/customers/" + uuid.NewString() }, status: http.StatusNotFound},
+		{name: "atualiza cliente", method: "PATCH", route: "/v1/customers/{id}", path: func() string { return "/v1/customers/" + customerID },
+			body: map[string]any{"phone": "11999999999"}, status: http.StatusOK},
+		{name: "salva cartão", method: "POST", route: "/v1/customers/{id}/payment-methods", path: func() string { return "/v1/customers/" + customerID + "/payment-methods" },
+			body: testCard, status: http.StatusCreated,
+			capture: func(body map[string]any) { paymentMethodID = body["id"].(string) }},
+		{name: "lista cartões", method: "GET", route: "/v1/customers/{id}/payment-methods", path: func() string { return "/v1/customers/" + customerID + "/payment-methods" }, status: http.StatusOK},
+
+		{name: "cria webhook", method: "POST", route: "/v1/webhooks", path: static("/v1/webhooks"),
+			body: map[string]any{"url": receiver.URL + "/hooks", "event_types": []string{"invoice.created", "invoice.approved", "invoice.rejected"}}, status: http.StatusCreated,
+			capture: func(body map[string]any) {
+				webhookID = body["id"].(string)
+				if secret, _ := body["secret"].(string); !strings.HasPrefix(secret, "whsec_") {
+					t.Errorf("criação do webhook deveria devolver o secret, recebido %q", secret)
+				}
+			}},
+		// sem estornos no gateway ainda não existe refund.created
+		{name: "evento de webhook inválido", method: "POST", route: "/v1/webhooks", path: static("/v1/webhooks"),
+			body: map[string]any{"url": receiver.URL, "event_types": []string{"refund.created"}}, status: http.StatusUnprocessableEntity, badRequest: true},
+		{name: "url de webhook inválida", method: "POST", route: "/v1/webhooks", path: static("/v1/webhooks"),
+			body: map[string]any{"url": "ftp://example.com", "event_types": []string{"invoice.created"}}, status: http.StatusUnprocessableEntity, badRequest: true},
+		{name: "lista webhooks", method: "GET", route: "/v1/webhooks", path: static("/v1/webhooks"), status: http.StatusOK},
+		{name: "busca webhook", method: "GET", route: "/v1/webhooks/{id}", path: func() string { return "/v1/webhooks/" + webhookID }, status: http.StatusOK,
+			capture: func(body map[string]any) {
+				if _, ok := body["secret"]; ok {
+					t.Error("o secret do webhook só deve aparecer na criação")
+				}
+			}},
+
+		// acima de 10000 a fatura fica pendente aguardando o antifraude
+		{name: "cria fatura pendente", method: "POST", route: "/v1/invoice", path: static("/v1/invoice"),
+			body: func() any {
+				return invoiceInput(20000, map[string]any{"external_reference": "order-1", "metadata": map[string]any{"order_id": "1"}, "customer_id": customerID})
+			}, status: http.StatusCreated,
+			capture: func(body map[string]any) { invoiceID = body["id"].(string) }},
+		{name: "referência duplicada", method: "POST", route: "/v1/invoice", path: static("/v1/invoice"),
+			body: invoiceInput(100, map[string]any{"external_reference": "order-1"}), status: http.StatusConflict},
+		{name: "valor inválido", method: "POST", route: "/v1/invoice", path: static("/v1/invoice"),
+			body: invoiceInput(-1, nil), status: http.StatusUnprocessableEntity, badRequest: true},
+		// o lote responde 200 mesmo com itens recusados, cada um tem o próprio status
+		{name: "lote de faturas", method: "POST", route: "/v1/invoices/batch", path: static("/v1/invoices/batch"),
+			body: map[string]any{"items": []any{
+				map[string]any{"idempotency_key": "batch-1", "invoice": invoiceInput(100, nil)},
+				map[string]any{"invoice": invoiceInput(100, map[string]any{"external_reference": "order-1"})},
+			}}, status: http.StatusOK},
+		{name: "lote vazio", method: "POST", route: "/v1/invoices/batch", path: static("/v1/invoices/batch"),
+			body: map[string]any{"items": []any{}}, status: http.StatusUnprocessableEntity},
+		{name: "busca fatura", method: "GET", route: "/v1/invoice/{id}", path: func() string { return "/v1/invoice/" + invoiceID }, status: http.StatusOK},
+		{name: "fatura inexistente", method: "GET", route: "/v1/invoice/{id}", path: func() string { return "/v1/invoice/" + uuid.NewString() }, status: http.StatusNotFound},
+		{name: "lista faturas", method: "GET", route: "/v1/invoices", path: static("/v1/invoices?limit=1&status=pending"), status: http.StatusOK},
+		{name: "limit inválido", method: "GET", route: "/v1/invoices", path: static("/v1/invoices?limit=abc"), status: http.StatusBadRequest},
+		{name: "last_event_id inválido", method: "GET", route: "/v1/invoices/stream", path: static("/v1/invoices/stream?last_event_id=abc"), status: http.StatusBadRequest},
+		{name: "cursor inválido", method: "GET", route: "/v1/invoices", path: static("/v1/invoices?cursor=invalido"), status: http.StatusBadRequest},
+		{name: "faturas do cliente", method: "GET", route: "/v1/customers/{id}/invoices", path: func() string { return "/v1/customers/" + customerID + "/invoices" }, status: http.StatusOK},
+		{name: "exporta faturas", method: "GET", route: "/v1/invoices/export", path: static("/v1/invoices/export?format=csv&status=pending&created_from=2025-01-01&timezone=America/Sao_Paulo"), status: http.StatusOK},
+		{name: "formato de exportação inválido", method: "GET", route: "/v1/invoices/export", path: static("/v1/invoices/export?format=xlsx"), status: http.StatusUnprocessableEntity},
+		{name: "fuso inválido", method: "GET", route: "/v1/invoices/export", path: static("/v1/invoices/export?timezone=Mars/Olympus"), status: http.StatusUnprocessableEntity},
+		{name: "agenda exportação", method: "POST", route: "/v1/invoices/exports", path: static("/v1/invoices/exports"),
+			body: map[string]any{"format": "ndjson", "created_from": "2025-01-01", "timezone": "America/Sao_Paulo"}, status: http.StatusAccepted,
+			capture: func(body map[string]any) { exportID = body["id"].(string) }},
+		{name: "período invertido", method: "POST", route: "/v1/invoices/exports", path: static("/v1/invoices/exports"),
+			body: map[string]any{"created_from": "2025-02-01", "created_to": "2025-01-01"}, status: http.StatusUnprocessableEntity},
+		{name: "busca exportação", method: "GET", route: "/v1/invoices/exports/{id}", path: func() string { return "/v1/invoices/exports/" + exportID }, status: http.StatusOK},
+		{name: "exportação inexistente", method: "GET", route: "/v1/invoices/exports/{id}", path: func() string { return "/v1/invoices/exports/" + uuid.NewString() }, status: http.StatusNotFound},
+		{name: "exportação não concluída", method: "GET", route: "/v1/invoices/exports/{id}/download", path: func() string { return "/v1/invoices/exports/" + exportID + "/download" }, status: http.StatusConflict},
+		{name: "relatório da conta", method: "GET", route: "/v1/accounts/reports", path: static("/v1/accounts/reports?granularity=week"), status: http.StatusOK},
+		{name: "granularidade inválida", method: "GET", route: "/v1/accounts/reports", path: static("/v1/accounts/reports?granularity=year"), status: http.StatusUnprocessableEntity},
+		{name: "período do relatório inválido", method: "GET", route: "/v1/accounts/reports", path: static("/v1/accounts/reports?from=2025-02-01&to=2025-01-01"), status: http.StatusUnprocessableEntity},
+		{name: "encerrar com fatura pendente", method: "POST", route: "/v1/accounts/close", path: static("/v1/accounts/close"), status: http.StatusConflict},
+
+		// 3-D Secure: acima do frictionless (1000) o comprador precisa passar pelo desafio
+		{name: "ativa 3ds", method: "PATCH", route: "/v1/accounts", path: static("/v1/accounts"),
+			body: map[string]any{"three_ds_mode": "above_amount", "three_ds_min_amount": 500}, status: http.StatusOK},
+		{name: "fatura com desafio", method: "POST", route: "/v1/invoice", path: static("/v1/invoice"),
+			// com cartão salvo os dados do cartão são dispensados
+			body: func() any {
+				return map[string]any{"amount": 5000, "payment_type": "credit_card", "customer_id": customerID, "payment_method_id": paymentMethodID}
+			}, status: http.StatusCreated,
+			capture: func(body map[string]any) {
+				challengeURL := body["challenge_url"].(string)
+				challengeID = challengeURL[strings.LastIndex(challengeURL, "/")+1:]
+			}},
+		{name: "página do desafio", method: "GET", route: "/3ds/challenge/{id}", path: func() string { return "/3ds/challenge/" + challengeID }, status: http.StatusOK},
+		{name: "callback antes do desafio", method: "POST", route: "/3ds/callback/{id}", path: func() string { return "/3ds/callback/" + challengeID }, status: http.StatusConflict},
+		{name: "responde o desafio", method: "POST", route: "/3ds/challenge/{id}", path: func() string { return "/3ds/challenge/" + challengeID },
+			form: url.Values{"code": {"123456"}}, status: http.StatusSeeOther},
+		{name: "desafio já respondido", method: "POST", route: "/3ds/challenge/{id}", path: func() string { return "/3ds/challenge/" + challengeID },
+			form: url.Values{"code": {"123456"}}, status: http.StatusConflict},
+		// o envio do desafio já concluiu a fatura, o callback repetido só devolve o estado atual
+		{name: "callback", method: "POST", route: "/3ds/callback/{id}", path: func() string { return "/3ds/callback/" + challengeID }, status: http.StatusOK},
+		{name: "desafio inexistente", method: "GET", route: "/3ds/challenge/{id}", path: func() string { return "/3ds/challenge/" + uuid.NewString() }, status: http.StatusNotFound},
+
+		{name: "remove cartão", method: "DELETE", route: "/v1/customers/{id}/payment-methods/{paymentMethodId}",
+			path: func() string { return "/v1/customers/" + customerID + "/payment-methods/" + paymentMethodID }, status: http.StatusNoContent},
+		{name: "remove cliente", method: "DELETE", route: "/v1/customers/{id}", path: func() string { return "/v1/customers/" + customerID }, status: http.StatusNoContent},
+
+		{name: "admin sem chave", method: "POST", route: "/v1/admin/accounts/{id}/suspend", path: func() string { return "/v1/admin/accounts/" + accountID + "/suspend" },
+			body: map[string]any{"reason": "teste"}, status: http.StatusUnauthorized},
+		{name: "suspende sem motivo", method: "POST", route: "/v1/admin/accounts/{id}/suspend", path: func() string { return "/v1/admin/accounts/" + accountID + "/suspend" },
+			headers: map[string]string{"X-ADMIN-KEY": testAdminKey}, status: http.StatusUnprocessableEntity, badRequest: true},
+		{name: "suspende conta", method: "POST", route: "/v1/admin/accounts/{id}/suspend", path: func() string { return "/v1/admin/accounts/" + accountID + "/suspend" },
+			headers: map[string]string{"X-ADMIN-KEY": testAdminKey}, body: map[string]any{"reason": "teste"}, status: http.StatusOK},
+		{name: "conta suspensa", method: "GET", route: "/v1/customers", path: static("/v1/customers"), status: http.StatusForbidden},
+		{name: "reativa conta", method: "POST", route: "/v1/admin/accounts/{id}/reactivate", path: func() string { return "/v1/admin/accounts/" + accountID + "/reactivate" },
+			headers: map[string]string{"X-ADMIN-KEY": testAdminKey}, status: http.StatusOK},
+
+		{name: "troca plano", method: "PUT", route: "/v1/admin/accounts/{id}/tier", path: func() string { return "/v1/admin/accounts/" + accountID + "/tier" },
+			headers: map[string]string{"X-ADMIN-KEY": testAdminKey}, body: map[string]any{"tier": "premium"}, status: http.StatusOK},
+		{name: "admin com apiKey de lojista", method: "GET", route: "/v1/admin/accounts/{id}", path: func() string { return "/v1/admin/accounts/" + accountID }, status: http.StatusUnauthorized},
+		{name: "admin busca conta", method: "GET", route: "/v1/admin/accounts/{id}", path: func() string { return "/v1/admin/accounts/" + accountID },
+			headers: map[string]string{"X-ADMIN-KEY": testAdminKey}, status: http.StatusOK,
+			capture: func(body map[string]any) {
+				if _, ok := body["api_key"]; ok {
+					t.Error("resposta administrativa não deve expor a apiKey do lojista")
+				}
+			}},
+		{name: "admin conta inexistente", method: "GET", route: "/v1/admin/accounts/{id}", path: func() string { return "/v1/admin/accounts/" + uuid.NewString() },
+			headers: map[string]string{"X-ADMIN-KEY": testAdminKey}, status: http.StatusNotFound},
+		{name: "admin faturas da conta", method: "GET", route: "/v1/admin/accounts/{id}/invoices", path: func() string { return "/v1/admin/accounts/" + accountID + "/invoices?status=pending" },
+			headers: map[string]string{"X-ADMIN-KEY": testAdminKey}, status: http.StatusOK},
+		{name: "força aprovação", method: "POST", route: "/v1/admin/invoices/{id}/status", path: func() string { return "/v1/admin/invoices/" + invoiceID + "/status" },
+			headers: map[string]string{"X-ADMIN-KEY": testAdminKey}, body: map[string]any{"status": "approved", "reason": "antifraude fora do ar"}, status: http.StatusOK},
+		{name: "fatura já decidida", method: "POST", route: "/v1/admin/invoices/{id}/status", path: func() string { return "/v1/admin/invoices/" + invoiceID + "/status" },
+			headers: map[string]string{"X-ADMIN-KEY": testAdminKey}, body: map[string]any{"status": "rejected", "reason": "teste"}, status: http.StatusConflict},
+		// fatura pendente, a do lote e a do 3DS: três criadas e três decididas (sorteio na criação, após o callback e aprovação forçada)
+		{name: "entregas do webhook", method: "GET", route: "/v1/webhooks/{id}/deliveries", path: func() string { return "/v1/webhooks/" + webhookID + "/deliveries?status=pending" },
+			status: http.StatusOK,
+			capture: func(body map[string]any) {
+				deliveries := body["data"].([]any)
+				if len(deliveries) != 6 {
+					t.Fatalf("webhook com %d entregas pendentes, esperado 6", len(deliveries))
+				}
+				deliveryID = deliveries[0].(map[string]any)["id"].(string)
+			}},
+		{name: "status de entrega inválido", method: "GET", route: "/v1/webhooks/{id}/deliveries", path: func() string { return "/v1/webhooks/" + webhookID + "/deliveries?status=ok" },
+			status: http.StatusBadRequest},
+		{name: "reenvia entrega", method: "POST", route: "/v1/webhooks/{id}/deliveries/{deliveryId}/redeliver",
+			path: func() string { return "/v1/webhooks/" + webhookID + "/deliveries/" + deliveryID + "/redeliver" }, status: http.StatusOK,
+			capture: func(body map[string]any) {
+				if body["status"] != "succeeded" {
+					t.Errorf("reenvio deveria ter sido entregue, status %v", body["status"])
+				}
+			}},
+		{name: "entrega inexistente", method: "POST", route: "/v1/webhooks/{id}/deliveries/{deliveryId}/redeliver",
+			path: func() string { return "/v1/webhooks/" + webhookID + "/deliveries/" + uuid.NewString() + "/redeliver" }, status: http.StatusNotFound},
+		{name: "remove webhook", method: "DELETE", route: "/v1/webhooks/{id}", path: func() string { return "/v1/webhooks/" + webhookID }, status: http.StatusNoContent},
+		{name: "webhook removido", method: "GET", route: "/v1/webhooks/{id}", path: func() string { return "/v1/webhooks/" + webhookID }, status: http.StatusNotFound},
+
+		{name: "ajuste sem motivo", method: "POST", route: "/v1/admin/accounts/{id}/balance-adjustments", path: func() string { return "/v1/admin/accounts/" + accountID + "/balance-adjustments" },
+			headers: map[string]string{"X-ADMIN-KEY": testAdminKey}, body: map[string]any{"amount": 10}, status: http.StatusUnprocessableEntity, badRequest: true},
+		{name: "ajusta saldo", method: "POST", route: "/v1/admin/accounts/{id}/balance-adjustments", path: func() string { return "/v1/admin/accounts/" + accountID + "/balance-adjustments" },
+			headers: map[string]string{"X-ADMIN-KEY": testAdminKey}, body: map[string]any{"amount": -10.5, "reason": "estorno de tarifa"}, status: http.StatusOK},
+		{name: "auditoria", method: "GET", route: "/v1/admin/audit-log", path: func() string { return "/v1/admin/audit-log?target_id=" + accountID },
+			headers: map[string]string{"X-ADMIN-KEY": testAdminKey}, status: http.StatusOK,
+			capture: func(body map[string]any) {
+				entries := body["data"].([]any)
+				// suspensão, reativação, plano, busca, faturas e ajuste de saldo
+				if len(entries) != 6 {
+					t.Errorf("auditoria da conta com %d entradas, esperado 6", len(entries))
+				}
+				for _, entry := range entries {
+					if operator := entry.(map[string]any)["operator_id"]; operator != testOperatorID {
+						t.Errorf("operador da auditoria = %v, esperado %s", operator, testOperatorID)
+					}
+				}
+			}},
+
+		{name: "spec", method: "GET", route: "/openapi.json", path: static("/openapi.json"), status: http.StatusOK},
+		{name: "docs", method: "GET", route: "/docs", path: static("/docs"), status: http.StatusOK},
+		{name: "liveness", method: "GET", route: "/healthz", path: static("/healthz"), status: http.StatusOK},
+		{name: "readiness", method: "GET", route: "/readyz", path: static("/readyz"), status: http.StatusOK},
+		{name: "métricas", method: "GET", route: "/metrics", path: static("/metrics"), status: http.StatusOK},
+	}
+
+	for _, step := range steps {
+		headers := map[string]string{}
+		// a maioria das rotas usa a apiKey, exceto os passos que testam a ausência dela
+		if step.name != "busca conta" && !strings.HasPrefix(step.route, "/v1/admin") && !strings.HasPrefix(step.route, "/3ds") {
+			headers = auth()
+		}
+		if step.name == "apiKey rotacionada" {
+			headers["X-API-KEY"] = previousAPIKey
+		}
+		if step.name == "admin com apiKey de lojista" {
+			headers["X-ADMIN-KEY"] = apiKey
+		}
+		for key, value := range step.headers {
+			headers[key] = value
+		}
+
+		body := step.body
+		if lazy, ok := body.(func() any); ok {
+			body = lazy()
+		}
+
+		operation := findOperation(t, spec, step.route, step.method)
+		if operation == nil {
+			continue
+		}
+
+		var reader io.Reader
+		contentType := ""
+		if step.form != nil {
+			reader = strings.NewReader(step.form.Encode())
+			contentType = "application/x-www-form-urlencoded"
+		} else if body != nil {
+			raw, _ := json.Marshal(body)
+			reader = bytes.NewReader(raw)
+			contentType = "application/json"
+
+			if !step.badRequest {
+				schema := requestSchema(operation, contentType)
+				if schema == nil {
+					t.Errorf("%s: %s %s não documenta corpo JSON", step.name, step.method, step.route)
+				} else {
+					for _, problem := range validateSchema(spec, schema, roundTrip(body), "request") {
+						t.Errorf("%s: requisição fora do contrato: %s", step.name, problem)
+					}
+				}
+			}
+		}
+
+		req := httptest.NewRequest(step.method, step.path(), reader)
+		if contentType != "" {
+			req.Header.Set("Content-Type", contentType)
+		}
+		for key, value := range headers {
+			req.Header.Set(key, value)
+		}
+
+		rec := httptest.NewRecorder()
+		srv.router.ServeHTTP(rec, req)
+
+		if rec.Code != step.status {
+			t.Errorf("%s: %s %s retornou %d, esperado %d: %s", step.name, step.method, step.path(), rec.Code, step.status, rec.Body.String())
+			continue
+		}
+
+		responses := operation["responses"].(map[string]any)
+		response, ok := responses[fmt.Sprint(rec.Code)].(map[string]any)
+		if !ok {
+			t.Errorf("%s: status %d não documentado em %s %s", step.name, rec.Code, step.method, step.route)
+			continue
+		}
+		response = resolve(spec, response)
+
+		content, _ := response["content"].(map[string]any)
+		if len(content) == 0 {
+			if rec.Body.Len() > 0 {
+				t.Errorf("%s: resposta %d deveria ser vazia", step.name, rec.Code)
+			}
+			continue
+		}
+
+		mediaType, _, _ := strings.Cut(rec.Header().Get("Content-Type"), ";")
+		media, ok := content[mediaType].(map[string]any)
+		if !ok {
+			t.Errorf("%s: content-type %q não documentado para %d", step.name, mediaType, rec.Code)
+			continue
+		}
+		if mediaType != "application/json" {
+			continue
+		}
+
+		var decoded any
+		if err := json.Unmarshal(rec.Body.Bytes(), &decoded); err != nil {
+			t.Errorf("%s: resposta não é JSON válido: %v", step.name, err)
+			continue
+		}
+		for _, problem := range validateSchema(spec, media["schema"].(map[string]any), decoded, "response") {
+			t.Errorf("%s: resposta fora do contrato: %s", step.name, problem)
+		}
+
+		if step.capture != nil {
+			step.capture(decoded.(map[string]any))
+		}
+	}
+}
+
+func findOperation(t *testing.T, spec map[string]any, route, method string) map[string]any {
+	t.Helper()
+	item, ok := spec["paths"].(map[string]any)[route].(map[string]any)
+	if !ok {
+		t.Errorf("path %s não existe no spec", route)
+		return nil
+	}
+	operation, ok := item[strings.ToLower(method)].(map[string]any)
+	if !ok {
+		t.Errorf("operação %s %s não existe no spec", method, route)
+		return nil
+	}
+	return operation
+}
+
+func requestSchema(operation map[string]any, contentType string) map[string]any {
+	body, ok := operation["requestBody"].(map[string]any)
+	if !ok {
+		return nil
+	}
+	media, ok := body["content"].(map[string]any)[contentType].(map[string]any)
+	if !ok {
+		return nil
+	}
+	return media["schema"].(map[string]any)
+}
+
+// validateSchema implementa o subconjunto de JSON Schema usado no openapi.json
+func validateSchema(spec map[string]any, schema map[string]any, value any, path string) []string {
+	schema = resolve(spec, schema)
+	var problems []string
+
+	if types := asSlice(schema["type"]); len(types) > 0 {
+		matched := false
+		for _, typ := range types {
+			if matchesType(typ.(string), value) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return []string{fmt.Sprintf("%s: esperado %v, recebido %T", path, schema["type"], value)}
+		}
+	} else if typ, ok := schema["type"].(string); ok && !matchesType(typ, value) {
+		return []string{fmt.Sprintf("%s: esperado %s, recebido %T", path, typ, value)}
+	}
+
+	if enum := asSlice(schema["enum"]); len(enum) > 0 {
+		found := false
+		for _, option := range enum {
+			if option == value {
+				found = true
+				break
+			}
+		}
+		if !found {
+			problems = append(problems, fmt.Sprintf("%s: %v fora do enum %v", path, value, enum))
+		}
+	}
+
+	switch v := value.(type) {
+	case string:
+		problems = append(problems, validateString(schema, v, path)...)
+	case float64:
+		if minimum, ok := schema["minimum"].(float64); ok && v < minimum {
+			problems = append(problems, fmt.Sprintf("%s: %v menor que %v", path, v, minimum))
+		}
+		if maximum, ok := schema["maximum"].(float64); ok && v > maximum {
+			problems = append(problems, fmt.Sprintf("%s: %v maior que %v", path, v, maximum))
+		}
+		if exclusiveMinimum, ok := schema["exclusiveMinimum"].(float64); ok && v <= exclusiveMinimum {
+			problems = append(problems, fmt.Sprintf("%s: %v deve ser maior que %v", path, v, exclusiveMinimum))
+		}
+	case []any:
+		if items, ok := schema["items"].(map[string]any); ok {
+			for i, item := range v {
+				problems = append(problems, validateSchema(spec, items, item, fmt.Sprintf("%s[%d]", path, i))...)
+			}
+		}
+	case map[string]any:
+		properties, _ := schema["properties"].(map[string]any)
+		for _, field := range asSlice(schema["required"]) {
+			if _, ok := v[field.(string)]; !ok {
+				problems = append(problems, fmt.Sprintf("%s.%s: campo obrigatório ausente", path, field))
+			}
+		}
+		for key, fieldValue := range v {
+			if propertySchema, ok := properties[key].(map[string]any); ok {
+				problems = append(problems, validateSchema(spec, propertySchema, fieldValue, path+"."+key)...)
+				continue
+			}
+			switch additional := schema["additionalProperties"].(type) {
+			case bool:
+				if !additional {
+					problems = append(problems, fmt.Sprintf("%s.%s: campo não documentado", path, key))
+				}
+			case map[string]any:
+				problems = append(problems, validateSchema(spec, additional, fieldValue, path+"."+key)...)
+			}
+		}
+		if maxProperties, ok := schema["maxProperties"].(float64); ok && float64(len(v)) > maxProperties {
+			problems = append(problems, fmt.Sprintf("%s: mais de %v propriedades", path, maxProperties))
+		}
+	}
+
+	return problems
+}
+
+func validateString(schema map[string]any, value, path string) []string {
+	var problems []string
+	if maxLength, ok := schema["maxLength"].(float64); ok && float64(len([]rune(value))) > maxLength {
+		problems = append(problems, fmt.Sprintf("%s: maior que %v caracteres", path, maxLength))
+	}
+	if pattern, ok := schema["pattern"].(string); ok && !regexp.MustCompile(pattern).MatchString(value) {
+		problems = append(problems, fmt.Sprintf("%s: %q não respeita o pattern %s", path, value, pattern))
+	}
+
+	switch schema["format"] {
+	case "date-time":
+		if _, err := time.Parse(time.RFC3339Nano, value); err != nil {
+			problems = append(problems, fmt.Sprintf("%s: %q não é date-time", path, value))
+		}
+	case "uuid":
+		if _, err := uuid.Parse(value); err != nil {
+			problems = append(problems, fmt.Sprintf("%s: %q não é uuid", path, value))
+		}
+	case "uri":
+		if _, err := url.ParseRequestURI(value); err != nil {
+			problems = append(problems, fmt.Sprintf("%s: %q não é uri", path, value))
+		}
+	}
+	return problems
+}
+
+func matchesType(typ string, value any) bool {
+	switch typ {
+	case "null":
+		return value == nil
+	case "string":
+		_, ok := value.(string)
+		return ok
+	case "number":
+		_, ok := value.(float64)
+		return ok
+	case "integer":
+		number, ok := value.(float64)
+		return ok && number == float64(int64(number))
+	case "boolean":
+		_, ok := value.(bool)
+		return ok
+	case "array":
+		_, ok := value.([]any)
+		return ok
+	case "object":
+		_, ok := value.(map[string]any)
+		return ok
+	}
+	return false
+}
+
+// resolve segue os $ref locais (#/components/...)
+func resolve(spec map[string]any, schema map[string]any) map[string]any {
+	for {
+		ref, ok := schema["$ref"].(string)
+		if !ok {
+			return schema
+		}
+		var current any = spec
+		for _, part := range strings.Split(strings.TrimPrefix(ref, "#/"), "/") {
+			current = current.(map[string]any)[part]
+		}
+		schema = current.(map[string]any)
+	}
+}
+
+func roundTrip(value any) any {
+	raw, _ := json.Marshal(value)
+	var decoded any
+	json.Unmarshal(raw, &decoded)
+	return decoded
+}
+
+func asSlice(value any) []any {
+	slice, _ := value.([]any)
+	return slice
+}
+
+func sortedKeys(values map[string]bool) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
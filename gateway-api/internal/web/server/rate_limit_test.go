@@ -0,0 +1,77 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/j-ordep/gateway/go-gateway/internal/domain"
+)
+
+// esgotado o bucket, a resposta é 429 no envelope padrão com Retry-After
+func TestRateLimitExceeded(t *testing.T) {
+	srv := newTestServerWith(testServerOptions{rateLimit: domain.RateLimit{Rate: 0.5, Burst: 2}})
+
+	var last *httptest.ResponseRecorder
+	for i := 0; i < 3; i++ {
+		last = httptest.NewRecorder()
+		srv.router.ServeHTTP(last, httptest.NewRequest("GET", "/openapi.json", nil))
+
+		if i == 0 && last.Header().Get("RateLimit-Remaining") != "1" {
+			t.Errorf("RateLimit-Remaining = %q, esperado 1", last.Header().Get("RateLimit-Remaining"))
+		}
+	}
+
+	if last.Code != http.StatusTooManyRequests {
+		t.Fatalf("terceira requisição retornou %d, esperado 429", last.Code)
+	}
+	if got := last.Header().Get("Retry-After"); got != "2" {
+		t.Errorf("Retry-After = %q, esperado 2", got)
+	}
+	if got := last.Header().Get("RateLimit-Limit"); got != "2" {
+		t.Errorf("RateLimit-Limit = %q, esperado 2", got)
+	}
+
+	var body map[string]any
+	json.Unmarshal(last.Body.Bytes(), &body)
+	if body["code"] != "rate_limit_exceeded" {
+		t.Errorf("code = %v, esperado rate_limit_exceeded", body["code"])
+	}
+}
+
+// o X-Forwarded-For só vale quando a conexão vem do proxy confiável, e o cliente é o hop mais à direita fora dele
+func TestRateLimitClientIP(t *testing.T) {
+	srv := newTestServerWith(testServerOptions{rateLimit: domain.RateLimit{Rate: 0.001, Burst: 1}})
+
+	send := func(remoteAddr string, forwardedFor string) int {
+		req := httptest.NewRequest("GET", "/openapi.json", nil)
+		req.RemoteAddr = remoteAddr
+		if forwardedFor != "" {
+			req.Header.Set("X-Forwarded-For", forwardedFor)
+		}
+		recorder := httptest.NewRecorder()
+		srv.router.ServeHTTP(recorder, req)
+		return recorder.Code
+	}
+
+	proxy := testProxyIP + ":4000"
+	if code := send(proxy, "203.0.113.9, 198.51.100.7"); code != http.StatusOK {
+		t.Fatalf("primeira requisição retornou %d", code)
+	}
+	// trocar a entrada forjada à esquerda não gera um bucket novo
+	if code := send(proxy, "10.0.0.1, 198.51.100.7"); code != http.StatusTooManyRequests {
+		t.Fatalf("o cliente é 198.51.100.7, a entrada à esquerda não conta: %d", code)
+	}
+	if code := send(proxy, "198.51.100.8"); code != http.StatusOK {
+		t.Fatalf("outro cliente atrás do proxy tem o próprio bucket: %d", code)
+	}
+
+	// fora do proxy confiável vale o endereço da conexão, o header é ignorado
+	if code := send("203.0.113.50:4000", "198.51.100.9"); code != http.StatusOK {
+		t.Fatalf("primeira requisição direta retornou %d", code)
+	}
+	if code := send("203.0.113.50:4000", "198.51.100.10"); code != http.StatusTooManyRequests {
+		t.Fatalf("X-Forwarded-For de conexão direta não deveria trocar o bucket: %d", code)
+	}
+}
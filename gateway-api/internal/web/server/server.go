@@ -1,12 +1,19 @@
 package server
 
 import (
+	"context"
 	"net/http"
+	"time"
 
 	"github.com/go-chi/chi/v5"
+	"github.com/j-ordep/gateway/go-gateway/internal/metrics"
 	"github.com/j-ordep/gateway/go-gateway/internal/service"
 	"github.com/j-ordep/gateway/go-gateway/internal/web/handler"
 	"github.com/j-ordep/gateway/go-gateway/internal/web/middleware"
+	"github.com/j-ordep/gateway/go-gateway/internal/web/openapi"
+	"github.com/j-ordep/gateway/go-gateway/internal/web/request"
+	"github.com/j-ordep/gateway/go-gateway/internal/web/response"
+	"github.com/j-ordep/gateway/go-gateway/internal/web/v1"
 )
 
 type Server struct {
@@ -14,39 +21,141 @@ type Server struct {
 	server *http.Server
 	accountService *service.AccountService
 	invoiceService *service.InvoiceService
+	invoiceBatchService *service.InvoiceBatchService
+	customerService *service.CustomerService
+	threeDSService *service.ThreeDSService
+	idempotencyService *service.IdempotencyService
+	rateLimitService *service.RateLimitService
+	healthService *service.HealthService
+	adminService *service.AdminService
+	webhookService *service.WebhookService
+	invoiceStreamService *service.InvoiceStreamService
+	invoiceExportService *service.InvoiceExportService
+	invoiceReportService *service.InvoiceReportService
 	port string
+	operatorTokens map[string]string // operador -> sha256 do token das rotas /admin
+	legacySunset time.Time
+	trustedProxies request.TrustedProxies // proxies cujo X-Forwarded-For é aceito
 }
 
-func NewServer(accountService *service.AccountService, invoiceService *service.InvoiceService, port string) *Server {
+func NewServer(accountService *service.AccountService, invoiceService *service.InvoiceService, invoiceBatchService *service.InvoiceBatchService, customerService *service.CustomerService, threeDSService *service.ThreeDSService, idempotencyService *service.IdempotencyService, rateLimitService *service.RateLimitService, healthService *service.HealthService, adminService *service.AdminService, webhookService *service.WebhookService, invoiceStreamService *service.InvoiceStreamService, invoiceExportService *service.InvoiceExportService, invoiceReportService *service.InvoiceReportService, port string, operatorTokens map[string]string, legacySunset time.Time, trustedProxies request.TrustedProxies) *Server {
+	router := chi.NewRouter()
 	return &Server{
-		router: chi.NewRouter(),
+		router: router,
+		// criado junto com o Server para que Shutdown possa ser chamado de outra goroutine que não a do Start
+		server: &http.Server{
+			Addr: ":" + port,
+			Handler: router,
+		},
 		accountService: accountService,
 		invoiceService: invoiceService,
+		invoiceBatchService: invoiceBatchService,
+		customerService: customerService,
+		threeDSService: threeDSService,
+		idempotencyService: idempotencyService,
+		rateLimitService: rateLimitService,
+		healthService: healthService,
+		adminService: adminService,
+		webhookService: webhookService,
+		invoiceStreamService: invoiceStreamService,
+		invoiceExportService: invoiceExportService,
+		invoiceReportService: invoiceReportService,
 		port: port,
+		operatorTokens: operatorTokens,
+		legacySunset: legacySunset,
+		trustedProxies: trustedProxies,
 	}
 }
 
 func (s *Server) ConfigureRoutes() {
 	accountHandler := handler.NewAccountHandler(s.accountService)
+	accountReportHandler := handler.NewAccountReportHandler(s.invoiceReportService)
 	invoiceHandler := handler.NewInvoiceHandler(s.invoiceService)
+	invoiceBatchHandler := handler.NewInvoiceBatchHandler(s.invoiceBatchService)
+	invoiceStreamHandler := handler.NewInvoiceStreamHandler(s.invoiceStreamService)
+	invoiceExportHandler := handler.NewInvoiceExportHandler(s.invoiceExportService)
+	customerHandler := handler.NewCustomerHandler(s.customerService)
+	threeDSHandler := handler.NewThreeDSHandler(s.threeDSService, s.invoiceService)
+	adminHandler := handler.NewAdminHandler(s.adminService)
+	webhookHandler := handler.NewWebhookHandler(s.webhookService)
+	healthHandler := handler.NewHealthHandler(s.healthService)
 	authMiddleware := middleware.NewAuthMiddleware(s.accountService)
+	adminMiddleware := middleware.NewAdminMiddleware(s.operatorTokens)
 
-	s.router.Post("/accounts", accountHandler.Create)
-	s.router.Get("/accounts", accountHandler.Get)
+	// Idempotency-Key vale para as mutações autenticadas (escopo por conta)
+	idempotent := middleware.NewIdempotencyMiddleware(s.idempotencyService, v1.Prefix).Handle
+
+	// request ID e access log primeiro, para cobrir inclusive as respostas do rate limit
+	// o IP do cliente é resolvido antes de todos que o registram ou limitam por ele
+	s.router.Use(middleware.RequestID)
+	s.router.Use(middleware.NewClientIPMiddleware(s.trustedProxies).Handle)
+	s.router.Use(middleware.Tracing)
+	s.router.Use(middleware.AccessLog)
+	s.router.Use(middleware.Metrics)
+
+	// rotas/métodos inexistentes também respondem no envelope de erro padrão
+	s.router.NotFound(response.NotFound)
+	s.router.MethodNotAllowed(response.MethodNotAllowed)
+
+	// probes do orquestrador e o scrape do Prometheus ficam fora do rate limit, para não tirar a instância do ar por excesso de checagens
+	s.router.Get("/healthz", healthHandler.Liveness)
+	s.router.Get("/readyz", healthHandler.Readiness)
+	s.router.Method(http.MethodGet, "/metrics", metrics.Handler())
+
+	v1Handlers := v1.Handlers{
+		Account:       accountHandler,
+		AccountReport: accountReportHandler,
+		Invoice:       invoiceHandler,
+		InvoiceBatch:  invoiceBatchHandler,
+		InvoiceStream: invoiceStreamHandler,
+		InvoiceExport: invoiceExportHandler,
+		Customer:      customerHandler,
+		Admin:         adminHandler,
+		Webhook:       webhookHandler,
+	}
+	v1Middlewares := v1.Middlewares{
+		Authenticate:      authMiddleware.Authenticate,
+		Identify:          authMiddleware.Identify,
+		RequireScope:      middleware.RequireScope,
+		AdminAuthenticate: adminMiddleware.Authenticate,
+		Idempotent:        idempotent,
+	}
 
 	s.router.Group(func(r chi.Router) {
-		r.Use(authMiddleware.Authenticate)
-		s.router.Post("/invoice", invoiceHandler.Create)
-		s.router.Get("/invoice/{id}", invoiceHandler.GetById)
-		s.router.Get("/invoices", invoiceHandler.ListByAccount)
+		// rate limit vale para as demais rotas, por conta (apiKey) ou por IP
+		r.Use(middleware.NewRateLimitMiddleware(s.accountService, s.rateLimitService).Handle)
+
+		r.Route(v1.Prefix, func(r chi.Router) {
+			v1.RegisterRoutes(r, v1Handlers, v1Middlewares)
+		})
+
+		// rotas antigas na raiz continuam funcionando até o Sunset, apontando para a /v1
+		r.Group(func(r chi.Router) {
+			r.Use(middleware.NewDeprecationMiddleware(s.legacySunset, v1.Prefix).Handle)
+			v1.RegisterRoutes(r, v1Handlers, v1Middlewares)
+		})
+
+		// ACS simulado do 3-D Secure, acessado pelo navegador do comprador (sem apiKey)
+		r.Get("/3ds/challenge/{id}", threeDSHandler.ChallengePage)
+		r.Post("/3ds/challenge/{id}", threeDSHandler.SubmitChallenge)
+		r.Post("/3ds/callback/{id}", threeDSHandler.Callback)
+
+		// contrato da API e documentação interativa
+		r.Get("/openapi.json", openapi.SpecHandler)
+		r.Get("/docs", openapi.DocsHandler)
 	})
 
 }
 
+// Start bloqueia até o servidor parar; após Shutdown retorna nil em vez de http.ErrServerClosed
 func (s *Server) Start() error {
-	s.server = &http.Server{
-		Addr: ":" + s.port,
-		Handler: s.router,
+	if err := s.server.ListenAndServe(); err != http.ErrServerClosed {
+		return err
 	}
-	return s.server.ListenAndServe()
+	return nil
+}
+
+// Shutdown para de aceitar conexões e aguarda as requisições em andamento até o prazo do ctx
+func (s *Server) Shutdown(ctx context.Context) error {
+	return s.server.Shutdown(ctx)
 }
\ No newline at end of file
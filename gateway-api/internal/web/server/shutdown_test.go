@@ -0,0 +1,115 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/j-ordep/gateway/go-gateway/internal/domain"
+	"github.com/j-ordep/gateway/go-gateway/internal/domain/events"
+	"github.com/j-ordep/gateway/go-gateway/internal/dto"
+	"github.com/j-ordep/gateway/go-gateway/internal/service"
+)
+
+// blockingKafkaProducer segura o envio da transação pendente até release ser fechado, deixando a requisição em andamento
+type blockingKafkaProducer struct {
+	fakeKafkaProducer
+	started chan struct{}
+	release chan struct{}
+}
+
+func (p *blockingKafkaProducer) SendingPendingTransaction(ctx context.Context, event events.PendingTransaction) error {
+	close(p.started)
+	<-p.release
+	return p.fakeKafkaProducer.SendingPendingTransaction(ctx, event)
+}
+
+// o shutdown em fases: o /readyz passa a responder 503, o servidor para de aceitar conexões
+// e a requisição em andamento termina normalmente antes de Shutdown retornar
+func TestServerShutdownDrainsRequests(t *testing.T) {
+	producer := &blockingKafkaProducer{started: make(chan struct{}), release: make(chan struct{})}
+	srv := newTestServerWith(testServerOptions{kafkaProducer: producer})
+	apiKey := newTestClient(t, srv).createAccount("Shutdown", "shutdown@example.com")
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	served := make(chan error, 1)
+	go func() { served <- srv.server.Serve(listener) }()
+	url := "http://" + listener.Addr().String()
+
+	// acima de 10000 a fatura fica pending e passa pelo kafka, que segura a requisição
+	type result struct {
+		status  int
+		invoice dto.InvoiceOutput
+		err     error
+	}
+	inFlight := make(chan result, 1)
+	go func() {
+		payload, _ := json.Marshal(invoiceInput(15000, nil))
+		req, _ := http.NewRequest("POST", url+"/v1/invoice", bytes.NewReader(payload))
+		req.Header.Set("X-API-KEY", apiKey)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			inFlight <- result{err: err}
+			return
+		}
+		defer resp.Body.Close()
+		var invoice dto.InvoiceOutput
+		err = json.NewDecoder(resp.Body).Decode(&invoice)
+		inFlight <- result{status: resp.StatusCode, invoice: invoice, err: err}
+	}()
+	<-producer.started
+
+	// fase 0: a instância sai do balanceamento, o liveness continua ok
+	srv.healthService.SetShuttingDown()
+	readyz := newTestClient(t, srv).request("GET", "/readyz", "", nil)
+	if body := decodeBody[dto.HealthOutput](readyz); readyz.Code != http.StatusServiceUnavailable || body.Status != service.HealthStatusShuttingDown {
+		t.Fatalf("readyz durante o shutdown = %d %q, esperado 503 shutting_down", readyz.Code, body.Status)
+	}
+	newTestClient(t, srv).do("GET", "/healthz", "", nil, http.StatusOK)
+
+	// fase 1: Shutdown fecha o listener e espera a requisição em andamento
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	shutdown := make(chan error, 1)
+	go func() { shutdown <- srv.Shutdown(ctx) }()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		conn, err := net.DialTimeout("tcp", listener.Addr().String(), 100*time.Millisecond)
+		if err != nil {
+			break
+		}
+		conn.Close()
+		if time.Now().After(deadline) {
+			t.Fatal("o servidor continua aceitando conexões durante o shutdown")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	select {
+	case err := <-shutdown:
+		t.Fatalf("Shutdown retornou com uma requisição em andamento: %v", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(producer.release)
+	response := <-inFlight
+	if response.err != nil || response.status != http.StatusCreated || response.invoice.Status != string(domain.StatusPending) {
+		t.Fatalf("a requisição em andamento deveria terminar: %d %+v %v", response.status, response.invoice, response.err)
+	}
+
+	if err := <-shutdown; err != nil {
+		t.Fatalf("Shutdown = %v, esperado nil depois de drenar", err)
+	}
+	if err := <-served; !errors.Is(err, http.ErrServerClosed) {
+		t.Fatalf("Serve = %v, esperado http.ErrServerClosed", err)
+	}
+}
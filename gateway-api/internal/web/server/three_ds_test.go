@@ -0,0 +1,116 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/j-ordep/gateway/go-gateway/internal/domain"
+	"github.com/j-ordep/gateway/go-gateway/internal/dto"
+)
+
+// o desafio só é respondido uma vez e a fatura só sai de requires_action uma vez:
+// callbacks repetidos ou simultâneos devolvem o estado atual sem publicar de novo no kafka nem creditar duas vezes
+func TestThreeDSChallenge(t *testing.T) {
+	producer := &fakeKafkaProducer{}
+	srv := newTestServerWith(testServerOptions{kafkaProducer: producer})
+	client := newTestClient(t, srv)
+	ctx := context.Background()
+
+	apiKey := client.createAccount("3DS", "three-ds@example.com")
+	client.do("PATCH", "/v1/accounts", apiKey, map[string]any{"three_ds_mode": "always"}, http.StatusOK)
+
+	challenge := func(amount float64) (invoiceID, challengeID string) {
+		t.Helper()
+		invoice := decodeBody[dto.InvoiceOutput](client.do("POST", "/v1/invoice", apiKey, invoiceInput(amount, nil), http.StatusCreated))
+		if invoice.Status != string(domain.StatusRequiresAction) || invoice.ChallengeURL == "" {
+			t.Fatalf("fatura deveria aguardar o desafio: %+v", invoice)
+		}
+		return invoice.ID, invoice.ChallengeURL[strings.LastIndex(invoice.ChallengeURL, "/")+1:]
+	}
+	submit := func(challengeID, code string, status int) map[string]any {
+		t.Helper()
+		req := httptest.NewRequest("POST", "/3ds/challenge/"+challengeID, strings.NewReader(url.Values{"code": {code}}.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		recorder := httptest.NewRecorder()
+		srv.router.ServeHTTP(recorder, req)
+		if recorder.Code != status {
+			t.Fatalf("resposta do desafio retornou %d, esperado %d: %s", recorder.Code, status, recorder.Body.String())
+		}
+		if status == http.StatusSeeOther && recorder.Header().Get("Location") != "/3ds/challenge/"+challengeID {
+			t.Fatalf("deveria voltar para a página do desafio: %s", recorder.Header().Get("Location"))
+		}
+		return decodeBody[map[string]any](recorder)
+	}
+	invoice := func(id string) dto.InvoiceOutput {
+		t.Helper()
+		return decodeBody[dto.InvoiceOutput](client.do("GET", "/v1/invoice/"+id, apiKey, nil, http.StatusOK))
+	}
+
+	// código errado rejeita a fatura; o desafio não pode ser respondido de novo
+	failedID, failedChallenge := challenge(5000)
+	if body := decodeBody[map[string]any](client.do("POST", "/3ds/callback/"+failedChallenge, "", nil, http.StatusConflict)); body["code"] != "three_ds_challenge_pending" {
+		t.Fatalf("callback antes da resposta do comprador: %v", body)
+	}
+	client.do("GET", "/3ds/callback/"+failedChallenge, "", nil, http.StatusMethodNotAllowed)
+	submit(failedChallenge, "000000", http.StatusSeeOther)
+	if failed := invoice(failedID); failed.Status != string(domain.StatusRejected) || failed.ThreeDSResult != string(domain.ThreeDSFailed) {
+		t.Fatalf("código errado deveria rejeitar a fatura: %+v", failed)
+	}
+	if body := submit(failedChallenge, "123456", http.StatusConflict); body["code"] != "three_ds_challenge_completed" {
+		t.Fatalf("desafio respondido duas vezes: %v", body)
+	}
+	if again := decodeBody[dto.InvoiceOutput](client.do("POST", "/3ds/callback/"+failedChallenge, "", nil, http.StatusOK)); again.Status != string(domain.StatusRejected) {
+		t.Fatalf("callback repetido deveria devolver o estado atual: %+v", again)
+	}
+
+	// alto valor segue para o antifraude: uma única transação publicada, mesmo com callbacks simultâneos
+	pendingID, pendingChallenge := challenge(20000)
+	submit(pendingChallenge, "123456", http.StatusSeeOther)
+	var wg sync.WaitGroup
+	for range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := srv.invoiceService.CompleteThreeDS(ctx, pendingChallenge); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+	if pending := invoice(pendingID); pending.Status != string(domain.StatusPending) || pending.ThreeDSResult != string(domain.ThreeDSChallenged) {
+		t.Fatalf("desafio aprovado deveria deixar a fatura aguardando o antifraude: %+v", pending)
+	}
+	if len(producer.events) != 1 || producer.events[0].InvoiceID != pendingID {
+		t.Fatalf("a transação pendente deveria ser publicada uma vez: %+v", producer.events)
+	}
+
+	// até 10000 a fatura é decidida no callback; corrida entre callbacks credita no máximo uma vez
+	decidedID, decidedChallenge := challenge(5000)
+	if _, err := srv.threeDSService.SubmitChallenge(decidedChallenge, "123456"); err != nil {
+		t.Fatal(err)
+	}
+	for range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := srv.invoiceService.CompleteThreeDS(ctx, decidedChallenge); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	expected := 0.0
+	if decided := invoice(decidedID); decided.Status == string(domain.StatusApproved) {
+		expected = 5000
+	}
+	account := decodeBody[dto.AccountOutput](client.do("GET", "/v1/accounts", apiKey, nil, http.StatusOK))
+	if account.Balance != expected {
+		t.Fatalf("saldo deveria ser %.2f, é %.2f", expected, account.Balance)
+	}
+}
@@ -0,0 +1,78 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/j-ordep/gateway/go-gateway/internal/tracing"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// a cobrança de alto valor precisa continuar o trace do cliente até a mensagem do kafka
+func TestTracingPropagatesToKafka(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(provider)
+	defer otel.SetTracerProvider(previous)
+	if _, err := tracing.Setup(context.Background(), tracing.Config{Exporter: "none"}); err != nil {
+		t.Fatal(err)
+	}
+
+	producer := &fakeKafkaProducer{}
+	srv := newTestServerWith(testServerOptions{kafkaProducer: producer})
+
+	do := func(method, path, apiKey string, body any) *httptest.ResponseRecorder {
+		payload, _ := json.Marshal(body)
+		req := httptest.NewRequest(method, path, bytes.NewReader(payload))
+		req.Header.Set("X-API-KEY", apiKey)
+		req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+		recorder := httptest.NewRecorder()
+		srv.router.ServeHTTP(recorder, req)
+		return recorder
+	}
+
+	var account map[string]any
+	json.Unmarshal(do("POST", "/v1/accounts", "", map[string]any{"name": "Trace", "email": "trace@example.com"}).Body.Bytes(), &account)
+
+	// acima de 10000 a fatura fica pendente e vai para o antifraude via kafka
+	created := do("POST", "/v1/invoice", account["api_key"].(string), map[string]any{
+		"amount": 15000, "description": "Alto valor", "payment_type": "credit_card",
+		"card_number": "4111111111111111", "cvv": "123", "expiry_month": 12, "expiry_year": 2030, "cardholder_name": "John Doe",
+	})
+	if created.Code != http.StatusCreated {
+		t.Fatalf("criação da fatura retornou %d: %s", created.Code, created.Body.String())
+	}
+
+	if len(producer.headers) != 1 {
+		t.Fatalf("esperado 1 evento no kafka, recebido %d", len(producer.headers))
+	}
+	traceparent := ""
+	for _, header := range producer.headers[0] {
+		if header.Key == "traceparent" {
+			traceparent = string(header.Value)
+		}
+	}
+	if !strings.HasPrefix(traceparent, "00-4bf92f3577b34da6a3ce929d0e0e4736-") {
+		t.Errorf("traceparent da mensagem = %q, esperado o trace do cliente", traceparent)
+	}
+
+	spans := map[string]bool{}
+	for _, span := range exporter.GetSpans() {
+		if span.SpanContext.TraceID().String() == "4bf92f3577b34da6a3ce929d0e0e4736" {
+			spans[span.Name] = true
+		}
+	}
+	for _, name := range []string{"POST /v1/invoice", "InvoiceService.Create", "VelocityService.Check", "InvoiceService.process"} {
+		if !spans[name] {
+			t.Errorf("span %q não encontrado no trace (spans: %v)", name, spans)
+		}
+	}
+}
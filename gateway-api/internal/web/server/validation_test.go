@@ -0,0 +1,79 @@
+package server
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/j-ordep/gateway/go-gateway/internal/validation"
+)
+
+// as regras declaradas nos DTOs voltam como lista {field, rule, message} em um 422
+// e a leitura do corpo é estrita (campo desconhecido, JSON malformado, tipo errado e tamanho)
+func TestRequestValidation(t *testing.T) {
+	srv := newTestServer()
+	client := newTestClient(t, srv)
+	apiKey := client.createAccount("Validação", "validation@example.com")
+
+	violations := func(method, path, apiKey string, body any) map[string]string {
+		t.Helper()
+		output := decodeBody[struct {
+			Code    string            `json:"code"`
+			Details validation.Errors `json:"details"`
+		}](client.do(method, path, apiKey, body, http.StatusUnprocessableEntity))
+		if output.Code != "validation_failed" {
+			t.Fatalf("%s %s deveria falhar na validação: %+v", method, path, output)
+		}
+		rules := make(map[string]string)
+		for _, fieldErr := range output.Details {
+			rules[fieldErr.Field] = fieldErr.Rule
+		}
+		return rules
+	}
+	expect := func(rules map[string]string, expected map[string]string) {
+		t.Helper()
+		if len(rules) != len(expected) {
+			t.Fatalf("violações esperadas %v, vieram %v", expected, rules)
+		}
+		for field, rule := range expected {
+			if rules[field] != rule {
+				t.Fatalf("%s deveria violar %s, violações: %v", field, rule, rules)
+			}
+		}
+	}
+
+	// todas as violações voltam de uma vez, usando o nome do campo no JSON
+	expect(violations("POST", "/v1/accounts", "", map[string]any{}), map[string]string{"name": "required", "email": "required"})
+	expect(violations("POST", "/v1/accounts", "", map[string]any{"name": "Conta", "email": "não é email"}), map[string]string{"email": "email"})
+	// campo desconhecido (ex: erro de digitação) é recusado antes das regras do DTO
+	expect(violations("POST", "/v1/accounts", "", map[string]any{"name": "Conta", "jason": "typo@example.com"}), map[string]string{"jason": "unknown"})
+
+	expect(violations("POST", "/v1/invoice", apiKey, invoiceInput(-10, map[string]any{"card_number": "4111-1111", "expiry_month": 13, "payment_type": "pix"})),
+		map[string]string{"amount": "gt", "card_number": "numeric", "expiry_month": "max", "payment_type": "oneof"})
+	// com cartão salvo os dados do cartão deixam de ser obrigatórios, mas o ID precisa ser um UUID
+	expect(violations("POST", "/v1/invoice", apiKey, map[string]any{"amount": 10, "payment_type": "credit_card", "payment_method_id": "abc"}),
+		map[string]string{"payment_method_id": "uuid"})
+	expect(violations("POST", "/v1/invoice", apiKey, map[string]any{"amount": "10", "payment_type": "credit_card"}), map[string]string{"amount": "type"})
+
+	raw := func(body string, status int) string {
+		t.Helper()
+		req := httptest.NewRequest("POST", "/v1/accounts", bytes.NewReader([]byte(body)))
+		recorder := httptest.NewRecorder()
+		srv.router.ServeHTTP(recorder, req)
+		if recorder.Code != status {
+			t.Fatalf("corpo %.40q retornou %d, esperado %d: %s", body, recorder.Code, status, recorder.Body.String())
+		}
+		return decodeBody[map[string]any](recorder)["code"].(string)
+	}
+	if code := raw(`{"name": "Conta",`, http.StatusBadRequest); code != "invalid_json" {
+		t.Fatalf("JSON malformado: %s", code)
+	}
+	if code := raw(`{"name": "A", "email": "a@example.com"}{"name": "B"}`, http.StatusBadRequest); code != "invalid_json" {
+		t.Fatalf("mais de um objeto no corpo: %s", code)
+	}
+	if code := raw(`{"name": "`+strings.Repeat("a", 1<<20)+`"}`, http.StatusRequestEntityTooLarge); code != "request_too_large" {
+		t.Fatalf("corpo acima do limite: %s", code)
+	}
+}
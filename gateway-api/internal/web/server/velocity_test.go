@@ -0,0 +1,48 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/j-ordep/gateway/go-gateway/internal/domain"
+	"github.com/j-ordep/gateway/go-gateway/internal/repository"
+	"github.com/j-ordep/gateway/go-gateway/internal/service"
+	"github.com/j-ordep/gateway/go-gateway/internal/web/response"
+)
+
+// cada dimensão (conta, cartão, IP) tem o próprio contador; estourar qualquer uma recusa a tentativa com 429
+func TestVelocityLimits(t *testing.T) {
+	ctx := context.Background()
+	velocityService := service.NewVelocityService(repository.NewMemoryVelocityRepository(), &service.VelocityConfig{
+		Account: service.VelocityLimit{Limit: 3, Window: time.Minute},
+		Card:    service.VelocityLimit{Limit: 2, Window: time.Minute},
+		IP:      service.VelocityLimit{Limit: 0, Window: time.Minute},
+	})
+
+	check := func(account, card, ip string, expected error) {
+		t.Helper()
+		if err := velocityService.Check(ctx, account, card, ip); !errors.Is(err, expected) {
+			t.Fatalf("Check(%s, %s, %s) = %v, esperado %v", account, card, ip, err, expected)
+		}
+	}
+
+	// card testing: o mesmo cartão estoura antes do limite da conta
+	check("account-1", "card-a", "203.0.113.1", nil)
+	check("account-1", "card-a", "203.0.113.1", nil)
+	check("account-1", "card-a", "203.0.113.1", domain.ErrVelocityLimitExceeded)
+
+	// outro cartão tem o próprio contador, mas esta é a 4ª tentativa da conta (a recusada também conta)
+	check("account-1", "card-b", "203.0.113.1", domain.ErrVelocityLimitExceeded)
+	check("account-2", "card-b", "203.0.113.1", nil)
+
+	// limite 0 desabilita a dimensão: o mesmo IP segue liberado em outra conta e cartão
+	check("account-3", "card-c", "203.0.113.1", nil)
+
+	status, body := response.ToErrorBody(domain.ErrVelocityLimitExceeded)
+	if status != http.StatusTooManyRequests || body.Code != "velocity_limit_exceeded" {
+		t.Fatalf("erro de velocidade deveria ser 429 velocity_limit_exceeded: %d %+v", status, body)
+	}
+}
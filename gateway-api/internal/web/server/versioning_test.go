@@ -0,0 +1,35 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// as rotas sem prefixo continuam respondendo, porém anunciam a remoção e a rota sucessora
+func TestLegacyRoutesAreDeprecated(t *testing.T) {
+	srv := newTestServer()
+
+	legacy := httptest.NewRecorder()
+	srv.router.ServeHTTP(legacy, httptest.NewRequest("GET", "/accounts", nil))
+
+	if legacy.Code != http.StatusUnauthorized {
+		t.Fatalf("GET /accounts retornou %d, esperado %d", legacy.Code, http.StatusUnauthorized)
+	}
+	if got := legacy.Header().Get("Deprecation"); got != "true" {
+		t.Errorf("Deprecation = %q, esperado true", got)
+	}
+	if got := legacy.Header().Get("Sunset"); got != "Fri, 30 Apr 2027 00:00:00 GMT" {
+		t.Errorf("Sunset = %q", got)
+	}
+	if got := legacy.Header().Get("Link"); got != `</v1/accounts>; rel="successor-version"` {
+		t.Errorf("Link = %q", got)
+	}
+
+	current := httptest.NewRecorder()
+	srv.router.ServeHTTP(current, httptest.NewRequest("GET", "/v1/accounts", nil))
+
+	if got := current.Header().Get("Deprecation"); got != "" {
+		t.Errorf("GET /v1/accounts não deveria ter Deprecation, recebido %q", got)
+	}
+}
@@ -0,0 +1,199 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/j-ordep/gateway/go-gateway/internal/domain"
+	"github.com/j-ordep/gateway/go-gateway/internal/dto"
+	"github.com/j-ordep/gateway/go-gateway/internal/service"
+)
+
+// o endpoint do lojista recebe o evento assinado, os retries seguem até esgotar e o reenvio manual entrega o mesmo corpo
+func TestWebhookDeliveryRetriesAndRedelivery(t *testing.T) {
+	srv := newTestServer()
+	ctx := context.Background()
+
+	var mu sync.Mutex
+	var received []*http.Request
+	var bodies [][]byte
+	responseStatus := http.StatusInternalServerError
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		defer mu.Unlock()
+		received = append(received, r)
+		bodies = append(bodies, body)
+		w.WriteHeader(responseStatus)
+	}))
+	defer receiver.Close()
+
+	client := newTestClient(t, srv)
+	dispatch := func(expected int) {
+		t.Helper()
+		dispatched, err := srv.webhookService.DispatchDue(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if dispatched != expected {
+			t.Fatalf("esperadas %d entregas enviadas, enviadas %d", expected, dispatched)
+		}
+	}
+
+	apiKey := client.createAccount("Webhook", "webhook@example.com")
+	endpoint := decodeBody[map[string]any](client.do("POST", "/v1/webhooks", apiKey, map[string]any{"url": receiver.URL, "event_types": []string{"invoice.approved"}}, http.StatusCreated))
+	endpointID, secret := endpoint["id"].(string), endpoint["secret"].(string)
+
+	// acima de 10000 a fatura fica pendente; invoice.created não foi assinado
+	invoice := decodeBody[map[string]any](client.do("POST", "/v1/invoice", apiKey, invoiceInput(15000, nil), http.StatusCreated))
+	dispatch(0)
+
+	if err := srv.invoiceService.ProcessTransactionResult(ctx, invoice["id"].(string), domain.StatusApproved); err != nil {
+		t.Fatal(err)
+	}
+
+	// MaxAttempts = 3 e RetryBaseDelay = 1ms no servidor de teste
+	for attempt := 1; attempt <= 3; attempt++ {
+		dispatch(1)
+		time.Sleep(10 * time.Millisecond)
+	}
+	dispatch(0)
+
+	deliveries := decodeBody[map[string]any](client.do("GET", "/v1/webhooks/"+endpointID+"/deliveries", apiKey, nil, http.StatusOK))["data"].([]any)
+	if len(deliveries) != 1 {
+		t.Fatalf("esperada 1 entrega, recebidas %d", len(deliveries))
+	}
+	delivery := deliveries[0].(map[string]any)
+	if delivery["status"] != "failed" || delivery["attempts"] != float64(3) || delivery["last_response_status"] != float64(500) {
+		t.Fatalf("entrega deveria estar failed após 3 tentativas com 500: %v", delivery)
+	}
+
+	// assinatura: t=<unix>,v1=<hex HMAC-SHA256(secret, "<t>.<corpo>")>
+	mu.Lock()
+	first, firstBody := received[0], bodies[0]
+	mu.Unlock()
+	parts := strings.Split(first.Header.Get("X-Gateway-Signature"), ",")
+	if len(parts) != 2 || !strings.HasPrefix(parts[0], "t=") || !strings.HasPrefix(parts[1], "v1=") {
+		t.Fatalf("assinatura em formato inesperado: %q", first.Header.Get("X-Gateway-Signature"))
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strings.TrimPrefix(parts[0], "t=") + "."))
+	mac.Write(firstBody)
+	if !hmac.Equal([]byte(hex.EncodeToString(mac.Sum(nil))), []byte(strings.TrimPrefix(parts[1], "v1="))) {
+		t.Fatal("assinatura não confere com o secret do endpoint")
+	}
+
+	var event map[string]any
+	json.Unmarshal(firstBody, &event)
+	if event["type"] != "invoice.approved" || event["id"] != first.Header.Get("X-Gateway-Event-Id") || first.Header.Get("X-Gateway-Event-Type") != "invoice.approved" {
+		t.Fatalf("evento inesperado: %v (headers %v)", event, first.Header)
+	}
+	if data := event["data"].(map[string]any); data["id"] != invoice["id"] || data["status"] != "approved" {
+		t.Fatalf("data deveria ser a fatura aprovada: %v", data)
+	}
+
+	// o reenvio manual funciona mesmo com a entrega failed e manda exatamente o mesmo corpo
+	mu.Lock()
+	responseStatus = http.StatusOK
+	mu.Unlock()
+	redelivered := decodeBody[map[string]any](client.do("POST", "/v1/webhooks/"+endpointID+"/deliveries/"+delivery["id"].(string)+"/redeliver", apiKey, nil, http.StatusOK))
+	if redelivered["status"] != "succeeded" || redelivered["attempts"] != float64(4) || redelivered["delivered_at"] == nil {
+		t.Fatalf("reenvio deveria ter sido entregue: %v", redelivered)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(bodies) != 4 || !bytes.Equal(bodies[3], firstBody) {
+		t.Fatalf("esperadas 4 requisições com o mesmo corpo, recebidas %d", len(bodies))
+	}
+}
+
+// as URLs dos lojistas não alcançam a rede interna (SSRF): fora do desenvolvimento só https, sem IPs internos
+// nem hosts que resolvem para eles, e redirects não são seguidos
+func TestWebhookEndpointNetworkGuard(t *testing.T) {
+	ctx := context.Background()
+	principal := domain.Principal{AccountID: "account-1"}
+
+	var received int
+	var mu sync.Mutex
+	receiver := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		received++
+		mu.Unlock()
+	}))
+	defer receiver.Close()
+
+	deliveryRepository := &fakeWebhookDeliveryRepository{}
+	webhookService := service.NewWebhookService(&fakeWebhookEndpointRepository{}, deliveryRepository, &service.WebhookConfig{
+		MaxAttempts:    3,
+		RetryBaseDelay: time.Millisecond,
+		Timeout:        time.Second,
+		BatchSize:      10,
+	})
+	for _, rejected := range []string{"http://example.com/hooks", "https://127.0.0.1/hooks", "https://10.0.0.8/hooks", "https://169.254.169.254/latest", "https://[::1]/hooks"} {
+		if _, err := webhookService.CreateEndpoint(ctx, principal, dto.CreateWebhookEndpointInput{URL: rejected}); err != domain.ErrInvalidWebhookURL {
+			t.Fatalf("%s deveria ser recusada: %v", rejected, err)
+		}
+	}
+
+	// o nome passa no cadastro, o endereço resolvido é barrado no dial
+	endpoint, err := webhookService.CreateEndpoint(ctx, principal, dto.CreateWebhookEndpointInput{URL: strings.Replace(receiver.URL, "127.0.0.1", "localhost", 1), EventTypes: []string{"invoice.created"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	deliveries, err := webhookService.Deliveries(ctx, principal.AccountID, domain.WebhookInvoiceCreated, map[string]any{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	deliveryRepository.save(deliveries...)
+	if dispatched, err := webhookService.DispatchDue(ctx); err != nil || dispatched != 1 {
+		t.Fatalf("esperada 1 entrega tentada: %d %v", dispatched, err)
+	}
+	listed, err := webhookService.ListDeliveries(ctx, principal, endpoint.ID, dto.WebhookDeliveryFilterInput{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if delivery := listed.Data[0]; delivery.Status == "succeeded" || !strings.Contains(delivery.LastError, "not allowed") {
+		t.Fatalf("o dial para loopback deveria ser recusado: %+v", delivery)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if received != 0 {
+		t.Fatalf("o endpoint interno não pode receber a entrega: %d", received)
+	}
+}
+
+// um 3xx do endpoint conta como falha, o client não segue para o destino do redirect
+func TestWebhookRedirectNotFollowed(t *testing.T) {
+	srv := newTestServer()
+	client := newTestClient(t, srv)
+
+	followed := false
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { followed = true }))
+	defer target.Close()
+	receiver := httptest.NewServer(http.RedirectHandler(target.URL, http.StatusTemporaryRedirect))
+	defer receiver.Close()
+
+	apiKey := client.createAccount("Redirect", "redirect@example.com")
+	endpoint := decodeBody[map[string]any](client.do("POST", "/v1/webhooks", apiKey, map[string]any{"url": receiver.URL, "event_types": []string{"invoice.created"}}, http.StatusCreated))
+	client.do("POST", "/v1/invoice", apiKey, invoiceInput(15000, nil), http.StatusCreated)
+	if _, err := srv.webhookService.DispatchDue(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	delivery := decodeBody[map[string]any](client.do("GET", "/v1/webhooks/"+endpoint["id"].(string)+"/deliveries", apiKey, nil, http.StatusOK))["data"].([]any)[0].(map[string]any)
+	if delivery["status"] == "succeeded" || delivery["last_response_status"] != float64(http.StatusTemporaryRedirect) || followed {
+		t.Fatalf("o redirect não deveria ser seguido: %v (seguido: %v)", delivery, followed)
+	}
+}
@@ -0,0 +1,106 @@
+package v1
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/j-ordep/gateway/go-gateway/internal/domain"
+	"github.com/j-ordep/gateway/go-gateway/internal/web/handler"
+)
+
+// Prefix é onde a versão 1 da API é montada
+// uma futura v2 ganha o próprio pacote (internal/web/v2) com handlers e DTOs próprios,
+// reaproveitando os mesmos services
+const Prefix = "/v1"
+
+// Handlers são os handlers HTTP da v1 (pacote handler + DTOs de internal/dto)
+type Handlers struct {
+	Account       *handler.AccountHandler
+	AccountReport *handler.AccountReportHandler
+	Invoice       *handler.InvoiceHandler
+	InvoiceBatch  *handler.InvoiceBatchHandler
+	InvoiceStream *handler.InvoiceStreamHandler
+	InvoiceExport *handler.InvoiceExportHandler
+	Customer      *handler.CustomerHandler
+	Admin         *handler.AdminHandler
+	Webhook       *handler.WebhookHandler
+}
+
+// Middlewares são compartilhados entre as versões, por isso chegam prontos do server
+type Middlewares struct {
+	Authenticate      func(http.Handler) http.Handler // exige conta ativa
+	Identify          func(http.Handler) http.Handler // aceita contas suspensas/encerradas (rotas do próprio cadastro)
+	RequireScope      func(domain.Scope) func(http.Handler) http.Handler
+	AdminAuthenticate func(http.Handler) http.Handler
+	Idempotent        func(http.Handler) http.Handler
+}
+
+// RegisterRoutes registra as rotas da v1 no router informado
+// o server chama duas vezes: em /v1 e na raiz (aliases legados, com headers de depreciação)
+// Idempotent fica sempre depois da autenticação, que coloca o principal no contexto
+func RegisterRoutes(r chi.Router, h Handlers, m Middlewares) {
+	r.Post("/accounts", h.Account.Create)
+
+	r.Group(func(r chi.Router) {
+		r.Use(m.Identify)
+		r.With(m.RequireScope(domain.ScopeAccountRead)).Get("/accounts", h.Account.Get)
+		// contas suspensas/encerradas continuam vendo o histórico; os totais expõem as faturas, por isso invoices:read também
+		r.With(m.RequireScope(domain.ScopeAccountRead), m.RequireScope(domain.ScopeInvoicesRead)).Get("/accounts/reports", h.AccountReport.Get)
+		r.With(m.RequireScope(domain.ScopeAccountWrite), m.Idempotent).Patch("/accounts", h.Account.Update)
+		r.With(m.RequireScope(domain.ScopeAccountWrite), m.Idempotent).Post("/accounts/close", h.Account.Close)
+		// sem Idempotent: a resposta traz a nova apiKey, que não deve ficar gravada na tabela de idempotência
+		r.With(m.RequireScope(domain.ScopeAccountWrite)).Post("/accounts/api-key/rotate", h.Account.RotateAPIKey)
+	})
+
+	r.Group(func(r chi.Router) {
+		r.Use(m.Authenticate)
+		r.With(m.RequireScope(domain.ScopeInvoicesWrite), m.Idempotent).Post("/invoice", h.Invoice.Create)
+		// sem Idempotent: a chave é por item (idempotency_key no corpo), um lote repetido só cria os itens que faltaram
+		r.With(m.RequireScope(domain.ScopeInvoicesWrite)).Post("/invoices/batch", h.InvoiceBatch.Create)
+		r.With(m.RequireScope(domain.ScopeInvoicesRead)).Get("/invoice/{id}", h.Invoice.GetById)
+		r.With(m.RequireScope(domain.ScopeInvoicesRead)).Get("/invoices", h.Invoice.ListByAccount)
+		r.With(m.RequireScope(domain.ScopeInvoicesRead)).Get("/invoices/stream", h.InvoiceStream.Stream)
+
+		// exportação só lê as faturas, por isso invoices:read também no POST
+		invoicesRead := m.RequireScope(domain.ScopeInvoicesRead)
+		r.With(invoicesRead).Get("/invoices/export", h.InvoiceExport.Export)
+		r.With(invoicesRead, m.Idempotent).Post("/invoices/exports", h.InvoiceExport.CreateJob)
+		r.With(invoicesRead).Get("/invoices/exports/{id}", h.InvoiceExport.GetJob)
+		r.With(invoicesRead).Get("/invoices/exports/{id}/download", h.InvoiceExport.Download)
+
+		customersRead := m.RequireScope(domain.ScopeCustomersRead)
+		customersWrite := m.RequireScope(domain.ScopeCustomersWrite)
+		r.With(customersWrite, m.Idempotent).Post("/customers", h.Customer.Create)
+		r.With(customersRead).Get("/customers", h.Customer.List)
+		r.With(customersRead).Get("/customers/{id}", h.Customer.GetById)
+		r.With(customersWrite, m.Idempotent).Patch("/customers/{id}", h.Customer.Update)
+		r.With(customersWrite, m.Idempotent).Delete("/customers/{id}", h.Customer.Delete)
+		r.With(customersRead, m.RequireScope(domain.ScopeInvoicesRead)).Get("/customers/{id}/invoices", h.Customer.ListInvoices)
+		r.With(customersWrite, m.Idempotent).Post("/customers/{id}/payment-methods", h.Customer.AddPaymentMethod)
+		r.With(customersRead).Get("/customers/{id}/payment-methods", h.Customer.ListPaymentMethods)
+		r.With(customersWrite, m.Idempotent).Delete("/customers/{id}/payment-methods/{paymentMethodId}", h.Customer.DeletePaymentMethod)
+
+		webhooksRead := m.RequireScope(domain.ScopeWebhooksRead)
+		webhooksWrite := m.RequireScope(domain.ScopeWebhooksWrite)
+		// sem Idempotent no POST: a resposta traz o secret, que não deve ficar gravado na tabela de idempotência
+		r.With(webhooksWrite).Post("/webhooks", h.Webhook.Create)
+		r.With(webhooksRead).Get("/webhooks", h.Webhook.List)
+		r.With(webhooksRead).Get("/webhooks/{id}", h.Webhook.GetById)
+		r.With(webhooksWrite, m.Idempotent).Delete("/webhooks/{id}", h.Webhook.Delete)
+		r.With(webhooksRead).Get("/webhooks/{id}/deliveries", h.Webhook.ListDeliveries)
+		r.With(webhooksWrite).Post("/webhooks/{id}/deliveries/{deliveryId}/redeliver", h.Webhook.Redeliver)
+	})
+
+	// suporte/operação: tokens de operador, nunca a apiKey do lojista; toda ação vai para a auditoria
+	r.Route("/admin", func(r chi.Router) {
+		r.Use(m.AdminAuthenticate)
+		r.Get("/accounts/{id}", h.Admin.GetAccount)
+		r.Get("/accounts/{id}/invoices", h.Admin.ListAccountInvoices)
+		r.Post("/accounts/{id}/suspend", h.Admin.SuspendAccount)
+		r.Post("/accounts/{id}/reactivate", h.Admin.ReactivateAccount)
+		r.Put("/accounts/{id}/tier", h.Admin.UpdateAccountTier)
+		r.Post("/accounts/{id}/balance-adjustments", h.Admin.AdjustBalance)
+		r.Post("/invoices/{id}/status", h.Admin.ForceInvoiceStatus)
+		r.Get("/audit-log", h.Admin.ListAuditLog)
+	})
+}
@@ -0,0 +1,5 @@
+ALTER TABLE accounts
+    ADD COLUMN status VARCHAR(20) NOT NULL DEFAULT 'active',
+    ADD COLUMN status_reason TEXT NOT NULL DEFAULT '';
+
+CREATE INDEX idx_accounts_status ON accounts(status);
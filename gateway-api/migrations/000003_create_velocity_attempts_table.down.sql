@@ -0,0 +1 @@
+DROP TABLE IF EXISTS velocity_attempts;
@@ -0,0 +1,7 @@
+CREATE TABLE IF NOT EXISTS velocity_attempts (
+    id BIGSERIAL PRIMARY KEY,
+    key VARCHAR(255) NOT NULL,
+    attempted_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_velocity_attempts_key_attempted_at ON velocity_attempts(key, attempted_at);
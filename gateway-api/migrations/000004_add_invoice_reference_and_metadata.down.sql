@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_invoices_metadata;
+DROP INDEX IF EXISTS idx_invoices_account_external_reference;
+ALTER TABLE invoices
+    DROP COLUMN IF EXISTS metadata,
+    DROP COLUMN IF EXISTS external_reference;
@@ -0,0 +1,9 @@
+ALTER TABLE invoices
+    ADD COLUMN external_reference VARCHAR(255),
+    ADD COLUMN metadata JSONB NOT NULL DEFAULT '{}';
+
+-- external_reference é opcional, mas quando informado é único por conta
+CREATE UNIQUE INDEX idx_invoices_account_external_reference ON invoices(account_id, external_reference) WHERE external_reference IS NOT NULL;
+
+-- jsonb_path_ops atende o operador @> usado nos filtros por metadata
+CREATE INDEX idx_invoices_metadata ON invoices USING GIN (metadata jsonb_path_ops);
@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS idx_invoices_customer_id;
+ALTER TABLE invoices
+    DROP COLUMN IF EXISTS payment_method_id,
+    DROP COLUMN IF EXISTS customer_id;
+DROP TABLE IF EXISTS payment_methods;
+DROP TABLE IF EXISTS customers;
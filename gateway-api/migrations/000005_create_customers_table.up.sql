@@ -0,0 +1,34 @@
+CREATE TABLE IF NOT EXISTS customers (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid (),
+    account_id UUID NOT NULL REFERENCES accounts(id),
+    name VARCHAR(255) NOT NULL,
+    email VARCHAR(255) NOT NULL DEFAULT '',
+    document VARCHAR(50) NOT NULL DEFAULT '',
+    phone VARCHAR(50) NOT NULL DEFAULT '',
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_customers_account_id ON customers(account_id);
+
+-- cartões tokenizados: nunca guardamos o número completo nem o CVV
+CREATE TABLE IF NOT EXISTS payment_methods (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid (),
+    account_id UUID NOT NULL REFERENCES accounts(id),
+    customer_id UUID NOT NULL REFERENCES customers(id) ON DELETE CASCADE,
+    brand VARCHAR(20) NOT NULL,
+    card_last_digits VARCHAR(4) NOT NULL,
+    expiry_month INTEGER NOT NULL,
+    expiry_year INTEGER NOT NULL,
+    cardholder_name VARCHAR(255) NOT NULL DEFAULT '',
+    fingerprint VARCHAR(64) NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_payment_methods_customer_id ON payment_methods(customer_id);
+
+ALTER TABLE invoices
+    ADD COLUMN customer_id UUID REFERENCES customers(id) ON DELETE SET NULL,
+    ADD COLUMN payment_method_id UUID REFERENCES payment_methods(id) ON DELETE SET NULL;
+
+CREATE INDEX idx_invoices_customer_id ON invoices(customer_id);
@@ -0,0 +1,7 @@
+DROP TABLE IF EXISTS three_ds_challenges;
+ALTER TABLE invoices
+    DROP COLUMN IF EXISTS three_ds_eci,
+    DROP COLUMN IF EXISTS three_ds_result;
+ALTER TABLE accounts
+    DROP COLUMN IF EXISTS three_ds_min_amount,
+    DROP COLUMN IF EXISTS three_ds_mode;
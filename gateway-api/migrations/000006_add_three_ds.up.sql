@@ -0,0 +1,20 @@
+-- regra de 3DS por conta: off, always ou above_amount (a partir de three_ds_min_amount)
+ALTER TABLE accounts
+    ADD COLUMN three_ds_mode VARCHAR(20) NOT NULL DEFAULT 'off',
+    ADD COLUMN three_ds_min_amount DECIMAL(10, 2) NOT NULL DEFAULT 0;
+
+-- resultado do 3DS na fatura: frictionless, challenged ou failed + ECI
+ALTER TABLE invoices
+    ADD COLUMN three_ds_result VARCHAR(20),
+    ADD COLUMN three_ds_eci VARCHAR(2);
+
+CREATE TABLE IF NOT EXISTS three_ds_challenges (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid (),
+    invoice_id UUID NOT NULL REFERENCES invoices(id),
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',
+    eci VARCHAR(2),
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    completed_at TIMESTAMP
+);
+
+CREATE INDEX idx_three_ds_challenges_invoice_id ON three_ds_challenges(invoice_id);
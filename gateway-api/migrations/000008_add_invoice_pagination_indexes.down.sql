@@ -0,0 +1,5 @@
+CREATE INDEX IF NOT EXISTS idx_invoices_customer_id ON invoices(customer_id);
+DROP INDEX IF EXISTS idx_invoices_customer_created_at_id;
+DROP INDEX IF EXISTS idx_invoices_account_status_created_at;
+CREATE INDEX IF NOT EXISTS idx_invoices_account_id ON invoices(account_id);
+DROP INDEX IF EXISTS idx_invoices_account_created_at_id;
@@ -0,0 +1,11 @@
+-- paginação por keyset em GET /invoices: WHERE account_id = ? AND (created_at, id) < (?, ?) ORDER BY created_at DESC, id DESC
+-- o índice composto cobre também as buscas apenas por account_id, por isso substitui idx_invoices_account_id
+CREATE INDEX idx_invoices_account_created_at_id ON invoices(account_id, created_at DESC, id DESC);
+DROP INDEX IF EXISTS idx_invoices_account_id;
+
+-- filtro por status é o mais comum nas listagens (ex: pendentes da conta)
+CREATE INDEX idx_invoices_account_status_created_at ON invoices(account_id, status, created_at DESC, id DESC);
+
+-- histórico do Customer usa a mesma ordenação
+CREATE INDEX idx_invoices_customer_created_at_id ON invoices(customer_id, created_at DESC, id DESC);
+DROP INDEX IF EXISTS idx_invoices_customer_id;
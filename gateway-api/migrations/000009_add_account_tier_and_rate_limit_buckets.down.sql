@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS rate_limit_buckets;
+ALTER TABLE accounts
+    DROP COLUMN IF EXISTS tier;
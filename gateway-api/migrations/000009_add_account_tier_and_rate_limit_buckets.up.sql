@@ -0,0 +1,11 @@
+ALTER TABLE accounts
+    ADD COLUMN tier VARCHAR(20) NOT NULL DEFAULT 'standard';
+
+-- token buckets do rate limiter (RATE_LIMIT_STORE=postgres), chave = conta ou IP
+CREATE TABLE IF NOT EXISTS rate_limit_buckets (
+    key VARCHAR(255) PRIMARY KEY,
+    tokens DOUBLE PRECISION NOT NULL,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_rate_limit_buckets_updated_at ON rate_limit_buckets(updated_at);
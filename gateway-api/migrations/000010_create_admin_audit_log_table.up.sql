@@ -0,0 +1,16 @@
+-- trilha das ações administrativas, uma linha por ação com o operador que a executou
+CREATE TABLE IF NOT EXISTS admin_audit_log (
+    id UUID PRIMARY KEY,
+    operator_id VARCHAR(100) NOT NULL,
+    action VARCHAR(50) NOT NULL,
+    target_type VARCHAR(20) NOT NULL,
+    target_id VARCHAR(255) NOT NULL,
+    reason TEXT NOT NULL DEFAULT '',
+    details JSONB NOT NULL DEFAULT '{}',
+    request_id VARCHAR(128) NOT NULL DEFAULT '',
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_admin_audit_log_target ON admin_audit_log(target_type, target_id, created_at DESC);
+CREATE INDEX idx_admin_audit_log_operator ON admin_audit_log(operator_id, created_at DESC);
+CREATE INDEX idx_admin_audit_log_created_at ON admin_audit_log(created_at DESC);
@@ -0,0 +1,13 @@
+-- mudanças de status das faturas, lidas pelo stream SSE para retomar a partir do Last-Event-ID
+-- cada INSERT também dispara um NOTIFY no canal invoice_events, para as demais instâncias
+CREATE TABLE IF NOT EXISTS invoice_events (
+    id BIGSERIAL PRIMARY KEY,
+    account_id UUID NOT NULL REFERENCES accounts(id),
+    invoice_id UUID NOT NULL,
+    status VARCHAR(50) NOT NULL,
+    amount DECIMAL(10, 2) NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_invoice_events_account_id ON invoice_events(account_id, id);
+CREATE INDEX idx_invoice_events_created_at ON invoice_events(created_at);
@@ -0,0 +1,23 @@
+-- exportações assíncronas de faturas; o arquivo gerado fica no ExportStorage (INVOICE_EXPORT_DIR), não no banco
+CREATE TABLE IF NOT EXISTS invoice_exports (
+    id UUID PRIMARY KEY,
+    account_id UUID NOT NULL REFERENCES accounts(id),
+    format VARCHAR(10) NOT NULL,
+    filter_status VARCHAR(20) NOT NULL DEFAULT '',
+    filter_payment_type VARCHAR(50) NOT NULL DEFAULT '',
+    created_from TIMESTAMP,
+    created_to TIMESTAMP,
+    timezone VARCHAR(64) NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',
+    row_count INTEGER NOT NULL DEFAULT 0,
+    error TEXT NOT NULL DEFAULT '',
+    locked_until TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    completed_at TIMESTAMP,
+    expires_at TIMESTAMP
+);
+
+-- fila do worker: só as ainda não concluídas entram no índice
+CREATE INDEX idx_invoice_exports_queue ON invoice_exports(created_at) WHERE status IN ('pending', 'processing');
+CREATE INDEX idx_invoice_exports_expires_at ON invoice_exports(expires_at);
@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS invoice_rollup_state;
+DROP TABLE IF EXISTS invoice_daily_rollups;
+DROP INDEX IF EXISTS idx_invoices_updated_at;
+ALTER TABLE invoices DROP COLUMN IF EXISTS card_brand;
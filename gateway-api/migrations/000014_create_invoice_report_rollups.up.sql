@@ -0,0 +1,29 @@
+-- bandeira do cartão na fatura, para os relatórios; as antigas herdam a do cartão salvo (as demais ficam como unknown)
+ALTER TABLE invoices ADD COLUMN card_brand VARCHAR(20) NOT NULL DEFAULT '';
+
+UPDATE invoices
+SET card_brand = payment_methods.brand
+FROM payment_methods
+WHERE payment_methods.id = invoices.payment_method_id;
+
+-- o refresh do rollup procura as faturas alteradas desde a última execução
+CREATE INDEX idx_invoices_updated_at ON invoices(updated_at);
+
+-- totais diários (UTC) por conta, status, tipo de pagamento e bandeira, usados por GET /accounts/reports quando INVOICE_REPORT_SOURCE=rollup
+CREATE TABLE IF NOT EXISTS invoice_daily_rollups (
+    account_id UUID NOT NULL REFERENCES accounts(id),
+    day DATE NOT NULL,
+    status VARCHAR(50) NOT NULL,
+    payment_type VARCHAR(50) NOT NULL,
+    card_brand VARCHAR(20) NOT NULL,
+    invoice_count INTEGER NOT NULL,
+    amount_sum DECIMAL(14, 2) NOT NULL,
+    PRIMARY KEY (account_id, day, status, payment_type, card_brand)
+);
+
+-- linha única com até onde o rollup está atualizado (updated_at das faturas já agregadas)
+CREATE TABLE IF NOT EXISTS invoice_rollup_state (
+    id BOOLEAN PRIMARY KEY DEFAULT TRUE CHECK (id),
+    refreshed_until TIMESTAMP NOT NULL,
+    refreshed_at TIMESTAMP NOT NULL
+);
@@ -0,0 +1,11 @@
+ALTER TABLE invoices
+    DROP CONSTRAINT invoices_payment_method_id_fkey,
+    ADD CONSTRAINT invoices_payment_method_id_fkey FOREIGN KEY (payment_method_id) REFERENCES payment_methods(id) ON DELETE SET NULL,
+    DROP CONSTRAINT invoices_customer_id_fkey,
+    ADD CONSTRAINT invoices_customer_id_fkey FOREIGN KEY (customer_id) REFERENCES customers(id) ON DELETE SET NULL;
+
+DELETE FROM payment_methods WHERE deleted_at IS NOT NULL;
+DELETE FROM customers WHERE deleted_at IS NOT NULL;
+
+ALTER TABLE payment_methods DROP COLUMN IF EXISTS deleted_at;
+ALTER TABLE customers DROP COLUMN IF EXISTS deleted_at;
@@ -0,0 +1,11 @@
+-- clientes e cartões removidos pelo lojista só são marcados com deleted_at:
+-- as faturas continuam apontando para quem pagou e com qual cartão
+ALTER TABLE customers ADD COLUMN deleted_at TIMESTAMP;
+ALTER TABLE payment_methods ADD COLUMN deleted_at TIMESTAMP;
+
+-- um DELETE físico não pode mais apagar o vínculo das faturas
+ALTER TABLE invoices
+    DROP CONSTRAINT invoices_customer_id_fkey,
+    ADD CONSTRAINT invoices_customer_id_fkey FOREIGN KEY (customer_id) REFERENCES customers(id) ON DELETE RESTRICT,
+    DROP CONSTRAINT invoices_payment_method_id_fkey,
+    ADD CONSTRAINT invoices_payment_method_id_fkey FOREIGN KEY (payment_method_id) REFERENCES payment_methods(id) ON DELETE RESTRICT;
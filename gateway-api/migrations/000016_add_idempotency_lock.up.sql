@@ -0,0 +1,3 @@
+-- prazo da requisição em processing: se ela cair sem concluir nem liberar a chave,
+-- uma nova tentativa assume depois de locked_until em vez de receber 409 até a chave expirar
+ALTER TABLE idempotency_keys ADD COLUMN locked_until TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP;
@@ -2,4 +2,6 @@ export class ProcessInvoiceFraudDto {
   invoice_id: string;
   account_id: string;
   amount: number;
+  // X-Request-ID e trace context da cobrança no gateway, devolvidos no resultado
+  correlation_headers?: Record<string, string>;
 }
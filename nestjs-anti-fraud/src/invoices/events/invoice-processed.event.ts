@@ -8,5 +8,6 @@ export class InvoiceProcessedEvent {
       reason?: FraudReason;
       description?: string;
     },
+    readonly correlationHeaders: Record<string, string> = {},
   ) {}
 }
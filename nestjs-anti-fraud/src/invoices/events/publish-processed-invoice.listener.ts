@@ -25,6 +25,7 @@ export class PublishProcessedInvoiceListener implements OnModuleInit {
             invoice_id: event.invoice.id,
             status: event.fraudResult.hasFraud ? 'rejected' : 'approved',
           }),
+          headers: event.correlationHeaders,
         },
       ],
     });
@@ -17,7 +17,8 @@ export class FraudService {
   ) {}
 
   async processInvoice(processInvoiceFraudDto: ProcessInvoiceFraudDto) {
-    const { invoice_id, account_id, amount } = processInvoiceFraudDto;
+    const { invoice_id, account_id, amount, correlation_headers } =
+      processInvoiceFraudDto;
 
     return this.prismaService.$transaction(async (prisma) => {
       const foundInvoice = await prisma.invoice.findUnique({
@@ -68,7 +69,11 @@ export class FraudService {
 
       await this.eventEmitter.emitAsync(
         'invoice.processed',
-        new InvoiceProcessedEvent(invoice, fraudResult),
+        new InvoiceProcessedEvent(
+          invoice,
+          fraudResult,
+          correlation_headers,
+        ),
       );
 
       return {
@@ -1,6 +1,11 @@
 import { Controller, Logger } from '@nestjs/common';
-import { EventPattern, Payload } from '@nestjs/microservices';
+import { Ctx, EventPattern, Payload } from '@nestjs/microservices';
 import { FraudService } from './fraud/fraud.service';
+import { ConfluentKafkaContext } from '../kafka/confluent-kafka-context';
+
+// headers de correlação enviados pelo gateway e devolvidos no transactions_result:
+// X-Request-ID (logs) e o trace context W3C (traceparent/tracestate)
+const CORRELATION_HEADERS = ['X-Request-ID', 'traceparent', 'tracestate'];
 
 export type PendingInvoicesMessage = {
   account_id: string;
@@ -15,12 +20,27 @@ export class InvoicesConsumer {
   constructor(private fraudService: FraudService) {}
 
   @EventPattern('pending_transactions')
-  async handlePendingInvoices(@Payload() message: PendingInvoicesMessage) {
-    this.logger.log(`Processing invoice: ${message.invoice_id}`);
+  async handlePendingInvoices(
+    @Payload() message: PendingInvoicesMessage,
+    @Ctx() context: ConfluentKafkaContext,
+  ) {
+    const headers = context.getMessage().headers ?? {};
+    const correlationHeaders: Record<string, string> = {};
+    for (const key of CORRELATION_HEADERS) {
+      const value = headers[key]?.toString();
+      if (value) {
+        correlationHeaders[key] = value;
+      }
+    }
+
+    this.logger.log(
+      `Processing invoice: ${message.invoice_id} (request_id: ${correlationHeaders['X-Request-ID']})`,
+    );
     await this.fraudService.processInvoice({
       account_id: message.account_id,
       amount: message.amount,
       invoice_id: message.invoice_id,
+      correlation_headers: correlationHeaders,
     });
     this.logger.log(`Invoice processed: ${message.invoice_id}`);
   }
@@ -9,7 +9,7 @@ import { StatusBadge } from "../../../components/StatusBadge";
 export async function getInvoice(id: string) {
   const cookiesStore = await cookies();
   const apiKey = cookiesStore.get("apiKey")?.value;
-  const response = await fetch(`http://localhost:8081/invoice/${id}`, {
+  const response = await fetch(`http://localhost:8081/v1/invoice/${id}`, {
     headers: {
       "X-API-KEY": apiKey as string,
     },
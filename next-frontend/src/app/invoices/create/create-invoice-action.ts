@@ -15,10 +15,12 @@ export async function createInvoiceAction(formData: FormData) {
     .get("expiryDate")!
     .toString()
     .split("/");
+  // o formulário usa MM/AA, a API exige o ano com quatro dígitos
+  const year = parseInt(expiryYear as string);
   const cvv = formData.get("cvv");
   const cardholderName = formData.get("cardholderName");
 
-  const response = await fetch("http://localhost:8081/invoice", {
+  const response = await fetch("http://localhost:8081/v1/invoice", {
     method: "POST",
     headers: {
       "Content-Type": "application/json",
@@ -29,7 +31,7 @@ export async function createInvoiceAction(formData: FormData) {
       description,
       card_number: cardNumber,
       expiry_month: parseInt(expiryMonth as string),
-      expiry_year: parseInt(expiryYear as string),
+      expiry_year: year < 100 ? 2000 + year : year,
       cvv,
       cardholder_name: cardholderName,
       payment_type: "credit_card",
@@ -9,7 +9,7 @@ import { cookies } from "next/headers";
 export async function getInvoices() {
   const cookiesStore = await cookies();
   const apiKey = cookiesStore.get("apiKey")?.value;
-  const response = await fetch("http://localhost:8081/invoices", {
+  const response = await fetch("http://localhost:8081/v1/invoices", {
     headers: {
       "X-API-KEY": apiKey as string,
     },
@@ -18,7 +18,9 @@ export async function getInvoices() {
       tags: [`accounts/${apiKey}/invoices`]
     }
   });
-  return response.json();
+  // a API retorna uma página: { data: [...], next_cursor }
+  const page = await response.json();
+  return page.data;
 }
 
 export async function InvoiceList() {
@@ -10,7 +10,7 @@ export async function loginAction(formData: FormData) {
   "use server";
   const apiKey = formData.get("apiKey");
 console.log(apiKey);
-  const response = await fetch("http://localhost:8081/accounts", {
+  const response = await fetch("http://localhost:8081/v1/accounts", {
     headers: {
       "X-API-KEY": apiKey as string,
     },